@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/cache"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/discovery"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
@@ -28,6 +30,15 @@ func main() {
 	}
 	logrus.Infof("verification modes: %s (policy=%s)", strings.Join(cfg.VerificationModes, ","), cfg.ModePolicy)
 
+	var trustPolicies []policy.TrustPolicy
+	if cfg.TrustPolicyFile != "" {
+		trustPolicies, err = policy.LoadTrustPolicies(cfg.TrustPolicyFile)
+		if err != nil {
+			logrus.Fatalf("loading trust policies: %v", err)
+		}
+		logrus.Infof("trust policies: %s", strings.Join(policy.TrustPolicyNames(trustPolicies), ","))
+	}
+
 	kubeClient, err := kubernetesClient()
 	if err != nil {
 		logrus.Fatalf("creating kubernetes client: %v", err)
@@ -47,16 +58,18 @@ func main() {
 	logrus.Infof("discovered %d images", len(discoveryResult.Images))
 	images := resolve.Images(ctx, prepared.Credentials, discoveryResult.Images, cfg.ResolveDigests, cfg.MaxConcurrentScans)
 
-	results, err := verifyImages(ctx, cfg, prepared.Credentials, images, time.Now())
+	results, err := verifyImages(ctx, cfg, trustPolicies, prepared.Credentials, images, time.Now())
 	if err != nil {
 		logrus.Fatalf("verifying images: %v", err)
 	}
 
-	finalReport := report.Build(results, report.PolicyFromAllowlists(
+	reportPolicy := report.PolicyFromAllowlists(
 		cfg.ImageAllowlist,
 		cfg.RegistryAllowlist,
 		cfg.SignerAllowlist,
-	))
+	)
+	reportPolicy.TrustPolicies = policy.TrustPolicyNames(trustPolicies)
+	finalReport := report.Build(results, reportPolicy)
 	if err := output.WriteFinalReport(finalReport); err != nil {
 		logrus.Fatalf("writing report: %v", err)
 	}
@@ -64,6 +77,72 @@ func main() {
 	logrus.Infof("wrote image trust report to %s", output.OutputFile)
 }
 
+// buildVerifier creates the verifier for the environment configuration and, when trust
+// policies are loaded, routes images to per-policy verifiers in order.
+func buildVerifier(cfg *config.Config, trustPolicies []policy.TrustPolicy, registryCreds registry.Credentials, verificationCache *cache.Cache) (verify.Verifier, func(), error) {
+	var cleanups []func()
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+
+	// Sigstore settings are global, so every policy shares one lazily loaded trusted root.
+	sigstoreTrust := verify.NewSigstoreTrust(cfg)
+	newVerifier := func(modeCfg *config.Config) (verify.Verifier, error) {
+		notation, err := verify.PrepareNotation(modeCfg.NotationTrust)
+		if err != nil {
+			return nil, err
+		}
+		cleanups = append(cleanups, notation.Cleanup)
+		runner := verify.ExecRunner{ExtraEnv: append(registryCreds.ExtraEnv(modeCfg.SigstoreEnv...), notation.Env...)}
+		verifier, err := verify.NewVerifier(modeCfg, runner, registryCreds, sigstoreTrust)
+		if err != nil || verificationCache == nil {
+			return verifier, err
+		}
+		policyHash, err := modeCfg.TrustHash()
+		if err != nil {
+			return nil, err
+		}
+		return verify.NewCachedVerifier(verifier, verificationCache, policyHash, registryCreds), nil
+	}
+
+	defaultVerifier, err := newVerifier(cfg)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if len(trustPolicies) == 0 {
+		return defaultVerifier, cleanup, nil
+	}
+
+	scoped := make([]verify.ScopedVerifier, 0, len(trustPolicies))
+	for _, trustPolicy := range trustPolicies {
+		policyCfg, err := cfg.WithTrustSettings(trustPolicy.TrustSettings)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("trust policy %q: %w", trustPolicy.Name, err)
+		}
+		verifier, err := newVerifier(policyCfg)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("trust policy %q: %w", trustPolicy.Name, err)
+		}
+		scoped = append(scoped, verify.ScopedVerifier{
+			Policy:   trustPolicy.Name,
+			Matches:  trustPolicy.Matches,
+			Verifier: verifier,
+		})
+	}
+
+	verifier, err := verify.NewPolicyVerifier(policy.DefaultTrustPolicyName, defaultVerifier, scoped...)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return verifier, cleanup, nil
+}
+
 func kubernetesClient() (kubernetes.Interface, error) {
 	kubeConfig, err := ctrl.GetConfig()
 	if err != nil {
@@ -72,12 +151,21 @@ func kubernetesClient() (kubernetes.Interface, error) {
 	return kubernetes.NewForConfig(kubeConfig)
 }
 
-func verifyImages(ctx context.Context, cfg *config.Config, registryCreds registry.Credentials, images []models.DiscoveredImage, now time.Time) ([]models.ImageTrustResult, error) {
-	runner := verify.ExecRunner{ExtraEnv: registryCreds.ExtraEnv(cfg.SigstoreEnv...)}
-	verifier, err := verify.NewVerifier(cfg, runner, registryCreds)
+func verifyImages(ctx context.Context, cfg *config.Config, trustPolicies []policy.TrustPolicy, registryCreds registry.Credentials, images []models.DiscoveredImage, now time.Time) ([]models.ImageTrustResult, error) {
+	var verificationCache *cache.Cache
+	if cfg.CacheFile != "" {
+		var err error
+		verificationCache, err = cache.Open(cfg.CacheFile, cfg.CacheTTL, cfg.CacheUnsignedTTL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	verifier, cleanup, err := buildVerifier(cfg, trustPolicies, registryCreds, verificationCache)
 	if err != nil {
 		return nil, err
 	}
+	defer cleanup()
 
 	results, err := verify.VerifyImages(
 		ctx,
@@ -94,6 +182,14 @@ func verifyImages(ctx context.Context, cfg *config.Config, registryCreds registr
 		return nil, err
 	}
 
+	if verificationCache != nil {
+		hits, misses := verificationCache.Stats()
+		logrus.Infof("verification cache: %d hits, %d misses", hits, misses)
+		if err := verificationCache.Save(); err != nil {
+			logrus.Warnf("saving verification cache: %v", err)
+		}
+	}
+
 	matcher := policy.NewAllowlistMatcher(cfg.ImageAllowlist, cfg.RegistryAllowlist, cfg.SignerAllowlist)
 	results, err = matcher.Apply(images, results)
 	if err != nil {
@@ -101,7 +197,9 @@ func verifyImages(ctx context.Context, cfg *config.Config, registryCreds registr
 	}
 
 	for i := range results {
-		results[i].LastCheckedAt = now.UTC()
+		if results[i].LastCheckedAt.IsZero() {
+			results[i].LastCheckedAt = now.UTC()
+		}
 	}
 	return results, nil
 }
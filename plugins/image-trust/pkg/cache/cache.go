@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	cacheVersion = 1
+	// MaxEntries bounds the cache file; the oldest entries are dropped first.
+	MaxEntries = 20000
+)
+
+// Entry is a cached verification observation.
+type Entry struct {
+	Observation models.VerificationObservation `json:"observation"`
+	CheckedAt   time.Time                      `json:"checkedAt"`
+	ExpiresAt   time.Time                      `json:"expiresAt"`
+}
+
+type fileFormat struct {
+	Version int              `json:"version"`
+	Entries map[string]Entry `json:"entries"`
+}
+
+// Cache stores verification observations by image digest and trust policy hash so
+// unchanged digests are not re-verified on every run.
+type Cache struct {
+	path        string
+	ttl         time.Duration
+	unsignedTTL time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]Entry
+	hits    int
+	misses  int
+}
+
+// Open loads the cache file at path. A missing or unreadable file starts an empty cache.
+// unsigned, signed_untrusted and provenance_violation results expire after unsignedTTL,
+// since a new signature or attestation can change them without the digest changing;
+// zero disables caching them.
+func Open(path string, ttl, unsignedTTL time.Duration) (*Cache, error) {
+	if path == "" {
+		return nil, fmt.Errorf("cache file path is required")
+	}
+	c := &Cache{
+		path:        path,
+		ttl:         ttl,
+		unsignedTTL: unsignedTTL,
+		now:         time.Now,
+		entries:     map[string]Entry{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading verification cache %s: %w", path, err)
+	}
+	var file fileFormat
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != cacheVersion {
+		logrus.Warnf("ignoring unreadable verification cache %s", path)
+		return c, nil
+	}
+	if file.Entries != nil {
+		c.entries = file.Entries
+	}
+	return c, nil
+}
+
+// Key identifies an observation for a digest reference under a trust policy hash.
+func Key(digestRef, policyHash string) string {
+	return policyHash + "|" + digestRef
+}
+
+// Get returns an unexpired observation with CheckedAt set to when it was verified.
+func (c *Cache) Get(key string) (models.VerificationObservation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.ExpiresAt) {
+		c.misses++
+		return models.VerificationObservation{}, false
+	}
+	c.hits++
+	observation := entry.Observation
+	observation.CheckedAt = entry.CheckedAt
+	return observation, true
+}
+
+// Put stores an observation. Operational failures and unknown results are not cached.
+func (c *Cache) Put(key string, observation models.VerificationObservation) {
+	var ttl time.Duration
+	switch observation.Status {
+	case models.StatusVerified:
+		ttl = c.ttl
+	case models.StatusUnsigned, models.StatusSignedUntrusted, models.StatusProvenanceViolation:
+		ttl = c.unsignedTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	now := c.now().UTC()
+	observation.CheckedAt = time.Time{}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = Entry{
+		Observation: observation,
+		CheckedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+}
+
+// Stats returns cache hits and misses since Open.
+func (c *Cache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Save drops expired entries and atomically rewrites the cache file.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.ExpiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	if excess := len(c.entries) - MaxEntries; excess > 0 {
+		c.evictOldestLocked(excess)
+	}
+	data, err := json.Marshal(fileFormat{Version: cacheVersion, Entries: c.entries})
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("marshalling verification cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("creating verification cache directory: %w", err)
+	}
+	tempFile := c.path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o644); err != nil {
+		return fmt.Errorf("writing verification cache: %w", err)
+	}
+	if err := os.Rename(tempFile, c.path); err != nil {
+		return fmt.Errorf("renaming verification cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) evictOldestLocked(n int) {
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].CheckedAt.Before(c.entries[keys[j]].CheckedAt)
+	})
+	for _, key := range keys[:n] {
+		delete(c.entries, key)
+	}
+}
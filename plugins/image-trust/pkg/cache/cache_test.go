@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "verifications.json")
+	c, err := Open(path, 24*time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	key := Key("ghcr.io/example/api@sha256:abc", "policy-hash")
+	c.Put(key, models.VerificationObservation{
+		Mode:   models.VerificationModeCosignKeyless,
+		Status: models.StatusVerified,
+		Signer: models.SignerDetails{Issuer: "https://token.actions.githubusercontent.com"},
+	})
+	require.NoError(t, c.Save())
+
+	reopened, err := Open(path, 24*time.Hour, time.Hour)
+	require.NoError(t, err)
+	observation, ok := reopened.Get(key)
+	require.True(t, ok)
+	require.Equal(t, models.StatusVerified, observation.Status)
+	require.Equal(t, "https://token.actions.githubusercontent.com", observation.Signer.Issuer)
+	require.False(t, observation.CheckedAt.IsZero())
+
+	_, ok = reopened.Get(Key("ghcr.io/example/api@sha256:abc", "other-policy"))
+	require.False(t, ok)
+	hits, misses := reopened.Stats()
+	require.Equal(t, 1, hits)
+	require.Equal(t, 1, misses)
+}
+
+func TestCacheExpiresUnsignedSooner(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "cache.json"), 24*time.Hour, time.Hour)
+	require.NoError(t, err)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Put("verified", models.VerificationObservation{Status: models.StatusVerified})
+	c.Put("unsigned", models.VerificationObservation{Status: models.StatusUnsigned})
+	c.Put("error", models.VerificationObservation{Status: models.StatusVerificationError})
+
+	now = now.Add(2 * time.Hour)
+	_, ok := c.Get("verified")
+	require.True(t, ok)
+	_, ok = c.Get("unsigned")
+	require.False(t, ok)
+	_, ok = c.Get("error")
+	require.False(t, ok)
+
+	require.NoError(t, c.Save())
+	require.Len(t, c.entries, 1)
+}
+
+func TestOpenIgnoresCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+
+	c, err := Open(path, time.Hour, time.Hour)
+	require.NoError(t, err)
+	_, ok := c.Get("anything")
+	require.False(t, ok)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +12,8 @@ import (
 const (
 	DefaultMaxConcurrentScans    = 5
 	DefaultImageVerifyTimeout    = 3 * time.Minute
+	DefaultCacheTTL              = 24 * time.Hour
+	DefaultCacheUnsignedTTL      = time.Hour
 	MaxTrustedSubjectRegexpLen   = 512
 	MaxTrustedSubjectRegexpCount = 32
 )
@@ -29,6 +32,9 @@ type Config struct {
 	PublicKeyDir            string
 	TrustedPublicKeys       []TrustedPublicKey
 	IgnoreTlog              bool
+	NotationTrustPolicyPath string
+	NotationTrustStoreDir   string
+	NotationTrust           *NotationTrust
 	SignerAllowlist         []string
 	ImageAllowlist          []string
 	RegistryAllowlist       []string
@@ -45,42 +51,62 @@ type Config struct {
 	RegistryDockerConfigDir string
 	AttestationTypes        []string
 	AttestationsEnabled     bool
+	Provenance              ProvenancePolicy
 	VerifyRetries           int
 	VerifyRetryBackoff      time.Duration
 	VerifyRetryJitter       bool
 	SigstoreEnvFile         string
+	TrustPolicyFile         string
+	CacheFile               string
+	CacheTTL                time.Duration
+	CacheUnsignedTTL        time.Duration
 	SigstoreEnv             []string
+	NativeModes             []string
+	SigstoreTrustedRootFile string
+	SigstoreTUFMirror       string
+	SigstoreTUFRootFile     string
 }
 
 // LoadFromEnvironment parses plugin configuration from environment variables.
 func LoadFromEnvironment() (*Config, error) {
 	cfg := &Config{
-		NamespaceAllowlist: parseLowerCSVEnv("IMAGE_TRUST_NAMESPACE_ALLOWLIST"),
-		NamespaceBlocklist: parseLowerCSVEnv("IMAGE_TRUST_NAMESPACE_BLOCKLIST"),
-		VerificationModes:  parseCSVEnv("IMAGE_TRUST_MODES"),
-		ModePolicy:         strings.ToLower(strings.TrimSpace(os.Getenv("IMAGE_TRUST_MODE_POLICY"))),
-		TrustedIssuers:     parseCSVEnv("IMAGE_TRUST_TRUSTED_ISSUERS"),
-		TrustedSubjects:    parseCSVEnv("IMAGE_TRUST_TRUSTED_SUBJECTS"),
-		TrustedSubjectREs:  parseCSVEnv("IMAGE_TRUST_TRUSTED_SUBJECT_REGEXPS"),
-		AttestationTypes:   parseCSVEnv("IMAGE_TRUST_ATTESTATION_TYPES"),
-		PublicKeyPaths:     parseCSVEnv("IMAGE_TRUST_PUBLIC_KEY_PATHS"),
-		PublicKeyRefs:      parseCSVEnv("IMAGE_TRUST_PUBLIC_KEY_REFS"),
-		PublicKeyDir:       strings.TrimSpace(os.Getenv("IMAGE_TRUST_PUBLIC_KEY_DIR")),
-		IgnoreTlog:         parseBoolEnv("IMAGE_TRUST_IGNORE_TLOG"),
-		SignerAllowlist:    parseCSVEnv("IMAGE_TRUST_SIGNER_ALLOWLIST"),
-		ImageAllowlist:     parseCSVEnv("IMAGE_TRUST_IMAGE_ALLOWLIST"),
-		RegistryAllowlist:  parseCSVEnv("IMAGE_TRUST_REGISTRY_ALLOWLIST"),
-		MaxConcurrentScans: DefaultMaxConcurrentScans,
-		ImageVerifyTimeout: DefaultImageVerifyTimeout,
-		ResolveDigests:     true,
-		VerifyRetries:      3,
-		VerifyRetryBackoff: 2 * time.Second,
-		VerifyRetryJitter:  true,
+		NamespaceAllowlist:      parseLowerCSVEnv("IMAGE_TRUST_NAMESPACE_ALLOWLIST"),
+		NamespaceBlocklist:      parseLowerCSVEnv("IMAGE_TRUST_NAMESPACE_BLOCKLIST"),
+		VerificationModes:       parseCSVEnv("IMAGE_TRUST_MODES"),
+		ModePolicy:              strings.ToLower(strings.TrimSpace(os.Getenv("IMAGE_TRUST_MODE_POLICY"))),
+		TrustedIssuers:          parseCSVEnv("IMAGE_TRUST_TRUSTED_ISSUERS"),
+		TrustedSubjects:         parseCSVEnv("IMAGE_TRUST_TRUSTED_SUBJECTS"),
+		TrustedSubjectREs:       parseCSVEnv("IMAGE_TRUST_TRUSTED_SUBJECT_REGEXPS"),
+		AttestationTypes:        parseCSVEnv("IMAGE_TRUST_ATTESTATION_TYPES"),
+		PublicKeyPaths:          parseCSVEnv("IMAGE_TRUST_PUBLIC_KEY_PATHS"),
+		PublicKeyRefs:           parseCSVEnv("IMAGE_TRUST_PUBLIC_KEY_REFS"),
+		PublicKeyDir:            strings.TrimSpace(os.Getenv("IMAGE_TRUST_PUBLIC_KEY_DIR")),
+		IgnoreTlog:              parseBoolEnv("IMAGE_TRUST_IGNORE_TLOG"),
+		NotationTrustPolicyPath: strings.TrimSpace(os.Getenv("IMAGE_TRUST_NOTATION_TRUST_POLICY")),
+		NotationTrustStoreDir:   strings.TrimSpace(os.Getenv("IMAGE_TRUST_NOTATION_TRUST_STORE_DIR")),
+		TrustPolicyFile:         strings.TrimSpace(os.Getenv("IMAGE_TRUST_POLICY_FILE")),
+		CacheFile:               strings.TrimSpace(os.Getenv("IMAGE_TRUST_CACHE_FILE")),
+		CacheTTL:                DefaultCacheTTL,
+		CacheUnsignedTTL:        DefaultCacheUnsignedTTL,
+		SignerAllowlist:         parseCSVEnv("IMAGE_TRUST_SIGNER_ALLOWLIST"),
+		ImageAllowlist:          parseCSVEnv("IMAGE_TRUST_IMAGE_ALLOWLIST"),
+		RegistryAllowlist:       parseCSVEnv("IMAGE_TRUST_REGISTRY_ALLOWLIST"),
+		MaxConcurrentScans:      DefaultMaxConcurrentScans,
+		ImageVerifyTimeout:      DefaultImageVerifyTimeout,
+		ResolveDigests:          true,
+		VerifyRetries:           3,
+		VerifyRetryBackoff:      2 * time.Second,
+		VerifyRetryJitter:       true,
 	}
 	if len(cfg.VerificationModes) == 0 {
 		cfg.VerificationModes = []string{ModeCosignKeyless}
 	}
 	cfg.VerificationModes = dedupeModes(cfg.VerificationModes)
+	provenance, err := loadProvenancePolicy()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Provenance = provenance
 	if cfg.ModePolicy == "" {
 		cfg.ModePolicy = ModePolicyAny
 	}
@@ -136,7 +162,32 @@ func LoadFromEnvironment() (*Config, error) {
 		cfg.VerifyRetryBackoff = time.Duration(value) * time.Second
 	}
 	cfg.VerifyRetryJitter = parseBoolEnvDefault("IMAGE_TRUST_VERIFY_RETRY_JITTER", true)
+
+	cacheTTL := os.Getenv("IMAGE_TRUST_CACHE_TTL_SECONDS")
+	if cacheTTL != "" {
+		value, err := strconv.Atoi(cacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing IMAGE_TRUST_CACHE_TTL_SECONDS: %w", err)
+		}
+		if value < 1 {
+			return nil, fmt.Errorf("IMAGE_TRUST_CACHE_TTL_SECONDS must be at least 1")
+		}
+		cfg.CacheTTL = time.Duration(value) * time.Second
+	}
+
+	cacheUnsignedTTL := os.Getenv("IMAGE_TRUST_CACHE_UNSIGNED_TTL_SECONDS")
+	if cacheUnsignedTTL != "" {
+		value, err := strconv.Atoi(cacheUnsignedTTL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing IMAGE_TRUST_CACHE_UNSIGNED_TTL_SECONDS: %w", err)
+		}
+		if value < 0 {
+			return nil, fmt.Errorf("IMAGE_TRUST_CACHE_UNSIGNED_TTL_SECONDS must be non-negative")
+		}
+		cfg.CacheUnsignedTTL = time.Duration(value) * time.Second
+	}
 	cfg.AttestationsEnabled = parseBoolEnv("IMAGE_TRUST_ATTESTATIONS_ENABLED")
+	cfg.loadNativeSettings()
 
 	cfg.ResolveVerificationModes()
 
@@ -163,15 +214,34 @@ func LoadFromEnvironment() (*Config, error) {
 		return nil, err
 	}
 
-	if modeEnabled(cfg.VerificationModes, ModeCosignKey) {
-		keys, err := LoadTrustedPublicKeys(cfg.PublicKeyPaths, cfg.PublicKeyRefs, cfg.PublicKeyDir)
+	if err := cfg.LoadTrustMaterial(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadTrustMaterial reads the public keys and notation trust required by the enabled modes.
+func (c *Config) LoadTrustMaterial() error {
+	if modeEnabled(c.VerificationModes, ModeCosignKey) {
+		keys, err := LoadTrustedPublicKeys(c.PublicKeyPaths, c.PublicKeyRefs, c.PublicKeyDir)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		c.TrustedPublicKeys = keys
+		if err := c.checkNativeKeys(); err != nil {
+			return err
 		}
-		cfg.TrustedPublicKeys = keys
 	}
 
-	return cfg, nil
+	if modeEnabled(c.VerificationModes, ModeNotation) {
+		trust, err := LoadNotationTrust(c.NotationTrustPolicyPath, c.NotationTrustStoreDir)
+		if err != nil {
+			return err
+		}
+		c.NotationTrust = trust
+	}
+	return nil
 }
 
 // Validate performs basic config validation.
@@ -193,7 +263,7 @@ func (c *Config) Validate() error {
 	}
 	for _, mode := range c.VerificationModes {
 		switch mode {
-		case ModeCosignKeyless, ModeCosignKey, ModeCosignAttestationKeyless, ModeCosignAttestationKey:
+		case ModeCosignKeyless, ModeCosignKey, ModeCosignAttestationKeyless, ModeCosignAttestationKey, ModeNotation:
 		default:
 			return fmt.Errorf("unsupported verification mode %q", mode)
 		}
@@ -226,6 +296,17 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("attestations enabled but no attestation verification mode could be configured; include cosign-keyless and/or cosign-key in IMAGE_TRUST_MODES (matching attestation modes are appended automatically), or set cosign-attestation-keyless and/or cosign-attestation-key explicitly")
 		}
 	}
+	if !c.Provenance.IsZero() {
+		if !modeEnabled(c.VerificationModes, ModeCosignAttestationKeyless) && !modeEnabled(c.VerificationModes, ModeCosignAttestationKey) {
+			return fmt.Errorf("IMAGE_TRUST_PROVENANCE_* settings require an attestation verification mode")
+		}
+		if !slices.ContainsFunc(c.AttestationTypes, IsProvenanceAttestationType) {
+			return fmt.Errorf("IMAGE_TRUST_PROVENANCE_* settings require a SLSA provenance type in IMAGE_TRUST_ATTESTATION_TYPES")
+		}
+		if err := c.Provenance.Validate(); err != nil {
+			return err
+		}
+	}
 	if modeEnabled(c.VerificationModes, ModeCosignAttestationKeyless) {
 		if len(c.TrustedIssuers) == 0 && len(c.TrustedSubjects) == 0 && len(c.TrustedSubjectREs) == 0 {
 			return fmt.Errorf("cosign-attestation-keyless requires at least one of IMAGE_TRUST_TRUSTED_ISSUERS, IMAGE_TRUST_TRUSTED_SUBJECTS, or IMAGE_TRUST_TRUSTED_SUBJECT_REGEXPS")
@@ -236,6 +317,14 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("cosign-attestation-key requires IMAGE_TRUST_PUBLIC_KEY_PATHS, IMAGE_TRUST_PUBLIC_KEY_REFS, or IMAGE_TRUST_PUBLIC_KEY_DIR")
 		}
 	}
+	if modeEnabled(c.VerificationModes, ModeNotation) {
+		if c.NotationTrustPolicyPath == "" || c.NotationTrustStoreDir == "" {
+			return fmt.Errorf("notation requires IMAGE_TRUST_NOTATION_TRUST_POLICY and IMAGE_TRUST_NOTATION_TRUST_STORE_DIR")
+		}
+	}
+	if err := c.validateNative(); err != nil {
+		return err
+	}
 	if len(c.TrustedSubjectREs) > MaxTrustedSubjectRegexpCount {
 		return fmt.Errorf("IMAGE_TRUST_TRUSTED_SUBJECT_REGEXPS supports at most %d patterns", MaxTrustedSubjectRegexpCount)
 	}
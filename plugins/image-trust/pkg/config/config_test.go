@@ -51,7 +51,7 @@ func TestValidateRejectsOverlap(t *testing.T) {
 
 func TestValidateRejectsUnsupportedMode(t *testing.T) {
 	setRequiredTrustPolicyEnv(t)
-	t.Setenv("IMAGE_TRUST_MODES", "notation")
+	t.Setenv("IMAGE_TRUST_MODES", "gpg")
 
 	_, err := LoadFromEnvironment()
 	require.Error(t, err)
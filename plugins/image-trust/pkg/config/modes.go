@@ -5,6 +5,7 @@ const (
 	ModeCosignKey                = "cosign-key"
 	ModeCosignAttestationKeyless = "cosign-attestation-keyless"
 	ModeCosignAttestationKey     = "cosign-attestation-key"
+	ModeNotation                 = "notation"
 
 	ModePolicyAny = "any"
 	ModePolicyAll = "all"
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadNativeSettings reads the modes verified in-process with the Sigstore libraries and
+// where their trusted root comes from.
+func (c *Config) loadNativeSettings() {
+	c.NativeModes = dedupeModes(parseCSVEnv("IMAGE_TRUST_NATIVE_MODES"))
+	c.SigstoreTrustedRootFile = strings.TrimSpace(os.Getenv("IMAGE_TRUST_SIGSTORE_TRUSTED_ROOT_FILE"))
+	c.SigstoreTUFMirror = strings.TrimSpace(os.Getenv("IMAGE_TRUST_SIGSTORE_TUF_MIRROR"))
+	c.SigstoreTUFRootFile = strings.TrimSpace(os.Getenv("IMAGE_TRUST_SIGSTORE_TUF_ROOT_FILE"))
+}
+
+// NativeModeEnabled reports whether mode is verified in-process instead of with the cosign binary.
+func (c *Config) NativeModeEnabled(mode string) bool {
+	return modeEnabled(c.NativeModes, mode)
+}
+
+func (c *Config) validateNative() error {
+	for _, mode := range c.NativeModes {
+		switch mode {
+		case ModeCosignKeyless, ModeCosignKey:
+		default:
+			return fmt.Errorf("IMAGE_TRUST_NATIVE_MODES: mode %q has no in-process verifier; supported: %s, %s", mode, ModeCosignKeyless, ModeCosignKey)
+		}
+	}
+	if c.SigstoreTrustedRootFile != "" && (c.SigstoreTUFMirror != "" || c.SigstoreTUFRootFile != "") {
+		return fmt.Errorf("IMAGE_TRUST_SIGSTORE_TRUSTED_ROOT_FILE cannot be combined with IMAGE_TRUST_SIGSTORE_TUF_MIRROR or IMAGE_TRUST_SIGSTORE_TUF_ROOT_FILE")
+	}
+	if c.SigstoreTUFMirror != "" && c.SigstoreTUFRootFile == "" {
+		return fmt.Errorf("IMAGE_TRUST_SIGSTORE_TUF_MIRROR requires IMAGE_TRUST_SIGSTORE_TUF_ROOT_FILE")
+	}
+	return nil
+}
+
+// checkNativeKeys rejects KMS and other remote key refs, which only the cosign binary resolves.
+func (c *Config) checkNativeKeys() error {
+	if !c.NativeModeEnabled(ModeCosignKey) {
+		return nil
+	}
+	for _, key := range c.TrustedPublicKeys {
+		if isRemoteKeyRef(key.Ref) {
+			return fmt.Errorf("in-process cosign-key verification supports only local public key files, not %s", key.Ref)
+		}
+	}
+	return nil
+}
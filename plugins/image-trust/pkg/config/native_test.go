@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromEnvironmentNativeModes(t *testing.T) {
+	setRequiredTrustPolicyEnv(t)
+	t.Setenv("IMAGE_TRUST_NATIVE_MODES", "cosign-keyless, cosign-keyless")
+	t.Setenv("IMAGE_TRUST_SIGSTORE_TUF_MIRROR", "https://tuf.internal.example.com")
+	t.Setenv("IMAGE_TRUST_SIGSTORE_TUF_ROOT_FILE", "/etc/sigstore/root.json")
+
+	cfg, err := LoadFromEnvironment()
+	require.NoError(t, err)
+	require.Equal(t, []string{ModeCosignKeyless}, cfg.NativeModes)
+	require.True(t, cfg.NativeModeEnabled(ModeCosignKeyless))
+	require.False(t, cfg.NativeModeEnabled(ModeCosignKey))
+	require.Equal(t, "https://tuf.internal.example.com", cfg.SigstoreTUFMirror)
+}
+
+func TestValidateNativeSettings(t *testing.T) {
+	require.ErrorContains(t, (&Config{NativeModes: []string{ModeNotation}}).validateNative(), "has no in-process verifier")
+	require.ErrorContains(t, (&Config{SigstoreTUFMirror: "https://tuf.example.com"}).validateNative(), "requires IMAGE_TRUST_SIGSTORE_TUF_ROOT_FILE")
+	require.ErrorContains(t, (&Config{
+		SigstoreTrustedRootFile: "/etc/sigstore/trusted_root.json",
+		SigstoreTUFRootFile:     "/etc/sigstore/root.json",
+	}).validateNative(), "cannot be combined")
+	require.NoError(t, (&Config{NativeModes: []string{ModeCosignKey}, SigstoreTrustedRootFile: "/etc/sigstore/trusted_root.json"}).validateNative())
+}
+
+func TestCheckNativeKeysRejectsRemoteRefs(t *testing.T) {
+	cfg := &Config{
+		NativeModes:       []string{ModeCosignKey},
+		TrustedPublicKeys: []TrustedPublicKey{{Ref: "awskms:///alias/release"}},
+	}
+	require.Error(t, cfg.checkNativeKeys())
+
+	cfg.NativeModes = nil
+	require.NoError(t, cfg.checkNativeKeys())
+}
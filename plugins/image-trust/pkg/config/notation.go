@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const MaxNotationTrustPolicyBytes = 256 * 1024
+
+var notationTrustStoreTypes = map[string]struct{}{
+	"ca":               {},
+	"signingAuthority": {},
+	"tsa":              {},
+}
+
+// NotationTrustPolicy is one entry of a Notary Project trust policy document.
+type NotationTrustPolicy struct {
+	Name                  string   `json:"name"`
+	RegistryScopes        []string `json:"registryScopes"`
+	SignatureVerification struct {
+		Level string `json:"level"`
+	} `json:"signatureVerification"`
+	TrustStores       []string `json:"trustStores"`
+	TrustedIdentities []string `json:"trustedIdentities"`
+}
+
+type notationTrustPolicyDocument struct {
+	Version       string                `json:"version"`
+	TrustPolicies []NotationTrustPolicy `json:"trustPolicies"`
+}
+
+// NotationTrust is a validated trust policy and trust store for notation mode.
+// TrustStoreDir uses the notation layout: x509/<ca|signingAuthority|tsa>/<name>/<certs>.
+type NotationTrust struct {
+	PolicyPath    string
+	TrustStoreDir string
+	Policies      []NotationTrustPolicy
+}
+
+// LoadNotationTrust reads the trust policy file and checks that every trust store it
+// references exists under trustStoreDir.
+func LoadNotationTrust(policyPath, trustStoreDir string) (*NotationTrust, error) {
+	if strings.TrimSpace(policyPath) == "" || strings.TrimSpace(trustStoreDir) == "" {
+		return nil, fmt.Errorf("notation requires a trust policy file and a trust store directory")
+	}
+	policyAbs, err := filepath.Abs(strings.TrimSpace(policyPath))
+	if err != nil {
+		return nil, fmt.Errorf("resolving notation trust policy path: %w", err)
+	}
+	storeAbs, err := filepath.Abs(strings.TrimSpace(trustStoreDir))
+	if err != nil {
+		return nil, fmt.Errorf("resolving notation trust store directory: %w", err)
+	}
+
+	info, err := os.Stat(policyAbs)
+	if err != nil {
+		return nil, fmt.Errorf("notation trust policy %s: %w", policyAbs, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("notation trust policy %s is a directory", policyAbs)
+	}
+	if info.Size() > MaxNotationTrustPolicyBytes {
+		return nil, fmt.Errorf("notation trust policy %s exceeds maximum size of %d bytes", policyAbs, MaxNotationTrustPolicyBytes)
+	}
+	data, err := os.ReadFile(policyAbs)
+	if err != nil {
+		return nil, fmt.Errorf("reading notation trust policy %s: %w", policyAbs, err)
+	}
+	var doc notationTrustPolicyDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing notation trust policy %s: %w", policyAbs, err)
+	}
+	if doc.Version == "" {
+		return nil, fmt.Errorf("notation trust policy %s is missing version", policyAbs)
+	}
+	if len(doc.TrustPolicies) == 0 {
+		return nil, fmt.Errorf("notation trust policy %s has no trustPolicies", policyAbs)
+	}
+
+	if info, err := os.Stat(storeAbs); err != nil {
+		return nil, fmt.Errorf("notation trust store %s: %w", storeAbs, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("notation trust store %s is not a directory", storeAbs)
+	}
+
+	for _, policy := range doc.TrustPolicies {
+		if policy.Name == "" {
+			return nil, fmt.Errorf("notation trust policy %s has an entry without a name", policyAbs)
+		}
+		if len(policy.RegistryScopes) == 0 {
+			return nil, fmt.Errorf("notation trust policy %q has no registryScopes", policy.Name)
+		}
+		if policy.SignatureVerification.Level == "skip" {
+			continue
+		}
+		if len(policy.TrustStores) == 0 {
+			return nil, fmt.Errorf("notation trust policy %q has no trustStores", policy.Name)
+		}
+		for _, store := range policy.TrustStores {
+			if err := checkNotationTrustStore(storeAbs, store); err != nil {
+				return nil, fmt.Errorf("notation trust policy %q: %w", policy.Name, err)
+			}
+		}
+	}
+
+	return &NotationTrust{
+		PolicyPath:    policyAbs,
+		TrustStoreDir: storeAbs,
+		Policies:      doc.TrustPolicies,
+	}, nil
+}
+
+// PolicyFor returns the trust policy that applies to a repository (for example
+// ghcr.io/example/api). An exact registry scope wins over the "*" wildcard.
+func (t *NotationTrust) PolicyFor(repository string) (NotationTrustPolicy, bool) {
+	if t == nil {
+		return NotationTrustPolicy{}, false
+	}
+	var wildcard *NotationTrustPolicy
+	for i, policy := range t.Policies {
+		for _, scope := range policy.RegistryScopes {
+			if scope == repository {
+				return policy, true
+			}
+			if scope == "*" && wildcard == nil {
+				wildcard = &t.Policies[i]
+			}
+		}
+	}
+	if wildcard != nil {
+		return *wildcard, true
+	}
+	return NotationTrustPolicy{}, false
+}
+
+func checkNotationTrustStore(root, store string) error {
+	storeType, name, ok := strings.Cut(store, ":")
+	if !ok || name == "" {
+		return fmt.Errorf("trust store %q must be in the form <type>:<name>", store)
+	}
+	if _, ok := notationTrustStoreTypes[storeType]; !ok {
+		return fmt.Errorf("trust store %q has unsupported type %q", store, storeType)
+	}
+	dir := filepath.Join(root, "x509", storeType, name)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("trust store %q: %w", store, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			return nil
+		}
+	}
+	return fmt.Errorf("trust store %q has no certificates in %s", store, dir)
+}
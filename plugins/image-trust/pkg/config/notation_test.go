@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testNotationPolicy = `{
+  "version": "1.0",
+  "trustPolicies": [
+    {
+      "name": "releases",
+      "registryScopes": ["ghcr.io/example/api"],
+      "signatureVerification": {"level": "strict"},
+      "trustStores": ["ca:release"],
+      "trustedIdentities": ["x509.subject: CN=release.example.com, O=Example"]
+    },
+    {
+      "name": "default",
+      "registryScopes": ["*"],
+      "signatureVerification": {"level": "skip"}
+    }
+  ]
+}`
+
+func writeNotationFixture(t *testing.T, policy string, withCert bool) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "trustpolicy.json")
+	require.NoError(t, os.WriteFile(policyPath, []byte(policy), 0o644))
+	storeDir := filepath.Join(dir, "truststore")
+	certDir := filepath.Join(storeDir, "x509", "ca", "release")
+	require.NoError(t, os.MkdirAll(certDir, 0o755))
+	if withCert {
+		require.NoError(t, os.WriteFile(filepath.Join(certDir, "root.crt"), []byte("cert"), 0o644))
+	}
+	return policyPath, storeDir
+}
+
+func TestLoadNotationTrust(t *testing.T) {
+	policyPath, storeDir := writeNotationFixture(t, testNotationPolicy, true)
+
+	trust, err := LoadNotationTrust(policyPath, storeDir)
+	require.NoError(t, err)
+	require.Len(t, trust.Policies, 2)
+	require.Equal(t, storeDir, trust.TrustStoreDir)
+
+	policy, ok := trust.PolicyFor("ghcr.io/example/api")
+	require.True(t, ok)
+	require.Equal(t, "releases", policy.Name)
+
+	policy, ok = trust.PolicyFor("docker.io/library/nginx")
+	require.True(t, ok)
+	require.Equal(t, "default", policy.Name)
+}
+
+func TestLoadNotationTrustRequiresCertificates(t *testing.T) {
+	policyPath, storeDir := writeNotationFixture(t, testNotationPolicy, false)
+
+	_, err := LoadNotationTrust(policyPath, storeDir)
+	require.ErrorContains(t, err, `trust store "ca:release" has no certificates`)
+}
+
+func TestLoadNotationTrustRejectsMalformedStore(t *testing.T) {
+	policyPath, storeDir := writeNotationFixture(t, `{"version":"1.0","trustPolicies":[{"name":"p","registryScopes":["*"],"signatureVerification":{"level":"strict"},"trustStores":["release"]}]}`, true)
+
+	_, err := LoadNotationTrust(policyPath, storeDir)
+	require.ErrorContains(t, err, "<type>:<name>")
+}
+
+func TestValidateNotationRequiresPolicyAndStore(t *testing.T) {
+	cfg := &Config{
+		VerificationModes:       []string{ModeNotation},
+		ModePolicy:              ModePolicyAny,
+		NotationTrustPolicyPath: "/etc/image-trust/notation/trustpolicy.json",
+	}
+	require.ErrorContains(t, cfg.Validate(), "IMAGE_TRUST_NOTATION_TRUST_STORE_DIR")
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxProvenancePatternLen bounds each provenance field pattern.
+const MaxProvenancePatternLen = 512
+
+// SLSAProvenancePredicatePrefix prefixes every SLSA provenance predicate type URI.
+const SLSAProvenancePredicatePrefix = "https://slsa.dev/provenance/"
+
+// ProvenancePolicy asserts on the contents of verified SLSA provenance attestations.
+// BuilderIDs, BuildTypes, SourceURIs and SourceRefs are regular expressions that must
+// match the whole field; several patterns for a field are OR. When
+// MaterialDigestAlgorithms is set, every material must record a digest in one of them
+// (compared case-insensitively, e.g. sha256 or gitCommit). Materials pins expected
+// materials by URI and digest.
+type ProvenancePolicy struct {
+	BuilderIDs               []string          `json:"builderIds,omitempty"`
+	BuildTypes               []string          `json:"buildTypes,omitempty"`
+	SourceURIs               []string          `json:"sourceUris,omitempty"`
+	SourceRefs               []string          `json:"sourceRefs,omitempty"`
+	MaterialDigestAlgorithms []string          `json:"materialDigestAlgorithms,omitempty"`
+	Materials                []MaterialMatcher `json:"materials,omitempty"`
+}
+
+// MaterialMatcher requires at least one material whose URI matches URI, and that every
+// such material records an Algorithm digest matching Digest. URI and Digest are regular
+// expressions that must match the whole value; Algorithm is compared case-insensitively.
+type MaterialMatcher struct {
+	URI       string `json:"uri"`
+	Algorithm string `json:"algorithm"`
+	Digest    string `json:"digest"`
+}
+
+func loadProvenancePolicy() (ProvenancePolicy, error) {
+	materials, err := parseMaterialMatchers(parseCSVEnv("IMAGE_TRUST_PROVENANCE_MATERIALS"))
+	if err != nil {
+		return ProvenancePolicy{}, fmt.Errorf("parsing IMAGE_TRUST_PROVENANCE_MATERIALS: %w", err)
+	}
+	return ProvenancePolicy{
+		BuilderIDs:               parseCSVEnv("IMAGE_TRUST_PROVENANCE_BUILDER_IDS"),
+		BuildTypes:               parseCSVEnv("IMAGE_TRUST_PROVENANCE_BUILD_TYPES"),
+		SourceURIs:               parseCSVEnv("IMAGE_TRUST_PROVENANCE_SOURCE_URIS"),
+		SourceRefs:               parseCSVEnv("IMAGE_TRUST_PROVENANCE_SOURCE_REFS"),
+		MaterialDigestAlgorithms: parseCSVEnv("IMAGE_TRUST_PROVENANCE_MATERIAL_DIGEST_ALGORITHMS"),
+		Materials:                materials,
+	}, nil
+}
+
+// parseMaterialMatchers parses uriPattern=algorithm:digestPattern entries. The URI
+// pattern ends at the last "=", so it may contain "=" itself.
+func parseMaterialMatchers(entries []string) ([]MaterialMatcher, error) {
+	var matchers []MaterialMatcher
+	for _, entry := range entries {
+		separator := strings.LastIndex(entry, "=")
+		if separator < 0 {
+			return nil, fmt.Errorf("material %q must be uriPattern=algorithm:digestPattern", entry)
+		}
+		algorithm, digest, ok := strings.Cut(entry[separator+1:], ":")
+		if !ok {
+			return nil, fmt.Errorf("material %q must be uriPattern=algorithm:digestPattern", entry)
+		}
+		matchers = append(matchers, MaterialMatcher{
+			URI:       strings.TrimSpace(entry[:separator]),
+			Algorithm: strings.TrimSpace(algorithm),
+			Digest:    strings.TrimSpace(digest),
+		})
+	}
+	return matchers, nil
+}
+
+// IsZero reports whether the policy has no assertions.
+func (p ProvenancePolicy) IsZero() bool {
+	return len(p.BuilderIDs) == 0 && len(p.BuildTypes) == 0 && len(p.SourceURIs) == 0 &&
+		len(p.SourceRefs) == 0 && len(p.MaterialDigestAlgorithms) == 0 && len(p.Materials) == 0
+}
+
+// Validate checks that every field pattern compiles.
+func (p ProvenancePolicy) Validate() error {
+	fields := []struct {
+		name     string
+		patterns []string
+	}{
+		{"builder id", p.BuilderIDs},
+		{"build type", p.BuildTypes},
+		{"source URI", p.SourceURIs},
+		{"source ref", p.SourceRefs},
+	}
+	for _, field := range fields {
+		for _, pattern := range field.patterns {
+			if len(pattern) > MaxProvenancePatternLen {
+				return fmt.Errorf("provenance %s pattern exceeds maximum length of %d characters", field.name, MaxProvenancePatternLen)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid provenance %s pattern %q: %w", field.name, pattern, err)
+			}
+		}
+	}
+	for _, material := range p.Materials {
+		if material.URI == "" || material.Algorithm == "" || material.Digest == "" {
+			return fmt.Errorf("provenance material matcher requires a uri, algorithm and digest pattern")
+		}
+		for _, pattern := range []string{material.URI, material.Digest} {
+			if len(pattern) > MaxProvenancePatternLen {
+				return fmt.Errorf("provenance material pattern exceeds maximum length of %d characters", MaxProvenancePatternLen)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid provenance material pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// IsProvenanceAttestationType reports whether a cosign --type value selects SLSA provenance.
+func IsProvenanceAttestationType(attestationType string) bool {
+	switch attestationType {
+	case "slsaprovenance", "slsaprovenance02", "slsaprovenance1":
+		return true
+	}
+	return strings.HasPrefix(attestationType, SLSAProvenancePredicatePrefix)
+}
+
+func (p ProvenancePolicy) trimmed() ProvenancePolicy {
+	return ProvenancePolicy{
+		BuilderIDs:               trimmedValues(p.BuilderIDs),
+		BuildTypes:               trimmedValues(p.BuildTypes),
+		SourceURIs:               trimmedValues(p.SourceURIs),
+		SourceRefs:               trimmedValues(p.SourceRefs),
+		MaterialDigestAlgorithms: trimmedValues(p.MaterialDigestAlgorithms),
+		Materials:                trimmedMaterials(p.Materials),
+	}
+}
+
+func trimmedMaterials(materials []MaterialMatcher) []MaterialMatcher {
+	if len(materials) == 0 {
+		return nil
+	}
+	trimmed := make([]MaterialMatcher, 0, len(materials))
+	for _, material := range materials {
+		trimmed = append(trimmed, MaterialMatcher{
+			URI:       strings.TrimSpace(material.URI),
+			Algorithm: strings.TrimSpace(material.Algorithm),
+			Digest:    strings.TrimSpace(material.Digest),
+		})
+	}
+	return trimmed
+}
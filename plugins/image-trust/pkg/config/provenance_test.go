@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromEnvironmentProvenancePolicy(t *testing.T) {
+	setRequiredTrustPolicyEnv(t)
+	t.Setenv("IMAGE_TRUST_ATTESTATION_TYPES", "slsaprovenance1")
+	t.Setenv("IMAGE_TRUST_PROVENANCE_BUILDER_IDS", `https://github\.com/slsa-framework/.+`)
+	t.Setenv("IMAGE_TRUST_PROVENANCE_SOURCE_REFS", "refs/heads/main, refs/tags/v.*")
+	t.Setenv("IMAGE_TRUST_PROVENANCE_MATERIAL_DIGEST_ALGORITHMS", "SHA256,gitCommit")
+	t.Setenv("IMAGE_TRUST_PROVENANCE_MATERIALS", `git\+https://github\.com/acme/api@.+=gitCommit:[0-9a-f]{40}, pkg:docker/golang@1\.22\?digest=.+=sha256:[0-9a-f]{64}`)
+
+	cfg, err := LoadFromEnvironment()
+	require.NoError(t, err)
+	require.Equal(t, []string{`https://github\.com/slsa-framework/.+`}, cfg.Provenance.BuilderIDs)
+	require.Equal(t, []string{"refs/heads/main", "refs/tags/v.*"}, cfg.Provenance.SourceRefs)
+	require.Equal(t, []string{"SHA256", "gitCommit"}, cfg.Provenance.MaterialDigestAlgorithms)
+	require.Equal(t, []MaterialMatcher{
+		{URI: `git\+https://github\.com/acme/api@.+`, Algorithm: "gitCommit", Digest: "[0-9a-f]{40}"},
+		{URI: `pkg:docker/golang@1\.22\?digest=.+`, Algorithm: "sha256", Digest: "[0-9a-f]{64}"},
+	}, cfg.Provenance.Materials)
+}
+
+func TestLoadFromEnvironmentRejectsMalformedProvenanceMaterial(t *testing.T) {
+	setRequiredTrustPolicyEnv(t)
+	t.Setenv("IMAGE_TRUST_PROVENANCE_MATERIALS", "pkg:docker/golang@1.22")
+
+	_, err := LoadFromEnvironment()
+	require.ErrorContains(t, err, "IMAGE_TRUST_PROVENANCE_MATERIALS")
+}
+
+func TestValidateProvenancePolicyRequiresAttestationMode(t *testing.T) {
+	cfg := &Config{
+		VerificationModes: []string{ModeCosignKeyless},
+		ModePolicy:        ModePolicyAny,
+		TrustedIssuers:    []string{"https://token.actions.githubusercontent.com"},
+		Provenance:        ProvenancePolicy{SourceRefs: []string{"refs/heads/main"}},
+	}
+	require.ErrorContains(t, cfg.Validate(), "require an attestation verification mode")
+}
+
+func TestValidateProvenancePolicyRequiresProvenanceAttestationType(t *testing.T) {
+	cfg := &Config{
+		VerificationModes: []string{ModeCosignAttestationKeyless},
+		ModePolicy:        ModePolicyAny,
+		AttestationTypes:  []string{"spdxjson"},
+		TrustedIssuers:    []string{"https://token.actions.githubusercontent.com"},
+		Provenance:        ProvenancePolicy{SourceRefs: []string{"refs/heads/main"}},
+	}
+	require.ErrorContains(t, cfg.Validate(), "require a SLSA provenance type")
+
+	cfg.AttestationTypes = append(cfg.AttestationTypes, "https://slsa.dev/provenance/v1")
+	require.NoError(t, cfg.Validate())
+}
+
+func TestValidateProvenancePolicyRejectsInvalidPattern(t *testing.T) {
+	require.Error(t, ProvenancePolicy{BuildTypes: []string{"(unclosed"}}.Validate())
+	require.NoError(t, ProvenancePolicy{BuildTypes: []string{`https://example\.com/build@v1`}}.Validate())
+	require.Error(t, ProvenancePolicy{Materials: []MaterialMatcher{{URI: ".+", Algorithm: "sha256", Digest: "(unclosed"}}}.Validate())
+	require.Error(t, ProvenancePolicy{Materials: []MaterialMatcher{{URI: ".+", Digest: ".+"}}}.Validate())
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TrustHash fingerprints the settings that decide a verification outcome: modes, signer
+// policy, provenance assertions, key and notation trust file contents, and Sigstore
+// environment and trusted root. Cached observations are only reused while the hash is unchanged.
+func (c *Config) TrustHash() (string, error) {
+	type keyFingerprint struct {
+		Ref     string `json:"ref"`
+		Content string `json:"content,omitempty"`
+	}
+	material := struct {
+		Modes             []string         `json:"modes"`
+		ModePolicy        string           `json:"modePolicy"`
+		TrustedIssuers    []string         `json:"trustedIssuers"`
+		TrustedSubjects   []string         `json:"trustedSubjects"`
+		TrustedSubjectREs []string         `json:"trustedSubjectREs"`
+		PublicKeys        []keyFingerprint `json:"publicKeys"`
+		IgnoreTlog        bool             `json:"ignoreTlog"`
+		AttestationTypes  []string         `json:"attestationTypes"`
+		Provenance        ProvenancePolicy `json:"provenance"`
+		NotationPolicy    string           `json:"notationPolicy,omitempty"`
+		NotationStore     []string         `json:"notationStore,omitempty"`
+		SigstoreEnv       []string         `json:"sigstoreEnv"`
+		NativeModes       []string         `json:"nativeModes,omitempty"`
+		TrustedRoot       string           `json:"trustedRoot,omitempty"`
+		TUFMirror         string           `json:"tufMirror,omitempty"`
+		TUFRoot           string           `json:"tufRoot,omitempty"`
+	}{
+		Modes:             c.VerificationModes,
+		ModePolicy:        c.ModePolicy,
+		TrustedIssuers:    c.TrustedIssuers,
+		TrustedSubjects:   c.TrustedSubjects,
+		TrustedSubjectREs: c.TrustedSubjectREs,
+		IgnoreTlog:        c.IgnoreTlog,
+		AttestationTypes:  c.AttestationTypes,
+		Provenance:        c.Provenance,
+		SigstoreEnv:       c.SigstoreEnv,
+		NativeModes:       c.NativeModes,
+		TUFMirror:         c.SigstoreTUFMirror,
+	}
+
+	for _, key := range c.TrustedPublicKeys {
+		fingerprint := keyFingerprint{Ref: key.Ref}
+		if !isRemoteKeyRef(key.Ref) {
+			digest, err := fileDigest(key.Ref)
+			if err != nil {
+				return "", err
+			}
+			fingerprint.Content = digest
+		}
+		material.PublicKeys = append(material.PublicKeys, fingerprint)
+	}
+
+	if c.NotationTrust != nil {
+		digest, err := fileDigest(c.NotationTrust.PolicyPath)
+		if err != nil {
+			return "", err
+		}
+		material.NotationPolicy = digest
+		store, err := notationTrustStoreDigests(c.NotationTrust)
+		if err != nil {
+			return "", err
+		}
+		material.NotationStore = store
+	}
+
+	if c.SigstoreTrustedRootFile != "" {
+		digest, err := fileDigest(c.SigstoreTrustedRootFile)
+		if err != nil {
+			return "", err
+		}
+		material.TrustedRoot = digest
+	}
+	if c.SigstoreTUFRootFile != "" {
+		digest, err := fileDigest(c.SigstoreTUFRootFile)
+		if err != nil {
+			return "", err
+		}
+		material.TUFRoot = digest
+	}
+
+	data, err := json.Marshal(material)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// notationTrustStoreDigests hashes the certificates of every trust store a policy references.
+// Paths are resolved through symlinks so mounted Secrets are read like plain directories.
+func notationTrustStoreDigests(trust *NotationTrust) ([]string, error) {
+	seen := make(map[string]struct{})
+	var digests []string
+	for _, policy := range trust.Policies {
+		if policy.SignatureVerification.Level == "skip" {
+			continue
+		}
+		for _, store := range policy.TrustStores {
+			storeType, name, _ := strings.Cut(store, ":")
+			dir := filepath.Join(trust.TrustStoreDir, "x509", storeType, name)
+			if _, ok := seen[dir]; ok {
+				continue
+			}
+			seen[dir] = struct{}{}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				return nil, fmt.Errorf("hashing notation trust store %q: %w", store, err)
+			}
+			for _, entry := range entries {
+				path := filepath.Join(dir, entry.Name())
+				if info, err := os.Stat(path); err != nil || info.IsDir() {
+					continue
+				}
+				digest, err := fileDigest(path)
+				if err != nil {
+					return nil, err
+				}
+				digests = append(digests, store+"/"+entry.Name()+"="+digest)
+			}
+		}
+	}
+	sort.Strings(digests)
+	return digests, nil
+}
+
+func fileDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
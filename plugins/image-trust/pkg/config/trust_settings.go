@@ -0,0 +1,72 @@
+package config
+
+import "strings"
+
+// TrustSettings are the verification settings a scoped trust policy carries. They
+// replace, rather than extend, the environment trust settings for matching images.
+type TrustSettings struct {
+	Modes                 []string         `json:"modes,omitempty"`
+	ModePolicy            string           `json:"modePolicy,omitempty"`
+	TrustedIssuers        []string         `json:"trustedIssuers,omitempty"`
+	TrustedSubjects       []string         `json:"trustedSubjects,omitempty"`
+	TrustedSubjectRegexps []string         `json:"trustedSubjectRegexps,omitempty"`
+	PublicKeyPaths        []string         `json:"publicKeyPaths,omitempty"`
+	PublicKeyRefs         []string         `json:"publicKeyRefs,omitempty"`
+	PublicKeyDir          string           `json:"publicKeyDir,omitempty"`
+	IgnoreTlog            bool             `json:"ignoreTlog,omitempty"`
+	AttestationTypes      []string         `json:"attestationTypes,omitempty"`
+	Provenance            ProvenancePolicy `json:"provenance,omitempty"`
+	NotationTrustPolicy   string           `json:"notationTrustPolicy,omitempty"`
+	NotationTrustStoreDir string           `json:"notationTrustStoreDir,omitempty"`
+}
+
+// WithTrustSettings returns a copy of the config whose trust settings are replaced by
+// settings. Registry, retry, timeout and Sigstore settings are kept. The copy is
+// validated and its keys and notation trust are loaded.
+func (c *Config) WithTrustSettings(settings TrustSettings) (*Config, error) {
+	scoped := *c
+	scoped.VerificationModes = dedupeModes(trimmedValues(settings.Modes))
+	if len(scoped.VerificationModes) == 0 {
+		scoped.VerificationModes = []string{ModeCosignKeyless}
+	}
+	scoped.ModePolicy = strings.ToLower(strings.TrimSpace(settings.ModePolicy))
+	if scoped.ModePolicy == "" {
+		scoped.ModePolicy = ModePolicyAny
+	}
+	scoped.TrustedIssuers = trimmedValues(settings.TrustedIssuers)
+	scoped.TrustedSubjects = trimmedValues(settings.TrustedSubjects)
+	scoped.TrustedSubjectREs = trimmedValues(settings.TrustedSubjectRegexps)
+	scoped.PublicKeyPaths = trimmedValues(settings.PublicKeyPaths)
+	scoped.PublicKeyRefs = trimmedValues(settings.PublicKeyRefs)
+	scoped.PublicKeyDir = strings.TrimSpace(settings.PublicKeyDir)
+	scoped.TrustedPublicKeys = nil
+	scoped.IgnoreTlog = settings.IgnoreTlog
+	scoped.AttestationTypes = trimmedValues(settings.AttestationTypes)
+	scoped.AttestationsEnabled = false
+	scoped.Provenance = settings.Provenance.trimmed()
+	scoped.NotationTrustPolicyPath = strings.TrimSpace(settings.NotationTrustPolicy)
+	scoped.NotationTrustStoreDir = strings.TrimSpace(settings.NotationTrustStoreDir)
+	scoped.NotationTrust = nil
+
+	scoped.ResolveVerificationModes()
+	if err := scoped.Validate(); err != nil {
+		return nil, err
+	}
+	if err := scoped.LoadTrustMaterial(); err != nil {
+		return nil, err
+	}
+	return &scoped, nil
+}
+
+func trimmedValues(values []string) []string {
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	if len(trimmed) == 0 {
+		return nil
+	}
+	return trimmed
+}
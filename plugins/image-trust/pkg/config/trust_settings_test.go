@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTrustSettingsReplacesTrustOnly(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor.pub"), []byte("key"), 0o644))
+	base := &Config{
+		VerificationModes:  []string{ModeCosignKeyless},
+		ModePolicy:         ModePolicyAny,
+		TrustedIssuers:     []string{"https://token.actions.githubusercontent.com"},
+		MaxConcurrentScans: 7,
+		RegistryUser:       "robot",
+	}
+
+	scoped, err := base.WithTrustSettings(TrustSettings{
+		Modes:            []string{ModeCosignKey},
+		PublicKeyDir:     dir,
+		AttestationTypes: []string{"slsaprovenance1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{ModeCosignKey, ModeCosignAttestationKey}, scoped.VerificationModes)
+	require.Empty(t, scoped.TrustedIssuers)
+	require.Len(t, scoped.TrustedPublicKeys, 1)
+	require.Equal(t, 7, scoped.MaxConcurrentScans)
+	require.Equal(t, "robot", scoped.RegistryUser)
+	require.Equal(t, []string{ModeCosignKeyless}, base.VerificationModes)
+}
+
+func TestWithTrustSettingsDefaultsToKeyless(t *testing.T) {
+	base := &Config{VerificationModes: []string{ModeCosignKey}, ModePolicy: ModePolicyAll}
+
+	_, err := base.WithTrustSettings(TrustSettings{})
+	require.ErrorContains(t, err, "cosign-keyless requires")
+
+	scoped, err := base.WithTrustSettings(TrustSettings{TrustedSubjectRegexps: []string{"^https://github.com/vendor/"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{ModeCosignKeyless}, scoped.VerificationModes)
+	require.Equal(t, ModePolicyAny, scoped.ModePolicy)
+}
+
+func TestTrustHashTracksKeyContent(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "release.pub")
+	require.NoError(t, os.WriteFile(keyPath, []byte("key-1"), 0o644))
+	cfg := &Config{
+		VerificationModes: []string{ModeCosignKey},
+		ModePolicy:        ModePolicyAny,
+		TrustedPublicKeys: []TrustedPublicKey{{Ref: keyPath, ID: "release.pub"}},
+	}
+
+	first, err := cfg.TrustHash()
+	require.NoError(t, err)
+	again, err := cfg.TrustHash()
+	require.NoError(t, err)
+	require.Equal(t, first, again)
+
+	require.NoError(t, os.WriteFile(keyPath, []byte("key-2"), 0o644))
+	rotated, err := cfg.TrustHash()
+	require.NoError(t, err)
+	require.NotEqual(t, first, rotated)
+
+	cfg.IgnoreTlog = true
+	withoutTlog, err := cfg.TrustHash()
+	require.NoError(t, err)
+	require.NotEqual(t, rotated, withoutTlog)
+}
@@ -51,7 +51,7 @@ func ListImages(ctx context.Context, kubeClient kubernetes.Interface, namespaceB
 	}
 
 	keyToImage := map[string]models.DiscoveredImage{}
-	imageOwners := map[string]map[models.Resource]struct{}{}
+	imageOwners := map[string]map[models.Resource]map[string]string{}
 	seenPods := map[string]struct{}{}
 
 	for _, controller := range controllers {
@@ -76,7 +76,7 @@ func ListImages(ctx context.Context, kubeClient kubernetes.Interface, namespaceB
 			markPodSeen(seenPods, pod.Namespace, pod.Name)
 
 			for _, status := range containerStatusesFromPod(pod) {
-				recordContainerImage(status, owner, keyToImage, imageOwners)
+				recordContainerImage(status, owner, pod.Labels, keyToImage, imageOwners)
 			}
 		}
 	}
@@ -95,6 +95,7 @@ func ListImages(ctx context.Context, kubeClient kubernetes.Interface, namespaceB
 	for key, image := range keyToImage {
 		if owners, ok := imageOwners[key]; ok {
 			image.Owners = lo.Keys(owners)
+			image.OwnerLabels = owners
 			keyToImage[key] = image
 		}
 	}
@@ -118,7 +119,7 @@ func recordOrphanPods(
 	namespaces []string,
 	seenPods map[string]struct{},
 	keyToImage map[string]models.DiscoveredImage,
-	imageOwners map[string]map[models.Resource]struct{},
+	imageOwners map[string]map[models.Resource]map[string]string,
 ) error {
 	for _, namespace := range namespaces {
 		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: "status.phase=Running"})
@@ -139,7 +140,7 @@ func recordOrphanPods(
 			}
 			markPodSeen(seenPods, pod.Namespace, pod.Name)
 			for _, status := range containerStatusesFromPod(pod) {
-				recordContainerImage(status, owner, keyToImage, imageOwners)
+				recordContainerImage(status, owner, pod.Labels, keyToImage, imageOwners)
 			}
 		}
 	}
@@ -152,7 +153,7 @@ func recordJobPods(
 	namespaces []string,
 	seenPods map[string]struct{},
 	keyToImage map[string]models.DiscoveredImage,
-	imageOwners map[string]map[models.Resource]struct{},
+	imageOwners map[string]map[models.Resource]map[string]string,
 ) error {
 	for _, namespace := range namespaces {
 		jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
@@ -187,7 +188,7 @@ func recordJobPods(
 				}
 				markPodSeen(seenPods, pod.Namespace, pod.Name)
 				for _, status := range containerStatusesFromPod(pod) {
-					recordContainerImage(status, owner, keyToImage, imageOwners)
+					recordContainerImage(status, owner, pod.Labels, keyToImage, imageOwners)
 				}
 			}
 		}
@@ -244,7 +245,7 @@ func containerStatusesFromPod(pod corev1.Pod) []corev1.ContainerStatus {
 	return statuses
 }
 
-func recordContainerImage(status corev1.ContainerStatus, owner models.Resource, keyToImage map[string]models.DiscoveredImage, imageOwners map[string]map[models.Resource]struct{}) {
+func recordContainerImage(status corev1.ContainerStatus, owner models.Resource, labels map[string]string, keyToImage map[string]models.DiscoveredImage, imageOwners map[string]map[models.Resource]map[string]string) {
 	imageName := status.Image
 	if strings.HasPrefix(status.Image, "sha256") {
 		imageName = strings.TrimPrefix(status.ImageID, "docker-pullable://")
@@ -262,9 +263,9 @@ func recordContainerImage(status corev1.ContainerStatus, owner models.Resource,
 
 	key := imageName + "/" + imageID
 	if imageOwners[key] == nil {
-		imageOwners[key] = map[models.Resource]struct{}{}
+		imageOwners[key] = map[models.Resource]map[string]string{}
 	}
-	imageOwners[key][owner] = struct{}{}
+	imageOwners[key][owner] = labels
 	if _, found := keyToImage[key]; found {
 		return
 	}
@@ -96,14 +96,14 @@ func TestRecordContainerImageDedupes(t *testing.T) {
 		Name:      "api",
 	}
 	keyToImage := map[string]models.DiscoveredImage{}
-	imageOwners := map[string]map[models.Resource]struct{}{}
+	imageOwners := map[string]map[models.Resource]map[string]string{}
 
 	status := corev1.ContainerStatus{
 		Name:    "app",
 		Image:   "docker.io/library/nginx:1.25",
 		ImageID: "docker-pullable://docker.io/library/nginx@sha256:abc",
 	}
-	recordContainerImage(status, owner, keyToImage, imageOwners)
+	recordContainerImage(status, owner, map[string]string{"app": "api"}, keyToImage, imageOwners)
 
 	if len(keyToImage) != 1 {
 		t.Fatalf("expected one image, got %d", len(keyToImage))
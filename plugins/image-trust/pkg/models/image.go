@@ -17,6 +17,8 @@ type DiscoveredImage struct {
 	PullRef            string     `json:"pullRef"`
 	Owners             []Resource `json:"owners"`
 	DigestResolveError string     `json:"digestResolveError,omitempty"`
+	// OwnerLabels holds the pod labels observed for each owner, for trust policy selectors.
+	OwnerLabels map[Resource]map[string]string `json:"-"`
 }
 
 // Digest returns the digest portion of the image ID when present.
@@ -5,11 +5,14 @@ import "time"
 type Status string
 
 const (
-	StatusVerified          Status = "verified"
-	StatusUnsigned          Status = "unsigned"
-	StatusSignedUntrusted   Status = "signed_untrusted"
-	StatusVerificationError Status = "verification_error"
-	StatusUnknown           Status = "unknown"
+	StatusVerified        Status = "verified"
+	StatusUnsigned        Status = "unsigned"
+	StatusSignedUntrusted Status = "signed_untrusted"
+	// StatusProvenanceViolation marks a trusted attestation whose SLSA provenance
+	// does not satisfy the configured provenance policy.
+	StatusProvenanceViolation Status = "provenance_violation"
+	StatusVerificationError   Status = "verification_error"
+	StatusUnknown             Status = "unknown"
 )
 
 // SignerDetails captures signer information when available.
@@ -21,21 +24,24 @@ type SignerDetails struct {
 
 // ImageTrustResult is the final per-image trust state sent to Insights.
 type ImageTrustResult struct {
-	Name               string          `json:"name"`
-	ID                 string          `json:"id"`
-	PullRef            string          `json:"pullRef"`
-	Status             Status          `json:"status"`
-	Reason             string          `json:"reason,omitempty"`
-	VerificationMode   string          `json:"verificationMode,omitempty"`
-	VerifiedBy         string          `json:"verifiedBy,omitempty"`
-	AttestationType    string          `json:"attestationType,omitempty"`
-	Allowlisted        bool            `json:"allowlisted"`
-	AllowlistReason    string          `json:"allowlistReason,omitempty"`
-	Owners             []Resource      `json:"owners"`
-	Signer             SignerDetails   `json:"signer"`
-	CandidateSigners   []SignerDetails `json:"candidateSigners,omitempty"`
-	DigestResolveError string          `json:"digestResolveError,omitempty"`
-	LastCheckedAt      time.Time       `json:"lastCheckedAt"`
+	Name                 string          `json:"name"`
+	ID                   string          `json:"id"`
+	PullRef              string          `json:"pullRef"`
+	Status               Status          `json:"status"`
+	Reason               string          `json:"reason,omitempty"`
+	VerificationMode     string          `json:"verificationMode,omitempty"`
+	VerifiedBy           string          `json:"verifiedBy,omitempty"`
+	AttestationType      string          `json:"attestationType,omitempty"`
+	Allowlisted          bool            `json:"allowlisted"`
+	AllowlistReason      string          `json:"allowlistReason,omitempty"`
+	Owners               []Resource      `json:"owners"`
+	Signer               SignerDetails   `json:"signer"`
+	CandidateSigners     []SignerDetails `json:"candidateSigners,omitempty"`
+	ProvenanceViolations []string        `json:"provenanceViolations,omitempty"`
+	DigestResolveError   string          `json:"digestResolveError,omitempty"`
+	TrustPolicy          string          `json:"trustPolicy,omitempty"`
+	Cached               bool            `json:"cached,omitempty"`
+	LastCheckedAt        time.Time       `json:"lastCheckedAt"`
 }
 
 // Finding is a derived action item for non-compliant images.
@@ -60,18 +66,20 @@ type AllowlistPolicy struct {
 
 // ReportPolicy captures trust-related configuration applied when the report was generated.
 type ReportPolicy struct {
-	Allowlists AllowlistPolicy `json:"allowlists"`
+	Allowlists    AllowlistPolicy `json:"allowlists"`
+	TrustPolicies []string        `json:"trustPolicies,omitempty"`
 }
 
 // Summary aggregates image-trust statuses across all images in the report.
 type Summary struct {
-	TotalImages       int `json:"totalImages"`
-	Verified          int `json:"verified"`
-	Unsigned          int `json:"unsigned"`
-	SignedUntrusted   int `json:"signedUntrusted"`
-	VerificationError int `json:"verificationError"`
-	Unknown           int `json:"unknown"`
-	Allowlisted       int `json:"allowlisted"`
+	TotalImages         int `json:"totalImages"`
+	Verified            int `json:"verified"`
+	Unsigned            int `json:"unsigned"`
+	SignedUntrusted     int `json:"signedUntrusted"`
+	ProvenanceViolation int `json:"provenanceViolation"`
+	VerificationError   int `json:"verificationError"`
+	Unknown             int `json:"unknown"`
+	Allowlisted         int `json:"allowlisted"`
 }
 
 // Report is the top-level image-trust report payload.
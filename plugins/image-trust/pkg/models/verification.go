@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // VerificationMode identifies the verification strategy used for an image.
 type VerificationMode string
 
@@ -8,15 +10,22 @@ const (
 	VerificationModeCosignKey                VerificationMode = "cosign-key"
 	VerificationModeCosignAttestationKeyless VerificationMode = "cosign-attestation-keyless"
 	VerificationModeCosignAttestationKey     VerificationMode = "cosign-attestation-key"
+	VerificationModeNotation                 VerificationMode = "notation"
 )
 
-// VerificationObservation is the raw result returned by a verifier.
+// VerificationObservation is the raw result returned by a verifier. CheckedAt is set
+// when the observation was served from the verification cache. ProvenanceViolations
+// lists the failed provenance assertions of a provenance_violation observation.
+// Transient marks a verification_error that verifiers know is worth retrying.
 type VerificationObservation struct {
-	Mode             VerificationMode
-	VerifiedBy       VerificationMode
-	Status           Status
-	Reason           string
-	AttestationType  string
-	Signer           SignerDetails
-	Signers          []SignerDetails
+	Mode                 VerificationMode
+	VerifiedBy           VerificationMode
+	Status               Status
+	Reason               string
+	AttestationType      string
+	Signer               SignerDetails
+	Signers              []SignerDetails
+	ProvenanceViolations []string
+	CheckedAt            time.Time
+	Transient            bool
 }
@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultTrustPolicyName is reported for images that no trust policy matched; they
+	// are verified with the environment configuration.
+	DefaultTrustPolicyName = "default"
+
+	MaxTrustPolicyFileBytes = 1024 * 1024
+	MaxTrustPolicies        = 64
+)
+
+// TrustPolicyFile is the YAML document referenced by IMAGE_TRUST_POLICY_FILE.
+type TrustPolicyFile struct {
+	Policies []TrustPolicy `json:"policies"`
+}
+
+// TrustPolicy scopes a set of trust settings to the images it matches.
+type TrustPolicy struct {
+	Name  string           `json:"name"`
+	Match TrustPolicyMatch `json:"match"`
+	config.TrustSettings
+
+	selector labels.Selector
+}
+
+// TrustPolicyMatch selects images. Every configured criterion must match; an empty
+// match selects all images. Namespaces and labelSelector must hold for the same owner.
+type TrustPolicyMatch struct {
+	Images        []string              `json:"images,omitempty"`
+	Registries    []string              `json:"registries,omitempty"`
+	Namespaces    []string              `json:"namespaces,omitempty"`
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// LoadTrustPolicies reads ordered trust policies from a YAML file.
+func LoadTrustPolicies(path string) ([]TrustPolicy, error) {
+	abs, err := filepath.Abs(strings.TrimSpace(path))
+	if err != nil {
+		return nil, fmt.Errorf("resolving trust policy file: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("trust policy file %s: %w", abs, err)
+	}
+	if info.Size() > MaxTrustPolicyFileBytes {
+		return nil, fmt.Errorf("trust policy file %s exceeds maximum size of %d bytes", abs, MaxTrustPolicyFileBytes)
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("reading trust policy file %s: %w", abs, err)
+	}
+	return ParseTrustPolicies(data)
+}
+
+// ParseTrustPolicies parses and validates a trust policy YAML document.
+func ParseTrustPolicies(data []byte) ([]TrustPolicy, error) {
+	var file TrustPolicyFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing trust policy file: %w", err)
+	}
+	if len(file.Policies) == 0 {
+		return nil, fmt.Errorf("trust policy file has no policies")
+	}
+	if len(file.Policies) > MaxTrustPolicies {
+		return nil, fmt.Errorf("trust policy file supports at most %d policies", MaxTrustPolicies)
+	}
+
+	seen := make(map[string]struct{}, len(file.Policies))
+	for i := range file.Policies {
+		policy := &file.Policies[i]
+		policy.Name = strings.TrimSpace(policy.Name)
+		if policy.Name == "" {
+			return nil, fmt.Errorf("trust policy %d has no name", i+1)
+		}
+		if policy.Name == DefaultTrustPolicyName {
+			return nil, fmt.Errorf("trust policy name %q is reserved for the environment configuration", DefaultTrustPolicyName)
+		}
+		if _, ok := seen[policy.Name]; ok {
+			return nil, fmt.Errorf("duplicate trust policy name %q", policy.Name)
+		}
+		seen[policy.Name] = struct{}{}
+
+		for _, pattern := range append(append(append([]string(nil), policy.Match.Images...), policy.Match.Registries...), policy.Match.Namespaces...) {
+			if !doublestar.ValidatePattern(pattern) {
+				return nil, fmt.Errorf("trust policy %q: invalid pattern %q", policy.Name, pattern)
+			}
+		}
+		if policy.Match.LabelSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(policy.Match.LabelSelector)
+			if err != nil {
+				return nil, fmt.Errorf("trust policy %q: invalid labelSelector: %w", policy.Name, err)
+			}
+			policy.selector = selector
+		}
+	}
+	return file.Policies, nil
+}
+
+// Matches reports whether the policy applies to an image.
+func (p TrustPolicy) Matches(image models.DiscoveredImage) bool {
+	if len(p.Match.Images) > 0 && !matchAny(p.Match.Images, image.Name, image.ID, image.PullRef, image.VerificationReference()) {
+		return false
+	}
+	if len(p.Match.Registries) > 0 && !matchAny(p.Match.Registries,
+		registryFromReference(image.Name),
+		registryFromReference(image.VerificationReference()),
+	) {
+		return false
+	}
+	if len(p.Match.Namespaces) == 0 && p.selector == nil {
+		return true
+	}
+	for _, owner := range image.Owners {
+		if len(p.Match.Namespaces) > 0 && !matchAny(p.Match.Namespaces, owner.Namespace) {
+			continue
+		}
+		if p.selector != nil && !p.selector.Matches(labels.Set(image.OwnerLabels[owner])) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// TrustPolicyNames returns policy names in evaluation order, ending with the default.
+func TrustPolicyNames(policies []TrustPolicy) []string {
+	if len(policies) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(policies)+1)
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+	return append(names, DefaultTrustPolicyName)
+}
+
+func matchAny(patterns []string, candidates ...string) bool {
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if candidate == "" {
+				continue
+			}
+			if matched, _ := doublestar.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
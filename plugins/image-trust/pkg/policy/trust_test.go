@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+const testTrustPolicies = `
+policies:
+  - name: platform
+    match:
+      registries: ["ghcr.io"]
+      images: ["ghcr.io/acme/**"]
+    modes: [cosign-keyless]
+    trustedIssuers: ["https://token.actions.githubusercontent.com"]
+    trustedSubjectRegexps: ["^https://github.com/acme/"]
+  - name: vendor-monitoring
+    match:
+      namespaces: ["monitoring"]
+      labelSelector:
+        matchLabels:
+          app.kubernetes.io/part-of: vendor
+    modes: [cosign-key]
+    publicKeyDir: /etc/image-trust/vendor-keys
+`
+
+func TestParseTrustPolicies(t *testing.T) {
+	policies, err := ParseTrustPolicies([]byte(testTrustPolicies))
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	require.Equal(t, []string{config.ModeCosignKeyless}, policies[0].Modes)
+	require.Equal(t, []string{"^https://github.com/acme/"}, policies[0].TrustedSubjectRegexps)
+	require.Equal(t, "/etc/image-trust/vendor-keys", policies[1].PublicKeyDir)
+	require.Equal(t, []string{"platform", "vendor-monitoring", DefaultTrustPolicyName}, TrustPolicyNames(policies))
+}
+
+func TestParseTrustPoliciesRejectsInvalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unknown field":  "policies:\n  - name: a\n    trustedIssuer: [x]\n",
+		"reserved name":  "policies:\n  - name: default\n",
+		"duplicate name": "policies:\n  - name: a\n  - name: a\n",
+		"bad selector":   "policies:\n  - name: a\n    match:\n      labelSelector:\n        matchExpressions:\n          - {key: app, operator: Bogus}\n",
+		"no policies":    "policies: []\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTrustPolicies([]byte(doc))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestTrustPolicyMatches(t *testing.T) {
+	policies, err := ParseTrustPolicies([]byte(testTrustPolicies))
+	require.NoError(t, err)
+	platform, vendor := policies[0], policies[1]
+
+	platformImage := models.DiscoveredImage{
+		Name: "ghcr.io/acme/api:1.0.0",
+		ID:   "ghcr.io/acme/api@sha256:abc",
+	}
+	require.True(t, platform.Matches(platformImage))
+	require.False(t, platform.Matches(models.DiscoveredImage{Name: "ghcr.io/other/api:1.0.0"}))
+
+	exporter := models.Resource{Namespace: "monitoring", Kind: "Deployment", Name: "exporter", Container: "exporter"}
+	web := models.Resource{Namespace: "web", Kind: "Deployment", Name: "frontend", Container: "app"}
+	vendorImage := models.DiscoveredImage{
+		Name:   "quay.io/vendor/exporter:2.0",
+		Owners: []models.Resource{web, exporter},
+		OwnerLabels: map[models.Resource]map[string]string{
+			web:      {"app.kubernetes.io/part-of": "vendor"},
+			exporter: {"app.kubernetes.io/part-of": "vendor"},
+		},
+	}
+	require.True(t, vendor.Matches(vendorImage))
+
+	// Namespace and selector must hold for the same owner.
+	vendorImage.OwnerLabels[exporter] = map[string]string{"app.kubernetes.io/part-of": "internal"}
+	require.False(t, vendor.Matches(vendorImage))
+}
@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// ListReferrers returns artifacts of artifactType attached to a digest reference.
+// Registries without the OCI referrers API are queried through the referrers tag schema.
+func ListReferrers(ctx context.Context, creds Credentials, ref string, artifactType string) ([]v1.Descriptor, error) {
+	digest, err := name.NewDigest(ref, name.WeakValidation)
+	if err != nil {
+		return nil, fmt.Errorf("parsing digest reference %q: %w", ref, err)
+	}
+
+	opts, err := remoteOptions(ctx, creds, ref)
+	if err != nil {
+		return nil, err
+	}
+	if artifactType != "" {
+		opts = append(opts, remote.WithFilter("artifactType", artifactType))
+	}
+
+	index, err := remote.Referrers(digest, opts...)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	referrers := make([]v1.Descriptor, 0, len(manifest.Manifests))
+	for _, desc := range manifest.Manifests {
+		if artifactType != "" && desc.ArtifactType != artifactType {
+			continue
+		}
+		referrers = append(referrers, desc)
+	}
+	return referrers, nil
+}
@@ -50,19 +50,11 @@ func ResolveDigest(ctx context.Context, creds Credentials, image models.Discover
 		return "", fmt.Errorf("parsing reference %q: %w", ref, err)
 	}
 
-	keychain, err := creds.Keychain()
+	opts, err := remoteOptions(ctx, creds, ref)
 	if err != nil {
 		return "", err
 	}
 
-	opts := []remote.Option{
-		remote.WithContext(ctx),
-		remote.WithAuthFromKeychain(keychain),
-	}
-	if transport, err := TransportForReference(ref, creds.CertDir, creds.PerRegistryCertDirs); err == nil && transport != nil {
-		opts = append(opts, remote.WithTransport(transport))
-	}
-
 	desc, err := remote.Head(parsed, opts...)
 	if err != nil {
 		return "", err
@@ -75,6 +67,23 @@ func ResolveDigest(ctx context.Context, creds Credentials, image models.Discover
 	return digestRef.String(), nil
 }
 
+// remoteOptions builds registry API options with the configured keychain and TLS settings.
+func remoteOptions(ctx context.Context, creds Credentials, ref string) ([]remote.Option, error) {
+	keychain, err := creds.Keychain()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(keychain),
+	}
+	if transport, err := TransportForReference(ref, creds.CertDir, creds.PerRegistryCertDirs); err == nil && transport != nil {
+		opts = append(opts, remote.WithTransport(transport))
+	}
+	return opts, nil
+}
+
 func tagReference(image models.DiscoveredImage) string {
 	candidates := []string{image.Name, image.PullRef}
 	for _, candidate := range candidates {
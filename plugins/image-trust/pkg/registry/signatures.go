@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	// SigstoreBundleArtifactType is the artifact type of Sigstore bundles attached as OCI referrers.
+	SigstoreBundleArtifactType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+	cosignSignatureAnnotation   = "dev.cosignproject.cosign/signature"
+	cosignCertificateAnnotation = "dev.sigstore.cosign/certificate"
+	cosignChainAnnotation       = "dev.sigstore.cosign/chain"
+	cosignBundleAnnotation      = "dev.sigstore.cosign/bundle"
+
+	maxSignatureLayerBytes = 4 * 1024 * 1024
+)
+
+// CosignSignature is one signature cosign stored under the sha256-<hex>.sig tag: the signed
+// payload layer and the annotations carrying the signature, certificate and Rekor bundle.
+type CosignSignature struct {
+	Payload     []byte
+	Signature   string
+	Certificate string
+	Chain       string
+	RekorBundle string
+}
+
+// FetchCosignSignatures reads the signatures attached to a digest reference with the cosign
+// tag layout. A missing signature tag returns no signatures.
+func FetchCosignSignatures(ctx context.Context, creds Credentials, ref string) ([]CosignSignature, error) {
+	digest, err := name.NewDigest(ref, name.WeakValidation)
+	if err != nil {
+		return nil, fmt.Errorf("parsing digest reference %q: %w", ref, err)
+	}
+	algorithm, hex, _ := strings.Cut(digest.DigestStr(), ":")
+	tag := digest.Context().Tag(algorithm + "-" + hex + ".sig")
+
+	opts, err := remoteOptions(ctx, creds, ref)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(tag, opts...)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := make([]CosignSignature, 0, len(manifest.Layers))
+	for _, desc := range manifest.Layers {
+		payload, err := readLayer(img, desc)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, CosignSignature{
+			Payload:     payload,
+			Signature:   desc.Annotations[cosignSignatureAnnotation],
+			Certificate: desc.Annotations[cosignCertificateAnnotation],
+			Chain:       desc.Annotations[cosignChainAnnotation],
+			RekorBundle: desc.Annotations[cosignBundleAnnotation],
+		})
+	}
+	return signatures, nil
+}
+
+// FetchSigstoreBundles returns the JSON Sigstore bundles attached to a digest reference as
+// OCI referrers, as written by cosign with the new bundle format.
+func FetchSigstoreBundles(ctx context.Context, creds Credentials, ref string) ([][]byte, error) {
+	referrers, err := ListReferrers(ctx, creds, ref, SigstoreBundleArtifactType)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(referrers) == 0 {
+		return nil, nil
+	}
+
+	digest, err := name.NewDigest(ref, name.WeakValidation)
+	if err != nil {
+		return nil, fmt.Errorf("parsing digest reference %q: %w", ref, err)
+	}
+	opts, err := remoteOptions(ctx, creds, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles [][]byte
+	for _, referrer := range referrers {
+		img, err := remote.Image(digest.Context().Digest(referrer.Digest.String()), opts...)
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := img.Manifest()
+		if err != nil {
+			return nil, err
+		}
+		for _, desc := range manifest.Layers {
+			if !strings.HasPrefix(string(desc.MediaType), "application/vnd.dev.sigstore.bundle") {
+				continue
+			}
+			data, err := readLayer(img, desc)
+			if err != nil {
+				return nil, err
+			}
+			bundles = append(bundles, data)
+		}
+	}
+	return bundles, nil
+}
+
+// IsNotFound reports whether a registry API error is an HTTP 404.
+func IsNotFound(err error) bool {
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound
+}
+
+func readLayer(img v1.Image, desc v1.Descriptor) ([]byte, error) {
+	if desc.Size > maxSignatureLayerBytes {
+		return nil, fmt.Errorf("signature layer %s exceeds maximum size of %d bytes", desc.Digest, maxSignatureLayerBytes)
+	}
+	layer, err := img.LayerByDigest(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(io.LimitReader(reader, maxSignatureLayerBytes))
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+)
+
+// pushImage writes a random image to an in-process registry and returns its repository
+// and digest.
+func pushImage(t *testing.T) (name.Repository, v1.Image) {
+	t.Helper()
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.WithReferrersSupport(true)))
+	t.Cleanup(server.Close)
+
+	repo, err := name.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/example/api")
+	require.NoError(t, err)
+	img, err := random.Image(256, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Tag("1.0.0"), img))
+	return repo, img
+}
+
+func TestFetchCosignSignatures(t *testing.T) {
+	repo, img := pushImage(t)
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	payload := []byte(`{"critical":{"image":{"docker-manifest-digest":"` + digest.String() + `"}}}`)
+	signature, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json"),
+		Annotations: map[string]string{
+			cosignSignatureAnnotation:   "c2lnbmF0dXJl",
+			cosignCertificateAnnotation: "-----BEGIN CERTIFICATE-----",
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Tag("sha256-"+digest.Hex+".sig"), signature))
+
+	signatures, err := FetchCosignSignatures(context.Background(), Credentials{}, repo.Digest(digest.String()).String())
+	require.NoError(t, err)
+	require.Len(t, signatures, 1)
+	require.Equal(t, payload, signatures[0].Payload)
+	require.Equal(t, "c2lnbmF0dXJl", signatures[0].Signature)
+	require.Equal(t, "-----BEGIN CERTIFICATE-----", signatures[0].Certificate)
+	require.Empty(t, signatures[0].RekorBundle)
+}
+
+func TestFetchCosignSignaturesUnsigned(t *testing.T) {
+	repo, img := pushImage(t)
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	signatures, err := FetchCosignSignatures(context.Background(), Credentials{}, repo.Digest(digest.String()).String())
+	require.NoError(t, err)
+	require.Empty(t, signatures)
+}
+
+func TestFetchSigstoreBundles(t *testing.T) {
+	repo, img := pushImage(t)
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	manifest, err := img.RawManifest()
+	require.NoError(t, err)
+	mediaType, err := img.MediaType()
+	require.NoError(t, err)
+
+	bundleJSON := []byte(`{"mediaType":"application/vnd.dev.sigstore.bundle.v0.3+json"}`)
+	referrer, err := mutate.Append(mutate.MediaType(empty.Image, types.OCIManifestSchema1), mutate.Addendum{
+		Layer: static.NewLayer(bundleJSON, SigstoreBundleArtifactType),
+	})
+	require.NoError(t, err)
+	referrer = mutate.ConfigMediaType(referrer, types.MediaType(SigstoreBundleArtifactType))
+	referrer = mutate.Subject(referrer, v1.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest,
+		Size:      int64(len(manifest)),
+	}).(v1.Image)
+	referrerDigest, err := referrer.Digest()
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Digest(referrerDigest.String()), referrer))
+
+	bundles, err := FetchSigstoreBundles(context.Background(), Credentials{}, repo.Digest(digest.String()).String())
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{bundleJSON}, bundles)
+}
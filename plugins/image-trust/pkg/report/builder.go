@@ -1,6 +1,8 @@
 package report
 
 import (
+	"strings"
+
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
 )
 
@@ -61,6 +63,8 @@ func incrementSummary(summary *models.Summary, result models.ImageTrustResult) {
 		summary.Unsigned++
 	case models.StatusSignedUntrusted:
 		summary.SignedUntrusted++
+	case models.StatusProvenanceViolation:
+		summary.ProvenanceViolation++
 	case models.StatusVerificationError:
 		summary.VerificationError++
 	case models.StatusUnknown:
@@ -73,6 +77,9 @@ func incrementSummary(summary *models.Summary, result models.ImageTrustResult) {
 
 func buildFinding(owner models.Resource, result models.ImageTrustResult) models.Finding {
 	title, description, remediation, severity := detailsForStatus(result)
+	if result.TrustPolicy != "" {
+		description += " Judged by trust policy " + result.TrustPolicy + "."
+	}
 	return models.Finding{
 		ResourceNamespace: owner.Namespace,
 		ResourceKind:      owner.Kind,
@@ -99,6 +106,11 @@ func detailsForStatus(result models.ImageTrustResult) (title, description, remed
 			"The image-trust plugin verified a Cosign signature on image " + imageRef + ", but the signer did not match the configured trusted issuer/subject policy.",
 			"Update signer trust configuration or publish the image using an approved signing identity.",
 			nonCompliantSeverity
+	case models.StatusProvenanceViolation:
+		return "Container image provenance violates policy",
+			"The image-trust plugin verified a trusted SLSA provenance attestation on image " + imageRef + ", but its contents did not satisfy the provenance policy: " + strings.Join(result.ProvenanceViolations, "; ") + ".",
+			"Build the image with an approved builder from an allowed source repository and ref, or update the IMAGE_TRUST_PROVENANCE_* settings.",
+			nonCompliantSeverity
 	case models.StatusVerificationError:
 		if result.DigestResolveError != "" {
 			return "Container image digest could not be resolved",
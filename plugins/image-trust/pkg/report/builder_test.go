@@ -93,6 +93,42 @@ func TestBuildSignedUntrustedProducesActionItem(t *testing.T) {
 	require.Contains(t, report.Findings[0].Description, "verified a Cosign signature")
 }
 
+func TestBuildProvenanceViolationProducesActionItem(t *testing.T) {
+	report := Build([]models.ImageTrustResult{
+		{
+			ID:                   "ghcr.io/example/api@sha256:abc",
+			Status:               models.StatusProvenanceViolation,
+			ProvenanceViolations: []string{`source ref "refs/heads/feature" is not allowed`},
+			Owners: []models.Resource{
+				{Name: "api", Kind: "Deployment", Namespace: "prod"},
+			},
+		},
+	}, models.ReportPolicy{})
+
+	require.Equal(t, 1, report.Summary.ProvenanceViolation)
+	require.Len(t, report.Findings, 1)
+	require.Equal(t, "Container image provenance violates policy", report.Findings[0].Title)
+	require.Contains(t, report.Findings[0].Description, `source ref "refs/heads/feature" is not allowed`)
+	require.Equal(t, nonCompliantSeverity, report.Findings[0].Severity)
+}
+
+func TestBuildFindingNamesTrustPolicy(t *testing.T) {
+	report := Build([]models.ImageTrustResult{
+		{
+			ID:          "quay.io/vendor/exporter@sha256:abc",
+			Status:      models.StatusSignedUntrusted,
+			TrustPolicy: "vendor-monitoring",
+			Owners: []models.Resource{
+				{Name: "exporter", Kind: "Deployment", Namespace: "monitoring"},
+			},
+		},
+	}, models.ReportPolicy{TrustPolicies: []string{"vendor-monitoring", "default"}})
+
+	require.Len(t, report.Findings, 1)
+	require.Contains(t, report.Findings[0].Description, "Judged by trust policy vendor-monitoring.")
+	require.Equal(t, []string{"vendor-monitoring", "default"}, report.Policy.TrustPolicies)
+}
+
 func TestBuildSuppressesAllowlistedFindings(t *testing.T) {
 	results := []models.ImageTrustResult{
 		{
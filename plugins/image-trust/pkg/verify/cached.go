@@ -0,0 +1,50 @@
+package verify
+
+import (
+	"context"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/cache"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+)
+
+// CachedVerifier serves observations for digests already verified under the same trust
+// policy hash and records new ones.
+type CachedVerifier struct {
+	verifier      Verifier
+	cache         *cache.Cache
+	policyHash    string
+	registryCreds registry.Credentials
+}
+
+// NewCachedVerifier wraps a verifier with the verification cache. policyHash must change
+// whenever the verifier's trust settings change.
+func NewCachedVerifier(verifier Verifier, c *cache.Cache, policyHash string, registryCreds registry.Credentials) *CachedVerifier {
+	return &CachedVerifier{
+		verifier:      verifier,
+		cache:         c,
+		policyHash:    policyHash,
+		registryCreds: registryCreds,
+	}
+}
+
+func (v *CachedVerifier) Name() models.VerificationMode {
+	return v.verifier.Name()
+}
+
+func (v *CachedVerifier) Verify(ctx context.Context, image models.DiscoveredImage) (models.VerificationObservation, error) {
+	ref := v.registryCreds.VerificationReference(image.VerificationReference())
+	if ref == "" {
+		return v.verifier.Verify(ctx, image)
+	}
+	key := cache.Key(ref, v.policyHash)
+	if observation, ok := v.cache.Get(key); ok {
+		return observation, nil
+	}
+	observation, err := v.verifier.Verify(ctx, image)
+	if err != nil {
+		return observation, err
+	}
+	v.cache.Put(key, observation)
+	return observation, nil
+}
@@ -0,0 +1,48 @@
+package verify
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/cache"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+	"github.com/stretchr/testify/require"
+)
+
+type countingStubVerifier struct {
+	stubVerifier
+	calls int
+}
+
+func (c *countingStubVerifier) Verify(ctx context.Context, image models.DiscoveredImage) (models.VerificationObservation, error) {
+	c.calls++
+	return c.stubVerifier.Verify(ctx, image)
+}
+
+func TestCachedVerifierSkipsKnownDigests(t *testing.T) {
+	c, err := cache.Open(filepath.Join(t.TempDir(), "cache.json"), time.Hour, time.Minute)
+	require.NoError(t, err)
+	inner := &countingStubVerifier{stubVerifier: stubVerifier{
+		name:   models.VerificationModeCosignKeyless,
+		result: models.VerificationObservation{Mode: models.VerificationModeCosignKeyless, Status: models.StatusVerified},
+	}}
+	image := models.DiscoveredImage{Name: "ghcr.io/example/api:1.0.0", ID: "ghcr.io/example/api@sha256:abc"}
+
+	first, err := NewCachedVerifier(inner, c, "hash-a", registry.Credentials{}).Verify(context.Background(), image)
+	require.NoError(t, err)
+	require.True(t, first.CheckedAt.IsZero())
+
+	results, err := VerifyImages(context.Background(), []models.DiscoveredImage{image}, registry.Credentials{},
+		NewCachedVerifier(inner, c, "hash-a", registry.Credentials{}), 1, time.Second, time.Millisecond, false, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, inner.calls)
+	require.True(t, results[0].Cached)
+	require.False(t, results[0].LastCheckedAt.IsZero())
+
+	_, err = NewCachedVerifier(inner, c, "hash-b", registry.Credentials{}).Verify(context.Background(), image)
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.calls)
+}
@@ -41,6 +41,38 @@ func classifyCosignFailure(message string) (models.Status, string) {
 	}
 }
 
+func classifyNotationFailure(message string) (models.Status, string) {
+	normalized := strings.ToLower(strings.TrimSpace(message))
+	reason := strings.TrimSpace(message)
+	if reason == "" {
+		reason = "notation verification failed"
+	}
+
+	switch {
+	case normalized == "":
+		return models.StatusVerificationError, "notation verification failed"
+	case strings.Contains(normalized, "no signature is associated with"),
+		strings.Contains(normalized, "no signatures found"):
+		return models.StatusUnsigned, reason
+	case strings.Contains(normalized, "unauthorized"),
+		strings.Contains(normalized, "authentication required"),
+		containsHTTPStatus(normalized, 401),
+		containsHTTPStatus(normalized, 403),
+		strings.Contains(normalized, "access denied"),
+		strings.Contains(normalized, "forbidden"):
+		return models.StatusVerificationError, reason
+	case strings.Contains(normalized, "no applicable trust policy"):
+		return models.StatusVerificationError, reason
+	case strings.Contains(normalized, "signature verification failed"),
+		strings.Contains(normalized, "trusted identities"),
+		strings.Contains(normalized, "does not contain any trusted certificate"),
+		strings.Contains(normalized, "certificate chain"):
+		return models.StatusSignedUntrusted, reason
+	default:
+		return models.StatusVerificationError, reason
+	}
+}
+
 func containsHTTPStatus(message string, code int) bool {
 	codeStr := strconv.Itoa(code)
 	return strings.Contains(message, " "+codeStr) ||
@@ -52,3 +52,39 @@ func TestClassifyCosignFailure(t *testing.T) {
 		})
 	}
 }
+
+func TestClassifyNotationFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    models.Status
+	}{
+		{
+			name:    "unsigned image",
+			message: `Error: no signature is associated with "ghcr.io/example/api@sha256:abc", make sure the artifact was signed successfully`,
+			want:    models.StatusUnsigned,
+		},
+		{
+			name:    "untrusted signer",
+			message: "Error: signature verification failed for all the signatures associated with ghcr.io/example/api@sha256:abc",
+			want:    models.StatusSignedUntrusted,
+		},
+		{
+			name:    "no applicable trust policy",
+			message: `Error: artifact "ghcr.io/example/api@sha256:abc" has no applicable trust policy`,
+			want:    models.StatusVerificationError,
+		},
+		{
+			name:    "registry auth failure",
+			message: "GET https://ghcr.io/v2/example/api/referrers/sha256:abc: response status code 401: unauthorized",
+			want:    models.StatusVerificationError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := classifyNotationFailure(tt.message)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -108,7 +108,7 @@ func mergeVerifiedObservations(attempts []models.VerificationObservation) models
 
 func isSignatureMode(mode models.VerificationMode) bool {
 	switch mode {
-	case models.VerificationModeCosignKeyless, models.VerificationModeCosignKey:
+	case models.VerificationModeCosignKeyless, models.VerificationModeCosignKey, models.VerificationModeNotation:
 		return true
 	default:
 		return false
@@ -148,20 +148,45 @@ func dedupeSigners(signers []models.SignerDetails) []models.SignerDetails {
 	return deduped
 }
 
+// provenanceAsserter is implemented by verifiers that can report provenance_violation.
+type provenanceAsserter interface {
+	assertsProvenance() bool
+}
+
+func assertsProvenance(verifier Verifier) bool {
+	asserter, ok := verifier.(provenanceAsserter)
+	return ok && asserter.assertsProvenance()
+}
+
+// verifyAny returns the first verified observation. Verifiers that assert provenance
+// still run after a success, and a provenance violation from any of them wins.
 func (c *CompositeVerifier) verifyAny(ctx context.Context, image models.DiscoveredImage) (models.VerificationObservation, error) {
 	attempts := make([]models.VerificationObservation, 0, len(c.verifiers))
+	var verified *models.VerificationObservation
 	for _, verifier := range c.verifiers {
+		if verified != nil && !assertsProvenance(verifier) {
+			continue
+		}
 		observation, err := verifier.Verify(ctx, image)
 		if err != nil {
 			return models.VerificationObservation{}, fmt.Errorf("%s: %w", verifier.Name(), err)
 		}
-		if observation.Status == models.StatusVerified {
-			if observation.VerifiedBy == "" {
-				observation.VerifiedBy = observation.Mode
-			}
+		if observation.VerifiedBy == "" {
+			observation.VerifiedBy = observation.Mode
+		}
+		switch observation.Status {
+		case models.StatusProvenanceViolation:
 			return observation, nil
+		case models.StatusVerified:
+			if verified == nil {
+				verified = &observation
+			}
+		default:
+			attempts = append(attempts, observation)
 		}
-		attempts = append(attempts, observation)
+	}
+	if verified != nil {
+		return *verified, nil
 	}
 	merged := mergeObservations(attempts)
 	if merged.VerifiedBy == "" && merged.Mode == "" && len(attempts) > 0 {
@@ -179,6 +204,7 @@ func mergeObservations(attempts []models.VerificationObservation) models.Verific
 	}
 
 	priority := []models.Status{
+		models.StatusProvenanceViolation,
 		models.StatusSignedUntrusted,
 		models.StatusUnsigned,
 		models.StatusVerificationError,
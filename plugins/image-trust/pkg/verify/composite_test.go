@@ -52,6 +52,50 @@ func TestCompositeVerifierAnyReturnsFirstVerified(t *testing.T) {
 	require.Equal(t, models.VerificationModeCosignKey, observation.VerifiedBy)
 }
 
+type provenanceStubVerifier struct {
+	stubVerifier
+}
+
+func (provenanceStubVerifier) assertsProvenance() bool {
+	return true
+}
+
+func TestCompositeVerifierAnyProvenanceViolationFailsVerifiedImage(t *testing.T) {
+	composite, err := NewCompositeVerifier("any",
+		stubVerifier{
+			name: models.VerificationModeCosignKeyless,
+			result: models.VerificationObservation{
+				Mode:   models.VerificationModeCosignKeyless,
+				Status: models.StatusVerified,
+			},
+		},
+		stubVerifier{
+			name: models.VerificationModeCosignKey,
+			result: models.VerificationObservation{
+				Mode:   models.VerificationModeCosignKey,
+				Status: models.StatusVerificationError,
+			},
+		},
+		provenanceStubVerifier{stubVerifier{
+			name: models.VerificationModeCosignAttestationKeyless,
+			result: models.VerificationObservation{
+				Mode:            models.VerificationModeCosignAttestationKeyless,
+				Status:          models.StatusProvenanceViolation,
+				AttestationType: "slsaprovenance1",
+			},
+		}},
+	)
+	require.NoError(t, err)
+
+	observation, err := composite.Verify(context.Background(), models.DiscoveredImage{
+		Name: "ghcr.io/example/api:1.0.0",
+		ID:   "ghcr.io/example/api@sha256:abc",
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusProvenanceViolation, observation.Status)
+	require.Equal(t, models.VerificationModeCosignAttestationKeyless, observation.VerifiedBy)
+}
+
 func TestCompositeVerifierAnyPrefersKeylessWhenBothVerify(t *testing.T) {
 	composite, err := NewCompositeVerifier("any",
 		stubVerifier{
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
 )
@@ -17,41 +19,28 @@ type CosignAttestationVerifier struct {
 	attestationTypes      []string
 	trustedIssuerMatcher  *regexp.Regexp
 	trustedSubjectMatcher *regexp.Regexp
+	provenance            *provenanceMatcher
 }
 
-// NewCosignAttestationVerifier creates a keyless attestation verifier.
+// NewCosignAttestationVerifier creates a keyless attestation verifier. A non-empty
+// provenance policy is asserted on verified SLSA provenance attestations.
 func NewCosignAttestationVerifier(
 	runner CommandRunner,
 	registryCreds registry.Credentials,
 	attestationTypes []string,
 	trustedIssuers, trustedSubjects, trustedSubjectREs []string,
+	provenancePolicy config.ProvenancePolicy,
 ) (*CosignAttestationVerifier, error) {
 	if len(attestationTypes) == 0 {
 		return nil, fmt.Errorf("at least one attestation type is required")
 	}
-	issuerRE, err := buildAlternationRegex(trustedIssuers, nil)
+	provenance, err := newProvenanceMatcher(provenancePolicy)
 	if err != nil {
-		return nil, fmt.Errorf("building issuer regex: %w", err)
+		return nil, fmt.Errorf("building provenance policy: %w", err)
 	}
-	subjectRE, err := buildAlternationRegex(trustedSubjects, trustedSubjectREs)
+	issuerMatcher, subjectMatcher, err := compileSignerMatchers(trustedIssuers, trustedSubjects, trustedSubjectREs)
 	if err != nil {
-		return nil, fmt.Errorf("building subject regex: %w", err)
-	}
-
-	var issuerMatcher *regexp.Regexp
-	if issuerRE != "" {
-		issuerMatcher, err = regexp.Compile(issuerRE)
-		if err != nil {
-			return nil, fmt.Errorf("compiling issuer regex: %w", err)
-		}
-	}
-
-	var subjectMatcher *regexp.Regexp
-	if subjectRE != "" {
-		subjectMatcher, err = regexp.Compile(subjectRE)
-		if err != nil {
-			return nil, fmt.Errorf("compiling subject regex: %w", err)
-		}
+		return nil, err
 	}
 
 	return &CosignAttestationVerifier{
@@ -60,6 +49,7 @@ func NewCosignAttestationVerifier(
 		attestationTypes:      append([]string(nil), attestationTypes...),
 		trustedIssuerMatcher:  issuerMatcher,
 		trustedSubjectMatcher: subjectMatcher,
+		provenance:            provenance,
 	}, nil
 }
 
@@ -85,16 +75,31 @@ func (v *CosignAttestationVerifier) Verify(ctx context.Context, image models.Dis
 		}, nil
 	}
 
+	// Once one type verifies, only provenance types are still checked: a provenance
+	// violation fails the image even when another attestation type verified.
 	var attempts []models.VerificationObservation
+	var verified *models.VerificationObservation
 	for _, attestationType := range v.attestationTypes {
+		if verified != nil && !v.provenance.asserts(attestationType) {
+			continue
+		}
 		observation, err := v.verifyType(ctx, ref, attestationType)
 		if err != nil {
 			return models.VerificationObservation{}, err
 		}
-		if observation.Status == models.StatusVerified {
+		switch observation.Status {
+		case models.StatusProvenanceViolation:
 			return observation, nil
+		case models.StatusVerified:
+			if verified == nil {
+				verified = &observation
+			}
+		default:
+			attempts = append(attempts, observation)
 		}
-		attempts = append(attempts, observation)
+	}
+	if verified != nil {
+		return *verified, nil
 	}
 
 	merged := mergeObservations(attempts)
@@ -148,14 +153,14 @@ func (v *CosignAttestationVerifier) verifyType(ctx context.Context, ref, attesta
 	}
 	for _, signer := range signers {
 		if v.isTrustedSigner(signer) {
-			return models.VerificationObservation{
+			return v.provenance.check(models.VerificationObservation{
 				Mode:            v.Name(),
 				Status:          models.StatusVerified,
 				Reason:          fmt.Sprintf("cosign attestation verification succeeded for type %s", attestationType),
 				AttestationType: attestationType,
 				Signer:          signer,
 				Signers:         signers,
-			}, nil
+			}, stdout), nil
 		}
 	}
 	return models.VerificationObservation{
@@ -180,3 +185,7 @@ func (v *CosignAttestationVerifier) isTrustedSigner(signer models.SignerDetails)
 	}
 	return true
 }
+
+func (v *CosignAttestationVerifier) assertsProvenance() bool {
+	return slices.ContainsFunc(v.attestationTypes, v.provenance.asserts)
+}
@@ -3,6 +3,7 @@ package verify
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
@@ -17,15 +18,18 @@ type CosignAttestationKeyVerifier struct {
 	publicKeys       []config.TrustedPublicKey
 	attestationTypes []string
 	ignoreTlog       bool
+	provenance       *provenanceMatcher
 }
 
-// NewCosignAttestationKeyVerifier creates a keyed attestation verifier.
+// NewCosignAttestationKeyVerifier creates a keyed attestation verifier. A non-empty
+// provenance policy is asserted on verified SLSA provenance attestations.
 func NewCosignAttestationKeyVerifier(
 	runner CommandRunner,
 	registryCreds registry.Credentials,
 	publicKeys []config.TrustedPublicKey,
 	attestationTypes []string,
 	ignoreTlog bool,
+	provenancePolicy config.ProvenancePolicy,
 ) (*CosignAttestationKeyVerifier, error) {
 	if len(publicKeys) == 0 {
 		return nil, fmt.Errorf("at least one trusted public key is required")
@@ -33,12 +37,17 @@ func NewCosignAttestationKeyVerifier(
 	if len(attestationTypes) == 0 {
 		return nil, fmt.Errorf("at least one attestation type is required")
 	}
+	provenance, err := newProvenanceMatcher(provenancePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("building provenance policy: %w", err)
+	}
 	return &CosignAttestationKeyVerifier{
 		runner:           runner,
 		registryCreds:    registryCreds,
 		publicKeys:       append([]config.TrustedPublicKey(nil), publicKeys...),
 		attestationTypes: append([]string(nil), attestationTypes...),
 		ignoreTlog:       ignoreTlog,
+		provenance:       provenance,
 	}, nil
 }
 
@@ -56,19 +65,34 @@ func (v *CosignAttestationKeyVerifier) Verify(ctx context.Context, image models.
 		}, nil
 	}
 
+	// Once one key and type verify, only provenance types are still checked: a
+	// provenance violation fails the image even when another attestation verified.
 	var attempts []models.VerificationObservation
+	var verified *models.VerificationObservation
 	for _, key := range v.publicKeys {
 		for _, attestationType := range v.attestationTypes {
+			if verified != nil && !v.provenance.asserts(attestationType) {
+				continue
+			}
 			observation, err := v.verifyWithKey(ctx, ref, key, attestationType)
 			if err != nil {
 				return models.VerificationObservation{}, err
 			}
-			if observation.Status == models.StatusVerified {
+			switch observation.Status {
+			case models.StatusProvenanceViolation:
 				return observation, nil
+			case models.StatusVerified:
+				if verified == nil {
+					verified = &observation
+				}
+			default:
+				attempts = append(attempts, observation)
 			}
-			attempts = append(attempts, observation)
 		}
 	}
+	if verified != nil {
+		return *verified, nil
+	}
 
 	merged := mergeObservations(attempts)
 	merged.Mode = v.Name()
@@ -125,12 +149,16 @@ func (v *CosignAttestationKeyVerifier) verifyWithKey(
 		signer.Subject = signers[0].Subject
 	}
 
-	return models.VerificationObservation{
+	return v.provenance.check(models.VerificationObservation{
 		Mode:            v.Name(),
 		Status:          models.StatusVerified,
 		Reason:          fmt.Sprintf("cosign attestation verification succeeded with trusted public key %s for type %s", key.ReportKeyRef(), attestationType),
 		AttestationType: attestationType,
 		Signer:          signer,
 		Signers:         signers,
-	}, nil
+	}, stdout), nil
+}
+
+func (v *CosignAttestationKeyVerifier) assertsProvenance() bool {
+	return slices.ContainsFunc(v.attestationTypes, v.provenance.asserts)
 }
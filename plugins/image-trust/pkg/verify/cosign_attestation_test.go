@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
 	"github.com/stretchr/testify/require"
@@ -19,6 +20,7 @@ func TestCosignAttestationVerifierVerifySuccess(t *testing.T) {
 		[]string{"https://token.actions.githubusercontent.com"},
 		nil,
 		[]string{"https://github.com/example/.+"},
+		config.ProvenancePolicy{},
 	)
 	require.NoError(t, err)
 
@@ -45,6 +47,7 @@ func TestCosignAttestationVerifierUnsigned(t *testing.T) {
 		[]string{"https://token.actions.githubusercontent.com"},
 		nil,
 		nil,
+		config.ProvenancePolicy{},
 	)
 	require.NoError(t, err)
 
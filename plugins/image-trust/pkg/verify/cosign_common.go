@@ -2,6 +2,7 @@ package verify
 
 import (
 	"encoding/json"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -61,6 +62,36 @@ func firstNonEmpty(values ...string) string {
 	return ""
 }
 
+// compileSignerMatchers compiles the keyless trust policy into issuer and subject matchers.
+// A nil matcher means that dimension is not constrained.
+func compileSignerMatchers(trustedIssuers, trustedSubjects, trustedSubjectREs []string) (*regexp.Regexp, *regexp.Regexp, error) {
+	issuerRE, err := buildAlternationRegex(trustedIssuers, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building issuer regex: %w", err)
+	}
+	subjectRE, err := buildAlternationRegex(trustedSubjects, trustedSubjectREs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building subject regex: %w", err)
+	}
+
+	var issuerMatcher *regexp.Regexp
+	if issuerRE != "" {
+		issuerMatcher, err = regexp.Compile(issuerRE)
+		if err != nil {
+			return nil, nil, fmt.Errorf("compiling issuer regex: %w", err)
+		}
+	}
+
+	var subjectMatcher *regexp.Regexp
+	if subjectRE != "" {
+		subjectMatcher, err = regexp.Compile(subjectRE)
+		if err != nil {
+			return nil, nil, fmt.Errorf("compiling subject regex: %w", err)
+		}
+	}
+	return issuerMatcher, subjectMatcher, nil
+}
+
 func buildAlternationRegex(exacts, regexes []string) (string, error) {
 	patterns := make([]string, 0, len(exacts)+len(regexes))
 	for _, exact := range exacts {
@@ -18,29 +18,9 @@ type CosignVerifier struct {
 }
 
 func NewCosignVerifier(runner CommandRunner, registryCreds registry.Credentials, trustedIssuers, trustedSubjects, trustedSubjectREs []string) (*CosignVerifier, error) {
-	issuerRE, err := buildAlternationRegex(trustedIssuers, nil)
+	issuerMatcher, subjectMatcher, err := compileSignerMatchers(trustedIssuers, trustedSubjects, trustedSubjectREs)
 	if err != nil {
-		return nil, fmt.Errorf("building issuer regex: %w", err)
-	}
-	subjectRE, err := buildAlternationRegex(trustedSubjects, trustedSubjectREs)
-	if err != nil {
-		return nil, fmt.Errorf("building subject regex: %w", err)
-	}
-
-	var issuerMatcher *regexp.Regexp
-	if issuerRE != "" {
-		issuerMatcher, err = regexp.Compile(issuerRE)
-		if err != nil {
-			return nil, fmt.Errorf("compiling issuer regex: %w", err)
-		}
-	}
-
-	var subjectMatcher *regexp.Regexp
-	if subjectRE != "" {
-		subjectMatcher, err = regexp.Compile(subjectRE)
-		if err != nil {
-			return nil, fmt.Errorf("compiling subject regex: %w", err)
-		}
+		return nil, err
 	}
 
 	return &CosignVerifier{
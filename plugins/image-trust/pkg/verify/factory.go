@@ -7,16 +7,34 @@ import (
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
 )
 
-// NewVerifier creates a verifier for all configured verification modes.
-func NewVerifier(cfg *config.Config, runner CommandRunner, registryCreds registry.Credentials) (Verifier, error) {
+// NewVerifier creates a verifier for all configured verification modes. Modes listed in
+// cfg.NativeModes are verified in-process against sigstoreTrust; a nil trust is built from cfg.
+func NewVerifier(cfg *config.Config, runner CommandRunner, registryCreds registry.Credentials, sigstoreTrust *SigstoreTrust) (Verifier, error) {
 	if len(cfg.VerificationModes) == 0 {
 		return nil, fmt.Errorf("no verification modes configured")
 	}
+	if sigstoreTrust == nil && len(cfg.NativeModes) > 0 {
+		sigstoreTrust = NewSigstoreTrust(cfg)
+	}
 
 	verifiers := make([]Verifier, 0, len(cfg.VerificationModes))
 	for _, mode := range cfg.VerificationModes {
 		switch mode {
 		case config.ModeCosignKeyless:
+			if cfg.NativeModeEnabled(mode) {
+				verifier, err := NewSigstoreKeylessVerifier(
+					registryCreds,
+					sigstoreTrust,
+					cfg.TrustedIssuers,
+					cfg.TrustedSubjects,
+					cfg.TrustedSubjectREs,
+				)
+				if err != nil {
+					return nil, err
+				}
+				verifiers = append(verifiers, verifier)
+				continue
+			}
 			verifier, err := NewCosignVerifier(
 				runner,
 				registryCreds,
@@ -29,6 +47,14 @@ func NewVerifier(cfg *config.Config, runner CommandRunner, registryCreds registr
 			}
 			verifiers = append(verifiers, verifier)
 		case config.ModeCosignKey:
+			if cfg.NativeModeEnabled(mode) {
+				verifier, err := NewSigstoreKeyVerifier(registryCreds, sigstoreTrust, cfg.TrustedPublicKeys, cfg.IgnoreTlog)
+				if err != nil {
+					return nil, err
+				}
+				verifiers = append(verifiers, verifier)
+				continue
+			}
 			verifier, err := NewCosignKeyVerifier(runner, registryCreds, cfg.TrustedPublicKeys, cfg.IgnoreTlog)
 			if err != nil {
 				return nil, err
@@ -42,6 +68,7 @@ func NewVerifier(cfg *config.Config, runner CommandRunner, registryCreds registr
 				cfg.TrustedIssuers,
 				cfg.TrustedSubjects,
 				cfg.TrustedSubjectREs,
+				cfg.Provenance,
 			)
 			if err != nil {
 				return nil, err
@@ -54,11 +81,18 @@ func NewVerifier(cfg *config.Config, runner CommandRunner, registryCreds registr
 				cfg.TrustedPublicKeys,
 				cfg.AttestationTypes,
 				cfg.IgnoreTlog,
+				cfg.Provenance,
 			)
 			if err != nil {
 				return nil, err
 			}
 			verifiers = append(verifiers, verifier)
+		case config.ModeNotation:
+			verifier, err := NewNotationVerifier(runner, registryCreds, cfg.NotationTrust)
+			if err != nil {
+				return nil, err
+			}
+			verifiers = append(verifiers, verifier)
 		default:
 			return nil, fmt.Errorf("unsupported verification mode %q", mode)
 		}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
 	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
 	"github.com/stretchr/testify/require"
 )
@@ -16,7 +17,7 @@ func TestNewVerifierCompositeForBothModes(t *testing.T) {
 		TrustedPublicKeys: []config.TrustedPublicKey{{Ref: "/keys/release.pub", ID: "release.pub"}},
 	}
 
-	verifier, err := NewVerifier(cfg, &fakeRunner{}, registry.Credentials{})
+	verifier, err := NewVerifier(cfg, &fakeRunner{}, registry.Credentials{}, nil)
 	require.NoError(t, err)
 	_, ok := verifier.(*CompositeVerifier)
 	require.True(t, ok)
@@ -28,8 +29,22 @@ func TestNewVerifierSingleModeSkipsComposite(t *testing.T) {
 		TrustedIssuers:    []string{"https://token.actions.githubusercontent.com"},
 	}
 
-	verifier, err := NewVerifier(cfg, &fakeRunner{}, registry.Credentials{})
+	verifier, err := NewVerifier(cfg, &fakeRunner{}, registry.Credentials{}, nil)
 	require.NoError(t, err)
 	_, ok := verifier.(*CosignVerifier)
 	require.True(t, ok)
 }
+
+func TestNewVerifierNativeMode(t *testing.T) {
+	cfg := &config.Config{
+		VerificationModes: []string{config.ModeCosignKeyless},
+		NativeModes:       []string{config.ModeCosignKeyless},
+		TrustedIssuers:    []string{"https://token.actions.githubusercontent.com"},
+	}
+
+	verifier, err := NewVerifier(cfg, &fakeRunner{}, registry.Credentials{}, nil)
+	require.NoError(t, err)
+	native, ok := verifier.(*SigstoreVerifier)
+	require.True(t, ok)
+	require.Equal(t, models.VerificationModeCosignKeyless, native.Name())
+}
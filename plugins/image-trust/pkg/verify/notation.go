@@ -0,0 +1,247 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// NotationSignatureArtifactType is the OCI artifact type of Notary Project signatures.
+const NotationSignatureArtifactType = "application/vnd.cncf.notary.signature"
+
+// NotationVerifier verifies Notary Project signatures with the notation CLI.
+type NotationVerifier struct {
+	runner         CommandRunner
+	registryCreds  registry.Credentials
+	trust          *config.NotationTrust
+	listSignatures func(ctx context.Context, ref string) ([]v1.Descriptor, error)
+}
+
+// NewNotationVerifier creates a verifier for notation signatures. The notation CLI must
+// see the trust policy and trust store through PrepareNotation's environment.
+func NewNotationVerifier(runner CommandRunner, registryCreds registry.Credentials, trust *config.NotationTrust) (*NotationVerifier, error) {
+	if trust == nil || len(trust.Policies) == 0 {
+		return nil, fmt.Errorf("notation requires a trust policy")
+	}
+	return &NotationVerifier{
+		runner:        runner,
+		registryCreds: registryCreds,
+		trust:         trust,
+		listSignatures: func(ctx context.Context, ref string) ([]v1.Descriptor, error) {
+			return registry.ListReferrers(ctx, registryCreds, ref, NotationSignatureArtifactType)
+		},
+	}, nil
+}
+
+func (v *NotationVerifier) Name() models.VerificationMode {
+	return models.VerificationModeNotation
+}
+
+func (v *NotationVerifier) Verify(ctx context.Context, image models.DiscoveredImage) (models.VerificationObservation, error) {
+	ref := v.registryCreds.VerificationReference(image.VerificationReference())
+	if ref == "" {
+		return models.VerificationObservation{
+			Mode:   v.Name(),
+			Status: models.StatusUnknown,
+			Reason: "image could not be resolved to an immutable digest reference",
+		}, nil
+	}
+
+	// Listing referrers is cheaper than a notation run and tells unsigned images apart
+	// from registry failures; on listing errors notation reports the outcome itself.
+	signatures, err := v.listSignatures(ctx, ref)
+	if err != nil {
+		logrus.Debugf("listing notation signatures for %s: %v", ref, err)
+	} else if len(signatures) == 0 {
+		return models.VerificationObservation{
+			Mode:   v.Name(),
+			Status: models.StatusUnsigned,
+			Reason: "no notation signatures found",
+		}, nil
+	}
+
+	stdout, stderr, err := v.runner.Run(ctx, "notation", "verify", ref)
+	if err != nil {
+		if strings.Contains(err.Error(), "executable file not found") {
+			return models.VerificationObservation{
+				Mode:   v.Name(),
+				Status: models.StatusVerificationError,
+				Reason: "notation binary not available",
+			}, nil
+		}
+		status, reason := classifyNotationFailure(firstNonEmpty(stderr, stdout, err.Error()))
+		return models.VerificationObservation{
+			Mode:   v.Name(),
+			Status: status,
+			Reason: reason,
+		}, nil
+	}
+
+	observation := models.VerificationObservation{
+		Mode:   v.Name(),
+		Status: models.StatusVerified,
+		Reason: "notation verification succeeded",
+	}
+	policy, ok := v.trust.PolicyFor(repositoryFromReference(ref))
+	if ok {
+		observation.Reason = fmt.Sprintf("notation verification succeeded with trust policy %s", policy.Name)
+	}
+
+	stdout, stderr, err = v.runner.Run(ctx, "notation", "inspect", "--output", "json", ref)
+	if err != nil {
+		logrus.Warnf("notation verified %s but signer details could not be read: %s", ref, firstNonEmpty(stderr, err.Error()))
+		return observation, nil
+	}
+	signers, err := extractNotationSigners(stdout)
+	if err != nil {
+		logrus.Warnf("notation verified %s but inspect output could not be parsed: %v", ref, err)
+		return observation, nil
+	}
+	observation.Signers = signers
+	observation.Signer = selectNotationSigner(signers, policy.TrustedIdentities)
+	return observation, nil
+}
+
+type notationInspectOutput struct {
+	Signatures []struct {
+		Certificates []struct {
+			SHA256Fingerprint string `json:"sha256Fingerprint"`
+			IssuedTo          string `json:"issuedTo"`
+			IssuedBy          string `json:"issuedBy"`
+		} `json:"certificates"`
+	} `json:"signatures"`
+}
+
+// extractNotationSigners maps the leaf certificate of each signature to signer details.
+func extractNotationSigners(stdout string) ([]models.SignerDetails, error) {
+	if strings.TrimSpace(stdout) == "" {
+		return nil, nil
+	}
+	var output notationInspectOutput
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		return nil, err
+	}
+
+	signers := make([]models.SignerDetails, 0, len(output.Signatures))
+	for _, signature := range output.Signatures {
+		if len(signature.Certificates) == 0 {
+			continue
+		}
+		leaf := signature.Certificates[0]
+		signer := models.SignerDetails{
+			Issuer:  leaf.IssuedBy,
+			Subject: leaf.IssuedTo,
+		}
+		if leaf.SHA256Fingerprint != "" {
+			signer.KeyRef = "sha256:" + strings.ToLower(leaf.SHA256Fingerprint)
+		}
+		signers = append(signers, signer)
+	}
+	return dedupeSigners(signers), nil
+}
+
+// selectNotationSigner returns the first signer whose subject matches the policy's
+// trusted identities. notation does not report which signature it accepted, so with a
+// wildcard identity (or no match) the first signer is used.
+func selectNotationSigner(signers []models.SignerDetails, trustedIdentities []string) models.SignerDetails {
+	if len(signers) == 0 {
+		return models.SignerDetails{}
+	}
+	for _, identity := range trustedIdentities {
+		subject, ok := strings.CutPrefix(strings.TrimSpace(identity), "x509.subject:")
+		if !ok {
+			continue
+		}
+		want := normalizeDistinguishedName(subject)
+		for _, signer := range signers {
+			if normalizeDistinguishedName(signer.Subject) == want {
+				return signer
+			}
+		}
+	}
+	return signers[0]
+}
+
+// normalizeDistinguishedName makes RDN order and spacing irrelevant; notation prints
+// subjects in certificate order while trust policies are usually written the other way.
+func normalizeDistinguishedName(dn string) string {
+	parts := strings.Split(dn, ",")
+	normalized := make([]string, 0, len(parts))
+	for _, part := range parts {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		normalized = append(normalized, strings.ToUpper(strings.TrimSpace(key))+"="+strings.TrimSpace(value))
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, ",")
+}
+
+func repositoryFromReference(ref string) string {
+	if idx := strings.Index(ref, "@"); idx >= 0 {
+		return ref[:idx]
+	}
+	return ref
+}
+
+// PreparedNotation is a notation configuration directory built from the trust policy
+// and trust store. Env points the notation CLI at it.
+type PreparedNotation struct {
+	Env     []string
+	cleanup func()
+}
+
+// Cleanup removes the temporary notation configuration directory.
+func (p PreparedNotation) Cleanup() {
+	if p.cleanup != nil {
+		p.cleanup()
+	}
+}
+
+// PrepareNotation lays out trust for the notation CLI, which reads
+// $XDG_CONFIG_HOME/notation/trustpolicy.json and $XDG_CONFIG_HOME/notation/truststore.
+func PrepareNotation(trust *config.NotationTrust) (PreparedNotation, error) {
+	if trust == nil {
+		return PreparedNotation{}, nil
+	}
+	home, err := os.MkdirTemp("", "image-trust-notation-")
+	if err != nil {
+		return PreparedNotation{}, fmt.Errorf("creating notation config directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(home) }
+
+	notationDir := filepath.Join(home, "notation")
+	if err := os.MkdirAll(notationDir, 0o700); err != nil {
+		cleanup()
+		return PreparedNotation{}, fmt.Errorf("creating notation config directory: %w", err)
+	}
+	policy, err := os.ReadFile(trust.PolicyPath)
+	if err != nil {
+		cleanup()
+		return PreparedNotation{}, fmt.Errorf("reading notation trust policy: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(notationDir, "trustpolicy.json"), policy, 0o600); err != nil {
+		cleanup()
+		return PreparedNotation{}, fmt.Errorf("writing notation trust policy: %w", err)
+	}
+	if err := os.Symlink(trust.TrustStoreDir, filepath.Join(notationDir, "truststore")); err != nil {
+		cleanup()
+		return PreparedNotation{}, fmt.Errorf("linking notation trust store: %w", err)
+	}
+
+	return PreparedNotation{
+		Env:     []string{"XDG_CONFIG_HOME=" + home},
+		cleanup: cleanup,
+	}, nil
+}
@@ -0,0 +1,187 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/require"
+)
+
+const notationInspectJSON = `{
+  "mediaType": "application/vnd.oci.image.manifest.v1+json",
+  "signatures": [
+    {
+      "mediaType": "application/jose+json",
+      "certificates": [
+        {"sha256Fingerprint": "AB12CD", "issuedTo": "CN=other.example.com,O=Example", "issuedBy": "CN=Example Root,O=Example"}
+      ]
+    },
+    {
+      "mediaType": "application/jose+json",
+      "certificates": [
+        {"sha256Fingerprint": "EF34", "issuedTo": "CN=release.example.com,O=Example", "issuedBy": "CN=Example Root,O=Example"},
+        {"sha256Fingerprint": "0099", "issuedTo": "CN=Example Root,O=Example", "issuedBy": "CN=Example Root,O=Example"}
+      ]
+    }
+  ]
+}`
+
+var testNotationTrust = &config.NotationTrust{
+	Policies: []config.NotationTrustPolicy{{
+		Name:              "releases",
+		RegistryScopes:    []string{"*"},
+		TrustStores:       []string{"ca:release"},
+		TrustedIdentities: []string{"x509.subject: O=Example, CN=release.example.com"},
+	}},
+}
+
+// pushTestImage writes a random image to an in-process registry and optionally attaches
+// a notation signature artifact to it. It returns the digest reference.
+func pushTestImage(t *testing.T, signed bool) string {
+	t.Helper()
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.WithReferrersSupport(true)))
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	repo, err := name.NewRepository(host + "/example/api")
+	require.NoError(t, err)
+	img, err := random.Image(256, 1)
+	require.NoError(t, err)
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Tag("1.0.0"), img))
+
+	if signed {
+		manifest, err := img.RawManifest()
+		require.NoError(t, err)
+		mediaType, err := img.MediaType()
+		require.NoError(t, err)
+		signature := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+		signature = mutate.ConfigMediaType(signature, types.MediaType(NotationSignatureArtifactType))
+		signature = mutate.Subject(signature, v1.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest,
+			Size:      int64(len(manifest)),
+		}).(v1.Image)
+		signatureDigest, err := signature.Digest()
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(repo.Digest(signatureDigest.String()), signature))
+	}
+
+	return repo.Digest(digest.String()).String()
+}
+
+func TestNotationVerifierUnsignedImageSkipsCLI(t *testing.T) {
+	ref := pushTestImage(t, false)
+	runner := &fakeRunner{}
+	verifier, err := NewNotationVerifier(runner, registry.Credentials{}, testNotationTrust)
+	require.NoError(t, err)
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: ref})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusUnsigned, observation.Status)
+	require.Equal(t, models.VerificationModeNotation, observation.Mode)
+	require.Empty(t, runner.name)
+}
+
+func TestNotationVerifierVerifiedMapsSigner(t *testing.T) {
+	ref := pushTestImage(t, true)
+	runner := &sequentialRunner{results: []fakeRunResult{
+		{stdout: "Successfully verified signature for " + ref},
+		{stdout: notationInspectJSON},
+	}}
+	verifier, err := NewNotationVerifier(runner, registry.Credentials{}, testNotationTrust)
+	require.NoError(t, err)
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: ref})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusVerified, observation.Status)
+	require.Contains(t, observation.Reason, "trust policy releases")
+	require.Equal(t, models.SignerDetails{
+		Issuer:  "CN=Example Root,O=Example",
+		Subject: "CN=release.example.com,O=Example",
+		KeyRef:  "sha256:ef34",
+	}, observation.Signer)
+	require.Len(t, observation.Signers, 2)
+	require.Equal(t, 2, runner.calls)
+}
+
+func TestNotationVerifierUntrustedSignature(t *testing.T) {
+	ref := pushTestImage(t, true)
+	runner := &fakeRunner{
+		stderr: "Error: signature verification failed for all the signatures associated with " + ref,
+		err:    errors.New("exit status 1"),
+	}
+	verifier, err := NewNotationVerifier(runner, registry.Credentials{}, testNotationTrust)
+	require.NoError(t, err)
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: ref})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusSignedUntrusted, observation.Status)
+	require.Equal(t, "notation", runner.name)
+	require.Equal(t, []string{"verify", ref}, runner.args)
+}
+
+func TestCompositeVerifierAllWithNotation(t *testing.T) {
+	ref := pushTestImage(t, true)
+	notation, err := NewNotationVerifier(&sequentialRunner{results: []fakeRunResult{
+		{stdout: "Successfully verified signature"},
+		{stdout: notationInspectJSON},
+	}}, registry.Credentials{}, testNotationTrust)
+	require.NoError(t, err)
+	cosignKey, err := NewCosignKeyVerifier(
+		&fakeRunner{stdout: `[{"optional":{"keyid":"deadbeef"}}]`},
+		registry.Credentials{},
+		[]config.TrustedPublicKey{{Ref: "/keys/release.pub", ID: "release.pub"}},
+		false,
+	)
+	require.NoError(t, err)
+
+	composite, err := NewCompositeVerifier(config.ModePolicyAll, notation, cosignKey)
+	require.NoError(t, err)
+	observation, err := composite.Verify(context.Background(), models.DiscoveredImage{ID: ref})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusVerified, observation.Status)
+	require.Equal(t, "CN=release.example.com,O=Example", observation.Signer.Subject)
+	require.Len(t, observation.Signers, 3)
+}
+
+func TestPrepareNotationLaysOutConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "trustpolicy.json")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`{"version":"1.0"}`), 0o644))
+	storeDir := filepath.Join(dir, "truststore")
+	require.NoError(t, os.MkdirAll(storeDir, 0o755))
+
+	prepared, err := PrepareNotation(&config.NotationTrust{PolicyPath: policyPath, TrustStoreDir: storeDir})
+	require.NoError(t, err)
+	require.Len(t, prepared.Env, 1)
+	home := strings.TrimPrefix(prepared.Env[0], "XDG_CONFIG_HOME=")
+
+	data, err := os.ReadFile(filepath.Join(home, "notation", "trustpolicy.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"version":"1.0"}`, string(data))
+	target, err := os.Readlink(filepath.Join(home, "notation", "truststore"))
+	require.NoError(t, err)
+	require.Equal(t, storeDir, target)
+
+	prepared.Cleanup()
+	_, err = os.Stat(home)
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,373 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+)
+
+// provenanceMatcher evaluates a config.ProvenancePolicy against the SLSA provenance
+// statements returned by cosign verify-attestation.
+type provenanceMatcher struct {
+	builderID        *regexp.Regexp
+	buildType        *regexp.Regexp
+	sourceURI        *regexp.Regexp
+	sourceRef        *regexp.Regexp
+	digestAlgorithms []string
+	materials        []materialMatcher
+}
+
+type materialMatcher struct {
+	pattern   string
+	uri       *regexp.Regexp
+	algorithm string
+	digest    *regexp.Regexp
+}
+
+// slsaProvenance is the subset of a SLSA v0.2 or v1 predicate that policies assert on.
+type slsaProvenance struct {
+	BuilderID string
+	BuildType string
+	SourceURI string
+	SourceRef string
+	Materials []slsaResource
+}
+
+type slsaResource struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+type inTotoStatement struct {
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+type slsaPredicateV02 struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string `json:"buildType"`
+	Invocation struct {
+		ConfigSource slsaResource `json:"configSource"`
+	} `json:"invocation"`
+	Materials []slsaResource `json:"materials"`
+}
+
+type slsaPredicateV1 struct {
+	BuildDefinition struct {
+		BuildType          string `json:"buildType"`
+		ExternalParameters struct {
+			Workflow struct {
+				Repository string `json:"repository"`
+				Ref        string `json:"ref"`
+			} `json:"workflow"`
+			Source string `json:"source"`
+		} `json:"externalParameters"`
+		ResolvedDependencies []slsaResource `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+	} `json:"runDetails"`
+}
+
+// newProvenanceMatcher compiles a provenance policy. It returns nil for an empty policy.
+func newProvenanceMatcher(policy config.ProvenancePolicy) (*provenanceMatcher, error) {
+	if policy.IsZero() {
+		return nil, nil
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	matcher := &provenanceMatcher{digestAlgorithms: append([]string(nil), policy.MaterialDigestAlgorithms...)}
+	fields := []struct {
+		patterns []string
+		target   **regexp.Regexp
+	}{
+		{policy.BuilderIDs, &matcher.builderID},
+		{policy.BuildTypes, &matcher.buildType},
+		{policy.SourceURIs, &matcher.sourceURI},
+		{policy.SourceRefs, &matcher.sourceRef},
+	}
+	for _, field := range fields {
+		pattern, err := buildAlternationRegex(nil, field.patterns)
+		if err != nil {
+			return nil, err
+		}
+		if pattern == "" {
+			continue
+		}
+		compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, err
+		}
+		*field.target = compiled
+	}
+	for _, material := range policy.Materials {
+		uri, err := regexp.Compile("^(?:" + material.URI + ")$")
+		if err != nil {
+			return nil, err
+		}
+		digest, err := regexp.Compile("^(?:" + material.Digest + ")$")
+		if err != nil {
+			return nil, err
+		}
+		matcher.materials = append(matcher.materials, materialMatcher{pattern: material.URI, uri: uri, algorithm: material.Algorithm, digest: digest})
+	}
+	return matcher, nil
+}
+
+// evaluate checks the provenance statements in cosign verify-attestation output. It
+// returns no violations when any provenance statement satisfies the policy, or the
+// violations of the first statement otherwise. Output without provenance statements
+// is reported as a single violation.
+func (m *provenanceMatcher) evaluate(stdout string) ([]string, error) {
+	statements, err := extractProvenanceStatements(stdout)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		return []string{"no SLSA provenance statement was found in the verified attestations"}, nil
+	}
+	var first []string
+	for i, statement := range statements {
+		violations := m.violations(statement)
+		if len(violations) == 0 {
+			return nil, nil
+		}
+		if i == 0 {
+			first = violations
+		}
+	}
+	return first, nil
+}
+
+// check downgrades a verified provenance attestation to provenance_violation when its
+// statements do not satisfy the policy. Other attestation types pass through unchanged.
+func (m *provenanceMatcher) check(observation models.VerificationObservation, stdout string) models.VerificationObservation {
+	if !m.asserts(observation.AttestationType) {
+		return observation
+	}
+	violations, err := m.evaluate(stdout)
+	if err != nil {
+		observation.Status = models.StatusVerificationError
+		observation.Reason = fmt.Sprintf("cosign attestation verification succeeded but provenance could not be parsed: %v", err)
+		return observation
+	}
+	if len(violations) == 0 {
+		return observation
+	}
+	observation.Status = models.StatusProvenanceViolation
+	observation.Reason = fmt.Sprintf("attestation type %s was verified but its provenance violates policy: %s", observation.AttestationType, strings.Join(violations, "; "))
+	observation.ProvenanceViolations = violations
+	return observation
+}
+
+// asserts reports whether the policy applies to attestations of the given type.
+func (m *provenanceMatcher) asserts(attestationType string) bool {
+	return m != nil && config.IsProvenanceAttestationType(attestationType)
+}
+
+func (m *provenanceMatcher) violations(provenance slsaProvenance) []string {
+	var violations []string
+	if m.builderID != nil && !m.builderID.MatchString(provenance.BuilderID) {
+		violations = append(violations, fmt.Sprintf("builder id %q is not trusted", provenance.BuilderID))
+	}
+	if m.buildType != nil && !m.buildType.MatchString(provenance.BuildType) {
+		violations = append(violations, fmt.Sprintf("build type %q is not allowed", provenance.BuildType))
+	}
+	if m.sourceURI != nil && !m.sourceURI.MatchString(provenance.SourceURI) {
+		violations = append(violations, fmt.Sprintf("source repository %q is not allowed", provenance.SourceURI))
+	}
+	if m.sourceRef != nil && !m.sourceRef.MatchString(provenance.SourceRef) {
+		violations = append(violations, fmt.Sprintf("source ref %q is not allowed", provenance.SourceRef))
+	}
+	if len(m.digestAlgorithms) > 0 {
+		if len(provenance.Materials) == 0 {
+			violations = append(violations, "provenance records no materials")
+		}
+		for _, material := range provenance.Materials {
+			if !hasDigest(material, m.digestAlgorithms) {
+				violations = append(violations, fmt.Sprintf("material %q has no %s digest", material.URI, strings.Join(m.digestAlgorithms, " or ")))
+			}
+		}
+	}
+	for _, expected := range m.materials {
+		violations = append(violations, expected.violations(provenance.Materials)...)
+	}
+	return violations
+}
+
+// violations requires a material matching the URI pattern, and a matching digest on
+// every material that does.
+func (m materialMatcher) violations(materials []slsaResource) []string {
+	var violations []string
+	matched := false
+	for _, material := range materials {
+		if !m.uri.MatchString(material.URI) {
+			continue
+		}
+		matched = true
+		value, ok := digestValue(material, m.algorithm)
+		switch {
+		case !ok:
+			violations = append(violations, fmt.Sprintf("material %q has no %s digest", material.URI, m.algorithm))
+		case !m.digest.MatchString(value):
+			violations = append(violations, fmt.Sprintf("material %q %s digest %q is not allowed", material.URI, m.algorithm, value))
+		}
+	}
+	if !matched {
+		violations = append(violations, fmt.Sprintf("no material matches uri pattern %q", m.pattern))
+	}
+	return violations
+}
+
+// digestValue returns the material's non-empty digest for algorithm, compared
+// case-insensitively.
+func digestValue(material slsaResource, algorithm string) (string, bool) {
+	for name, value := range material.Digest {
+		if strings.EqualFold(name, algorithm) && strings.TrimSpace(value) != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func hasDigest(material slsaResource, algorithms []string) bool {
+	for name, value := range material.Digest {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		for _, algorithm := range algorithms {
+			if strings.EqualFold(name, algorithm) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extractProvenanceStatements decodes the DSSE envelopes cosign prints, one JSON value
+// per line or a single array, and returns the SLSA provenance predicates they carry.
+func extractProvenanceStatements(stdout string) ([]slsaProvenance, error) {
+	decoder := json.NewDecoder(strings.NewReader(stdout))
+	var envelopes []dsseEnvelope
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("decoding attestation output: %w", err)
+		}
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			var batch []dsseEnvelope
+			if err := json.Unmarshal(trimmed, &batch); err != nil {
+				return nil, fmt.Errorf("decoding attestation output: %w", err)
+			}
+			envelopes = append(envelopes, batch...)
+			continue
+		}
+		var envelope dsseEnvelope
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			return nil, fmt.Errorf("decoding attestation output: %w", err)
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	var statements []slsaProvenance
+	for _, envelope := range envelopes {
+		if envelope.Payload == "" {
+			continue
+		}
+		payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("decoding attestation payload: %w", err)
+		}
+		var statement inTotoStatement
+		if err := json.Unmarshal(payload, &statement); err != nil {
+			return nil, fmt.Errorf("decoding in-toto statement: %w", err)
+		}
+		if !strings.HasPrefix(statement.PredicateType, config.SLSAProvenancePredicatePrefix) {
+			continue
+		}
+		provenance, err := parseSLSAPredicate(statement)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, provenance)
+	}
+	return statements, nil
+}
+
+func parseSLSAPredicate(statement inTotoStatement) (slsaProvenance, error) {
+	if strings.HasPrefix(statement.PredicateType, config.SLSAProvenancePredicatePrefix+"v0.") {
+		var predicate slsaPredicateV02
+		if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+			return slsaProvenance{}, fmt.Errorf("decoding SLSA %s predicate: %w", statement.PredicateType, err)
+		}
+		sourceURI, sourceRef := splitSourceURI(predicate.Invocation.ConfigSource.URI)
+		return slsaProvenance{
+			BuilderID: predicate.Builder.ID,
+			BuildType: predicate.BuildType,
+			SourceURI: sourceURI,
+			SourceRef: sourceRef,
+			Materials: predicate.Materials,
+		}, nil
+	}
+
+	var predicate slsaPredicateV1
+	if err := json.Unmarshal(statement.Predicate, &predicate); err != nil {
+		return slsaProvenance{}, fmt.Errorf("decoding SLSA %s predicate: %w", statement.PredicateType, err)
+	}
+	definition := predicate.BuildDefinition
+	provenance := slsaProvenance{
+		BuilderID: predicate.RunDetails.Builder.ID,
+		BuildType: definition.BuildType,
+		Materials: definition.ResolvedDependencies,
+	}
+	switch {
+	case definition.ExternalParameters.Workflow.Repository != "":
+		provenance.SourceURI, _ = splitSourceURI(definition.ExternalParameters.Workflow.Repository)
+		provenance.SourceRef = definition.ExternalParameters.Workflow.Ref
+	case definition.ExternalParameters.Source != "":
+		provenance.SourceURI, provenance.SourceRef = splitSourceURI(definition.ExternalParameters.Source)
+	default:
+		for _, dependency := range definition.ResolvedDependencies {
+			if strings.HasPrefix(dependency.URI, "git+") {
+				provenance.SourceURI, provenance.SourceRef = splitSourceURI(dependency.URI)
+				break
+			}
+		}
+	}
+	return provenance, nil
+}
+
+// splitSourceURI turns a SLSA source such as git+https://github.com/org/repo@refs/heads/main
+// into the repository URI and ref. An "@" in the authority is userinfo, not a ref.
+func splitSourceURI(uri string) (string, string) {
+	uri = strings.TrimPrefix(uri, "git+")
+	at := strings.LastIndex(uri, "@")
+	if at < 0 || at < strings.Index(uri, "://") {
+		return uri, ""
+	}
+	ref := uri[at+1:]
+	if strings.Contains(ref, "/") && !strings.HasPrefix(ref, "refs/") {
+		return uri, ""
+	}
+	return uri[:at], ref
+}
@@ -0,0 +1,231 @@
+package verify
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+	"github.com/stretchr/testify/require"
+)
+
+const slsaV1Predicate = `{
+  "buildDefinition": {
+    "buildType": "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+    "externalParameters": {
+      "workflow": {"repository": "https://github.com/example/api", "ref": "refs/heads/main", "path": ".github/workflows/release.yml"}
+    },
+    "resolvedDependencies": [
+      {"uri": "git+https://github.com/example/api@refs/heads/main", "digest": {"gitCommit": "0123abcd"}}
+    ]
+  },
+  "runDetails": {
+    "builder": {"id": "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0"}
+  }
+}`
+
+const slsaV02Predicate = `{
+  "builder": {"id": "https://cloudbuild.googleapis.com/GoogleHostedWorker"},
+  "buildType": "https://cloudbuild.googleapis.com/CloudBuildYaml@v1",
+  "invocation": {"configSource": {"uri": "git+https://github.com/example/api@refs/heads/feature", "entryPoint": "cloudbuild.yaml"}},
+  "materials": [
+    {"uri": "git+https://github.com/example/api", "digest": {"sha1": "0123abcd"}},
+    {"uri": "pkg:docker/golang@1.22"}
+  ]
+}`
+
+// attestationOutput builds cosign verify-attestation output carrying one in-toto statement.
+func attestationOutput(t *testing.T, predicateType, predicate string) string {
+	t.Helper()
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"predicateType": predicateType,
+		"predicate":     json.RawMessage(predicate),
+	})
+	require.NoError(t, err)
+	output, err := json.Marshal([]map[string]any{{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+		"optional": map[string]string{
+			"Issuer":  "https://token.actions.githubusercontent.com",
+			"Subject": "https://github.com/example/api/.github/workflows/release.yml@refs/heads/main",
+		},
+	}})
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestProvenanceMatcherAcceptsSLSAv1(t *testing.T) {
+	matcher, err := newProvenanceMatcher(config.ProvenancePolicy{
+		BuilderIDs:               []string{`https://github\.com/slsa-framework/slsa-github-generator/.+`},
+		SourceURIs:               []string{"https://github.com/example/api"},
+		SourceRefs:               []string{"refs/heads/main", "refs/tags/v.*"},
+		MaterialDigestAlgorithms: []string{"gitCommit"},
+	})
+	require.NoError(t, err)
+
+	violations, err := matcher.evaluate(attestationOutput(t, "https://slsa.dev/provenance/v1", slsaV1Predicate))
+	require.NoError(t, err)
+	require.Empty(t, violations)
+}
+
+func TestProvenanceMatcherReportsSLSAv02Violations(t *testing.T) {
+	matcher, err := newProvenanceMatcher(config.ProvenancePolicy{
+		BuildTypes:               []string{`https://cloudbuild\.googleapis\.com/CloudBuildYaml@v1`},
+		SourceRefs:               []string{"refs/heads/main"},
+		MaterialDigestAlgorithms: []string{"sha256", "sha1"},
+	})
+	require.NoError(t, err)
+
+	violations, err := matcher.evaluate(attestationOutput(t, "https://slsa.dev/provenance/v0.2", slsaV02Predicate))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`source ref "refs/heads/feature" is not allowed`,
+		`material "pkg:docker/golang@1.22" has no sha256 or sha1 digest`,
+	}, violations)
+}
+
+func TestProvenanceMatcherChecksMaterialDigests(t *testing.T) {
+	output := attestationOutput(t, "https://slsa.dev/provenance/v1", slsaV1Predicate)
+
+	matcher, err := newProvenanceMatcher(config.ProvenancePolicy{
+		Materials: []config.MaterialMatcher{{URI: `git\+https://github\.com/example/api@.+`, Algorithm: "gitcommit", Digest: "0123abcd"}},
+	})
+	require.NoError(t, err)
+	violations, err := matcher.evaluate(output)
+	require.NoError(t, err)
+	require.Empty(t, violations)
+
+	matcher, err = newProvenanceMatcher(config.ProvenancePolicy{
+		Materials: []config.MaterialMatcher{
+			{URI: `git\+https://github\.com/example/api@.+`, Algorithm: "gitCommit", Digest: "ffff.*"},
+			{URI: `git\+https://github\.com/example/api@.+`, Algorithm: "sha256", Digest: "[0-9a-f]{64}"},
+			{URI: `pkg:docker/golang@.+`, Algorithm: "sha256", Digest: "[0-9a-f]{64}"},
+		},
+	})
+	require.NoError(t, err)
+	violations, err = matcher.evaluate(output)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`material "git+https://github.com/example/api@refs/heads/main" gitCommit digest "0123abcd" is not allowed`,
+		`material "git+https://github.com/example/api@refs/heads/main" has no sha256 digest`,
+		`no material matches uri pattern "pkg:docker/golang@.+"`,
+	}, violations)
+}
+
+func TestProvenanceMatcherWithoutProvenanceStatement(t *testing.T) {
+	matcher, err := newProvenanceMatcher(config.ProvenancePolicy{BuilderIDs: []string{".*"}})
+	require.NoError(t, err)
+
+	violations, err := matcher.evaluate(attestationOutput(t, "https://spdx.dev/Document", `{}`))
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+}
+
+func TestNewProvenanceMatcherEmptyPolicy(t *testing.T) {
+	matcher, err := newProvenanceMatcher(config.ProvenancePolicy{})
+	require.NoError(t, err)
+	require.Nil(t, matcher)
+
+	_, err = newProvenanceMatcher(config.ProvenancePolicy{SourceRefs: []string{"refs/(heads"}})
+	require.Error(t, err)
+}
+
+func TestSplitSourceURI(t *testing.T) {
+	tests := map[string][2]string{
+		"git+https://github.com/example/api@refs/heads/main": {"https://github.com/example/api", "refs/heads/main"},
+		"git+https://github.com/example/api@0123abcd":        {"https://github.com/example/api", "0123abcd"},
+		"https://github.com/example/api":                     {"https://github.com/example/api", ""},
+		"ssh://git@github.com/example/api":                   {"ssh://git@github.com/example/api", ""},
+	}
+	for uri, want := range tests {
+		repo, ref := splitSourceURI(uri)
+		require.Equal(t, want, [2]string{repo, ref}, uri)
+	}
+}
+
+func TestCosignAttestationVerifierProvenanceViolation(t *testing.T) {
+	runner := &fakeRunner{stdout: attestationOutput(t, "https://slsa.dev/provenance/v1", slsaV1Predicate)}
+	verifier, err := NewCosignAttestationVerifier(
+		runner,
+		registry.Credentials{},
+		[]string{"slsaprovenance1"},
+		[]string{"https://token.actions.githubusercontent.com"},
+		nil,
+		nil,
+		config.ProvenancePolicy{SourceRefs: []string{"refs/tags/v.*"}},
+	)
+	require.NoError(t, err)
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{
+		ID: "ghcr.io/example/api@sha256:abc",
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusProvenanceViolation, observation.Status)
+	require.Equal(t, []string{`source ref "refs/heads/main" is not allowed`}, observation.ProvenanceViolations)
+	require.Equal(t, "slsaprovenance1", observation.AttestationType)
+	require.Equal(t, "https://token.actions.githubusercontent.com", observation.Signer.Issuer)
+}
+
+func TestCosignAttestationKeyVerifierSkipsNonProvenanceTypes(t *testing.T) {
+	runner := &fakeRunner{stdout: attestationOutput(t, "https://spdx.dev/Document", `{}`)}
+	verifier, err := NewCosignAttestationKeyVerifier(
+		runner,
+		registry.Credentials{},
+		[]config.TrustedPublicKey{{Ref: "/keys/release.pub", ID: "release.pub"}},
+		[]string{"spdxjson"},
+		false,
+		config.ProvenancePolicy{BuilderIDs: []string{"https://builder.example.com"}},
+	)
+	require.NoError(t, err)
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{
+		ID: "ghcr.io/example/api@sha256:abc",
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusVerified, observation.Status)
+}
+
+func TestCosignAttestationVerifierProvenanceViolationOverridesOtherTypes(t *testing.T) {
+	runner := &fakeRunner{stdout: attestationOutput(t, "https://slsa.dev/provenance/v1", slsaV1Predicate)}
+	verifier, err := NewCosignAttestationVerifier(
+		runner,
+		registry.Credentials{},
+		[]string{"spdxjson", "slsaprovenance1"},
+		[]string{"https://token.actions.githubusercontent.com"},
+		nil,
+		nil,
+		config.ProvenancePolicy{SourceRefs: []string{"refs/tags/v.*"}},
+	)
+	require.NoError(t, err)
+	require.True(t, verifier.assertsProvenance())
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{
+		ID: "ghcr.io/example/api@sha256:abc",
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusProvenanceViolation, observation.Status)
+	require.Equal(t, "slsaprovenance1", observation.AttestationType)
+}
+
+func TestCosignAttestationKeyVerifierProvenanceViolationOverridesOtherTypes(t *testing.T) {
+	runner := &fakeRunner{stdout: attestationOutput(t, "https://slsa.dev/provenance/v1", slsaV1Predicate)}
+	verifier, err := NewCosignAttestationKeyVerifier(
+		runner,
+		registry.Credentials{},
+		[]config.TrustedPublicKey{{Ref: "/keys/release.pub", ID: "release.pub"}},
+		[]string{"spdxjson", "slsaprovenance1"},
+		false,
+		config.ProvenancePolicy{SourceRefs: []string{"refs/tags/v.*"}},
+	)
+	require.NoError(t, err)
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{
+		ID: "ghcr.io/example/api@sha256:abc",
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusProvenanceViolation, observation.Status)
+}
@@ -32,7 +32,7 @@ func VerifyWithRetries(
 			return models.VerificationObservation{}, err
 		}
 		last = observation
-		if observation.Status != models.StatusVerificationError || !IsTransientFailure(observation.Reason) {
+		if observation.Status != models.StatusVerificationError || !(observation.Transient || IsTransientFailure(observation.Reason)) {
 			return observation, nil
 		}
 		if attempt == retries {
@@ -72,3 +72,29 @@ func TestPreflightDigestResolveError(t *testing.T) {
 	require.Equal(t, models.StatusVerificationError, observation.Status)
 	require.Contains(t, observation.Reason, "digest lookup failed")
 }
+
+func TestVerifyWithRetriesRetriesTransientObservation(t *testing.T) {
+	verifier := &sequentialVerifier{observations: []models.VerificationObservation{
+		{Mode: models.VerificationModeCosignKey, Status: models.StatusVerificationError, Reason: "sigstore verification failed: registry", Transient: true},
+		{Mode: models.VerificationModeCosignKey, Status: models.StatusVerified},
+	}}
+	observation, err := VerifyWithRetries(context.Background(), verifier, models.DiscoveredImage{}, 3, 10*time.Millisecond, false)
+	require.NoError(t, err)
+	require.Equal(t, models.StatusVerified, observation.Status)
+	require.Equal(t, 2, verifier.calls)
+}
+
+type sequentialVerifier struct {
+	observations []models.VerificationObservation
+	calls        int
+}
+
+func (s *sequentialVerifier) Name() models.VerificationMode {
+	return s.observations[0].Mode
+}
+
+func (s *sequentialVerifier) Verify(context.Context, models.DiscoveredImage) (models.VerificationObservation, error) {
+	observation := s.observations[min(s.calls, len(s.observations)-1)]
+	s.calls++
+	return observation, nil
+}
@@ -0,0 +1,64 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+)
+
+// ScopedVerifier is the verifier for images matched by one trust policy.
+type ScopedVerifier struct {
+	Policy   string
+	Matches  func(models.DiscoveredImage) bool
+	Verifier Verifier
+}
+
+// PolicyVerifier routes each image to the first scoped verifier whose policy matches,
+// falling back to the default verifier.
+type PolicyVerifier struct {
+	scoped          []ScopedVerifier
+	defaultPolicy   string
+	defaultVerifier Verifier
+}
+
+// NewPolicyVerifier creates a verifier that evaluates trust policies in order.
+func NewPolicyVerifier(defaultPolicy string, defaultVerifier Verifier, scoped ...ScopedVerifier) (*PolicyVerifier, error) {
+	if defaultVerifier == nil {
+		return nil, fmt.Errorf("a default verifier is required")
+	}
+	for _, s := range scoped {
+		if s.Verifier == nil || s.Matches == nil {
+			return nil, fmt.Errorf("trust policy %q has no verifier", s.Policy)
+		}
+	}
+	return &PolicyVerifier{
+		scoped:          append([]ScopedVerifier(nil), scoped...),
+		defaultPolicy:   defaultPolicy,
+		defaultVerifier: defaultVerifier,
+	}, nil
+}
+
+func (p *PolicyVerifier) Name() models.VerificationMode {
+	return p.defaultVerifier.Name()
+}
+
+func (p *PolicyVerifier) Verify(ctx context.Context, image models.DiscoveredImage) (models.VerificationObservation, error) {
+	_, verifier := p.route(image)
+	return verifier.Verify(ctx, image)
+}
+
+// PolicyFor returns the name of the trust policy that judges an image.
+func (p *PolicyVerifier) PolicyFor(image models.DiscoveredImage) string {
+	policy, _ := p.route(image)
+	return policy
+}
+
+func (p *PolicyVerifier) route(image models.DiscoveredImage) (string, Verifier) {
+	for _, s := range p.scoped {
+		if s.Matches(image) {
+			return s.Policy, s.Verifier
+		}
+	}
+	return p.defaultPolicy, p.defaultVerifier
+}
@@ -0,0 +1,45 @@
+package verify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyVerifierFirstMatchWins(t *testing.T) {
+	verified := func(mode models.VerificationMode) stubVerifier {
+		return stubVerifier{name: mode, result: models.VerificationObservation{Mode: mode, Status: models.StatusVerified}}
+	}
+	isPlatform := func(image models.DiscoveredImage) bool { return strings.HasPrefix(image.Name, "ghcr.io/acme/") }
+	isGHCR := func(image models.DiscoveredImage) bool { return strings.HasPrefix(image.Name, "ghcr.io/") }
+
+	verifier, err := NewPolicyVerifier("default", verified(models.VerificationModeCosignKeyless),
+		ScopedVerifier{Policy: "platform", Matches: isPlatform, Verifier: verified(models.VerificationModeCosignKey)},
+		ScopedVerifier{Policy: "ghcr", Matches: isGHCR, Verifier: verified(models.VerificationModeNotation)},
+	)
+	require.NoError(t, err)
+
+	images := []models.DiscoveredImage{
+		{Name: "ghcr.io/acme/api:1.0.0", ID: "ghcr.io/acme/api@sha256:abc"},
+		{Name: "ghcr.io/other/api:1.0.0", ID: "ghcr.io/other/api@sha256:def"},
+		{Name: "docker.io/library/nginx:1.25", ID: "docker.io/library/nginx@sha256:123"},
+		{Name: "docker.io/library/redis:7"},
+	}
+	results, err := VerifyImages(context.Background(), images, registry.Credentials{}, verifier, 2, time.Second, time.Millisecond, false, 1)
+	require.NoError(t, err)
+
+	require.Equal(t, "platform", results[0].TrustPolicy)
+	require.Equal(t, string(models.VerificationModeCosignKey), results[0].VerifiedBy)
+	require.Equal(t, "ghcr", results[1].TrustPolicy)
+	require.Equal(t, string(models.VerificationModeNotation), results[1].VerifiedBy)
+	require.Equal(t, "default", results[2].TrustPolicy)
+	require.Equal(t, string(models.VerificationModeCosignKeyless), results[2].VerifiedBy)
+	// Images stopped by preflight still record the policy that would have judged them.
+	require.Equal(t, models.StatusUnknown, results[3].Status)
+	require.Equal(t, "default", results[3].TrustPolicy)
+}
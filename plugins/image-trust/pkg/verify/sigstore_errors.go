@@ -0,0 +1,80 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// SigstoreFailure identifies why in-process Sigstore verification did not succeed.
+type SigstoreFailure string
+
+const (
+	// SigstoreFailureNoSignatures means no signature or bundle is attached to the digest.
+	SigstoreFailureNoSignatures SigstoreFailure = "no_signatures"
+	// SigstoreFailureUntrusted means signatures exist but none verify against the trusted
+	// root or the trusted public keys.
+	SigstoreFailureUntrusted SigstoreFailure = "untrusted"
+	// SigstoreFailureRegistry means the signatures could not be read from the registry.
+	SigstoreFailureRegistry SigstoreFailure = "registry"
+	// SigstoreFailureTrustedRoot means the Sigstore trusted root could not be loaded.
+	SigstoreFailureTrustedRoot SigstoreFailure = "trusted_root"
+	// SigstoreFailureMalformed means an attached signature or bundle could not be decoded.
+	SigstoreFailureMalformed SigstoreFailure = "malformed"
+)
+
+// SigstoreError is a structured failure from the in-process Sigstore verifier, used in
+// place of classifying cosign stderr text.
+type SigstoreError struct {
+	Failure   SigstoreFailure
+	Ref       string
+	Transient bool
+	Err       error
+}
+
+func (e *SigstoreError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("sigstore verification of %s failed: %s", e.Ref, e.Failure)
+	}
+	return fmt.Sprintf("sigstore verification of %s failed: %s: %v", e.Ref, e.Failure, e.Err)
+}
+
+func (e *SigstoreError) Unwrap() error {
+	return e.Err
+}
+
+// Status maps the failure to the image trust status it reports.
+func (e *SigstoreError) Status() models.Status {
+	switch e.Failure {
+	case SigstoreFailureNoSignatures:
+		return models.StatusUnsigned
+	case SigstoreFailureUntrusted:
+		return models.StatusSignedUntrusted
+	default:
+		return models.StatusVerificationError
+	}
+}
+
+func sigstoreRegistryError(ref string, err error) *SigstoreError {
+	return &SigstoreError{
+		Failure:   SigstoreFailureRegistry,
+		Ref:       ref,
+		Transient: isTransientRegistryError(err),
+		Err:       err,
+	}
+}
+
+// isTransientRegistryError reports whether a registry API error is worth retrying:
+// rate limiting, server errors and network timeouts.
+func isTransientRegistryError(err error) bool {
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) {
+		return transportErr.StatusCode == http.StatusTooManyRequests || transportErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,504 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	sgverify "github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+const (
+	bundleMediaTypeV01 = "application/vnd.dev.sigstore.bundle+json;version=0.1"
+	bundleMediaTypeV03 = "application/vnd.dev.sigstore.bundle.v0.3+json"
+)
+
+// sigstoreSource reads the signatures attached to an image digest reference.
+type sigstoreSource interface {
+	CosignSignatures(ctx context.Context, ref string) ([]registry.CosignSignature, error)
+	Bundles(ctx context.Context, ref string) ([][]byte, error)
+}
+
+type registrySigstoreSource struct {
+	creds registry.Credentials
+}
+
+func (s registrySigstoreSource) CosignSignatures(ctx context.Context, ref string) ([]registry.CosignSignature, error) {
+	return registry.FetchCosignSignatures(ctx, s.creds, ref)
+}
+
+func (s registrySigstoreSource) Bundles(ctx context.Context, ref string) ([][]byte, error) {
+	return registry.FetchSigstoreBundles(ctx, s.creds, ref)
+}
+
+// SigstoreVerifier verifies cosign signatures in-process with the Sigstore Go libraries
+// instead of running the cosign binary. It reads both Sigstore bundles attached as OCI
+// referrers and signatures in the cosign .sig tag layout.
+type SigstoreVerifier struct {
+	mode          models.VerificationMode
+	registryCreds registry.Credentials
+	source        sigstoreSource
+	trust         *SigstoreTrust
+
+	trustedIssuerMatcher  *regexp.Regexp
+	trustedSubjectMatcher *regexp.Regexp
+
+	publicKeys []sigstoreKey
+	ignoreTlog bool
+}
+
+type sigstoreKey struct {
+	key      config.TrustedPublicKey
+	verifier root.TimeConstrainedVerifier
+}
+
+// signedEntity is a decoded signature with the artifact policy it must be verified against.
+type signedEntity struct {
+	bundle   *bundle.Bundle
+	artifact func() sgverify.ArtifactPolicyOption
+}
+
+// NewSigstoreKeylessVerifier creates an in-process keyless verifier. Certificates must chain
+// to the trusted root's Fulcio CAs, carry SCTs, and be logged in Rekor.
+func NewSigstoreKeylessVerifier(
+	registryCreds registry.Credentials,
+	trust *SigstoreTrust,
+	trustedIssuers, trustedSubjects, trustedSubjectREs []string,
+) (*SigstoreVerifier, error) {
+	if trust == nil {
+		return nil, fmt.Errorf("sigstore trusted root is required")
+	}
+	issuerMatcher, subjectMatcher, err := compileSignerMatchers(trustedIssuers, trustedSubjects, trustedSubjectREs)
+	if err != nil {
+		return nil, err
+	}
+	return &SigstoreVerifier{
+		mode:                  models.VerificationModeCosignKeyless,
+		registryCreds:         registryCreds,
+		source:                registrySigstoreSource{creds: registryCreds},
+		trust:                 trust,
+		trustedIssuerMatcher:  issuerMatcher,
+		trustedSubjectMatcher: subjectMatcher,
+	}, nil
+}
+
+// NewSigstoreKeyVerifier creates an in-process verifier for signatures made with local
+// public keys. Unless ignoreTlog is set, signatures must also be logged in Rekor.
+func NewSigstoreKeyVerifier(
+	registryCreds registry.Credentials,
+	trust *SigstoreTrust,
+	publicKeys []config.TrustedPublicKey,
+	ignoreTlog bool,
+) (*SigstoreVerifier, error) {
+	if len(publicKeys) == 0 {
+		return nil, fmt.Errorf("at least one trusted public key is required")
+	}
+	if trust == nil && !ignoreTlog {
+		return nil, fmt.Errorf("sigstore trusted root is required unless the transparency log is ignored")
+	}
+	keys := make([]sigstoreKey, 0, len(publicKeys))
+	for _, key := range publicKeys {
+		verifier, err := loadSigstoreKey(key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, sigstoreKey{key: key, verifier: verifier})
+	}
+	return &SigstoreVerifier{
+		mode:          models.VerificationModeCosignKey,
+		registryCreds: registryCreds,
+		source:        registrySigstoreSource{creds: registryCreds},
+		trust:         trust,
+		publicKeys:    keys,
+		ignoreTlog:    ignoreTlog,
+	}, nil
+}
+
+func (v *SigstoreVerifier) Name() models.VerificationMode {
+	return v.mode
+}
+
+func (v *SigstoreVerifier) Verify(ctx context.Context, image models.DiscoveredImage) (models.VerificationObservation, error) {
+	ref := v.registryCreds.VerificationReference(image.VerificationReference())
+	if ref == "" {
+		return models.VerificationObservation{
+			Mode:   v.Name(),
+			Status: models.StatusUnknown,
+			Reason: "image could not be resolved to an immutable digest reference",
+		}, nil
+	}
+	if v.mode == models.VerificationModeCosignKeyless && v.trustedIssuerMatcher == nil && v.trustedSubjectMatcher == nil {
+		return models.VerificationObservation{
+			Mode:   v.Name(),
+			Status: models.StatusVerificationError,
+			Reason: "trusted issuer or subject policy is not configured",
+		}, nil
+	}
+
+	observation, err := v.verify(ctx, ref)
+	var sigstoreErr *SigstoreError
+	if errors.As(err, &sigstoreErr) {
+		return models.VerificationObservation{
+			Mode:      v.Name(),
+			Status:    sigstoreErr.Status(),
+			Reason:    sigstoreErr.Error(),
+			Transient: sigstoreErr.Transient,
+		}, nil
+	}
+	if err != nil {
+		return models.VerificationObservation{}, err
+	}
+	observation.Mode = v.Name()
+	return observation, nil
+}
+
+func (v *SigstoreVerifier) verify(ctx context.Context, ref string) (models.VerificationObservation, error) {
+	digest, err := digestFromReference(ref)
+	if err != nil {
+		return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureMalformed, Ref: ref, Err: err}
+	}
+	entities, err := v.signedEntities(ctx, ref, digest)
+	if err != nil {
+		return models.VerificationObservation{}, err
+	}
+	if len(entities) == 0 {
+		return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureNoSignatures, Ref: ref}
+	}
+
+	if v.mode == models.VerificationModeCosignKey {
+		return v.verifyWithKeys(ref, entities)
+	}
+	return v.verifyKeyless(ref, entities)
+}
+
+func (v *SigstoreVerifier) verifyKeyless(ref string, entities []signedEntity) (models.VerificationObservation, error) {
+	trustedRoot, err := v.trust.TrustedMaterial()
+	if err != nil {
+		return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureTrustedRoot, Ref: ref, Err: err}
+	}
+	verifier, err := sgverify.NewVerifier(trustedRoot,
+		sgverify.WithSignedCertificateTimestamps(1),
+		sgverify.WithTransparencyLog(1),
+		sgverify.WithObserverTimestamps(1),
+	)
+	if err != nil {
+		return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureTrustedRoot, Ref: ref, Err: err}
+	}
+
+	var signers []models.SignerDetails
+	var lastErr error
+	for _, entity := range entities {
+		// Identities are checked against the trust policy below so that a valid signature
+		// from another signer reports signed_untrusted rather than unsigned.
+		result, err := verifier.Verify(entity.bundle, sgverify.NewPolicy(entity.artifact(), sgverify.WithoutIdentitiesUnsafe()))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if result.Signature == nil || result.Signature.Certificate == nil {
+			continue
+		}
+		signers = append(signers, models.SignerDetails{
+			Issuer:  result.Signature.Certificate.Extensions.Issuer,
+			Subject: result.Signature.Certificate.SubjectAlternativeName,
+		})
+	}
+
+	for _, signer := range signers {
+		if v.isTrustedSigner(signer) {
+			return models.VerificationObservation{
+				Status:  models.StatusVerified,
+				Reason:  "sigstore verification succeeded",
+				Signer:  signer,
+				Signers: dedupeSigners(signers),
+			}, nil
+		}
+	}
+	if len(signers) > 0 {
+		return models.VerificationObservation{
+			Status:  models.StatusSignedUntrusted,
+			Reason:  "signature was verified but no signer matched the configured trust policy",
+			Signer:  signers[0],
+			Signers: dedupeSigners(signers),
+		}, nil
+	}
+	return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureUntrusted, Ref: ref, Err: lastErr}
+}
+
+func (v *SigstoreVerifier) verifyWithKeys(ref string, entities []signedEntity) (models.VerificationObservation, error) {
+	options := []sgverify.VerifierOption{sgverify.WithCurrentTime()}
+	var trustedRoot root.TrustedMaterial
+	if !v.ignoreTlog {
+		var err error
+		trustedRoot, err = v.trust.TrustedMaterial()
+		if err != nil {
+			return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureTrustedRoot, Ref: ref, Err: err}
+		}
+		options = []sgverify.VerifierOption{sgverify.WithTransparencyLog(1), sgverify.WithIntegratedTimestamps(1)}
+	}
+
+	var lastErr error
+	for _, key := range v.publicKeys {
+		keyVerifier := key.verifier
+		material := root.TrustedMaterialCollection{
+			root.NewTrustedPublicKeyMaterial(func(string) (root.TimeConstrainedVerifier, error) {
+				return keyVerifier, nil
+			}),
+		}
+		if trustedRoot != nil {
+			material = append(material, trustedRoot)
+		}
+		verifier, err := sgverify.NewVerifier(material, options...)
+		if err != nil {
+			return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureTrustedRoot, Ref: ref, Err: err}
+		}
+		for _, entity := range entities {
+			if _, err := verifier.Verify(entity.bundle, sgverify.NewPolicy(entity.artifact(), sgverify.WithKey())); err != nil {
+				lastErr = err
+				continue
+			}
+			signer := models.SignerDetails{KeyRef: key.key.ReportKeyRef()}
+			return models.VerificationObservation{
+				Status:  models.StatusVerified,
+				Reason:  fmt.Sprintf("sigstore verification succeeded with trusted public key %s", key.key.ReportKeyRef()),
+				Signer:  signer,
+				Signers: []models.SignerDetails{signer},
+			}, nil
+		}
+	}
+	return models.VerificationObservation{}, &SigstoreError{Failure: SigstoreFailureUntrusted, Ref: ref, Err: lastErr}
+}
+
+func (v *SigstoreVerifier) isTrustedSigner(signer models.SignerDetails) bool {
+	if v.trustedIssuerMatcher != nil && !v.trustedIssuerMatcher.MatchString(signer.Issuer) {
+		return false
+	}
+	if v.trustedSubjectMatcher != nil && !v.trustedSubjectMatcher.MatchString(signer.Subject) {
+		return false
+	}
+	return true
+}
+
+// signedEntities collects the bundles and tag-layout signatures that claim to sign digest.
+// Tag-layout signatures whose payload names another digest are ignored, as cosign does.
+func (v *SigstoreVerifier) signedEntities(ctx context.Context, ref string, digest []byte) ([]signedEntity, error) {
+	bundles, err := v.source.Bundles(ctx, ref)
+	if err != nil {
+		return nil, sigstoreRegistryError(ref, err)
+	}
+	signatures, err := v.source.CosignSignatures(ctx, ref)
+	if err != nil {
+		return nil, sigstoreRegistryError(ref, err)
+	}
+
+	entities := make([]signedEntity, 0, len(bundles)+len(signatures))
+	for _, data := range bundles {
+		b := &bundle.Bundle{}
+		if err := b.UnmarshalJSON(data); err != nil {
+			return nil, &SigstoreError{Failure: SigstoreFailureMalformed, Ref: ref, Err: fmt.Errorf("decoding sigstore bundle: %w", err)}
+		}
+		entities = append(entities, signedEntity{
+			bundle: b,
+			artifact: func() sgverify.ArtifactPolicyOption {
+				return sgverify.WithArtifactDigest("sha256", digest)
+			},
+		})
+	}
+	for _, sig := range signatures {
+		if !payloadSignsDigest(sig.Payload, digest) {
+			continue
+		}
+		b, err := bundleFromCosignSignature(sig)
+		if err != nil {
+			return nil, &SigstoreError{Failure: SigstoreFailureMalformed, Ref: ref, Err: err}
+		}
+		payload := sig.Payload
+		entities = append(entities, signedEntity{
+			bundle: b,
+			artifact: func() sgverify.ArtifactPolicyOption {
+				return sgverify.WithArtifact(bytes.NewReader(payload))
+			},
+		})
+	}
+	return entities, nil
+}
+
+// simpleSigningPayload is the part of the cosign signed payload that binds it to an image.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+func payloadSignsDigest(payload, digest []byte) bool {
+	var parsed simpleSigningPayload
+	if err := json.Unmarshal(payload, &parsed); err != nil {
+		return false
+	}
+	return parsed.Critical.Image.DockerManifestDigest == "sha256:"+hex.EncodeToString(digest)
+}
+
+// rekorBundle is the Rekor inclusion promise cosign stores in the dev.sigstore.cosign/bundle
+// annotation.
+type rekorBundle struct {
+	SignedEntryTimestamp []byte `json:"SignedEntryTimestamp"`
+	Payload              struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogIndex       int64  `json:"logIndex"`
+		LogID          string `json:"logID"`
+	} `json:"Payload"`
+}
+
+// bundleFromCosignSignature converts a tag-layout signature to a Sigstore bundle. Signatures
+// with a Rekor inclusion promise become v0.1 bundles, which accept promises without proofs.
+func bundleFromCosignSignature(sig registry.CosignSignature) (*bundle.Bundle, error) {
+	rawSignature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("decoding cosign signature: %w", err)
+	}
+	payloadDigest := sha256.Sum256(sig.Payload)
+
+	material := &protobundle.VerificationMaterial{}
+	mediaType := bundleMediaTypeV03
+	if sig.Certificate != "" {
+		certificates, err := pemCertificates(sig.Certificate + "\n" + sig.Chain)
+		if err != nil {
+			return nil, err
+		}
+		material.Content = &protobundle.VerificationMaterial_Certificate{Certificate: certificates[0]}
+		if sig.RekorBundle != "" {
+			material.Content = &protobundle.VerificationMaterial_X509CertificateChain{
+				X509CertificateChain: &protocommon.X509CertificateChain{Certificates: certificates},
+			}
+		}
+	} else {
+		material.Content = &protobundle.VerificationMaterial_PublicKey{PublicKey: &protocommon.PublicKeyIdentifier{}}
+	}
+
+	if sig.RekorBundle != "" {
+		entry, err := tlogEntryFromRekorBundle(sig.RekorBundle)
+		if err != nil {
+			return nil, err
+		}
+		material.TlogEntries = []*protorekor.TransparencyLogEntry{entry}
+		mediaType = bundleMediaTypeV01
+	}
+
+	return bundle.NewBundle(&protobundle.Bundle{
+		MediaType:            mediaType,
+		VerificationMaterial: material,
+		Content: &protobundle.Bundle_MessageSignature{
+			MessageSignature: &protocommon.MessageSignature{
+				MessageDigest: &protocommon.HashOutput{
+					Algorithm: protocommon.HashAlgorithm_SHA2_256,
+					Digest:    payloadDigest[:],
+				},
+				Signature: rawSignature,
+			},
+		},
+	})
+}
+
+func tlogEntryFromRekorBundle(data string) (*protorekor.TransparencyLogEntry, error) {
+	var rekor rekorBundle
+	if err := json.Unmarshal([]byte(data), &rekor); err != nil {
+		return nil, fmt.Errorf("decoding rekor bundle: %w", err)
+	}
+	body, err := base64.StdEncoding.DecodeString(rekor.Payload.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding rekor entry body: %w", err)
+	}
+	var kind struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal(body, &kind); err != nil {
+		return nil, fmt.Errorf("decoding rekor entry kind: %w", err)
+	}
+	logID, err := hex.DecodeString(rekor.Payload.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("decoding rekor log id: %w", err)
+	}
+	return &protorekor.TransparencyLogEntry{
+		LogIndex:          rekor.Payload.LogIndex,
+		LogId:             &protocommon.LogId{KeyId: logID},
+		KindVersion:       &protorekor.KindVersion{Kind: kind.Kind, Version: kind.APIVersion},
+		IntegratedTime:    rekor.Payload.IntegratedTime,
+		InclusionPromise:  &protorekor.InclusionPromise{SignedEntryTimestamp: rekor.SignedEntryTimestamp},
+		CanonicalizedBody: body,
+	}, nil
+}
+
+func pemCertificates(data string) ([]*protocommon.X509Certificate, error) {
+	var certificates []*protocommon.X509Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			certificates = append(certificates, &protocommon.X509Certificate{RawBytes: block.Bytes})
+		}
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("signing certificate annotation has no PEM certificate")
+	}
+	return certificates, nil
+}
+
+func loadSigstoreKey(key config.TrustedPublicKey) (root.TimeConstrainedVerifier, error) {
+	if strings.Contains(key.Ref, "://") {
+		return nil, fmt.Errorf("in-process verification supports only local public key files, not %s", key.Ref)
+	}
+	data, err := os.ReadFile(key.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key %s: %w", key.ID, err)
+	}
+	publicKey, err := cryptoutils.UnmarshalPEMToPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key %s: %w", key.ID, err)
+	}
+	verifier, err := signature.LoadVerifier(publicKey, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("loading public key %s: %w", key.ID, err)
+	}
+	return root.NewExpiringKey(verifier, time.Time{}, time.Time{}), nil
+}
+
+func digestFromReference(ref string) ([]byte, error) {
+	_, digest, ok := strings.Cut(ref, "@sha256:")
+	if !ok {
+		return nil, fmt.Errorf("reference %q has no sha256 digest", ref)
+	}
+	decoded, err := hex.DecodeString(digest)
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, fmt.Errorf("reference %q has an invalid sha256 digest", ref)
+	}
+	return decoded, nil
+}
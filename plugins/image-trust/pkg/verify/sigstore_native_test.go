@@ -0,0 +1,159 @@
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/stretchr/testify/require"
+)
+
+const testImageDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+var testImageRef = "ghcr.io/example/api@" + testImageDigest
+
+type fakeSigstoreSource struct {
+	signatures []registry.CosignSignature
+	bundles    [][]byte
+	err        error
+}
+
+func (s fakeSigstoreSource) CosignSignatures(context.Context, string) ([]registry.CosignSignature, error) {
+	return s.signatures, s.err
+}
+
+func (s fakeSigstoreSource) Bundles(context.Context, string) ([][]byte, error) {
+	return s.bundles, s.err
+}
+
+// newTestKey writes a P-256 public key to a temp file and returns it with its signer.
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, config.TrustedPublicKey) {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "release.pub")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644))
+	return private, config.TrustedPublicKey{Ref: path, ID: "release.pub"}
+}
+
+// signPayload builds a tag-layout cosign signature over a simple signing payload for digest.
+func signPayload(t *testing.T, private *ecdsa.PrivateKey, digest string) registry.CosignSignature {
+	t.Helper()
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"ghcr.io/example/api"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"},"optional":null}`)
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, private, sum[:])
+	require.NoError(t, err)
+	return registry.CosignSignature{Payload: payload, Signature: base64.StdEncoding.EncodeToString(sig)}
+}
+
+func newTestKeyVerifier(t *testing.T, key config.TrustedPublicKey, source sigstoreSource) *SigstoreVerifier {
+	t.Helper()
+	verifier, err := NewSigstoreKeyVerifier(registry.Credentials{}, nil, []config.TrustedPublicKey{key}, true)
+	require.NoError(t, err)
+	verifier.source = source
+	return verifier
+}
+
+func TestSigstoreKeyVerifierVerified(t *testing.T) {
+	private, key := newTestKey(t)
+	verifier := newTestKeyVerifier(t, key, fakeSigstoreSource{
+		signatures: []registry.CosignSignature{signPayload(t, private, testImageDigest)},
+	})
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: testImageRef})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusVerified, observation.Status)
+	require.Equal(t, models.VerificationModeCosignKey, observation.Mode)
+	require.Equal(t, "release.pub", observation.Signer.KeyRef)
+}
+
+func TestSigstoreKeyVerifierOtherKeyIsUntrusted(t *testing.T) {
+	other, _ := newTestKey(t)
+	_, key := newTestKey(t)
+	verifier := newTestKeyVerifier(t, key, fakeSigstoreSource{
+		signatures: []registry.CosignSignature{signPayload(t, other, testImageDigest)},
+	})
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: testImageRef})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusSignedUntrusted, observation.Status)
+	require.Contains(t, observation.Reason, string(SigstoreFailureUntrusted))
+}
+
+func TestSigstoreKeyVerifierIgnoresSignaturesForOtherDigests(t *testing.T) {
+	private, key := newTestKey(t)
+	verifier := newTestKeyVerifier(t, key, fakeSigstoreSource{
+		signatures: []registry.CosignSignature{signPayload(t, private, "sha256:ffff")},
+	})
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: testImageRef})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusUnsigned, observation.Status)
+}
+
+func TestSigstoreVerifierRegistryErrorIsTransient(t *testing.T) {
+	_, key := newTestKey(t)
+	verifier := newTestKeyVerifier(t, key, fakeSigstoreSource{
+		err: &transport.Error{StatusCode: http.StatusServiceUnavailable},
+	})
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: testImageRef})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusVerificationError, observation.Status)
+	require.True(t, observation.Transient)
+}
+
+func TestSigstoreKeylessVerifierRequiresTrustPolicy(t *testing.T) {
+	verifier, err := NewSigstoreKeylessVerifier(registry.Credentials{}, &SigstoreTrust{}, nil, nil, nil)
+	require.NoError(t, err)
+
+	observation, err := verifier.Verify(context.Background(), models.DiscoveredImage{ID: testImageRef})
+	require.NoError(t, err)
+	require.Equal(t, models.StatusVerificationError, observation.Status)
+}
+
+func TestSigstoreKeyVerifierRejectsRemoteKeys(t *testing.T) {
+	_, err := NewSigstoreKeyVerifier(registry.Credentials{}, nil, []config.TrustedPublicKey{{Ref: "gcpkms://projects/p/keys/k"}}, true)
+	require.Error(t, err)
+}
+
+func TestBundleFromCosignSignatureWithRekorBundle(t *testing.T) {
+	private, _ := newTestKey(t)
+	sig := signPayload(t, private, testImageDigest)
+	body := base64.StdEncoding.EncodeToString([]byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{}}`))
+	sig.RekorBundle = `{"SignedEntryTimestamp":"c2V0","Payload":{"body":"` + body + `","integratedTime":1700000000,"logIndex":42,"logID":"c0d23d6ad406973f"}}`
+
+	b, err := bundleFromCosignSignature(sig)
+	require.NoError(t, err)
+	require.Equal(t, bundleMediaTypeV01, b.GetMediaType())
+	entries := b.GetVerificationMaterial().GetTlogEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, int64(42), entries[0].GetLogIndex())
+	require.Equal(t, "hashedrekord", entries[0].GetKindVersion().GetKind())
+	require.Equal(t, []byte("set"), entries[0].GetInclusionPromise().GetSignedEntryTimestamp())
+}
+
+func TestSigstoreErrorStatus(t *testing.T) {
+	require.Equal(t, models.StatusUnsigned, (&SigstoreError{Failure: SigstoreFailureNoSignatures}).Status())
+	require.Equal(t, models.StatusSignedUntrusted, (&SigstoreError{Failure: SigstoreFailureUntrusted}).Status())
+	require.Equal(t, models.StatusVerificationError, (&SigstoreError{Failure: SigstoreFailureTrustedRoot}).Status())
+
+	cause := errors.New("boom")
+	require.ErrorIs(t, &SigstoreError{Failure: SigstoreFailureRegistry, Err: cause}, cause)
+}
@@ -0,0 +1,70 @@
+package verify
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/fairwindsops/insights-plugins/plugins/image-trust/pkg/config"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+// SigstoreTrust loads the Sigstore trusted root (Fulcio CAs, Rekor and CT log keys) for
+// in-process verifiers. The root is read once, on first use, and shared by every verifier
+// built from the same trust; it comes from a trusted_root.json file or from TUF.
+type SigstoreTrust struct {
+	trustedRootFile string
+	tufMirror       string
+	tufRootFile     string
+
+	once     sync.Once
+	material root.TrustedMaterial
+	err      error
+}
+
+// NewSigstoreTrust creates a lazily loaded trusted root from the plugin configuration.
+func NewSigstoreTrust(cfg *config.Config) *SigstoreTrust {
+	return &SigstoreTrust{
+		trustedRootFile: cfg.SigstoreTrustedRootFile,
+		tufMirror:       cfg.SigstoreTUFMirror,
+		tufRootFile:     cfg.SigstoreTUFRootFile,
+	}
+}
+
+// TrustedMaterial returns the trusted root, loading it on the first call.
+func (t *SigstoreTrust) TrustedMaterial() (root.TrustedMaterial, error) {
+	t.once.Do(func() {
+		t.material, t.err = t.load()
+	})
+	return t.material, t.err
+}
+
+func (t *SigstoreTrust) load() (root.TrustedMaterial, error) {
+	if t.trustedRootFile != "" {
+		trustedRoot, err := root.NewTrustedRootFromPath(t.trustedRootFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading Sigstore trusted root %s: %w", t.trustedRootFile, err)
+		}
+		return trustedRoot, nil
+	}
+
+	opts := tuf.DefaultOptions()
+	// Each run is a one-shot job; keep TUF metadata in memory rather than under $HOME.
+	opts.DisableLocalCache = true
+	if t.tufRootFile != "" {
+		rootJSON, err := os.ReadFile(t.tufRootFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading TUF root %s: %w", t.tufRootFile, err)
+		}
+		opts.Root = rootJSON
+	}
+	if t.tufMirror != "" {
+		opts.RepositoryBaseURL = t.tufMirror
+	}
+	trustedRoot, err := root.FetchTrustedRootWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("fetching Sigstore trusted root from TUF %s: %w", opts.RepositoryBaseURL, err)
+	}
+	return trustedRoot, nil
+}
@@ -99,9 +99,14 @@ func VerifyImages(
 			}
 			if err != nil {
 				results[index] = imageTrustResultFromError(img, err)
-				return
+			} else {
+				results[index] = imageTrustResultFromObservation(img, observation)
+			}
+			if scoped, ok := verifier.(interface {
+				PolicyFor(models.DiscoveredImage) string
+			}); ok {
+				results[index].TrustPolicy = scoped.PolicyFor(img)
 			}
-			results[index] = imageTrustResultFromObservation(img, observation)
 		}(i, image)
 	}
 
@@ -116,19 +121,22 @@ func imageTrustResultFromObservation(img models.DiscoveredImage, observation mod
 		verifiedBy = string(observation.Mode)
 	}
 	return models.ImageTrustResult{
-		Name:               img.Name,
-		ID:                 img.ID,
-		PullRef:            img.PullRef,
-		Status:             observation.Status,
-		Reason:             observation.Reason,
-		VerificationMode:   verificationModeFromObservation(observation),
-		VerifiedBy:         verifiedBy,
-		AttestationType:    observation.AttestationType,
-		Allowlisted:        false,
-		Owners:             img.Owners,
-		Signer:             observation.Signer,
-		CandidateSigners:   append([]models.SignerDetails(nil), observation.Signers...),
-		DigestResolveError: img.DigestResolveError,
+		Name:                 img.Name,
+		ID:                   img.ID,
+		PullRef:              img.PullRef,
+		Status:               observation.Status,
+		Reason:               observation.Reason,
+		VerificationMode:     verificationModeFromObservation(observation),
+		VerifiedBy:           verifiedBy,
+		AttestationType:      observation.AttestationType,
+		Allowlisted:          false,
+		Owners:               img.Owners,
+		Signer:               observation.Signer,
+		CandidateSigners:     append([]models.SignerDetails(nil), observation.Signers...),
+		ProvenanceViolations: append([]string(nil), observation.ProvenanceViolations...),
+		DigestResolveError:   img.DigestResolveError,
+		Cached:               !observation.CheckedAt.IsZero(),
+		LastCheckedAt:        observation.CheckedAt,
 	}
 }
 
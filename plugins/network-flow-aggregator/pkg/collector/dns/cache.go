@@ -1,6 +1,7 @@
 package dns
 
 import (
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -80,6 +81,27 @@ func (c *Cache) Lookup(ns, pod, ip string) (string, bool) {
 	return "", false
 }
 
+// Addresses returns every unexpired IP the cluster has resolved hostname to.
+func (c *Cache) Addresses(hostname string) []string {
+	hostname = strings.TrimSuffix(strings.TrimSpace(hostname), ".")
+	if c == nil || hostname == "" {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	var out []string
+	for ip, entry := range c.byIP {
+		if entry.hostname == hostname && !c.expired(entry, now) {
+			out = append(out, ip)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
 func (c *Cache) pruneLocked(now time.Time) {
 	for k, entry := range c.byWorkload {
 		if c.expired(entry, now) {
@@ -62,3 +62,17 @@ func TestCacheMultipleAddresses(t *testing.T) {
 		}
 	}
 }
+
+func TestCacheAddressesByHostname(t *testing.T) {
+	c := NewCache(time.Minute)
+	c.RecordResponse("default", "frontend", "api.stripe.com.", "A", "Success", []string{"104.21.11.16", "172.67.0.1"}, time.Now())
+	c.RecordResponse("default", "frontend", "example.com", "A", "Success", []string{"93.184.216.34"}, time.Now())
+
+	got := c.Addresses("api.stripe.com")
+	if len(got) != 2 || got[0] != "104.21.11.16" || got[1] != "172.67.0.1" {
+		t.Fatalf("addresses = %v", got)
+	}
+	if got := c.Addresses("missing.example"); len(got) != 0 {
+		t.Fatalf("unexpected addresses = %v", got)
+	}
+}
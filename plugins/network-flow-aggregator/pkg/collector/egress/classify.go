@@ -0,0 +1,89 @@
+package egress
+
+import (
+	"net"
+	"strings"
+)
+
+const (
+	ClassInternet = "internet"
+	ClassCloud    = "cloud"
+	ClassPrivate  = "private"
+)
+
+var privateNets = mustParseCIDRs(
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10", // carrier-grade NAT, common for VPN and peered VPC ranges
+	"fc00::/7",
+)
+
+// cloudSuffixes maps hostname suffixes of well-known cloud provider APIs and
+// hosting domains to a provider name. Traffic to hosts the provider serves for
+// customers (e.g. S3 buckets) is attributed to the provider, not the customer.
+var cloudSuffixes = []struct {
+	suffix   string
+	provider string
+}{
+	{".amazonaws.com", "aws"},
+	{".aws", "aws"},
+	{".cloudfront.net", "aws"},
+	{".googleapis.com", "gcp"},
+	{".gcr.io", "gcp"},
+	{".pkg.dev", "gcp"},
+	{".appspot.com", "gcp"},
+	{".run.app", "gcp"},
+	{".azure.com", "azure"},
+	{".azurecr.io", "azure"},
+	{".windows.net", "azure"},
+	{".microsoftonline.com", "azure"},
+	{".digitaloceanspaces.com", "digitalocean"},
+	{".oraclecloud.com", "oracle"},
+}
+
+// Classify returns the destination class and, for cloud destinations, the
+// provider. Private addresses win over hostnames so private endpoints (e.g.
+// VPC endpoints resolving to 10.x) are reported as private network. Hostname
+// suffixes are checked before the published cloud IP ranges, so destinations
+// without a DNS attribution are still classified by address.
+func Classify(hostname, addr string, ranges *CloudRanges) (class, provider string) {
+	if ip := net.ParseIP(addr); ip != nil {
+		for _, n := range privateNets {
+			if n.Contains(ip) {
+				return ClassPrivate, ""
+			}
+		}
+	}
+	host := "." + strings.TrimSuffix(strings.ToLower(hostname), ".")
+	for _, c := range cloudSuffixes {
+		if strings.HasSuffix(host, c.suffix) {
+			return ClassCloud, c.provider
+		}
+	}
+	if provider, ok := ranges.Provider(addr); ok {
+		return ClassCloud, provider
+	}
+	return ClassInternet, ""
+}
+
+// leavesCluster reports whether addr can be an off-cluster destination.
+func leavesCluster(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	out := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,244 @@
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRangesBytes bounds a published range file; Azure's service tags, the
+// largest, are a few MB.
+const maxRangesBytes = 64 << 20
+
+// CloudRangeSource is where the published IP ranges of a provider are read
+// from: an http(s) URL or a local file path.
+type CloudRangeSource struct {
+	Provider string
+	Location string
+}
+
+// DefaultCloudRangeSources are the stable URLs of the AWS and GCP range files,
+// in the format read by ParseCloudRangeSources. Azure publishes its service
+// tags under a URL that changes weekly, so it has to be configured explicitly.
+const DefaultCloudRangeSources = "aws=https://ip-ranges.amazonaws.com/ip-ranges.json,gcp=https://www.gstatic.com/ipranges/cloud.json"
+
+// ParseCloudRangeSources parses a comma-separated list of provider=location
+// pairs, e.g. "aws=https://ip-ranges.amazonaws.com/ip-ranges.json".
+func ParseCloudRangeSources(v string) ([]CloudRangeSource, error) {
+	sources := []CloudRangeSource{}
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		provider, location, ok := strings.Cut(part, "=")
+		if !ok || location == "" {
+			return nil, fmt.Errorf("cloud range source %q: expected provider=location", part)
+		}
+		switch provider {
+		case "aws", "gcp", "azure":
+		default:
+			return nil, fmt.Errorf("cloud range source %q: unknown provider %q (expected aws, gcp, or azure)", part, provider)
+		}
+		sources = append(sources, CloudRangeSource{Provider: provider, Location: location})
+	}
+	return sources, nil
+}
+
+// CloudRanges maps addresses to the cloud provider whose published ranges
+// contain them. It is safe for concurrent use; a nil *CloudRanges matches
+// nothing.
+type CloudRanges struct {
+	mu        sync.RWMutex
+	providers map[string][]netip.Prefix
+	// index holds, per prefix length, the masked prefixes and their provider.
+	index map[int]map[netip.Prefix]string
+}
+
+func NewCloudRanges() *CloudRanges {
+	return &CloudRanges{
+		providers: make(map[string][]netip.Prefix),
+		index:     make(map[int]map[netip.Prefix]string),
+	}
+}
+
+// Set replaces the ranges of provider.
+func (r *CloudRanges) Set(provider string, prefixes []netip.Prefix) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[provider] = prefixes
+	r.index = make(map[int]map[netip.Prefix]string)
+	for p, prefixes := range r.providers {
+		for _, prefix := range prefixes {
+			prefix = prefix.Masked()
+			byPrefix, ok := r.index[prefix.Bits()]
+			if !ok {
+				byPrefix = make(map[netip.Prefix]string)
+				r.index[prefix.Bits()] = byPrefix
+			}
+			byPrefix[prefix] = p
+		}
+	}
+}
+
+// Provider returns the provider whose ranges contain addr.
+func (r *CloudRanges) Provider(addr string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return "", false
+	}
+	ip = ip.Unmap()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for bits, byPrefix := range r.index {
+		prefix, err := ip.Prefix(bits)
+		if err != nil {
+			continue // length of the other address family
+		}
+		if provider, ok := byPrefix[prefix]; ok {
+			return provider, true
+		}
+	}
+	return "", false
+}
+
+// Run loads every source, then reloads them every refresh interval until ctx
+// is cancelled. A source that fails to load keeps its previous ranges.
+func (r *CloudRanges) Run(ctx context.Context, sources []CloudRangeSource, refresh time.Duration, log *slog.Logger) {
+	if log == nil {
+		log = slog.Default()
+	}
+	client := &http.Client{Timeout: time.Minute}
+	for {
+		for _, src := range sources {
+			prefixes, err := loadCloudRanges(ctx, client, src)
+			if err != nil {
+				log.Warn("cloud IP ranges not loaded", "provider", src.Provider, "location", src.Location, "err", err)
+				continue
+			}
+			r.Set(src.Provider, prefixes)
+			log.Info("cloud IP ranges loaded", "provider", src.Provider, "prefixes", len(prefixes))
+		}
+		if refresh <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(refresh):
+		}
+	}
+}
+
+func loadCloudRanges(ctx context.Context, client *http.Client, src CloudRangeSource) ([]netip.Prefix, error) {
+	var data []byte
+	if strings.HasPrefix(src.Location, "http://") || strings.HasPrefix(src.Location, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.Location, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch: status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, maxRangesBytes)); err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(src.Location); err != nil {
+			return nil, err
+		}
+	}
+	return ParseCloudRanges(src.Provider, data)
+}
+
+// ParseCloudRanges reads the prefixes from a provider's published range file:
+// AWS ip-ranges.json, GCP cloud.json, or the AzureCloud tag of Azure's
+// ServiceTags_Public JSON.
+func ParseCloudRanges(provider string, data []byte) ([]netip.Prefix, error) {
+	var cidrs []string
+	switch provider {
+	case "aws":
+		var doc struct {
+			Prefixes []struct {
+				IPPrefix string `json:"ip_prefix"`
+			} `json:"prefixes"`
+			IPv6Prefixes []struct {
+				IPv6Prefix string `json:"ipv6_prefix"`
+			} `json:"ipv6_prefixes"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode aws ranges: %w", err)
+		}
+		for _, p := range doc.Prefixes {
+			cidrs = append(cidrs, p.IPPrefix)
+		}
+		for _, p := range doc.IPv6Prefixes {
+			cidrs = append(cidrs, p.IPv6Prefix)
+		}
+	case "gcp":
+		var doc struct {
+			Prefixes []struct {
+				IPv4Prefix string `json:"ipv4Prefix"`
+				IPv6Prefix string `json:"ipv6Prefix"`
+			} `json:"prefixes"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode gcp ranges: %w", err)
+		}
+		for _, p := range doc.Prefixes {
+			cidrs = append(cidrs, p.IPv4Prefix, p.IPv6Prefix)
+		}
+	case "azure":
+		var doc struct {
+			Values []struct {
+				Name       string `json:"name"`
+				Properties struct {
+					AddressPrefixes []string `json:"addressPrefixes"`
+				} `json:"properties"`
+			} `json:"values"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode azure service tags: %w", err)
+		}
+		for _, v := range doc.Values {
+			if v.Name == "AzureCloud" {
+				cidrs = append(cidrs, v.Properties.AddressPrefixes...)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown cloud provider %q", provider)
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%s range %q: %w", provider, cidr, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("no %s ranges found", provider)
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,377 @@
+package egress
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+// ephemeralPortMin matches Linux's default ip_local_port_range. Unattributed
+// rows to ephemeral ports are replies to inbound clients, not egress.
+const ephemeralPortMin = 32768
+
+const maxIPsPerDestination = 16
+
+// pruneInterval bounds how often Observe sweeps destinations past retention.
+const pruneInterval = time.Minute
+
+// Workload is the in-cluster source of external traffic.
+type Workload struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+}
+
+// Destination is one external endpoint a workload talks to. Hostname is empty
+// when no DNS answer attributed the IPs.
+type Destination struct {
+	Hostname      string    `json:"hostname,omitempty"`
+	IPs           []string  `json:"ips"`
+	Port          int32     `json:"port"`
+	Protocol      string    `json:"protocol"`
+	Class         string    `json:"class"`
+	Provider      string    `json:"provider,omitempty"`
+	Connections   int64     `json:"connections"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+	New           bool      `json:"new"`
+}
+
+type WorkloadEgress struct {
+	Workload
+	Destinations []Destination `json:"destinations"`
+}
+
+// Report is the inventory snapshot served over HTTP and uploaded to Insights.
+type Report struct {
+	GeneratedAt     time.Time        `json:"generated_at"`
+	NewDestinations int              `json:"new_destinations"`
+	Workloads       []WorkloadEgress `json:"workloads"`
+}
+
+type ListOpts struct {
+	Namespace string
+	Class     string
+	NewOnly   bool
+}
+
+type Options struct {
+	// Retention drops destinations not seen for this long (default 7d).
+	Retention time.Duration
+	// Baseline is the learning period after startup during which destinations
+	// are recorded but never flagged as new. Zero flags every destination.
+	Baseline time.Duration
+	// NewFor is how long a destination stays flagged after first sighting (default 24h).
+	NewFor time.Duration
+	// MaxDestinations caps tracked destinations; the least recently seen one is
+	// evicted beyond it (default 100000).
+	MaxDestinations int
+	// CloudRanges classifies destinations by the published cloud provider IP
+	// ranges; nil classifies cloud destinations by hostname only.
+	CloudRanges *CloudRanges
+}
+
+type destKey struct {
+	workload Workload
+	name     string // hostname, or IP when unattributed
+	port     int32
+	protocol insightsv1.Protocol
+}
+
+type entry struct {
+	dest  Destination
+	addr  string // first address, used for classification
+	ips   map[string]struct{}
+	newAt time.Time
+}
+
+// Inventory maintains per-workload external egress destinations from enriched
+// flow events.
+type Inventory struct {
+	mu        sync.RWMutex
+	entries   map[destKey]*entry
+	opts      Options
+	started   time.Time
+	lastPrune time.Time
+	evicted   int64
+	log       *slog.Logger
+}
+
+func New(opts Options, log *slog.Logger) *Inventory {
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	if opts.Baseline < 0 {
+		opts.Baseline = 0
+	}
+	if opts.NewFor <= 0 {
+		opts.NewFor = 24 * time.Hour
+	}
+	if opts.MaxDestinations <= 0 {
+		opts.MaxDestinations = 100_000
+	}
+	if log == nil {
+		log = slog.Default()
+	}
+	now := time.Now()
+	return &Inventory{
+		entries:   make(map[destKey]*entry),
+		opts:      opts,
+		started:   now,
+		lastPrune: now,
+		log:       log,
+	}
+}
+
+// Observe folds enriched events into the inventory and returns how many were
+// external egress. Destinations first seen after the baseline period are
+// flagged as new and logged.
+func (inv *Inventory) Observe(events []*insightsv1.EnrichedFlowEvent) int {
+	if inv == nil || len(events) == 0 {
+		return 0
+	}
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if now := time.Now(); now.Sub(inv.lastPrune) >= pruneInterval {
+		inv.pruneLocked(now)
+	}
+
+	observed := 0
+	for _, event := range events {
+		key, hostname, ok := destKeyFromEvent(event)
+		if !ok {
+			continue
+		}
+		ts := eventTime(event)
+		addr := event.GetDst().GetAddr()
+		e, exists := inv.entries[key]
+		if !exists {
+			if len(inv.entries) >= inv.opts.MaxDestinations {
+				inv.evictLocked(time.Now())
+			}
+			class, provider := Classify(hostname, addr, inv.opts.CloudRanges)
+			e = &entry{
+				addr: addr,
+				dest: Destination{
+					Hostname:  hostname,
+					Port:      key.port,
+					Protocol:  strings.TrimPrefix(key.protocol.String(), "PROTOCOL_"),
+					Class:     class,
+					Provider:  provider,
+					FirstSeen: ts,
+					LastSeen:  ts,
+				},
+				ips: make(map[string]struct{}),
+			}
+			if ts.After(inv.started.Add(inv.opts.Baseline)) {
+				e.newAt = ts
+				inv.log.Info("new external egress destination",
+					"namespace", key.workload.Namespace,
+					"kind", key.workload.Kind,
+					"workload", key.workload.Name,
+					"destination", key.name,
+					"port", key.port,
+					"class", class,
+				)
+			}
+			inv.entries[key] = e
+		}
+		if _, seen := e.ips[addr]; !seen && len(e.ips) < maxIPsPerDestination {
+			e.ips[addr] = struct{}{}
+		}
+		if event.GetEventKind() == insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT {
+			e.dest.Connections++
+		}
+		e.dest.BytesSent += event.GetBytesSent()
+		e.dest.BytesReceived += event.GetBytesReceived()
+		if ts.Before(e.dest.FirstSeen) {
+			e.dest.FirstSeen = ts
+		}
+		if ts.After(e.dest.LastSeen) {
+			e.dest.LastSeen = ts
+		}
+		observed++
+	}
+	return observed
+}
+
+// Evicted returns the number of destinations dropped because the inventory was full.
+func (inv *Inventory) Evicted() int64 {
+	if inv == nil {
+		return 0
+	}
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return inv.evicted
+}
+
+// pruneLocked drops destinations not seen within retention.
+func (inv *Inventory) pruneLocked(now time.Time) {
+	cutoff := now.Add(-inv.opts.Retention)
+	for key, e := range inv.entries {
+		if e.dest.LastSeen.Before(cutoff) {
+			delete(inv.entries, key)
+		}
+	}
+	inv.lastPrune = now
+}
+
+// evictLocked makes room for one destination: expired destinations are pruned
+// first, then the least recently seen one is dropped.
+func (inv *Inventory) evictLocked(now time.Time) {
+	inv.pruneLocked(now)
+	if len(inv.entries) < inv.opts.MaxDestinations {
+		return
+	}
+	var oldestKey destKey
+	var oldest *entry
+	for key, e := range inv.entries {
+		if oldest == nil || e.dest.LastSeen.Before(oldest.dest.LastSeen) {
+			oldestKey, oldest = key, e
+		}
+	}
+	delete(inv.entries, oldestKey)
+	inv.evicted++
+}
+
+// Report returns the inventory grouped by workload, after dropping
+// destinations past retention.
+func (inv *Inventory) Report(opts ListOpts, now time.Time) Report {
+	report := Report{GeneratedAt: now.UTC(), Workloads: []WorkloadEgress{}}
+	if inv == nil {
+		return report
+	}
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.pruneLocked(now)
+	byWorkload := make(map[Workload][]Destination)
+	for key, e := range inv.entries {
+		if opts.Namespace != "" && key.workload.Namespace != opts.Namespace {
+			continue
+		}
+		// Cloud ranges may have been loaded or refreshed since the destination
+		// was first seen.
+		e.dest.Class, e.dest.Provider = Classify(e.dest.Hostname, e.addr, inv.opts.CloudRanges)
+		if opts.Class != "" && e.dest.Class != opts.Class {
+			continue
+		}
+		d := e.dest
+		d.New = !e.newAt.IsZero() && now.Sub(e.newAt) < inv.opts.NewFor
+		if opts.NewOnly && !d.New {
+			continue
+		}
+		d.IPs = make([]string, 0, len(e.ips))
+		for ip := range e.ips {
+			d.IPs = append(d.IPs, ip)
+		}
+		sort.Strings(d.IPs)
+		if d.New {
+			report.NewDestinations++
+		}
+		byWorkload[key.workload] = append(byWorkload[key.workload], d)
+	}
+
+	for w, dests := range byWorkload {
+		sort.Slice(dests, func(i, j int) bool {
+			if destName(dests[i]) != destName(dests[j]) {
+				return destName(dests[i]) < destName(dests[j])
+			}
+			if dests[i].Port != dests[j].Port {
+				return dests[i].Port < dests[j].Port
+			}
+			return dests[i].Protocol < dests[j].Protocol
+		})
+		report.Workloads = append(report.Workloads, WorkloadEgress{Workload: w, Destinations: dests})
+	}
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i].Workload, report.Workloads[j].Workload
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+func (inv *Inventory) Count() int {
+	if inv == nil {
+		return 0
+	}
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return len(inv.entries)
+}
+
+// destKeyFromEvent keeps flow rows whose destination is outside the cluster:
+// DNS-attributed external hostnames, or unresolved addresses on non-ephemeral
+// ports. Rows resolved to Services, workloads, Nodes, loopback or link-local
+// are in-cluster.
+func destKeyFromEvent(event *insightsv1.EnrichedFlowEvent) (destKey, string, bool) {
+	if event == nil || event.GetProtocol() == insightsv1.Protocol_PROTOCOL_DNS {
+		return destKey{}, "", false
+	}
+	switch event.GetEventKind() {
+	case insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC:
+	default:
+		return destKey{}, "", false
+	}
+	src := event.GetSrcWorkload()
+	addr := event.GetDst().GetAddr()
+	port := event.GetDst().GetPort()
+	if src.GetName() == "" || !leavesCluster(addr) {
+		return destKey{}, "", false
+	}
+
+	var hostname string
+	switch kind := event.GetDstRef().GetKind(); kind {
+	case "ExternalHostname":
+		hostname = strings.TrimSuffix(strings.ToLower(event.GetDstRef().GetName()), ".")
+	case "":
+		if port == 0 || port >= ephemeralPortMin {
+			return destKey{}, "", false
+		}
+	default:
+		return destKey{}, "", false
+	}
+
+	name := hostname
+	if name == "" {
+		name = addr
+	}
+	return destKey{
+		workload: Workload{Namespace: src.GetNamespace(), Kind: src.GetKind(), Name: src.GetName()},
+		name:     name,
+		port:     port,
+		protocol: event.GetProtocol(),
+	}, hostname, true
+}
+
+func destName(d Destination) string {
+	if d.Hostname != "" {
+		return d.Hostname
+	}
+	if len(d.IPs) > 0 {
+		return d.IPs[0]
+	}
+	return ""
+}
+
+func eventTime(event *insightsv1.EnrichedFlowEvent) time.Time {
+	if ts := event.GetTimestampUnixNano(); ts > 0 {
+		return time.Unix(0, ts).UTC()
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,242 @@
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	version "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator"
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+func egressEvent(dstKind, dstName, addr string, port int32, sent int64) *insightsv1.EnrichedFlowEvent {
+	event := &insightsv1.EnrichedFlowEvent{
+		EventKind:         insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC,
+		Protocol:          insightsv1.Protocol_PROTOCOL_TCP,
+		TimestampUnixNano: time.Now().UnixNano(),
+		SrcWorkload:       &insightsv1.KubernetesRef{Namespace: "shop", Kind: "Deployment", Name: "api"},
+		Dst:               &insightsv1.Endpoint{Addr: addr, Port: port},
+		BytesSent:         sent,
+	}
+	if dstKind != "" {
+		event.DstRef = &insightsv1.KubernetesRef{Kind: dstKind, Name: dstName}
+	}
+	return event
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		hostname, addr, class, provider string
+	}{
+		{"api.stripe.com", "104.21.11.16", ClassInternet, ""},
+		{"my-bucket.s3.us-east-1.amazonaws.com", "52.216.1.1", ClassCloud, "aws"},
+		{"storage.googleapis.com.", "142.250.1.1", ClassCloud, "gcp"},
+		{"vpce.s3.amazonaws.com", "10.1.2.3", ClassPrivate, ""},
+		{"", "100.64.3.4", ClassPrivate, ""},
+	} {
+		class, provider := Classify(tc.hostname, tc.addr, nil)
+		if class != tc.class || provider != tc.provider {
+			t.Errorf("Classify(%q, %q) = %s/%s", tc.hostname, tc.addr, class, provider)
+		}
+	}
+}
+
+func TestClassifyByCloudRanges(t *testing.T) {
+	ranges := NewCloudRanges()
+	aws, err := ParseCloudRanges("aws", []byte(`{"prefixes":[{"ip_prefix":"52.216.0.0/15","service":"S3"}],"ipv6_prefixes":[{"ipv6_prefix":"2600:1f00::/24"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcp, err := ParseCloudRanges("gcp", []byte(`{"prefixes":[{"ipv4Prefix":"34.1.208.0/20"},{"ipv6Prefix":"2600:1900::/35"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	azure, err := ParseCloudRanges("azure", []byte(`{"values":[{"name":"AzureCloud","properties":{"addressPrefixes":["20.33.0.0/16"]}},{"name":"Storage","properties":{"addressPrefixes":["203.0.113.0/24"]}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranges.Set("aws", aws)
+	ranges.Set("gcp", gcp)
+	ranges.Set("azure", azure)
+
+	for _, tc := range []struct {
+		hostname, addr, class, provider string
+	}{
+		{"", "52.217.4.1", ClassCloud, "aws"},
+		{"", "2600:1f00::1", ClassCloud, "aws"},
+		{"", "34.1.210.9", ClassCloud, "gcp"},
+		{"", "20.33.1.1", ClassCloud, "azure"},
+		{"", "203.0.113.9", ClassInternet, ""},
+		{"storage.googleapis.com", "52.217.4.1", ClassCloud, "gcp"},
+	} {
+		class, provider := Classify(tc.hostname, tc.addr, ranges)
+		if class != tc.class || provider != tc.provider {
+			t.Errorf("Classify(%q, %q) = %s/%s", tc.hostname, tc.addr, class, provider)
+		}
+	}
+}
+
+func TestReportReclassifiesAfterRangesLoad(t *testing.T) {
+	ranges := NewCloudRanges()
+	inv := New(Options{CloudRanges: ranges}, nil)
+	inv.Observe([]*insightsv1.EnrichedFlowEvent{egressEvent("", "", "52.217.4.1", 443, 1)})
+	if dests := inv.Report(ListOpts{}, time.Now()).Workloads[0].Destinations; dests[0].Class != ClassInternet {
+		t.Fatalf("before ranges = %+v", dests)
+	}
+
+	ranges.Set("aws", []netip.Prefix{netip.MustParsePrefix("52.216.0.0/15")})
+	report := inv.Report(ListOpts{Class: ClassCloud}, time.Now())
+	if len(report.Workloads) != 1 || report.Workloads[0].Destinations[0].Provider != "aws" {
+		t.Fatalf("after ranges = %+v", report)
+	}
+}
+
+func TestParseCloudRangeSources(t *testing.T) {
+	sources, err := ParseCloudRangeSources("aws=https://ip-ranges.amazonaws.com/ip-ranges.json, azure=/etc/ranges/azure.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[1].Provider != "azure" || sources[1].Location != "/etc/ranges/azure.json" {
+		t.Fatalf("sources = %+v", sources)
+	}
+	if _, err := ParseCloudRangeSources("oracle=https://example.com/ranges.json"); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func TestObserveKeepsOnlyExternalEgress(t *testing.T) {
+	inv := New(Options{}, nil)
+	observed := inv.Observe([]*insightsv1.EnrichedFlowEvent{
+		egressEvent("ExternalHostname", "api.stripe.com", "104.21.11.16", 443, 100),
+		egressEvent("ExternalHostname", "api.stripe.com", "104.21.11.17", 443, 50),
+		egressEvent("", "", "203.0.113.9", 514, 10),
+		egressEvent("", "", "203.0.113.9", 51000, 10), // reply to an inbound client
+		egressEvent("Service", "db", "10.96.0.10", 5432, 10),
+		egressEvent("", "", "127.0.0.1", 8080, 10),
+	})
+	if observed != 3 {
+		t.Fatalf("observed = %d", observed)
+	}
+
+	report := inv.Report(ListOpts{}, time.Now())
+	if len(report.Workloads) != 1 || len(report.Workloads[0].Destinations) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	dests := report.Workloads[0].Destinations
+	ip, stripe := dests[0], dests[1]
+	if ip.Hostname != "" || ip.IPs[0] != "203.0.113.9" || ip.Class != ClassInternet {
+		t.Fatalf("ip destination = %+v", ip)
+	}
+	if stripe.Hostname != "api.stripe.com" || len(stripe.IPs) != 2 || stripe.BytesSent != 150 {
+		t.Fatalf("stripe destination = %+v", stripe)
+	}
+}
+
+func TestNewDestinationsAfterBaseline(t *testing.T) {
+	inv := New(Options{Baseline: time.Hour}, nil)
+	inv.Observe([]*insightsv1.EnrichedFlowEvent{egressEvent("ExternalHostname", "api.stripe.com", "104.21.11.16", 443, 1)})
+
+	// Pretend the baseline has elapsed.
+	inv.started = time.Now().Add(-2 * time.Hour)
+	inv.Observe([]*insightsv1.EnrichedFlowEvent{egressEvent("ExternalHostname", "hooks.slack.com", "34.1.1.1", 443, 1)})
+
+	report := inv.Report(ListOpts{NewOnly: true}, time.Now())
+	if report.NewDestinations != 1 || len(report.Workloads) != 1 || report.Workloads[0].Destinations[0].Hostname != "hooks.slack.com" {
+		t.Fatalf("report = %+v", report)
+	}
+	if later := inv.Report(ListOpts{NewOnly: true}, time.Now().Add(25*time.Hour)); later.NewDestinations != 0 {
+		t.Fatalf("still new after NewFor: %+v", later)
+	}
+}
+
+func TestReportDropsPastRetention(t *testing.T) {
+	inv := New(Options{Retention: time.Hour}, nil)
+	inv.Observe([]*insightsv1.EnrichedFlowEvent{egressEvent("", "", "203.0.113.9", 443, 1)})
+	inv.Report(ListOpts{}, time.Now().Add(2*time.Hour))
+	if inv.Count() != 0 {
+		t.Fatalf("count = %d", inv.Count())
+	}
+}
+
+func TestObservePrunesPastRetention(t *testing.T) {
+	inv := New(Options{Retention: time.Hour}, nil)
+	stale := egressEvent("", "", "203.0.113.9", 443, 1)
+	stale.TimestampUnixNano = time.Now().Add(-2 * time.Hour).UnixNano()
+	inv.Observe([]*insightsv1.EnrichedFlowEvent{stale})
+
+	// Pretend the last sweep was a while ago.
+	inv.lastPrune = time.Now().Add(-2 * pruneInterval)
+	inv.Observe([]*insightsv1.EnrichedFlowEvent{egressEvent("", "", "203.0.113.10", 443, 1)})
+	if inv.Count() != 1 {
+		t.Fatalf("count = %d", inv.Count())
+	}
+}
+
+func TestObserveEvictsLeastRecentlySeenAtCap(t *testing.T) {
+	inv := New(Options{MaxDestinations: 2}, nil)
+	now := time.Now()
+	for i, addr := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		event := egressEvent("", "", addr, 443, 1)
+		event.TimestampUnixNano = now.Add(time.Duration(i) * time.Second).UnixNano()
+		inv.Observe([]*insightsv1.EnrichedFlowEvent{event})
+	}
+	report := inv.Report(ListOpts{}, now)
+	dests := report.Workloads[0].Destinations
+	if len(dests) != 2 || dests[0].IPs[0] != "203.0.113.2" || dests[1].IPs[0] != "203.0.113.3" {
+		t.Fatalf("destinations = %+v", dests)
+	}
+	if inv.Evicted() != 1 {
+		t.Fatalf("evicted = %d", inv.Evicted())
+	}
+}
+
+func TestUploaderPostsReport(t *testing.T) {
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/organizations/acme/clusters/prod/data/network-egress" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("request = %s %v", r.URL.Path, r.Header)
+		}
+		if r.Header.Get("X-Fairwinds-Report-Version") != version.String() || r.Header.Get("X-Fairwinds-Agent-Version") != version.String() ||
+			r.Header.Get("X-Fairwinds-Agent-Chart-Version") != "4.5.6" {
+			t.Errorf("version headers = %v", r.Header)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	inv := New(Options{}, nil)
+	inv.Observe([]*insightsv1.EnrichedFlowEvent{egressEvent("ExternalHostname", "api.stripe.com", "104.21.11.16", 443, 1)})
+	u := NewUploader(UploadConfig{Host: srv.URL + "/", Organization: "acme", Cluster: "prod", Token: "secret", AgentChartVersion: "4.5.6"}, inv, nil)
+	if err := u.Upload(context.Background(), inv.Report(ListOpts{}, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Workloads) != 1 || got.Workloads[0].Name != "api" {
+		t.Fatalf("uploaded = %+v", got)
+	}
+}
+
+func TestUploaderRunUploadsAfterWarmUp(t *testing.T) {
+	uploaded := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case uploaded <- struct{}{}:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	u := NewUploader(UploadConfig{Host: srv.URL, Organization: "acme", Cluster: "prod", Token: "secret", Interval: time.Hour, WarmUp: 10 * time.Millisecond}, New(Options{}, nil), nil)
+	go u.Run(ctx)
+
+	select {
+	case <-uploaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no upload before the first interval")
+	}
+}
@@ -0,0 +1,110 @@
+package egress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	version "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator"
+)
+
+// ReportType is the Insights report the inventory is uploaded as. Like reports
+// sent by the uploader, it is versioned with the plugin version.
+const ReportType = "network-egress"
+
+type UploadConfig struct {
+	Host         string
+	Organization string
+	Cluster      string
+	Token        string
+	// AgentChartVersion is the insights-agent chart version sent with each upload.
+	AgentChartVersion string
+	Interval          time.Duration
+	// WarmUp is the delay before the first upload (default 5m, at most Interval),
+	// so a pod that restarts more often than Interval still reports.
+	WarmUp time.Duration
+}
+
+// Uploader periodically posts the inventory to the Insights report endpoint.
+type Uploader struct {
+	cfg       UploadConfig
+	inventory *Inventory
+	client    *http.Client
+	log       *slog.Logger
+}
+
+func NewUploader(cfg UploadConfig, inventory *Inventory, log *slog.Logger) *Uploader {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.WarmUp <= 0 {
+		cfg.WarmUp = 5 * time.Minute
+	}
+	cfg.WarmUp = min(cfg.WarmUp, cfg.Interval)
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Uploader{
+		cfg:       cfg,
+		inventory: inventory,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		log:       log,
+	}
+}
+
+// Run uploads a report after the warm-up and then every interval until ctx is
+// cancelled. Failures are logged and retried on the next tick; the inventory
+// itself is cumulative, so nothing is lost by a missed upload.
+func (u *Uploader) Run(ctx context.Context) {
+	timer := time.NewTimer(u.cfg.WarmUp)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-timer.C:
+			timer.Reset(u.cfg.Interval)
+			report := u.inventory.Report(ListOpts{}, now)
+			if err := u.Upload(ctx, report); err != nil {
+				u.log.Warn("egress report upload failed", "err", err)
+				continue
+			}
+			u.log.Info("egress report uploaded", "workloads", len(report.Workloads), "new_destinations", report.NewDestinations)
+		}
+	}
+}
+
+func (u *Uploader) Upload(ctx context.Context, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	url := fmt.Sprintf("%s/v0/organizations/%s/clusters/%s/data/%s",
+		strings.TrimSuffix(u.cfg.Host, "/"), u.cfg.Organization, u.cfg.Cluster, ReportType)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+u.cfg.Token)
+	req.Header.Set("X-Fairwinds-Agent-Version", version.String())
+	req.Header.Set("X-Fairwinds-Report-Version", version.String())
+	req.Header.Set("X-Fairwinds-Agent-Chart-Version", u.cfg.AgentChartVersion)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("insights API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
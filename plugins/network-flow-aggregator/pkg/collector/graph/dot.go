@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT renders edges as a Graphviz digraph. Nodes are labelled
+// "kind/name" and grouped into one cluster per namespace.
+func WriteDOT(w io.Writer, edges []Edge) error {
+	byNamespace := make(map[string]map[string]Node)
+	for _, edge := range edges {
+		for _, n := range []Node{edge.Src, edge.Dst} {
+			if byNamespace[n.Namespace] == nil {
+				byNamespace[n.Namespace] = make(map[string]Node)
+			}
+			byNamespace[n.Namespace][nodeID(n)] = n
+		}
+	}
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var b strings.Builder
+	b.WriteString("digraph flows {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for i, ns := range namespaces {
+		indent := "  "
+		if ns != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(&b, "    label=%s;\n", strconv.Quote(ns))
+			indent = "    "
+		}
+		ids := make([]string, 0, len(byNamespace[ns]))
+		for id := range byNamespace[ns] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			n := byNamespace[ns][id]
+			fmt.Fprintf(&b, "%s%s [label=%s];\n", indent, strconv.Quote(id), strconv.Quote(n.Kind+"/"+n.Name))
+		}
+		if ns != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, edge := range edges {
+		label := fmt.Sprintf("%s/%d conns=%d sent=%d recv=%d", edge.Protocol, edge.Port, edge.Connections, edge.BytesSent, edge.BytesReceived)
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", strconv.Quote(nodeID(edge.Src)), strconv.Quote(nodeID(edge.Dst)), strconv.Quote(label))
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func nodeID(n Node) string {
+	return n.Namespace + "/" + n.Kind + "/" + n.Name
+}
@@ -0,0 +1,342 @@
+package graph
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+// Node identifies one side of an edge: a source workload, or a destination
+// Service, workload, Node, or external hostname.
+type Node struct {
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+}
+
+// EdgeKey is the rollup key: source workload, destination ref, port, and protocol.
+type EdgeKey struct {
+	Src      Node
+	Dst      Node
+	Port     int32
+	Protocol insightsv1.Protocol
+}
+
+// Edge carries connection and byte totals for one EdgeKey.
+type Edge struct {
+	Src           Node      `json:"src"`
+	Dst           Node      `json:"dst"`
+	Port          int32     `json:"port"`
+	Protocol      string    `json:"protocol"`
+	Connections   int64     `json:"connections"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+type ListOpts struct {
+	Namespace string
+	Since     time.Time
+}
+
+// Graph keeps a rolled-up service dependency graph built from enriched flow
+// events. Cumulative totals back the HTTP API; per-rollup deltas back upstream
+// delivery so each interval is sent once.
+type Graph struct {
+	mu         sync.RWMutex
+	edges      map[EdgeKey]*trackedEdge
+	byLastSeen lastSeenHeap
+	pending    map[EdgeKey]*Edge
+	retention  time.Duration
+	maxEdges   int
+	evicted    int64
+}
+
+// trackedEdge is a cumulative edge with its position in the last-seen heap.
+type trackedEdge struct {
+	Edge
+	key   EdgeKey
+	index int
+}
+
+// lastSeenHeap orders edges by last seen, oldest first, so pruning and
+// eviction pop stale edges instead of scanning the graph.
+type lastSeenHeap []*trackedEdge
+
+func (h lastSeenHeap) Len() int           { return len(h) }
+func (h lastSeenHeap) Less(i, j int) bool { return h[i].LastSeen.Before(h[j].LastSeen) }
+func (h lastSeenHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lastSeenHeap) Push(x any) {
+	edge := x.(*trackedEdge)
+	edge.index = len(*h)
+	*h = append(*h, edge)
+}
+
+func (h *lastSeenHeap) Pop() any {
+	old := *h
+	edge := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return edge
+}
+
+// New returns a graph that drops edges not seen within retention and holds at
+// most maxEdges edges, evicting the least recently seen edge beyond that.
+func New(retention time.Duration, maxEdges int) *Graph {
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	if maxEdges <= 0 {
+		maxEdges = 50_000
+	}
+	return &Graph{
+		edges:     make(map[EdgeKey]*trackedEdge),
+		pending:   make(map[EdgeKey]*Edge),
+		retention: retention,
+		maxEdges:  maxEdges,
+	}
+}
+
+// Observe folds enriched events into the graph. Events without a resolved
+// source workload or destination ref (DNS, server-observed TCP, unresolved
+// egress) are skipped; they never form service-map edges.
+func (g *Graph) Observe(events []*insightsv1.EnrichedFlowEvent) int {
+	if g == nil || len(events) == 0 {
+		return 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	observed := 0
+	for _, event := range events {
+		key, ok := edgeKeyFromEvent(event)
+		if !ok {
+			continue
+		}
+		ts := eventTime(event)
+		edge, ok := g.edges[key]
+		if !ok {
+			if len(g.edges) >= g.maxEdges {
+				g.evictLocked(ts)
+			}
+			edge = &trackedEdge{Edge: *newEdge(key, ts), key: key}
+			g.edges[key] = edge
+			heap.Push(&g.byLastSeen, edge)
+		}
+		lastSeen := edge.LastSeen
+		addToEdge(&edge.Edge, event, ts)
+		if !edge.LastSeen.Equal(lastSeen) {
+			heap.Fix(&g.byLastSeen, edge.index)
+		}
+		delta, ok := g.pending[key]
+		if !ok {
+			delta = newEdge(key, ts)
+			g.pending[key] = delta
+		}
+		addToEdge(delta, event, ts)
+		observed++
+	}
+	return observed
+}
+
+// Rollup returns the edge deltas accumulated since the previous rollup and
+// drops edges not seen within the retention window.
+func (g *Graph) Rollup(now time.Time) []Edge {
+	if g == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out := make([]Edge, 0, len(g.pending))
+	for _, edge := range g.pending {
+		out = append(out, *edge)
+	}
+	g.pending = make(map[EdgeKey]*Edge)
+	g.pruneLocked(now)
+
+	sortEdges(out)
+	return out
+}
+
+// Run calls Rollup every interval and hands non-empty deltas to sink until ctx
+// is cancelled. A final rollup is flushed on shutdown.
+func (g *Graph) Run(ctx context.Context, interval time.Duration, sink func([]Edge)) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if edges := g.Rollup(time.Now()); len(edges) > 0 && sink != nil {
+				sink(edges)
+			}
+			return
+		case now := <-ticker.C:
+			if edges := g.Rollup(now); len(edges) > 0 && sink != nil {
+				sink(edges)
+			}
+		}
+	}
+}
+
+func (g *Graph) Edges(opts ListOpts) []Edge {
+	if g == nil {
+		return nil
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out := make([]Edge, 0, len(g.edges))
+	for _, edge := range g.edges {
+		if opts.Namespace != "" && edge.Src.Namespace != opts.Namespace && edge.Dst.Namespace != opts.Namespace {
+			continue
+		}
+		if !opts.Since.IsZero() && !edge.LastSeen.After(opts.Since) {
+			continue
+		}
+		out = append(out, edge.Edge)
+	}
+	sortEdges(out)
+	return out
+}
+
+func (g *Graph) Count() int {
+	if g == nil {
+		return 0
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.edges)
+}
+
+// Evicted returns the number of edges dropped because the graph was full.
+func (g *Graph) Evicted() int64 {
+	if g == nil {
+		return 0
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.evicted
+}
+
+// evictLocked makes room for one edge: stale edges are pruned first, then the
+// least recently seen edge is dropped along with its pending delta.
+func (g *Graph) evictLocked(now time.Time) {
+	g.pruneLocked(now)
+	if len(g.edges) < g.maxEdges {
+		return
+	}
+	oldest := heap.Pop(&g.byLastSeen).(*trackedEdge)
+	delete(g.edges, oldest.key)
+	delete(g.pending, oldest.key)
+	g.evicted++
+}
+
+func (g *Graph) pruneLocked(now time.Time) {
+	cutoff := now.Add(-g.retention)
+	for len(g.byLastSeen) > 0 && g.byLastSeen[0].LastSeen.Before(cutoff) {
+		stale := heap.Pop(&g.byLastSeen).(*trackedEdge)
+		delete(g.edges, stale.key)
+	}
+}
+
+func edgeKeyFromEvent(event *insightsv1.EnrichedFlowEvent) (EdgeKey, bool) {
+	if event == nil || event.GetProtocol() == insightsv1.Protocol_PROTOCOL_DNS {
+		return EdgeKey{}, false
+	}
+	switch event.GetEventKind() {
+	case insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC:
+	default:
+		return EdgeKey{}, false
+	}
+	src := event.GetSrcWorkload()
+	dst := event.GetDstRef()
+	if src.GetName() == "" || dst.GetKind() == "" || dst.GetName() == "" {
+		return EdgeKey{}, false
+	}
+	return EdgeKey{
+		Src:      Node{Namespace: src.GetNamespace(), Kind: src.GetKind(), Name: src.GetName()},
+		Dst:      Node{Namespace: dst.GetNamespace(), Kind: dst.GetKind(), Name: dst.GetName()},
+		Port:     event.GetDst().GetPort(),
+		Protocol: event.GetProtocol(),
+	}, true
+}
+
+func newEdge(key EdgeKey, ts time.Time) *Edge {
+	return &Edge{
+		Src:       key.Src,
+		Dst:       key.Dst,
+		Port:      key.Port,
+		Protocol:  protocolName(key.Protocol),
+		FirstSeen: ts,
+		LastSeen:  ts,
+	}
+}
+
+func addToEdge(edge *Edge, event *insightsv1.EnrichedFlowEvent, ts time.Time) {
+	if event.GetEventKind() == insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT {
+		edge.Connections++
+	}
+	edge.BytesSent += event.GetBytesSent()
+	edge.BytesReceived += event.GetBytesReceived()
+	if ts.Before(edge.FirstSeen) {
+		edge.FirstSeen = ts
+	}
+	if ts.After(edge.LastSeen) {
+		edge.LastSeen = ts
+	}
+}
+
+func eventTime(event *insightsv1.EnrichedFlowEvent) time.Time {
+	if ts := event.GetTimestampUnixNano(); ts > 0 {
+		return time.Unix(0, ts).UTC()
+	}
+	return time.Now().UTC()
+}
+
+func protocolName(p insightsv1.Protocol) string {
+	return strings.TrimPrefix(p.String(), "PROTOCOL_")
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.Src != b.Src {
+			return nodeLess(a.Src, b.Src)
+		}
+		if a.Dst != b.Dst {
+			return nodeLess(a.Dst, b.Dst)
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Protocol < b.Protocol
+	})
+}
+
+func nodeLess(a, b Node) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	return a.Name < b.Name
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+func sampleEdgeEvent(kind insightsv1.FlowEventKind, ts int64, sent, recv int64) *insightsv1.EnrichedFlowEvent {
+	return &insightsv1.EnrichedFlowEvent{
+		EventKind:         kind,
+		Protocol:          insightsv1.Protocol_PROTOCOL_TCP,
+		TimestampUnixNano: ts,
+		Src:               &insightsv1.WorkloadRef{Namespace: "shop", Pod: "frontend-abc"},
+		SrcWorkload:       &insightsv1.KubernetesRef{Namespace: "shop", Kind: "Deployment", Name: "frontend"},
+		Dst:               &insightsv1.Endpoint{Addr: "10.96.0.10", Port: 5432},
+		DstRef:            &insightsv1.KubernetesRef{Namespace: "shop", Kind: "Service", Name: "postgres"},
+		BytesSent:         sent,
+		BytesReceived:     recv,
+	}
+}
+
+func TestObserveRollsUpEdge(t *testing.T) {
+	g := New(time.Hour, 0)
+	now := time.Now()
+	observed := g.Observe([]*insightsv1.EnrichedFlowEvent{
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, now.UnixNano(), 0, 0),
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC, now.Add(time.Second).UnixNano(), 100, 200),
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC, now.Add(2*time.Second).UnixNano(), 50, 60),
+	})
+	if observed != 3 {
+		t.Fatalf("observed = %d", observed)
+	}
+
+	edges := g.Edges(ListOpts{})
+	if len(edges) != 1 {
+		t.Fatalf("edges = %d", len(edges))
+	}
+	edge := edges[0]
+	if edge.Connections != 1 || edge.BytesSent != 150 || edge.BytesReceived != 260 {
+		t.Fatalf("edge totals = %+v", edge)
+	}
+	if edge.Port != 5432 || edge.Protocol != "TCP" {
+		t.Fatalf("edge key = %+v", edge)
+	}
+	if !edge.FirstSeen.Equal(time.Unix(0, now.UnixNano())) || !edge.LastSeen.Equal(time.Unix(0, now.Add(2*time.Second).UnixNano())) {
+		t.Fatalf("first/last seen = %v/%v", edge.FirstSeen, edge.LastSeen)
+	}
+}
+
+func TestObserveSkipsUnresolvedAndDNS(t *testing.T) {
+	g := New(time.Hour, 0)
+	now := time.Now().UnixNano()
+	unresolved := sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC, now, 1, 1)
+	unresolved.DstRef = nil
+	dnsEvent := sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_DNS_QUERY, now, 0, 0)
+	dnsEvent.Protocol = insightsv1.Protocol_PROTOCOL_DNS
+
+	if observed := g.Observe([]*insightsv1.EnrichedFlowEvent{unresolved, dnsEvent, nil}); observed != 0 {
+		t.Fatalf("observed = %d", observed)
+	}
+	if g.Count() != 0 {
+		t.Fatalf("edges = %d", g.Count())
+	}
+}
+
+func TestRollupReturnsDeltasAndKeepsTotals(t *testing.T) {
+	g := New(time.Hour, 0)
+	now := time.Now()
+	g.Observe([]*insightsv1.EnrichedFlowEvent{
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC, now.UnixNano(), 100, 0),
+	})
+	first := g.Rollup(now)
+	if len(first) != 1 || first[0].BytesSent != 100 {
+		t.Fatalf("first rollup = %+v", first)
+	}
+	if again := g.Rollup(now); len(again) != 0 {
+		t.Fatalf("expected empty rollup, got %+v", again)
+	}
+
+	g.Observe([]*insightsv1.EnrichedFlowEvent{
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC, now.UnixNano(), 25, 0),
+	})
+	second := g.Rollup(now)
+	if len(second) != 1 || second[0].BytesSent != 25 {
+		t.Fatalf("second rollup = %+v", second)
+	}
+	if total := g.Edges(ListOpts{})[0].BytesSent; total != 125 {
+		t.Fatalf("cumulative bytes = %d", total)
+	}
+}
+
+func TestRollupPrunesStaleEdges(t *testing.T) {
+	g := New(time.Minute, 0)
+	old := time.Now().Add(-time.Hour)
+	g.Observe([]*insightsv1.EnrichedFlowEvent{
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, old.UnixNano(), 0, 0),
+	})
+	g.Rollup(time.Now())
+	if g.Count() != 0 {
+		t.Fatalf("expected stale edge to be pruned, edges = %d", g.Count())
+	}
+}
+
+func TestObserveEvictsLeastRecentlySeenEdgeAtCap(t *testing.T) {
+	g := New(time.Hour, 2)
+	now := time.Now()
+	for i, name := range []string{"postgres", "redis", "kafka"} {
+		event := sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, now.Add(time.Duration(i)*time.Second).UnixNano(), 0, 0)
+		event.DstRef.Name = name
+		g.Observe([]*insightsv1.EnrichedFlowEvent{event})
+	}
+	edges := g.Edges(ListOpts{})
+	if len(edges) != 2 || edges[0].Dst.Name != "kafka" || edges[1].Dst.Name != "redis" {
+		t.Fatalf("edges = %+v", edges)
+	}
+	if g.Evicted() != 1 {
+		t.Fatalf("evicted = %d", g.Evicted())
+	}
+	if rollup := g.Rollup(now); len(rollup) != 2 {
+		t.Fatalf("rollup = %+v", rollup)
+	}
+}
+
+func TestObserveEvictsByLatestSighting(t *testing.T) {
+	g := New(time.Hour, 2)
+	now := time.Now()
+	for i, name := range []string{"postgres", "redis", "postgres", "kafka"} {
+		event := sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, now.Add(time.Duration(i)*time.Second).UnixNano(), 0, 0)
+		event.DstRef.Name = name
+		g.Observe([]*insightsv1.EnrichedFlowEvent{event})
+	}
+	edges := g.Edges(ListOpts{})
+	if len(edges) != 2 || edges[0].Dst.Name != "kafka" || edges[1].Dst.Name != "postgres" || edges[1].Connections != 2 {
+		t.Fatalf("edges = %+v", edges)
+	}
+}
+
+func TestEdgesNamespaceFilter(t *testing.T) {
+	g := New(time.Hour, 0)
+	other := sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, time.Now().UnixNano(), 0, 0)
+	other.SrcWorkload = &insightsv1.KubernetesRef{Namespace: "ops", Kind: "CronJob", Name: "backup"}
+	other.DstRef = &insightsv1.KubernetesRef{Kind: "ExternalHostname", Name: "s3.amazonaws.com"}
+	g.Observe([]*insightsv1.EnrichedFlowEvent{
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, time.Now().UnixNano(), 0, 0),
+		other,
+	})
+
+	edges := g.Edges(ListOpts{Namespace: "ops"})
+	if len(edges) != 1 || edges[0].Dst.Name != "s3.amazonaws.com" {
+		t.Fatalf("filtered edges = %+v", edges)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := New(time.Hour, 0)
+	g.Observe([]*insightsv1.EnrichedFlowEvent{
+		sampleEdgeEvent(insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT, time.Now().UnixNano(), 0, 0),
+	})
+	var b strings.Builder
+	if err := WriteDOT(&b, g.Edges(ListOpts{})); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"digraph flows {",
+		`label="shop";`,
+		`"shop/Deployment/frontend" -> "shop/Service/postgres" [label="TCP/5432 conns=1 sent=0 recv=0"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("dot output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestToEnrichedEvents(t *testing.T) {
+	ts := time.Now()
+	rows := ToEnrichedEvents("aggregator-0", []Edge{{
+		Src:       Node{Namespace: "shop", Kind: "Deployment", Name: "frontend"},
+		Dst:       Node{Namespace: "shop", Kind: "Service", Name: "postgres"},
+		Port:      5432,
+		Protocol:  "TCP",
+		BytesSent: 10,
+		LastSeen:  ts,
+	}})
+	if len(rows) != 1 {
+		t.Fatalf("rows = %d", len(rows))
+	}
+	row := rows[0]
+	if row.GetProtocol() != insightsv1.Protocol_PROTOCOL_TCP || row.GetEventKind() != insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC {
+		t.Fatalf("row kind = %v/%v", row.GetProtocol(), row.GetEventKind())
+	}
+	if row.GetDstRef().GetName() != "postgres" || row.GetDst().GetPort() != 5432 || row.GetBytesSent() != 10 {
+		t.Fatalf("row = %+v", row)
+	}
+	if row.GetTimestampUnixNano() != ts.UnixNano() || row.GetAgentId() != "aggregator-0" {
+		t.Fatalf("row meta = %+v", row)
+	}
+}
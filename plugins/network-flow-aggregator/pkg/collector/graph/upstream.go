@@ -0,0 +1,39 @@
+package graph
+
+import (
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+// ToEnrichedEvents converts rolled-up edge deltas into one TRAFFIC row per edge
+// so they can be sent over the existing NetworkFlowIngest contract in place of
+// raw events. Only byte deltas carry over, timestamped at the edge's last
+// sighting. The contract has no fields for connection counts or first-seen
+// times, and an edge spans pods and nodes, so NodeName and the source pod are
+// left empty. Those stay local to the graph API; upstream consumers that need
+// them should use the events upstream mode.
+func ToEnrichedEvents(agentID string, edges []Edge) []*insightsv1.EnrichedFlowEvent {
+	out := make([]*insightsv1.EnrichedFlowEvent, 0, len(edges))
+	for _, edge := range edges {
+		out = append(out, &insightsv1.EnrichedFlowEvent{
+			AgentId:           agentID,
+			EventKind:         insightsv1.FlowEventKind_FLOW_EVENT_KIND_TRAFFIC,
+			Protocol:          insightsv1.Protocol(insightsv1.Protocol_value["PROTOCOL_"+edge.Protocol]),
+			TimestampUnixNano: edge.LastSeen.UnixNano(),
+			Src:               &insightsv1.WorkloadRef{Namespace: edge.Src.Namespace},
+			SrcWorkload: &insightsv1.KubernetesRef{
+				Namespace: edge.Src.Namespace,
+				Kind:      edge.Src.Kind,
+				Name:      edge.Src.Name,
+			},
+			Dst: &insightsv1.Endpoint{Port: edge.Port},
+			DstRef: &insightsv1.KubernetesRef{
+				Namespace: edge.Dst.Namespace,
+				Kind:      edge.Dst.Kind,
+				Name:      edge.Dst.Name,
+			},
+			BytesSent:     edge.BytesSent,
+			BytesReceived: edge.BytesReceived,
+		})
+	}
+	return out
+}
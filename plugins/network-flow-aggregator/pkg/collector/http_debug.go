@@ -1,21 +1,32 @@
 package collector
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/egress"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/graph"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/netpol"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/store"
 	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
 type DebugHTTPServer struct {
-	store *store.Store
+	store    *store.Store
+	graph    *graph.Graph
+	egress   *egress.Inventory
+	policies *netpol.Generator
 }
 
-func NewDebugHTTPServer(st *store.Store) *DebugHTTPServer {
-	return &DebugHTTPServer{store: st}
+// NewDebugHTTPServer serves the debug API. policies may be nil when Kubernetes
+// enrichment is disabled.
+func NewDebugHTTPServer(st *store.Store, g *graph.Graph, inventory *egress.Inventory, policies *netpol.Generator) *DebugHTTPServer {
+	return &DebugHTTPServer{store: st, graph: g, egress: inventory, policies: policies}
 }
 
 func (h *DebugHTTPServer) Register(mux *http.ServeMux) {
@@ -24,6 +35,9 @@ func (h *DebugHTTPServer) Register(mux *http.ServeMux) {
 		_, _ = w.Write([]byte("ok"))
 	})
 	mux.HandleFunc("GET /api/v1/flows", h.handleFlows)
+	mux.HandleFunc("GET /api/v1/graph", h.handleGraph)
+	mux.HandleFunc("GET /api/v1/networkpolicies", h.handleNetworkPolicies)
+	mux.HandleFunc("GET /api/v1/egress", h.handleEgress)
 }
 
 func (h *DebugHTTPServer) handleFlows(w http.ResponseWriter, r *http.Request) {
@@ -61,6 +75,120 @@ func (h *DebugHTTPServer) handleFlows(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *DebugHTTPServer) handleGraph(w http.ResponseWriter, r *http.Request) {
+	if h.graph == nil {
+		http.Error(w, "graph disabled", http.StatusNotFound)
+		return
+	}
+	opts := graph.ListOpts{Namespace: r.URL.Query().Get("namespace")}
+	if since := parseInt64(r.URL.Query().Get("since")); since > 0 {
+		opts.Since = time.Unix(0, since)
+	}
+	edges := h.graph.Edges(opts)
+
+	if wantsDOT(r) {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		_ = graph.WriteDOT(w, edges)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"edges": edges,
+		"count": len(edges),
+	})
+}
+
+func (h *DebugHTTPServer) handleNetworkPolicies(w http.ResponseWriter, r *http.Request) {
+	if h.policies == nil {
+		http.Error(w, "policy generation requires kubernetes enrichment", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	req := netpol.Request{Namespace: q.Get("namespace")}
+	if workload := q.Get("workload"); workload != "" {
+		kind, name, ok := strings.Cut(workload, "/")
+		if !ok {
+			http.Error(w, "workload must be kind/name", http.StatusBadRequest)
+			return
+		}
+		req.WorkloadKind, req.WorkloadName = kind, name
+	}
+	if window := q.Get("window"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			http.Error(w, "invalid window: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Window = d
+	}
+	flavor, err := netpol.ParseFlavor(q.Get("flavor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.policies.Generate(req, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.EqualFold(q.Get("format"), "yaml") {
+		var buf bytes.Buffer
+		if err := netpol.WriteYAML(&buf, result, flavor); err != nil {
+			http.Error(w, "marshal policies", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(buf.Bytes())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"policies": netpol.Render(result.Policies, flavor),
+		"denied":   result.Denied,
+		"skipped":  result.Skipped,
+	})
+}
+
+func (h *DebugHTTPServer) handleEgress(w http.ResponseWriter, r *http.Request) {
+	if h.egress == nil {
+		http.Error(w, "egress inventory disabled", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	opts := egress.ListOpts{Namespace: q.Get("namespace"), Class: q.Get("class")}
+	switch opts.Class {
+	case "", egress.ClassInternet, egress.ClassCloud, egress.ClassPrivate:
+	default:
+		http.Error(w, "class must be internet, cloud, or private", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("new"); v != "" {
+		newOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "invalid new: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts.NewOnly = newOnly
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.egress.Report(opts, time.Now()))
+}
+
+func wantsDOT(r *http.Request) bool {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "dot":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/vnd.graphviz")
+}
+
 func parseInt64(v string) int64 {
 	if v == "" {
 		return 0
@@ -44,7 +44,11 @@ type Enricher struct {
 	epSliceLister  discoverylisters.EndpointSliceLister
 	nodeLister     corelisters.NodeLister
 	rsLister       appslisters.ReplicaSetLister
+	deployLister   appslisters.DeploymentLister
+	stsLister      appslisters.StatefulSetLister
+	dsLister       appslisters.DaemonSetLister
 	jobLister      batchlisters.JobLister
+	cronJobLister  batchlisters.CronJobLister
 	podsSynced     cache.InformerSynced
 	svcsSynced     cache.InformerSynced
 	epSlicesSynced cache.InformerSynced
@@ -81,7 +85,11 @@ func NewEnricher(ctx context.Context, clients *Clients, log *slog.Logger) (*Enri
 		epSliceLister:  epSliceInformer.Lister(),
 		nodeLister:     nodeInformer.Lister(),
 		rsLister:       rsInformer.Lister(),
+		deployLister:   deployInformer.Lister(),
+		stsLister:      stsInformer.Lister(),
+		dsLister:       dsInformer.Lister(),
 		jobLister:      jobInformer.Lister(),
+		cronJobLister:  cronJobInformer.Lister(),
 		podsSynced:     podInformer.Informer().HasSynced,
 		svcsSynced:     svcInformer.Informer().HasSynced,
 		epSlicesSynced: epSliceInformer.Informer().HasSynced,
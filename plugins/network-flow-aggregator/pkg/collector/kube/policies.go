@@ -0,0 +1,103 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+var policySyncTimeout = 30 * time.Second
+
+// PolicyIndex watches installed NetworkPolicies and Namespace labels. It is
+// separate from Enricher so missing RBAC for these kinds degrades policy
+// generation only and never blocks flow enrichment.
+type PolicyIndex struct {
+	log           *slog.Logger
+	netpolLister  networkinglisters.NetworkPolicyLister
+	nsLister      corelisters.NamespaceLister
+	stopInformers context.CancelFunc
+}
+
+func NewPolicyIndex(ctx context.Context, clients *Clients, log *slog.Logger) (*PolicyIndex, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+
+	factory := informers.NewSharedInformerFactory(clients.Kubernetes, defaultResync)
+	netpolInformer := factory.Networking().V1().NetworkPolicies()
+	nsInformer := factory.Core().V1().Namespaces()
+
+	idx := &PolicyIndex{
+		log:          log,
+		netpolLister: netpolInformer.Lister(),
+		nsLister:     nsInformer.Lister(),
+	}
+
+	// The informers run until ctx is done or Stop is called; when the cache
+	// does not sync in time they are stopped before returning the error.
+	informerCtx, stopInformers := context.WithCancel(ctx)
+	idx.stopInformers = stopInformers
+	factory.Start(informerCtx.Done())
+	syncCtx, cancel := context.WithTimeout(informerCtx, policySyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), netpolInformer.Informer().HasSynced, nsInformer.Informer().HasSynced) {
+		stopInformers()
+		factory.Shutdown()
+		return nil, fmt.Errorf("networkpolicy/namespace informer cache sync")
+	}
+
+	log.Info("networkpolicy informers synced")
+	return idx, nil
+}
+
+// Stop stops the NetworkPolicy and Namespace informers.
+func (p *PolicyIndex) Stop() {
+	if p != nil && p.stopInformers != nil {
+		p.stopInformers()
+	}
+}
+
+func (p *PolicyIndex) NetworkPolicies(namespace string) []*networkingv1.NetworkPolicy {
+	if p == nil || p.netpolLister == nil {
+		return nil
+	}
+	policies, err := p.netpolLister.NetworkPolicies(namespace).List(labels.Everything())
+	if err != nil {
+		p.log.Debug("networkpolicy list failed", "namespace", namespace, "err", err)
+		return nil
+	}
+	return policies
+}
+
+// NamespaceLabels returns the labels of a namespace. The well-known
+// kubernetes.io/metadata.name label is always present so namespaceSelectors
+// written against it match even before the informer has the object.
+func (p *PolicyIndex) NamespaceLabels(namespace string) map[string]string {
+	out := map[string]string{"kubernetes.io/metadata.name": namespace}
+	if p == nil || p.nsLister == nil || namespace == "" {
+		return out
+	}
+	ns, err := p.nsLister.Get(namespace)
+	if err != nil {
+		return out
+	}
+	for k, v := range ns.Labels {
+		out[k] = v
+	}
+	return out
+}
+
+// ClusterView joins workload/service lookups from the Enricher with installed
+// policies from the PolicyIndex. Either may be nil.
+type ClusterView struct {
+	*Enricher
+	*PolicyIndex
+}
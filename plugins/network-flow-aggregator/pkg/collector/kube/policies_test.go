@@ -0,0 +1,36 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNewPolicyIndexStopsInformersWhenSyncTimesOut(t *testing.T) {
+	orig := policySyncTimeout
+	policySyncTimeout = 100 * time.Millisecond
+	defer func() { policySyncTimeout = orig }()
+
+	client := fake.NewClientset()
+	var lists atomic.Int64
+	client.PrependReactor("list", "networkpolicies", func(k8stesting.Action) (bool, runtime.Object, error) {
+		lists.Add(1)
+		return true, nil, errors.New("forbidden")
+	})
+
+	idx, err := NewPolicyIndex(context.Background(), &Clients{Kubernetes: client}, nil)
+	if err == nil || idx != nil {
+		t.Fatalf("NewPolicyIndex = %v, %v; want sync error", idx, err)
+	}
+	after := lists.Load()
+	time.Sleep(2 * time.Second) // longer than the reflector's first retry backoff
+	if got := lists.Load(); got != after {
+		t.Fatalf("networkpolicy informer kept listing after the error: %d -> %d", after, got)
+	}
+}
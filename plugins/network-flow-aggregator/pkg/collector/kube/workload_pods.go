@@ -0,0 +1,194 @@
+package kube
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// WorkloadPods describes the pods behind a workload: Selector is the stable
+// label set to target them with, Labels the full pod template labels.
+type WorkloadPods struct {
+	Selector map[string]string
+	Labels   map[string]string
+}
+
+// ServiceBackends describes the pods a Service port forwards to. TargetPorts may
+// hold named ports when the Service targets a container port by name.
+type ServiceBackends struct {
+	Selector    map[string]string
+	TargetPorts []intstr.IntOrString
+}
+
+// Controller-generated labels that change on every rollout or Job run; they make
+// poor policy selectors.
+var volatilePodLabels = map[string]struct{}{
+	"pod-template-hash":                  {},
+	"controller-revision-hash":           {},
+	"pod-template-generation":            {},
+	"statefulset.kubernetes.io/pod-name": {},
+	"apps.kubernetes.io/pod-index":       {},
+	"controller-uid":                     {},
+	"batch.kubernetes.io/controller-uid": {},
+	"job-name":                           {},
+	"batch.kubernetes.io/job-name":       {},
+}
+
+// WorkloadPods resolves the pod labels of a top-controller workload as returned
+// by ResolveSrcWorkload.
+func (e *Enricher) WorkloadPods(namespace, kind, name string) (WorkloadPods, bool) {
+	if e == nil || namespace == "" || name == "" {
+		return WorkloadPods{}, false
+	}
+	switch kind {
+	case "Deployment":
+		if e.deployLister == nil {
+			return WorkloadPods{}, false
+		}
+		d, err := e.deployLister.Deployments(namespace).Get(name)
+		if err != nil {
+			return WorkloadPods{}, false
+		}
+		return workloadPodsFromSelector(d.Spec.Selector, d.Spec.Template.Labels), true
+	case "StatefulSet":
+		if e.stsLister == nil {
+			return WorkloadPods{}, false
+		}
+		sts, err := e.stsLister.StatefulSets(namespace).Get(name)
+		if err != nil {
+			return WorkloadPods{}, false
+		}
+		return workloadPodsFromSelector(sts.Spec.Selector, sts.Spec.Template.Labels), true
+	case "DaemonSet":
+		if e.dsLister == nil {
+			return WorkloadPods{}, false
+		}
+		ds, err := e.dsLister.DaemonSets(namespace).Get(name)
+		if err != nil {
+			return WorkloadPods{}, false
+		}
+		return workloadPodsFromSelector(ds.Spec.Selector, ds.Spec.Template.Labels), true
+	case "ReplicaSet":
+		if e.rsLister == nil {
+			return WorkloadPods{}, false
+		}
+		rs, err := e.rsLister.ReplicaSets(namespace).Get(name)
+		if err != nil {
+			return WorkloadPods{}, false
+		}
+		return workloadPodsFromSelector(rs.Spec.Selector, rs.Spec.Template.Labels), true
+	case "CronJob":
+		if e.cronJobLister == nil {
+			return WorkloadPods{}, false
+		}
+		cj, err := e.cronJobLister.CronJobs(namespace).Get(name)
+		if err != nil {
+			return WorkloadPods{}, false
+		}
+		return workloadPodsFromLabels(cj.Spec.JobTemplate.Spec.Template.Labels), true
+	case "Job":
+		if e.jobLister == nil {
+			return WorkloadPods{}, false
+		}
+		job, err := e.jobLister.Jobs(namespace).Get(name)
+		if err != nil {
+			return WorkloadPods{}, false
+		}
+		return workloadPodsFromLabels(job.Spec.Template.Labels), true
+	case "Pod":
+		if e.podLister == nil {
+			return WorkloadPods{}, false
+		}
+		pod, err := e.podLister.Pods(namespace).Get(name)
+		if err != nil {
+			return WorkloadPods{}, false
+		}
+		return workloadPodsFromLabels(pod.Labels), true
+	}
+	return WorkloadPods{}, false
+}
+
+func workloadPodsFromSelector(selector *metav1.LabelSelector, labels map[string]string) WorkloadPods {
+	if selector == nil || len(selector.MatchLabels) == 0 || len(selector.MatchExpressions) > 0 {
+		return workloadPodsFromLabels(labels)
+	}
+	return WorkloadPods{Selector: copyLabels(selector.MatchLabels), Labels: copyLabels(labels)}
+}
+
+func workloadPodsFromLabels(labels map[string]string) WorkloadPods {
+	selector := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if _, ok := volatilePodLabels[k]; ok {
+			continue
+		}
+		selector[k] = v
+	}
+	return WorkloadPods{Selector: selector, Labels: copyLabels(labels)}
+}
+
+// ServiceBackends resolves the pod selector and target port(s) behind a Service
+// port. Selector-less Services (manually managed EndpointSlices) are not resolved.
+func (e *Enricher) ServiceBackends(namespace, name string, port int32) (ServiceBackends, bool) {
+	if e == nil || e.svcLister == nil {
+		return ServiceBackends{}, false
+	}
+	svc, err := e.svcLister.Services(namespace).Get(name)
+	if err != nil || len(svc.Spec.Selector) == 0 {
+		return ServiceBackends{}, false
+	}
+	out := ServiceBackends{Selector: copyLabels(svc.Spec.Selector)}
+	for _, p := range svc.Spec.Ports {
+		if port != 0 && p.Port != port {
+			continue
+		}
+		out.TargetPorts = append(out.TargetPorts, serviceTargetPort(p))
+	}
+	if len(out.TargetPorts) == 0 {
+		// Port-mismatch fallbacks in ResolveDst can attribute any port to the
+		// Service; keep the observed port rather than guessing a target.
+		out.TargetPorts = []intstr.IntOrString{intstr.FromInt32(port)}
+	}
+	return out, true
+}
+
+func serviceTargetPort(p corev1.ServicePort) intstr.IntOrString {
+	if p.TargetPort.Type == intstr.String && p.TargetPort.StrVal != "" {
+		return p.TargetPort
+	}
+	if p.TargetPort.IntVal != 0 {
+		return p.TargetPort
+	}
+	return intstr.FromInt32(p.Port)
+}
+
+// NodeAddresses returns the InternalIP and ExternalIP addresses of a node.
+func (e *Enricher) NodeAddresses(name string) []string {
+	if e == nil || e.nodeLister == nil || name == "" {
+		return nil
+	}
+	node, err := e.nodeLister.Get(name)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, addr := range node.Status.Addresses {
+		switch addr.Type {
+		case corev1.NodeInternalIP, corev1.NodeExternalIP:
+			if addr.Address != "" {
+				out = append(out, addr.Address)
+			}
+		}
+	}
+	return out
+}
+
+func copyLabels(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,235 @@
+package netpol
+
+import (
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/graph"
+)
+
+// DeniedFlow is an observed edge that the NetworkPolicies installed today would
+// block. Policies lists the policies selecting the blocked side.
+type DeniedFlow struct {
+	Src       graph.Node `json:"src"`
+	Dst       graph.Node `json:"dst"`
+	Port      int32      `json:"port"`
+	Protocol  string     `json:"protocol"`
+	Direction string     `json:"direction"`
+	Policies  []string   `json:"policies"`
+}
+
+// flowEndpoint is one side of an edge as NetworkPolicy sees it: pods with
+// labels in a namespace, or plain addresses.
+type flowEndpoint struct {
+	namespace string
+	labels    map[string]string
+	addrs     []string
+	port      int32
+}
+
+// evaluate checks an observed edge against installed policies. Edges whose
+// sides cannot be resolved to pods or addresses are not reported. Named ports
+// are assumed to match since flows only carry numbers.
+func (gen *Generator) evaluate(edge graph.Edge) []DeniedFlow {
+	src, ok := gen.srcEndpoint(edge.Src)
+	if !ok {
+		return nil
+	}
+	dst, ok := gen.dstEndpoint(edge)
+	if !ok {
+		return nil
+	}
+
+	var out []DeniedFlow
+	if blocking := gen.blockingPolicies(src, dst, dst.port, edge.Protocol, networkingv1.PolicyTypeEgress); len(blocking) > 0 {
+		out = append(out, DeniedFlow{Src: edge.Src, Dst: edge.Dst, Port: edge.Port, Protocol: edge.Protocol, Direction: "egress", Policies: blocking})
+	}
+	if dst.labels != nil {
+		if blocking := gen.blockingPolicies(dst, src, dst.port, edge.Protocol, networkingv1.PolicyTypeIngress); len(blocking) > 0 {
+			out = append(out, DeniedFlow{Src: edge.Src, Dst: edge.Dst, Port: edge.Port, Protocol: edge.Protocol, Direction: "ingress", Policies: blocking})
+		}
+	}
+	return out
+}
+
+func (gen *Generator) srcEndpoint(n graph.Node) (flowEndpoint, bool) {
+	if !isWorkloadKind(n.Kind) {
+		return flowEndpoint{}, false
+	}
+	pods, ok := gen.view.WorkloadPods(n.Namespace, n.Kind, n.Name)
+	if !ok {
+		return flowEndpoint{}, false
+	}
+	return flowEndpoint{namespace: n.Namespace, labels: pods.Labels}, true
+}
+
+func (gen *Generator) dstEndpoint(edge graph.Edge) (flowEndpoint, bool) {
+	switch edge.Dst.Kind {
+	case "Service":
+		backends, ok := gen.view.ServiceBackends(edge.Dst.Namespace, edge.Dst.Name, edge.Port)
+		if !ok {
+			return flowEndpoint{}, false
+		}
+		port := edge.Port
+		for _, tp := range backends.TargetPorts {
+			if tp.Type == intstr.Int {
+				port = tp.IntVal
+				break
+			}
+		}
+		// The Service selector is a subset of the backend pod labels; policies
+		// selecting on other labels may be missed.
+		return flowEndpoint{namespace: edge.Dst.Namespace, labels: backends.Selector, port: port}, true
+	case "ExternalHostname":
+		if gen.hosts == nil {
+			return flowEndpoint{}, false
+		}
+		addrs := gen.hosts.Addresses(edge.Dst.Name)
+		return flowEndpoint{addrs: addrs, port: edge.Port}, len(addrs) > 0
+	case "Node":
+		addrs := gen.view.NodeAddresses(edge.Dst.Name)
+		return flowEndpoint{addrs: addrs, port: edge.Port}, len(addrs) > 0
+	}
+	if !isWorkloadKind(edge.Dst.Kind) {
+		return flowEndpoint{}, false
+	}
+	pods, ok := gen.view.WorkloadPods(edge.Dst.Namespace, edge.Dst.Kind, edge.Dst.Name)
+	if !ok {
+		return flowEndpoint{}, false
+	}
+	return flowEndpoint{namespace: edge.Dst.Namespace, labels: pods.Labels, port: edge.Port}, true
+}
+
+// blockingPolicies returns the names of policies of policyType that select
+// subject, when none of them allows traffic with peer. It returns nil when the
+// traffic is allowed or no policy isolates subject.
+func (gen *Generator) blockingPolicies(subject, peer flowEndpoint, port int32, protocol string, policyType networkingv1.PolicyType) []string {
+	var selecting []string
+	for _, p := range gen.view.NetworkPolicies(subject.namespace) {
+		if p == nil || !hasPolicyType(p, policyType) || !selectorMatchesLabels(&p.Spec.PodSelector, subject.labels) {
+			continue
+		}
+		selecting = append(selecting, p.Name)
+		if policyType == networkingv1.PolicyTypeEgress {
+			for _, rule := range p.Spec.Egress {
+				if gen.peersMatch(rule.To, p.Namespace, peer) && portsMatch(rule.Ports, protocol, port) {
+					return nil
+				}
+			}
+		} else {
+			for _, rule := range p.Spec.Ingress {
+				if gen.peersMatch(rule.From, p.Namespace, peer) && portsMatch(rule.Ports, protocol, port) {
+					return nil
+				}
+			}
+		}
+	}
+	return selecting
+}
+
+func hasPolicyType(p *networkingv1.NetworkPolicy, t networkingv1.PolicyType) bool {
+	if len(p.Spec.PolicyTypes) == 0 {
+		// API defaulting: Ingress always, Egress only when egress rules exist.
+		return t == networkingv1.PolicyTypeIngress || len(p.Spec.Egress) > 0
+	}
+	for _, pt := range p.Spec.PolicyTypes {
+		if pt == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (gen *Generator) peersMatch(peers []networkingv1.NetworkPolicyPeer, policyNamespace string, ep flowEndpoint) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if peer.IPBlock != nil {
+			if ipBlockMatches(peer.IPBlock, ep.addrs) {
+				return true
+			}
+			continue
+		}
+		if ep.labels == nil {
+			continue
+		}
+		if peer.NamespaceSelector == nil {
+			if ep.namespace != policyNamespace {
+				continue
+			}
+		} else if !selectorMatchesLabels(peer.NamespaceSelector, gen.view.NamespaceLabels(ep.namespace)) {
+			continue
+		}
+		if peer.PodSelector != nil && !selectorMatchesLabels(peer.PodSelector, ep.labels) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func ipBlockMatches(block *networkingv1.IPBlock, addrs []string) bool {
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil || !cidr.Contains(ip) {
+			continue
+		}
+		excluded := false
+		for _, except := range block.Except {
+			if _, ex, err := net.ParseCIDR(except); err == nil && ex.Contains(ip) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			return true
+		}
+	}
+	return false
+}
+
+func portsMatch(ports []networkingv1.NetworkPolicyPort, protocol string, port int32) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		proto := corev1.ProtocolTCP
+		if p.Protocol != nil {
+			proto = *p.Protocol
+		}
+		if string(proto) != protocol {
+			continue
+		}
+		if p.Port == nil || p.Port.Type == intstr.String {
+			return true
+		}
+		if p.EndPort != nil {
+			if port >= p.Port.IntVal && port <= *p.EndPort {
+				return true
+			}
+			continue
+		}
+		if p.Port.IntVal == port {
+			return true
+		}
+	}
+	return false
+}
+
+func selectorMatchesLabels(selector *metav1.LabelSelector, set map[string]string) bool {
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return sel.Matches(labels.Set(set))
+}
@@ -0,0 +1,426 @@
+package netpol
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/graph"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/kube"
+)
+
+type Flavor string
+
+const (
+	FlavorKubernetes Flavor = "kubernetes"
+	FlavorCilium     Flavor = "cilium"
+	FlavorCalico     Flavor = "calico"
+)
+
+func ParseFlavor(v string) (Flavor, error) {
+	switch Flavor(strings.ToLower(v)) {
+	case "", FlavorKubernetes:
+		return FlavorKubernetes, nil
+	case FlavorCilium:
+		return FlavorCilium, nil
+	case FlavorCalico:
+		return FlavorCalico, nil
+	}
+	return "", fmt.Errorf("unknown policy flavor %q; expected kubernetes, cilium, or calico", v)
+}
+
+// ClusterView is the cluster state the generator needs; kube.ClusterView
+// implements it from informers.
+type ClusterView interface {
+	WorkloadPods(namespace, kind, name string) (kube.WorkloadPods, bool)
+	ServiceBackends(namespace, name string, port int32) (kube.ServiceBackends, bool)
+	NodeAddresses(name string) []string
+	NetworkPolicies(namespace string) []*networkingv1.NetworkPolicy
+	NamespaceLabels(namespace string) map[string]string
+}
+
+// HostResolver maps external hostnames back to the IPs workloads resolved them to.
+type HostResolver interface {
+	Addresses(hostname string) []string
+}
+
+type Request struct {
+	Namespace    string
+	WorkloadKind string
+	WorkloadName string
+	Window       time.Duration
+}
+
+// Policy is the flavor-neutral least-privilege policy for one workload.
+type Policy struct {
+	Namespace    string
+	Name         string
+	WorkloadKind string
+	WorkloadName string
+	Selector     map[string]string
+	Ingress      []Rule
+	Egress       []Rule
+}
+
+type Rule struct {
+	Peer  Peer
+	Ports []Port
+}
+
+// Peer is one side of an allowed connection: pods (Namespace + PodLabels), or
+// external addresses (CIDRs, optionally named by Hostname).
+type Peer struct {
+	Namespace string
+	PodLabels map[string]string
+	CIDRs     []string
+	Hostname  string
+}
+
+type Port struct {
+	Protocol string
+	Port     intstr.IntOrString
+}
+
+// SkippedEdge records an observed edge the generator could not turn into a rule.
+type SkippedEdge struct {
+	Src    graph.Node `json:"src"`
+	Dst    graph.Node `json:"dst"`
+	Port   int32      `json:"port"`
+	Reason string     `json:"reason"`
+}
+
+type Result struct {
+	Policies []Policy
+	Denied   []DeniedFlow
+	Skipped  []SkippedEdge
+}
+
+type Generator struct {
+	graph *graph.Graph
+	view  ClusterView
+	hosts HostResolver
+}
+
+func NewGenerator(g *graph.Graph, view ClusterView, hosts HostResolver) *Generator {
+	return &Generator{graph: g, view: view, hosts: hosts}
+}
+
+// Generate proposes one policy per workload in req.Namespace (or just the
+// requested workload) from edges observed within req.Window, and reports
+// observed flows the currently installed policies would deny.
+func (gen *Generator) Generate(req Request, now time.Time) (Result, error) {
+	if req.Namespace == "" {
+		return Result{}, fmt.Errorf("namespace is required")
+	}
+	if (req.WorkloadKind == "") != (req.WorkloadName == "") {
+		return Result{}, fmt.Errorf("workload must be given as kind/name")
+	}
+	opts := graph.ListOpts{}
+	if req.Window > 0 {
+		opts.Since = now.Add(-req.Window)
+	}
+	edges := gen.graph.Edges(opts)
+	candidates := namespaceWorkloads(edges, req.Namespace)
+
+	targets := make(map[graph.Node]*Policy)
+	var result Result
+	target := func(n graph.Node) (*Policy, bool) {
+		if n.Namespace != req.Namespace || !isWorkloadKind(n.Kind) {
+			return nil, false
+		}
+		if req.WorkloadName != "" && (n.Kind != req.WorkloadKind || n.Name != req.WorkloadName) {
+			return nil, false
+		}
+		if p, ok := targets[n]; ok {
+			return p, p != nil
+		}
+		pods, ok := gen.view.WorkloadPods(n.Namespace, n.Kind, n.Name)
+		if !ok || len(pods.Selector) == 0 {
+			targets[n] = nil
+			return nil, false
+		}
+		p := &Policy{
+			Namespace:    n.Namespace,
+			Name:         policyName(n),
+			WorkloadKind: n.Kind,
+			WorkloadName: n.Name,
+			Selector:     pods.Selector,
+		}
+		targets[n] = p
+		return p, true
+	}
+
+	for _, edge := range edges {
+		inScope := false
+		if p, ok := target(edge.Src); ok {
+			inScope = true
+			peer, ports, reason := gen.egressPeer(edge)
+			if reason != "" {
+				result.Skipped = append(result.Skipped, SkippedEdge{Src: edge.Src, Dst: edge.Dst, Port: edge.Port, Reason: reason})
+			} else {
+				p.Egress = addRule(p.Egress, peer, ports)
+			}
+		}
+		for _, dst := range gen.ingressTargets(edge, candidates) {
+			p, ok := target(dst.node)
+			if !ok {
+				continue
+			}
+			inScope = true
+			peer, reason := gen.workloadPeer(edge.Src)
+			if reason != "" {
+				result.Skipped = append(result.Skipped, SkippedEdge{Src: edge.Src, Dst: dst.node, Port: edge.Port, Reason: reason})
+				continue
+			}
+			p.Ingress = addRule(p.Ingress, peer, dst.ports)
+		}
+		if inScope {
+			result.Denied = append(result.Denied, gen.evaluate(edge)...)
+		}
+	}
+
+	for _, p := range targets {
+		if p == nil || (len(p.Ingress) == 0 && len(p.Egress) == 0) {
+			continue
+		}
+		if len(p.Egress) > 0 {
+			p.Egress = addRule(p.Egress, dnsPeer(), dnsPorts())
+		}
+		sortRules(p.Ingress)
+		sortRules(p.Egress)
+		result.Policies = append(result.Policies, *p)
+	}
+	sort.Slice(result.Policies, func(i, j int) bool { return result.Policies[i].Name < result.Policies[j].Name })
+	return result, nil
+}
+
+func (gen *Generator) egressPeer(edge graph.Edge) (Peer, []Port, string) {
+	observed := []Port{{Protocol: edge.Protocol, Port: intstr.FromInt32(edge.Port)}}
+	switch edge.Dst.Kind {
+	case "Service":
+		backends, ok := gen.view.ServiceBackends(edge.Dst.Namespace, edge.Dst.Name, edge.Port)
+		if !ok {
+			return Peer{}, nil, "service has no pod selector"
+		}
+		ports := make([]Port, 0, len(backends.TargetPorts))
+		for _, tp := range backends.TargetPorts {
+			ports = append(ports, Port{Protocol: edge.Protocol, Port: tp})
+		}
+		return Peer{Namespace: edge.Dst.Namespace, PodLabels: backends.Selector}, ports, ""
+	case "ExternalHostname":
+		var addrs []string
+		if gen.hosts != nil {
+			addrs = gen.hosts.Addresses(edge.Dst.Name)
+		}
+		if len(addrs) == 0 {
+			return Peer{}, nil, "no cached DNS addresses for hostname"
+		}
+		return Peer{Hostname: edge.Dst.Name, CIDRs: hostCIDRs(addrs)}, observed, ""
+	case "Node":
+		addrs := gen.view.NodeAddresses(edge.Dst.Name)
+		if len(addrs) == 0 {
+			return Peer{}, nil, "node has no addresses"
+		}
+		return Peer{CIDRs: hostCIDRs(addrs)}, observed, ""
+	case "Loopback":
+		return Peer{}, nil, "loopback traffic is not subject to NetworkPolicy"
+	case "LinkLocal":
+		return Peer{}, nil, "link-local destination has no address to allow"
+	}
+	peer, reason := gen.workloadPeer(edge.Dst)
+	return peer, observed, reason
+}
+
+func (gen *Generator) workloadPeer(n graph.Node) (Peer, string) {
+	if !isWorkloadKind(n.Kind) {
+		return Peer{}, fmt.Sprintf("%s peers cannot be selected by labels", n.Kind)
+	}
+	pods, ok := gen.view.WorkloadPods(n.Namespace, n.Kind, n.Name)
+	if !ok || len(pods.Selector) == 0 {
+		return Peer{}, "workload pod labels not found"
+	}
+	return Peer{Namespace: n.Namespace, PodLabels: pods.Selector}, ""
+}
+
+type ingressTarget struct {
+	node  graph.Node
+	ports []Port
+}
+
+// ingressTargets lists the workloads that accepted an edge. Service edges fan
+// out to every candidate workload the Service selects.
+func (gen *Generator) ingressTargets(edge graph.Edge, candidates []graph.Node) []ingressTarget {
+	if isWorkloadKind(edge.Dst.Kind) {
+		return []ingressTarget{{node: edge.Dst, ports: []Port{{Protocol: edge.Protocol, Port: intstr.FromInt32(edge.Port)}}}}
+	}
+	if edge.Dst.Kind != "Service" {
+		return nil
+	}
+	backends, ok := gen.view.ServiceBackends(edge.Dst.Namespace, edge.Dst.Name, edge.Port)
+	if !ok {
+		return nil
+	}
+	ports := make([]Port, 0, len(backends.TargetPorts))
+	for _, tp := range backends.TargetPorts {
+		ports = append(ports, Port{Protocol: edge.Protocol, Port: tp})
+	}
+
+	var out []ingressTarget
+	for _, n := range candidates {
+		if n.Namespace != edge.Dst.Namespace {
+			continue
+		}
+		pods, ok := gen.view.WorkloadPods(n.Namespace, n.Kind, n.Name)
+		if !ok || !selectorMatches(backends.Selector, pods.Labels) {
+			continue
+		}
+		out = append(out, ingressTarget{node: n, ports: ports})
+	}
+	return out
+}
+
+// namespaceWorkloads lists workloads in namespace that appear anywhere in
+// edges; those are the only candidates a Service edge can land on.
+func namespaceWorkloads(edges []graph.Edge, namespace string) []graph.Node {
+	seen := make(map[graph.Node]struct{})
+	var out []graph.Node
+	for _, edge := range edges {
+		for _, n := range []graph.Node{edge.Src, edge.Dst} {
+			if n.Namespace != namespace || !isWorkloadKind(n.Kind) {
+				continue
+			}
+			if _, ok := seen[n]; ok {
+				continue
+			}
+			seen[n] = struct{}{}
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func isWorkloadKind(kind string) bool {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "CronJob", "Pod":
+		return true
+	}
+	return false
+}
+
+func selectorMatches(selector, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// policyName names the policy after its workload. Names over the DNS-1123
+// subdomain limit are cut, trimmed back to an alphanumeric and given a short
+// hash of the full name, so they stay valid and long workload names sharing a
+// prefix do not collide.
+func policyName(n graph.Node) string {
+	const suffix = "-observed"
+	name := strings.ToLower(n.Kind) + "-" + n.Name
+	if len(name)+len(suffix) <= validation.DNS1123SubdomainMaxLength {
+		return name + suffix
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:10]
+	name = strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-len(suffix)-len(hash)-1], "-.")
+	return name + "-" + hash + suffix
+}
+
+func hostCIDRs(addrs []string) []string {
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			out = append(out, ip.String()+"/32")
+		} else {
+			out = append(out, ip.String()+"/128")
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func dnsPeer() Peer {
+	return Peer{Namespace: "kube-system", PodLabels: map[string]string{"k8s-app": "kube-dns"}}
+}
+
+func dnsPorts() []Port {
+	return []Port{
+		{Protocol: "UDP", Port: intstr.FromInt32(53)},
+		{Protocol: "TCP", Port: intstr.FromInt32(53)},
+	}
+}
+
+func addRule(rules []Rule, peer Peer, ports []Port) []Rule {
+	key := peerKey(peer)
+	for i := range rules {
+		if peerKey(rules[i].Peer) == key {
+			rules[i].Ports = mergePorts(rules[i].Ports, ports)
+			return rules
+		}
+	}
+	return append(rules, Rule{Peer: peer, Ports: mergePorts(nil, ports)})
+}
+
+func mergePorts(existing, add []Port) []Port {
+	for _, p := range add {
+		dup := false
+		for _, e := range existing {
+			if e.Protocol == p.Protocol && e.Port.String() == p.Port.String() {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			existing = append(existing, p)
+		}
+	}
+	sort.Slice(existing, func(i, j int) bool {
+		if existing[i].Protocol != existing[j].Protocol {
+			return existing[i].Protocol < existing[j].Protocol
+		}
+		return existing[i].Port.String() < existing[j].Port.String()
+	})
+	return existing
+}
+
+func sortRules(rules []Rule) {
+	sort.Slice(rules, func(i, j int) bool { return peerKey(rules[i].Peer) < peerKey(rules[j].Peer) })
+}
+
+func peerKey(p Peer) string {
+	return p.Namespace + "|" + labelString(p.PodLabels) + "|" + strings.Join(p.CIDRs, ",") + "|" + p.Hostname
+}
+
+func labelString(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,242 @@
+package netpol
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/graph"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/kube"
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+type fakeView struct {
+	workloads map[string]kube.WorkloadPods
+	services  map[string]kube.ServiceBackends
+	nodes     map[string][]string
+	policies  map[string][]*networkingv1.NetworkPolicy
+}
+
+func (f *fakeView) WorkloadPods(namespace, kind, name string) (kube.WorkloadPods, bool) {
+	w, ok := f.workloads[namespace+"/"+kind+"/"+name]
+	return w, ok
+}
+
+func (f *fakeView) ServiceBackends(namespace, name string, _ int32) (kube.ServiceBackends, bool) {
+	s, ok := f.services[namespace+"/"+name]
+	return s, ok
+}
+
+func (f *fakeView) NodeAddresses(name string) []string {
+	return f.nodes[name]
+}
+
+func (f *fakeView) NetworkPolicies(namespace string) []*networkingv1.NetworkPolicy {
+	return f.policies[namespace]
+}
+
+func (f *fakeView) NamespaceLabels(namespace string) map[string]string {
+	return map[string]string{"kubernetes.io/metadata.name": namespace}
+}
+
+type fakeHosts map[string][]string
+
+func (f fakeHosts) Addresses(hostname string) []string {
+	return f[hostname]
+}
+
+func flowEvent(src, dst *insightsv1.KubernetesRef, port int32) *insightsv1.EnrichedFlowEvent {
+	return &insightsv1.EnrichedFlowEvent{
+		EventKind:         insightsv1.FlowEventKind_FLOW_EVENT_KIND_CONNECT,
+		Protocol:          insightsv1.Protocol_PROTOCOL_TCP,
+		TimestampUnixNano: time.Now().UnixNano(),
+		SrcWorkload:       src,
+		Dst:               &insightsv1.Endpoint{Port: port},
+		DstRef:            dst,
+	}
+}
+
+func shopView() *fakeView {
+	return &fakeView{
+		workloads: map[string]kube.WorkloadPods{
+			"shop/Deployment/frontend": {Selector: map[string]string{"app": "frontend"}, Labels: map[string]string{"app": "frontend", "tier": "web"}},
+			"shop/Deployment/api":      {Selector: map[string]string{"app": "api"}, Labels: map[string]string{"app": "api"}},
+		},
+		services: map[string]kube.ServiceBackends{
+			"shop/api": {Selector: map[string]string{"app": "api"}, TargetPorts: []intstr.IntOrString{intstr.FromInt32(8080)}},
+		},
+	}
+}
+
+func shopGraph() *graph.Graph {
+	g := graph.New(time.Hour, 0)
+	frontend := &insightsv1.KubernetesRef{Namespace: "shop", Kind: "Deployment", Name: "frontend"}
+	api := &insightsv1.KubernetesRef{Namespace: "shop", Kind: "Deployment", Name: "api"}
+	g.Observe([]*insightsv1.EnrichedFlowEvent{
+		flowEvent(frontend, &insightsv1.KubernetesRef{Namespace: "shop", Kind: "Service", Name: "api"}, 80),
+		flowEvent(api, &insightsv1.KubernetesRef{Kind: "ExternalHostname", Name: "api.stripe.com"}, 443),
+		flowEvent(api, &insightsv1.KubernetesRef{Kind: "Loopback", Name: "localhost"}, 9000),
+	})
+	return g
+}
+
+func TestGenerateServiceAndExternalEgress(t *testing.T) {
+	gen := NewGenerator(shopGraph(), shopView(), fakeHosts{"api.stripe.com": {"104.21.11.16"}})
+	result, err := gen.Generate(Request{Namespace: "shop", Window: time.Hour}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Policies) != 2 {
+		t.Fatalf("policies = %+v", result.Policies)
+	}
+
+	api, frontend := result.Policies[0], result.Policies[1]
+	if api.Name != "deployment-api-observed" || frontend.Name != "deployment-frontend-observed" {
+		t.Fatalf("names = %s, %s", api.Name, frontend.Name)
+	}
+
+	// frontend -> Service api:80 becomes egress to api pods on target port 8080.
+	if len(frontend.Egress) != 2 {
+		t.Fatalf("frontend egress = %+v", frontend.Egress)
+	}
+	svcRule := frontend.Egress[1]
+	if svcRule.Peer.PodLabels["app"] != "api" || svcRule.Ports[0].Port.IntValue() != 8080 {
+		t.Fatalf("frontend service rule = %+v", svcRule)
+	}
+
+	// api accepts frontend on 8080 and egresses to stripe by cached IP.
+	if len(api.Ingress) != 1 || api.Ingress[0].Peer.PodLabels["app"] != "frontend" || api.Ingress[0].Ports[0].Port.IntValue() != 8080 {
+		t.Fatalf("api ingress = %+v", api.Ingress)
+	}
+	var stripe *Rule
+	for i := range api.Egress {
+		if api.Egress[i].Peer.Hostname == "api.stripe.com" {
+			stripe = &api.Egress[i]
+		}
+	}
+	if stripe == nil || len(stripe.Peer.CIDRs) != 1 || stripe.Peer.CIDRs[0] != "104.21.11.16/32" {
+		t.Fatalf("api egress = %+v", api.Egress)
+	}
+
+	if len(result.Skipped) != 1 || result.Skipped[0].Dst.Kind != "Loopback" {
+		t.Fatalf("skipped = %+v", result.Skipped)
+	}
+}
+
+func TestGenerateSingleWorkload(t *testing.T) {
+	gen := NewGenerator(shopGraph(), shopView(), nil)
+	result, err := gen.Generate(Request{Namespace: "shop", WorkloadKind: "Deployment", WorkloadName: "frontend"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Policies) != 1 || result.Policies[0].WorkloadName != "frontend" {
+		t.Fatalf("policies = %+v", result.Policies)
+	}
+}
+
+func TestGenerateRequiresNamespace(t *testing.T) {
+	gen := NewGenerator(shopGraph(), shopView(), nil)
+	if _, err := gen.Generate(Request{}, time.Now()); err == nil {
+		t.Fatal("expected error without namespace")
+	}
+}
+
+func TestGenerateReportsDeniedFlows(t *testing.T) {
+	view := shopView()
+	view.policies = map[string][]*networkingv1.NetworkPolicy{
+		"shop": {{
+			ObjectMeta: metav1.ObjectMeta{Name: "default-deny", Namespace: "shop"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}},
+	}
+	gen := NewGenerator(shopGraph(), view, nil)
+	result, err := gen.Generate(Request{Namespace: "shop"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Denied) != 1 {
+		t.Fatalf("denied = %+v", result.Denied)
+	}
+	denied := result.Denied[0]
+	if denied.Direction != "ingress" || denied.Src.Name != "frontend" || denied.Dst.Name != "api" || denied.Policies[0] != "default-deny" {
+		t.Fatalf("denied = %+v", denied)
+	}
+}
+
+func TestGenerateAllowedByInstalledPolicy(t *testing.T) {
+	view := shopView()
+	port := intstr.FromInt32(8080)
+	view.policies = map[string][]*networkingv1.NetworkPolicy{
+		"shop": {{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-ingress", Namespace: "shop"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "web"}}}},
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &port}},
+				}},
+			},
+		}},
+	}
+	gen := NewGenerator(shopGraph(), view, nil)
+	result, err := gen.Generate(Request{Namespace: "shop"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Denied) != 0 {
+		t.Fatalf("denied = %+v", result.Denied)
+	}
+}
+
+func TestWriteYAMLFlavors(t *testing.T) {
+	gen := NewGenerator(shopGraph(), shopView(), fakeHosts{"api.stripe.com": {"104.21.11.16"}})
+	result, err := gen.Generate(Request{Namespace: "shop"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for flavor, want := range map[Flavor][]string{
+		FlavorKubernetes: {"kind: NetworkPolicy", "cidr: 104.21.11.16/32", "---"},
+		FlavorCilium:     {"kind: CiliumNetworkPolicy", "matchName: api.stripe.com", "matchPattern: '*'"},
+		FlavorCalico:     {"kind: NetworkPolicy", "apiVersion: projectcalico.org/v3", "selector: app == 'api'"},
+	} {
+		var b strings.Builder
+		if err := WriteYAML(&b, result, flavor); err != nil {
+			t.Fatalf("%s: %v", flavor, err)
+		}
+		for _, w := range want {
+			if !strings.Contains(b.String(), w) {
+				t.Fatalf("%s output missing %q:\n%s", flavor, w, b.String())
+			}
+		}
+	}
+}
+
+func TestPolicyNameTruncatesToValidUniqueNames(t *testing.T) {
+	if got := policyName(graph.Node{Kind: "Deployment", Name: "api"}); got != "deployment-api-observed" {
+		t.Fatalf("short name = %q", got)
+	}
+
+	prefix := strings.Repeat("a", 221) + "." + strings.Repeat("b", 15)
+	first := policyName(graph.Node{Kind: "Deployment", Name: prefix + "-first"})
+	second := policyName(graph.Node{Kind: "Deployment", Name: prefix + "-second"})
+	if first == second {
+		t.Fatalf("long names collide: %q", first)
+	}
+	for _, name := range []string{first, second} {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			t.Errorf("policyName = %q: %v", name, errs)
+		}
+		if !strings.HasSuffix(name, "-observed") {
+			t.Errorf("policyName = %q, want -observed suffix", name)
+		}
+	}
+}
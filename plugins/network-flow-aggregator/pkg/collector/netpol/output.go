@@ -0,0 +1,58 @@
+package netpol
+
+import (
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/graph"
+)
+
+// WriteYAML writes the rendered manifests as a multi-document YAML stream,
+// followed by comments listing flows installed policies deny today and edges
+// no rule could be generated for.
+func WriteYAML(w io.Writer, result Result, flavor Flavor) error {
+	for i, obj := range Render(result.Policies, flavor) {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("marshal policy: %w", err)
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	if len(result.Denied) > 0 {
+		if _, err := io.WriteString(w, "# Observed flows denied by currently installed NetworkPolicies:\n"); err != nil {
+			return err
+		}
+		for _, d := range result.Denied {
+			if _, err := fmt.Fprintf(w, "#   %s %s -> %s %s/%d (%v)\n", d.Direction, nodeString(d.Src), nodeString(d.Dst), d.Protocol, d.Port, d.Policies); err != nil {
+				return err
+			}
+		}
+	}
+	if len(result.Skipped) > 0 {
+		if _, err := io.WriteString(w, "# Observed edges without a generated rule:\n"); err != nil {
+			return err
+		}
+		for _, s := range result.Skipped {
+			if _, err := fmt.Fprintf(w, "#   %s -> %s :%d: %s\n", nodeString(s.Src), nodeString(s.Dst), s.Port, s.Reason); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func nodeString(n graph.Node) string {
+	if n.Namespace == "" {
+		return n.Kind + "/" + n.Name
+	}
+	return n.Namespace + "/" + n.Kind + "/" + n.Name
+}
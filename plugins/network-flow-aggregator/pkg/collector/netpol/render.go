@@ -0,0 +1,257 @@
+package netpol
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// Render converts policies into manifests of the requested flavor. A direction
+// is only isolated when traffic was observed in it, so workloads with no
+// observed inbound flows keep accepting ingress.
+func Render(policies []Policy, flavor Flavor) []any {
+	out := make([]any, 0, len(policies))
+	for _, p := range policies {
+		switch flavor {
+		case FlavorCilium:
+			out = append(out, toCilium(p))
+		case FlavorCalico:
+			out = append(out, toCalico(p))
+		default:
+			out = append(out, ToKubernetes(p))
+		}
+	}
+	return out
+}
+
+func ToKubernetes(p Policy) *networkingv1.NetworkPolicy {
+	np := &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      p.Name,
+			Namespace: p.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "network-flow-aggregator"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: p.Selector},
+		},
+	}
+	if len(p.Ingress) > 0 {
+		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, networkingv1.PolicyTypeIngress)
+		for _, rule := range p.Ingress {
+			np.Spec.Ingress = append(np.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+				From:  kubernetesPeers(rule.Peer, p.Namespace),
+				Ports: kubernetesPorts(rule.Ports),
+			})
+		}
+	}
+	if len(p.Egress) > 0 {
+		np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		for _, rule := range p.Egress {
+			np.Spec.Egress = append(np.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+				To:    kubernetesPeers(rule.Peer, p.Namespace),
+				Ports: kubernetesPorts(rule.Ports),
+			})
+		}
+	}
+	return np
+}
+
+func kubernetesPeers(peer Peer, policyNamespace string) []networkingv1.NetworkPolicyPeer {
+	if len(peer.CIDRs) > 0 {
+		out := make([]networkingv1.NetworkPolicyPeer, 0, len(peer.CIDRs))
+		for _, cidr := range peer.CIDRs {
+			out = append(out, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		return out
+	}
+	np := networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: peer.PodLabels},
+	}
+	if peer.Namespace != policyNamespace {
+		np.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{namespaceNameLabel: peer.Namespace}}
+	}
+	return []networkingv1.NetworkPolicyPeer{np}
+}
+
+func kubernetesPorts(ports []Port) []networkingv1.NetworkPolicyPort {
+	out := make([]networkingv1.NetworkPolicyPort, 0, len(ports))
+	for _, p := range ports {
+		proto := corev1.Protocol(p.Protocol)
+		port := p.Port
+		out = append(out, networkingv1.NetworkPolicyPort{Protocol: &proto, Port: &port})
+	}
+	return out
+}
+
+func toCilium(p Policy) map[string]any {
+	spec := map[string]any{
+		"endpointSelector": map[string]any{"matchLabels": p.Selector},
+	}
+	if len(p.Ingress) > 0 {
+		rules := make([]any, 0, len(p.Ingress))
+		for _, rule := range p.Ingress {
+			rules = append(rules, map[string]any{
+				"fromEndpoints": []any{ciliumEndpoint(rule.Peer)},
+				"toPorts":       ciliumPorts(rule, false),
+			})
+		}
+		spec["ingress"] = rules
+	}
+	if len(p.Egress) > 0 {
+		dnsKey := peerKey(dnsPeer())
+		rules := make([]any, 0, len(p.Egress))
+		for _, rule := range p.Egress {
+			r := map[string]any{"toPorts": ciliumPorts(rule, peerKey(rule.Peer) == dnsKey)}
+			switch {
+			case rule.Peer.Hostname != "":
+				r["toFQDNs"] = []any{map[string]any{"matchName": rule.Peer.Hostname}}
+			case len(rule.Peer.CIDRs) > 0:
+				r["toCIDR"] = rule.Peer.CIDRs
+			default:
+				r["toEndpoints"] = []any{ciliumEndpoint(rule.Peer)}
+			}
+			rules = append(rules, r)
+		}
+		spec["egress"] = rules
+	}
+	return map[string]any{
+		"apiVersion": "cilium.io/v2",
+		"kind":       "CiliumNetworkPolicy",
+		"metadata":   policyMetadata(p),
+		"spec":       spec,
+	}
+}
+
+func ciliumEndpoint(peer Peer) map[string]any {
+	labels := make(map[string]string, len(peer.PodLabels)+1)
+	for k, v := range peer.PodLabels {
+		labels[k] = v
+	}
+	labels["k8s:io.kubernetes.pod.namespace"] = peer.Namespace
+	return map[string]any{"matchLabels": labels}
+}
+
+// ciliumPorts renders a rule's ports. The DNS rule carries an L7 DNS policy so
+// Cilium's proxy can learn the IPs behind toFQDNs rules.
+func ciliumPorts(rule Rule, dns bool) []any {
+	ports := make([]any, 0, len(rule.Ports))
+	for _, p := range rule.Ports {
+		ports = append(ports, map[string]any{"port": p.Port.String(), "protocol": p.Protocol})
+	}
+	entry := map[string]any{"ports": ports}
+	if dns {
+		entry["rules"] = map[string]any{"dns": []any{map[string]any{"matchPattern": "*"}}}
+	}
+	return []any{entry}
+}
+
+func toCalico(p Policy) map[string]any {
+	spec := map[string]any{
+		"selector": calicoSelector(p.Selector),
+	}
+	var types []string
+	if len(p.Ingress) > 0 {
+		types = append(types, "Ingress")
+		var rules []any
+		for _, rule := range p.Ingress {
+			byProto := portsByProtocol(rule.Ports)
+			for _, proto := range sortedProtocols(byProto) {
+				rules = append(rules, map[string]any{
+					"action":      "Allow",
+					"protocol":    proto,
+					"source":      calicoEntity(rule.Peer, nil),
+					"destination": map[string]any{"ports": byProto[proto]},
+				})
+			}
+		}
+		spec["ingress"] = rules
+	}
+	if len(p.Egress) > 0 {
+		types = append(types, "Egress")
+		var rules []any
+		for _, rule := range p.Egress {
+			byProto := portsByProtocol(rule.Ports)
+			for _, proto := range sortedProtocols(byProto) {
+				rules = append(rules, map[string]any{
+					"action":      "Allow",
+					"protocol":    proto,
+					"destination": calicoEntity(rule.Peer, byProto[proto]),
+				})
+			}
+		}
+		spec["egress"] = rules
+	}
+	spec["types"] = types
+	return map[string]any{
+		"apiVersion": "projectcalico.org/v3",
+		"kind":       "NetworkPolicy",
+		"metadata":   policyMetadata(p),
+		"spec":       spec,
+	}
+}
+
+func calicoEntity(peer Peer, ports []any) map[string]any {
+	entity := map[string]any{}
+	if len(peer.CIDRs) > 0 {
+		entity["nets"] = peer.CIDRs
+	} else {
+		entity["selector"] = calicoSelector(peer.PodLabels)
+		entity["namespaceSelector"] = "projectcalico.org/name == '" + peer.Namespace + "'"
+	}
+	if len(ports) > 0 {
+		entity["ports"] = ports
+	}
+	return entity
+}
+
+func calicoSelector(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+" == '"+labels[k]+"'")
+	}
+	if len(parts) == 0 {
+		return "all()"
+	}
+	return strings.Join(parts, " && ")
+}
+
+func portsByProtocol(ports []Port) map[string][]any {
+	out := make(map[string][]any)
+	for _, p := range ports {
+		if p.Port.Type == intstr.String {
+			out[p.Protocol] = append(out[p.Protocol], p.Port.StrVal)
+		} else {
+			out[p.Protocol] = append(out[p.Protocol], p.Port.IntVal)
+		}
+	}
+	return out
+}
+
+func sortedProtocols(byProto map[string][]any) []string {
+	out := make([]string, 0, len(byProto))
+	for proto := range byProto {
+		out = append(out, proto)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func policyMetadata(p Policy) map[string]any {
+	return map[string]any{
+		"name":      p.Name,
+		"namespace": p.Namespace,
+		"labels":    map[string]string{"app.kubernetes.io/managed-by": "network-flow-aggregator"},
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/dns"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/egress"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/graph"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/kube"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/peerindex"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/store"
@@ -30,11 +32,15 @@ type Server struct {
 	enricher  flowEnricher
 	dnsCache  *dns.Cache
 	peerIndex *peerindex.Index
+	graph     *graph.Graph
+	egress    *egress.Inventory
 	upstream  *upstream.Client
 	log       *slog.Logger
 }
 
-func NewServer(st *store.Store, enricher *kube.Enricher, dnsCache *dns.Cache, upstreamClient *upstream.Client, log *slog.Logger) *Server {
+// NewServer wires ingestion. upstreamClient is notified on raw appends and should
+// be nil when upstream delivery is fed from graph rollups instead.
+func NewServer(st *store.Store, enricher *kube.Enricher, dnsCache *dns.Cache, g *graph.Graph, inventory *egress.Inventory, upstreamClient *upstream.Client, log *slog.Logger) *Server {
 	if log == nil {
 		log = slog.Default()
 	}
@@ -43,6 +49,8 @@ func NewServer(st *store.Store, enricher *kube.Enricher, dnsCache *dns.Cache, up
 		enricher:  enricher,
 		dnsCache:  dnsCache,
 		peerIndex: peerindex.New(defaultPeerIndexTTL),
+		graph:     g,
+		egress:    inventory,
 		upstream:  upstreamClient,
 		log:       log,
 	}
@@ -59,7 +67,9 @@ func (s *Server) PushEvents(stream aggregv1.AgentIngest_PushEventsServer) error
 			return status.Errorf(codes.Internal, "recv batch: %v", err)
 		}
 
-		accepted, _ := s.store.AppendBatch(batch, s.enrichEvent)
+		accepted, rows := s.store.AppendBatch(batch, s.enrichEvent)
+		s.graph.Observe(rows)
+		s.egress.Observe(rows)
 		if s.upstream != nil && accepted > 0 {
 			s.upstream.NotifyAppended()
 		}
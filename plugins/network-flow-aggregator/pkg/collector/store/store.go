@@ -32,9 +32,20 @@ type ListOpts struct {
 	DstKind         string
 }
 
+// Log persists appended rows so unsent events survive restarts. Append
+// returns the sequence number of the first row; Ack marks every sequence below
+// next as delivered. See package wal.
+type Log interface {
+	Append(rows []*insightsv1.EnrichedFlowEvent) uint64
+	Ack(next uint64)
+	Replay(fn func(seq uint64, event *insightsv1.EnrichedFlowEvent)) error
+}
+
 type Store struct {
 	mu             sync.RWMutex
 	events         []*insightsv1.EnrichedFlowEvent
+	log            Log
+	seqs           []uint64 // log sequence per event, only with a log attached
 	maxEvents      int
 	maxAge         time.Duration
 	sendCursor     int
@@ -60,6 +71,31 @@ func (s *Store) MaxAge() time.Duration {
 	return s.maxAge
 }
 
+// AttachLog replays unacknowledged events from l as unsent and writes every
+// later append to it. Call it before ingestion starts. With a log attached,
+// max age only evicts events already sent; unsent events are bounded by max
+// events and the log's own caps.
+func (s *Store) AttachLog(l Log) (int, error) {
+	var restored []*insightsv1.EnrichedFlowEvent
+	var seqs []uint64
+	err := l.Replay(func(seq uint64, event *insightsv1.EnrichedFlowEvent) {
+		restored = append(restored, event)
+		seqs = append(seqs, seq)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = l
+	s.events = restored
+	s.seqs = seqs
+	s.sendCursor = 0
+	s.enforceMaxLocked()
+	return len(s.events), nil
+}
+
 func (s *Store) AppendBatch(batch *aggregv1.FlowEventBatch, enrich func(*aggregv1.FlowEvent) Enrichment) (int64, []*insightsv1.EnrichedFlowEvent) {
 	if batch == nil {
 		return 0, nil
@@ -93,10 +129,36 @@ func (s *Store) AppendBatch(batch *aggregv1.FlowEventBatch, enrich func(*aggregv
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.appendLocked(rows)
+	return int64(len(rows)), rows
+}
+
+// AppendEnriched stores already-enriched rows, e.g. graph rollups queued for
+// upstream delivery. Retention applies as for AppendBatch.
+func (s *Store) AppendEnriched(rows []*insightsv1.EnrichedFlowEvent) int64 {
+	if len(rows) == 0 {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.appendLocked(rows)
+	return int64(len(rows))
+}
+
+// appendLocked writes rows to the log under the store lock so log order
+// matches store order.
+func (s *Store) appendLocked(rows []*insightsv1.EnrichedFlowEvent) {
+	if s.log != nil {
+		first := s.log.Append(rows)
+		for i := range rows {
+			s.seqs = append(s.seqs, first+uint64(i))
+		}
+	}
 	s.events = append(s.events, rows...)
 	s.pruneLocked()
 	s.enforceMaxLocked()
-	return int64(len(rows)), rows
 }
 
 func enrichedFromEvent(nodeName, agentID string, event *aggregv1.FlowEvent, enrich Enrichment) *insightsv1.EnrichedFlowEvent {
@@ -160,11 +222,13 @@ func (s *Store) pruneLocked() {
 	cutoff := time.Now().Add(-s.maxAge).UnixNano()
 	start := 0
 	for start < len(s.events) && s.events[start].GetTimestampUnixNano() < cutoff {
+		if s.log != nil && start >= s.sendCursor {
+			break
+		}
 		start++
 	}
 	if start > 0 {
-		s.events = append([]*insightsv1.EnrichedFlowEvent(nil), s.events[start:]...)
-		s.adjustCursorOnDrop(start, "max_age")
+		s.dropFrontLocked(start, "max_age")
 	}
 }
 
@@ -173,8 +237,15 @@ func (s *Store) enforceMaxLocked() {
 	if overflow <= 0 {
 		return
 	}
-	s.events = append([]*insightsv1.EnrichedFlowEvent(nil), s.events[overflow:]...)
-	s.adjustCursorOnDrop(overflow, "max_events")
+	s.dropFrontLocked(overflow, "max_events")
+}
+
+func (s *Store) dropFrontLocked(n int, reason string) {
+	s.events = append([]*insightsv1.EnrichedFlowEvent(nil), s.events[n:]...)
+	if s.log != nil {
+		s.seqs = append([]uint64(nil), s.seqs[n:]...)
+	}
+	s.adjustCursorOnDrop(n, reason)
 }
 
 func (s *Store) adjustCursorOnDrop(droppedFromFront int, reason string) {
@@ -273,11 +344,25 @@ func (s *Store) AdvanceSendCursor(n int) {
 		return
 	}
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.sendCursor += n
 	if s.sendCursor > len(s.events) {
 		s.sendCursor = len(s.events)
 	}
+	l, next, ok := s.log, uint64(0), false
+	if l != nil && len(s.seqs) > 0 {
+		if s.sendCursor < len(s.seqs) {
+			next = s.seqs[s.sendCursor]
+		} else {
+			next = s.seqs[len(s.seqs)-1] + 1
+		}
+		ok = true
+	}
+	s.mu.Unlock()
+
+	// The log persists its cursor file; keep that I/O off the store lock.
+	if ok {
+		l.Ack(next)
+	}
 }
 
 func (s *Store) TakeDroppedUnsent() (count int64, reason string) {
@@ -350,3 +350,72 @@ func sampleEvent(ts int64, sent, received uint64) *aggregv1.FlowEvent {
 		BytesReceived:     received,
 	}
 }
+
+type memLog struct {
+	rows  []*insightsv1.EnrichedFlowEvent
+	acked uint64
+}
+
+func (m *memLog) Append(rows []*insightsv1.EnrichedFlowEvent) uint64 {
+	first := uint64(len(m.rows))
+	m.rows = append(m.rows, rows...)
+	return first
+}
+
+func (m *memLog) Ack(next uint64) {
+	m.acked = next
+}
+
+func (m *memLog) Replay(fn func(uint64, *insightsv1.EnrichedFlowEvent)) error {
+	for i := m.acked; i < uint64(len(m.rows)); i++ {
+		fn(i, m.rows[i])
+	}
+	return nil
+}
+
+func TestAttachLogReplaysAndAcks(t *testing.T) {
+	log := &memLog{}
+	st := NewStore(1000, time.Minute)
+	if _, err := st.AttachLog(log); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UnixNano()
+	st.AppendBatch(&aggregv1.FlowEventBatch{Events: []*aggregv1.FlowEvent{
+		sampleEvent(now, 0, 0), sampleEvent(now, 0, 0), sampleEvent(now, 0, 0),
+	}}, nil)
+	st.AdvanceSendCursor(2)
+	if log.acked != 2 {
+		t.Fatalf("acked = %d", log.acked)
+	}
+
+	restarted := NewStore(1000, time.Minute)
+	restored, err := restarted.AttachLog(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != 1 || restarted.UnsentCount() != 1 {
+		t.Fatalf("restored = %d, unsent = %d", restored, restarted.UnsentCount())
+	}
+	restarted.AdvanceSendCursor(1)
+	if log.acked != 3 {
+		t.Fatalf("acked after restart = %d", log.acked)
+	}
+}
+
+func TestAttachLogKeepsUnsentPastMaxAge(t *testing.T) {
+	st := NewStore(1000, time.Minute)
+	if _, err := st.AttachLog(&memLog{}); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour).UnixNano()
+	st.AppendBatch(&aggregv1.FlowEventBatch{Events: []*aggregv1.FlowEvent{sampleEvent(old, 0, 0)}}, nil)
+	st.AppendBatch(&aggregv1.FlowEventBatch{Events: []*aggregv1.FlowEvent{sampleEvent(time.Now().UnixNano(), 0, 0)}}, nil)
+	if st.UnsentCount() != 2 {
+		t.Fatalf("unsent = %d", st.UnsentCount())
+	}
+	st.AdvanceSendCursor(1)
+	st.AppendBatch(&aggregv1.FlowEventBatch{Events: []*aggregv1.FlowEvent{sampleEvent(time.Now().UnixNano(), 0, 0)}}, nil)
+	if st.Count() != 2 {
+		t.Fatalf("count = %d", st.Count())
+	}
+}
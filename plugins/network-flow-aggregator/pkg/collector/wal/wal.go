@@ -0,0 +1,448 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+const (
+	segmentSuffix  = ".wal"
+	cursorFile     = "cursor"
+	headerSize     = 16 // length uint32 + crc32 uint32 + seq uint64
+	maxRecordBytes = 16 << 20
+	errorLogPeriod = time.Minute
+)
+
+type Options struct {
+	Dir          string
+	SegmentBytes int64
+	MaxBytes     int64
+	MaxAge       time.Duration
+}
+
+type segment struct {
+	firstSeq uint64
+	path     string
+	size     int64
+	modTime  time.Time
+}
+
+// Log is a disk-backed, segmented log of enriched flow events. Every record
+// carries its sequence number; the cursor file records the first sequence not
+// yet acknowledged upstream. Writes are not fsynced per append, so the log
+// survives process restarts and rollouts but not a node crash.
+type Log struct {
+	opts Options
+	log  *slog.Logger
+
+	mu         sync.Mutex
+	segments   []segment
+	active     *os.File
+	activeSize int64
+	nextSeq    uint64
+	acked      uint64
+	closed     bool
+	errLogNext time.Time
+}
+
+func Open(opts Options, log *slog.Logger) (*Log, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("wal dir is required")
+	}
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = 64 << 20
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 1 << 30
+	}
+	if opts.MaxBytes < opts.SegmentBytes {
+		opts.SegmentBytes = opts.MaxBytes
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+	if log == nil {
+		log = slog.Default()
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create wal dir: %w", err)
+	}
+
+	l := &Log{opts: opts, log: log}
+	acked, err := readCursor(filepath.Join(opts.Dir, cursorFile))
+	if err != nil {
+		return nil, err
+	}
+	l.acked = acked
+
+	segments, err := listSegments(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	l.segments = segments
+	l.nextSeq = acked
+	for _, seg := range segments {
+		if seg.firstSeq > l.nextSeq {
+			l.nextSeq = seg.firstSeq
+		}
+	}
+	if n := len(segments); n > 0 {
+		if last := lastSeq(segments[n-1].path); last != nil && *last+1 > l.nextSeq {
+			l.nextSeq = *last + 1
+		}
+	}
+
+	// Always start a fresh segment: the previous one may end in a torn write.
+	if err := l.rollLocked(); err != nil {
+		return nil, err
+	}
+	l.compactLocked(time.Now())
+	return l, nil
+}
+
+// Append writes rows and returns the sequence number of the first one.
+// Sequence numbers are assigned even when the write fails so callers can
+// keep a contiguous mapping; failures are logged and the rows are simply not
+// durable.
+func (l *Log) Append(rows []*insightsv1.EnrichedFlowEvent) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed && l.active == nil {
+		if err := l.rollLocked(); err != nil {
+			l.logErrorLocked("open wal segment", err)
+		}
+	}
+	first := l.nextSeq
+	l.nextSeq += uint64(len(rows))
+	if l.closed || l.active == nil || len(rows) == 0 {
+		return first
+	}
+
+	buf := make([]byte, 0, 256*len(rows))
+	for i, row := range rows {
+		payload, err := proto.Marshal(row)
+		if err != nil {
+			l.logErrorLocked("marshal wal record", err)
+			continue
+		}
+		var header [headerSize]byte
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(payload))
+		binary.BigEndian.PutUint64(header[8:16], first+uint64(i))
+		buf = append(buf, header[:]...)
+		buf = append(buf, payload...)
+	}
+
+	n, err := l.active.Write(buf)
+	l.activeSize += int64(n)
+	if err != nil {
+		l.logErrorLocked("write wal segment", err)
+		// Start over in a fresh segment so later records are not stuck behind
+		// a partial one.
+		if err := l.rollLocked(); err != nil {
+			l.logErrorLocked("roll wal segment", err)
+		}
+		return first
+	}
+	if l.activeSize >= l.opts.SegmentBytes {
+		if err := l.rollLocked(); err != nil {
+			l.logErrorLocked("roll wal segment", err)
+		}
+		l.compactLocked(time.Now())
+	}
+	return first
+}
+
+// Ack records that every sequence number below next has been delivered, then
+// deletes segments that hold only acknowledged records.
+func (l *Log) Ack(next uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || next <= l.acked {
+		return
+	}
+	l.acked = next
+	if err := writeCursor(filepath.Join(l.opts.Dir, cursorFile), next); err != nil {
+		l.logErrorLocked("write wal cursor", err)
+	}
+	l.compactLocked(time.Now())
+}
+
+// Replay calls fn for every unacknowledged record younger than MaxAge, in
+// sequence order. A corrupt or truncated record ends replay of its segment.
+func (l *Log) Replay(fn func(seq uint64, event *insightsv1.EnrichedFlowEvent)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := time.Now().Add(-l.opts.MaxAge).UnixNano()
+	for _, seg := range l.segments {
+		if l.active != nil && seg.path == l.active.Name() {
+			continue
+		}
+		err := readSegment(seg.path, func(seq uint64, payload []byte) error {
+			if seq < l.acked {
+				return nil
+			}
+			event := &insightsv1.EnrichedFlowEvent{}
+			if err := proto.Unmarshal(payload, event); err != nil {
+				return err
+			}
+			if event.GetTimestampUnixNano() < cutoff {
+				return nil
+			}
+			fn(seq, event)
+			return nil
+		})
+		if err != nil {
+			l.log.Warn("wal segment truncated; skipping remainder", "segment", seg.path, "err", err)
+		}
+	}
+	return nil
+}
+
+// Pending reports the bytes held in segments, including acknowledged records
+// not yet compacted.
+func (l *Log) Pending() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var total int64
+	for _, seg := range l.segments {
+		total += l.segmentSizeLocked(seg)
+	}
+	return total
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	if l.active == nil {
+		return nil
+	}
+	if err := l.active.Sync(); err != nil {
+		_ = l.active.Close()
+		return err
+	}
+	return l.active.Close()
+}
+
+func (l *Log) rollLocked() error {
+	if l.active != nil {
+		if err := l.active.Sync(); err != nil {
+			l.logErrorLocked("sync wal segment", err)
+		}
+		if err := l.active.Close(); err != nil {
+			l.logErrorLocked("close wal segment", err)
+		}
+		l.setSegmentSizeLocked(l.active.Name(), l.activeSize)
+	}
+	path := filepath.Join(l.opts.Dir, segmentName(l.nextSeq))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		l.active = nil
+		return fmt.Errorf("open wal segment: %w", err)
+	}
+	l.active = f
+	l.activeSize = 0
+	kept := l.segments[:0]
+	for _, seg := range l.segments {
+		if seg.path != path {
+			kept = append(kept, seg)
+		}
+	}
+	l.segments = append(kept, segment{firstSeq: l.nextSeq, path: path, modTime: time.Now()})
+	return nil
+}
+
+// compactLocked deletes closed segments that are fully acknowledged, older than
+// MaxAge, or needed to get back under MaxBytes (oldest first).
+func (l *Log) compactLocked(now time.Time) {
+	var total int64
+	for _, seg := range l.segments {
+		total += l.segmentSizeLocked(seg)
+	}
+
+	kept := make([]segment, 0, len(l.segments))
+	for i, seg := range l.segments {
+		isActive := l.active != nil && seg.path == l.active.Name()
+		if isActive {
+			kept = append(kept, seg)
+			continue
+		}
+		// Segments are ordered; a closed segment ends right before the next begins.
+		nextFirst := l.nextSeq
+		if i+1 < len(l.segments) {
+			nextFirst = l.segments[i+1].firstSeq
+		}
+		reason := ""
+		switch {
+		case nextFirst <= l.acked:
+			reason = "acknowledged"
+		case now.Sub(seg.modTime) > l.opts.MaxAge:
+			reason = "max_age"
+		case total > l.opts.MaxBytes:
+			reason = "max_bytes"
+		}
+		if reason == "" {
+			kept = append(kept, seg)
+			continue
+		}
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			l.logErrorLocked("remove wal segment", err)
+			kept = append(kept, seg)
+			continue
+		}
+		total -= seg.size
+		if reason != "acknowledged" {
+			l.log.Warn("wal segment dropped with unsent events", "segment", seg.path, "reason", reason)
+		}
+	}
+	l.segments = kept
+}
+
+func (l *Log) segmentSizeLocked(seg segment) int64 {
+	if l.active != nil && seg.path == l.active.Name() {
+		return l.activeSize
+	}
+	return seg.size
+}
+
+func (l *Log) setSegmentSizeLocked(path string, size int64) {
+	for i := range l.segments {
+		if l.segments[i].path == path {
+			l.segments[i].size = size
+			l.segments[i].modTime = time.Now()
+		}
+	}
+}
+
+func (l *Log) logErrorLocked(msg string, err error) {
+	now := time.Now()
+	if now.Before(l.errLogNext) {
+		return
+	}
+	l.errLogNext = now.Add(errorLogPeriod)
+	l.log.Warn(msg, "dir", l.opts.Dir, "err", err)
+}
+
+func segmentName(firstSeq uint64) string {
+	return fmt.Sprintf("%020d%s", firstSeq, segmentSuffix)
+}
+
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read wal dir: %w", err)
+	}
+	var out []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat wal segment: %w", err)
+		}
+		out = append(out, segment{
+			firstSeq: first,
+			path:     filepath.Join(dir, name),
+			size:     info.Size(),
+			modTime:  info.ModTime(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].firstSeq < out[j].firstSeq })
+	return out, nil
+}
+
+func readSegment(path string, fn func(seq uint64, payload []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var header [headerSize]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		if length > maxRecordBytes {
+			return fmt.Errorf("record length %d exceeds limit", length)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return errors.New("record checksum mismatch")
+		}
+		if err := fn(binary.BigEndian.Uint64(header[8:16]), payload); err != nil {
+			return err
+		}
+	}
+}
+
+// lastSeq returns the sequence of the last readable record; a corrupt tail is
+// expected after a crash and ends the scan.
+func lastSeq(path string) *uint64 {
+	var last *uint64
+	_ = readSegment(path, func(seq uint64, _ []byte) error {
+		s := seq
+		last = &s
+		return nil
+	})
+	return last
+}
+
+func readCursor(path string) (uint64, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read wal cursor: %w", err)
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse wal cursor: %w", err)
+	}
+	return v, nil
+}
+
+func writeCursor(path string, next uint64) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(next, 10)+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,160 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	insightsv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/insights/v1"
+)
+
+func rows(n int, ts time.Time) []*insightsv1.EnrichedFlowEvent {
+	out := make([]*insightsv1.EnrichedFlowEvent, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, &insightsv1.EnrichedFlowEvent{
+			NodeName:          "node-a",
+			TimestampUnixNano: ts.UnixNano(),
+			Dst:               &insightsv1.Endpoint{Addr: "10.0.0.1", Port: int32(8000 + i)},
+		})
+	}
+	return out
+}
+
+func replayed(t *testing.T, l *Log) []uint64 {
+	t.Helper()
+	var seqs []uint64
+	if err := l.Replay(func(seq uint64, _ *insightsv1.EnrichedFlowEvent) { seqs = append(seqs, seq) }); err != nil {
+		t.Fatal(err)
+	}
+	return seqs
+}
+
+func TestReplayFromCursorAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(Options{Dir: dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := l.Append(rows(3, time.Now())); first != 0 {
+		t.Fatalf("first = %d", first)
+	}
+	if first := l.Append(rows(2, time.Now())); first != 3 {
+		t.Fatalf("first = %d", first)
+	}
+	l.Ack(2)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = Open(Options{Dir: dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	got := replayed(t, l)
+	if len(got) != 3 || got[0] != 2 || got[2] != 4 {
+		t.Fatalf("replayed = %v", got)
+	}
+	if first := l.Append(rows(1, time.Now())); first != 5 {
+		t.Fatalf("first after restart = %d", first)
+	}
+}
+
+func TestReplaySkipsTornTail(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(Options{Dir: dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append(rows(2, time.Now()))
+	path := l.active.Name()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte{0, 0, 0, 9, 1, 2})
+	_ = f.Close()
+
+	l, err = Open(Options{Dir: dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := replayed(t, l); len(got) != 2 {
+		t.Fatalf("replayed = %v", got)
+	}
+	if first := l.Append(rows(1, time.Now())); first != 2 {
+		t.Fatalf("first after torn tail = %d", first)
+	}
+}
+
+func TestCompactsAcknowledgedSegments(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(Options{Dir: dir, SegmentBytes: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 3; i++ {
+		l.Append(rows(1, time.Now()))
+	}
+	if n := segmentFiles(t, dir); n != 4 {
+		t.Fatalf("segments before ack = %d", n)
+	}
+	l.Ack(2)
+	if n := segmentFiles(t, dir); n != 2 {
+		t.Fatalf("segments after ack = %d", n)
+	}
+}
+
+func TestMaxBytesDropsOldestSegments(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(Options{Dir: dir, SegmentBytes: 1, MaxBytes: 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for i := 0; i < 10; i++ {
+		l.Append(rows(1, time.Now()))
+	}
+	if pending := l.Pending(); pending > 100 {
+		t.Fatalf("pending = %d", pending)
+	}
+	got := replayed(t, l)
+	if len(got) == 0 || got[len(got)-1] != 9 {
+		t.Fatalf("replayed = %v", got)
+	}
+}
+
+func TestReplaySkipsEventsOlderThanMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(Options{Dir: dir, MaxAge: time.Hour}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append(rows(1, time.Now().Add(-2*time.Hour)))
+	l.Append(rows(1, time.Now()))
+	_ = l.Close()
+
+	l, err = Open(Options{Dir: dir, MaxAge: time.Hour}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if got := replayed(t, l); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("replayed = %v", got)
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) int {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(matches)
+}
@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,12 +21,20 @@ import (
 	aggregv1 "github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/aggregator/v1"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/dns"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/egress"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/graph"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/kube"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/netpol"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/store"
 	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/upstream"
+	"github.com/fairwindsops/insights-plugins/plugins/network-flow-aggregator/pkg/collector/wal"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "netpol" {
+		os.Exit(runNetpol(os.Args[2:]))
+	}
+
 	grpcAddr := flag.String("grpc-addr", envOr("GRPC_ADDR", ":4317"), "gRPC listen address")
 	httpAddr := flag.String("http-addr", envOr("HTTP_ADDR", ":8080"), "debug HTTP listen address")
 	kubeconfig := flag.String("kubeconfig", envOr("KUBECONFIG", ""), "path to kubeconfig; in-cluster config is used when empty")
@@ -40,17 +52,57 @@ func main() {
 	upstreamFlushInterval := flag.Duration("upstream-flush-interval", parseDurationEnv("UPSTREAM_FLUSH_INTERVAL", 10*time.Second), "Insights upstream flush interval")
 	reconnectBackoffMin := flag.Duration("reconnect-backoff-min", parseDurationEnv("RECONNECT_BACKOFF_MIN", time.Second), "minimum gRPC reconnect backoff")
 	reconnectBackoffMax := flag.Duration("reconnect-backoff-max", parseDurationEnv("RECONNECT_BACKOFF_MAX", 30*time.Second), "maximum gRPC reconnect backoff")
+	upstreamMode := flag.String("upstream-mode", envOr("UPSTREAM_MODE", "events"), "what to send to Insights: events (raw enriched events) or graph (edge rollups)")
+	graphRetention := flag.Duration("graph-retention", parseDurationEnv("GRAPH_RETENTION", 24*time.Hour), "drop graph edges not seen for this long")
+	graphMaxEdges := flag.Int("graph-max-edges", parseIntEnv("GRAPH_MAX_EDGES", 50_000), "maximum graph edges; the least recently seen edge is evicted beyond it")
+	graphRollupInterval := flag.Duration("graph-rollup-interval", parseDurationEnv("GRAPH_ROLLUP_INTERVAL", time.Minute), "interval between graph edge rollups")
+	walDir := flag.String("wal-dir", envOr("WAL_DIR", ""), "directory for the write-ahead log of unsent upstream events; disabled when empty")
+	walMaxBytes := flag.Int("wal-max-bytes", parseIntEnv("WAL_MAX_BYTES", 1<<30), "maximum write-ahead log size in bytes; oldest segments are dropped beyond it")
+	walMaxAge := flag.Duration("wal-max-age", parseDurationEnv("WAL_MAX_AGE", 24*time.Hour), "maximum age of write-ahead log segments and replayed events")
+	walSegmentBytes := flag.Int("wal-segment-bytes", parseIntEnv("WAL_SEGMENT_BYTES", 64<<20), "write-ahead log segment size in bytes")
+	insightsHost := flag.String("insights-host", envOr("FAIRWINDS_INSIGHTS_HOST", ""), "Insights HTTP API base URL for report uploads; egress report upload is disabled when empty")
+	egressRetention := flag.Duration("egress-retention", parseDurationEnv("EGRESS_RETENTION", 7*24*time.Hour), "drop external egress destinations not seen for this long")
+	egressBaseline := flag.Duration("egress-baseline", parseDurationEnv("EGRESS_BASELINE", time.Hour), "learning period after startup before new egress destinations are flagged")
+	egressNewFor := flag.Duration("egress-new-for", parseDurationEnv("EGRESS_NEW_FOR", 24*time.Hour), "how long a new egress destination stays flagged")
+	egressMaxDestinations := flag.Int("egress-max-destinations", parseIntEnv("EGRESS_MAX_DESTINATIONS", 100_000), "maximum tracked egress destinations; the least recently seen one is evicted beyond it")
+	egressReportInterval := flag.Duration("egress-report-interval", parseDurationEnv("EGRESS_REPORT_INTERVAL", time.Hour), "interval between egress report uploads")
+	egressReportWarmUp := flag.Duration("egress-report-warmup", parseDurationEnv("EGRESS_REPORT_WARMUP", 5*time.Minute), "delay before the first egress report upload")
+	cloudRangesDefault := egress.DefaultCloudRangeSources
+	if v, ok := os.LookupEnv("EGRESS_CLOUD_RANGES"); ok {
+		cloudRangesDefault = v
+	}
+	egressCloudRanges := flag.String("egress-cloud-ranges", cloudRangesDefault, "comma-separated provider=url-or-file list of published cloud IP ranges (aws, gcp, azure); empty classifies cloud destinations by hostname only")
+	egressCloudRangesRefresh := flag.Duration("egress-cloud-ranges-refresh", parseDurationEnv("EGRESS_CLOUD_RANGES_REFRESH", 24*time.Hour), "interval between reloads of the published cloud IP ranges")
 	logLevel := flag.String("log-level", envOr("LOG_LEVEL", "info"), "log level")
 	flag.Parse()
 
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: parseLogLevel(*logLevel)}))
 	st := store.NewStore(*maxEvents, *maxAge)
 	dnsCache := dns.NewCache(*maxAge)
+	flowGraph := graph.New(*graphRetention, *graphMaxEdges)
+	cloudRangeSources, err := egress.ParseCloudRangeSources(*egressCloudRanges)
+	if err != nil {
+		log.Error("invalid egress-cloud-ranges", "err", err)
+		os.Exit(1)
+	}
+	cloudRanges := egress.NewCloudRanges()
+	egressInventory := egress.New(egress.Options{
+		Retention:       *egressRetention,
+		Baseline:        *egressBaseline,
+		NewFor:          *egressNewFor,
+		MaxDestinations: *egressMaxDestinations,
+		CloudRanges:     cloudRanges,
+	}, log)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	if len(cloudRangeSources) > 0 {
+		go cloudRanges.Run(ctx, cloudRangeSources, *egressCloudRangesRefresh, log)
+	}
+
 	var enricher *kube.Enricher
+	var policyIndex *kube.PolicyIndex
 	if !*disableKube {
 		clients, err := kube.NewClients(ctx, *kubeconfig)
 		if err != nil {
@@ -59,11 +111,45 @@ func main() {
 			enricher, err = kube.NewEnricher(ctx, clients, log)
 			if err != nil {
 				log.Warn("kubernetes informers unavailable; running without enrichment", "err", err)
+			} else if policyIndex, err = kube.NewPolicyIndex(ctx, clients, log); err != nil {
+				log.Warn("networkpolicy informers unavailable; generated policies will not report denied flows", "err", err)
 			}
 		}
 	}
 
+	if *upstreamMode != "events" && *upstreamMode != "graph" {
+		log.Error("invalid upstream-mode; expected events or graph", "upstream_mode", *upstreamMode)
+		os.Exit(1)
+	}
+
 	var upstreamClient *upstream.Client
+	// In graph mode the upstream client drains a separate store fed by edge
+	// rollups; raw events stay local for the debug API.
+	upstreamStore := st
+	if *upstreamMode == "graph" && *insightsAddr != "" {
+		upstreamStore = store.NewStore(*maxEvents, *maxAge)
+	}
+	if *walDir != "" && *insightsAddr != "" {
+		walLog, err := wal.Open(wal.Options{
+			Dir:          *walDir,
+			SegmentBytes: int64(*walSegmentBytes),
+			MaxBytes:     int64(*walMaxBytes),
+			MaxAge:       *walMaxAge,
+		}, log)
+		if err != nil {
+			log.Error("open write-ahead log", "dir", *walDir, "err", err)
+			os.Exit(1)
+		}
+		defer walLog.Close()
+		restored, err := upstreamStore.AttachLog(walLog)
+		if err != nil {
+			log.Error("replay write-ahead log", "dir", *walDir, "err", err)
+			os.Exit(1)
+		}
+		log.Info("write-ahead log enabled", "dir", *walDir, "replayed_unsent", restored, "bytes", walLog.Pending())
+	} else if *walDir != "" {
+		log.Warn("wal-dir ignored without insights-grpc-addr", "dir", *walDir)
+	}
 	if *insightsAddr != "" {
 		if *organization == "" || *cluster == "" || *authToken == "" {
 			log.Error("insights upstream requires organization, cluster, and auth-token")
@@ -83,7 +169,7 @@ func main() {
 			FlushInterval:       *upstreamFlushInterval,
 			ReconnectBackoffMin: *reconnectBackoffMin,
 			ReconnectBackoffMax: *reconnectBackoffMax,
-		}, st, log)
+		}, upstreamStore, log)
 		go func() {
 			if err := upstreamClient.Run(ctx); err != nil && ctx.Err() == nil {
 				log.Error("insights upstream client stopped", "err", err)
@@ -92,8 +178,45 @@ func main() {
 		}()
 	}
 
+	rawUpstream := upstreamClient
+	if *upstreamMode == "graph" {
+		rawUpstream = nil
+		agentID, _ := os.Hostname()
+		var sink func([]graph.Edge)
+		if upstreamClient != nil {
+			sink = func(edges []graph.Edge) {
+				upstreamStore.AppendEnriched(graph.ToEnrichedEvents(agentID, edges))
+				upstreamClient.Flush()
+				log.Debug("graph rollup", "edges", len(edges), "total_edges", flowGraph.Count(), "evicted_edges", flowGraph.Evicted())
+			}
+		} else {
+			// Nothing would drain rollups without an upstream; the graph is
+			// still served from /api/v1/graph.
+			log.Warn("upstream-mode graph without insights-grpc-addr; edge rollups are not sent")
+		}
+		go flowGraph.Run(ctx, *graphRollupInterval, sink)
+	} else {
+		go flowGraph.Run(ctx, *graphRollupInterval, nil)
+	}
+
+	if *insightsHost != "" {
+		if *organization == "" || *cluster == "" || *authToken == "" {
+			log.Error("egress report upload requires organization, cluster, and auth-token")
+			os.Exit(1)
+		}
+		go egress.NewUploader(egress.UploadConfig{
+			Host:              *insightsHost,
+			Organization:      *organization,
+			Cluster:           *cluster,
+			Token:             *authToken,
+			AgentChartVersion: os.Getenv("FAIRWINDS_AGENT_CHART_VERSION"),
+			Interval:          *egressReportInterval,
+			WarmUp:            *egressReportWarmUp,
+		}, egressInventory, log).Run(ctx)
+	}
+
 	grpcServer := grpc.NewServer()
-	aggregv1.RegisterAgentIngestServer(grpcServer, collector.NewServer(st, enricher, dnsCache, upstreamClient, log))
+	aggregv1.RegisterAgentIngestServer(grpcServer, collector.NewServer(st, enricher, dnsCache, flowGraph, egressInventory, rawUpstream, log))
 
 	lis, err := net.Listen("tcp", *grpcAddr)
 	if err != nil {
@@ -110,7 +233,11 @@ func main() {
 	}()
 
 	mux := http.NewServeMux()
-	collector.NewDebugHTTPServer(st).Register(mux)
+	var policyGenerator *netpol.Generator
+	if enricher != nil {
+		policyGenerator = netpol.NewGenerator(flowGraph, kube.ClusterView{Enricher: enricher, PolicyIndex: policyIndex}, dnsCache)
+	}
+	collector.NewDebugHTTPServer(st, flowGraph, egressInventory, policyGenerator).Register(mux)
 	httpServer := &http.Server{Addr: *httpAddr, Handler: mux}
 
 	go func() {
@@ -168,3 +295,60 @@ func parseLogLevel(level string) slog.Level {
 	}
 	return slog.LevelInfo
 }
+
+// runNetpol implements `network-flow-aggregator netpol`: it asks a running
+// aggregator's HTTP API for policies generated from observed traffic and
+// prints them, so the usual workflow is a port-forward plus this command.
+func runNetpol(args []string) int {
+	fs := flag.NewFlagSet("netpol", flag.ContinueOnError)
+	addr := fs.String("addr", envOr("AGGREGATOR_HTTP_ADDR", "http://localhost:8080"), "aggregator HTTP base URL")
+	namespace := fs.String("namespace", "", "namespace to generate policies for (required)")
+	workload := fs.String("workload", "", "limit to one workload, as kind/name (e.g. Deployment/frontend)")
+	window := fs.Duration("window", time.Hour, "observation window; 0 uses every retained graph edge")
+	flavor := fs.String("flavor", "kubernetes", "policy flavor: kubernetes, cilium, or calico")
+	format := fs.String("format", "yaml", "output format: yaml or json")
+	timeout := fs.Duration("timeout", 30*time.Second, "request timeout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *namespace == "" {
+		fmt.Fprintln(os.Stderr, "netpol: -namespace is required")
+		fs.Usage()
+		return 2
+	}
+
+	q := url.Values{}
+	q.Set("namespace", *namespace)
+	q.Set("window", window.String())
+	q.Set("flavor", *flavor)
+	q.Set("format", *format)
+	if *workload != "" {
+		q.Set("workload", *workload)
+	}
+	endpoint := strings.TrimSuffix(*addr, "/") + "/api/v1/networkpolicies?" + q.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "netpol: %v\n", err)
+		return 1
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "netpol: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		fmt.Fprintf(os.Stderr, "netpol: %s: %s\n", resp.Status, strings.TrimSpace(string(body)))
+		return 1
+	}
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		fmt.Fprintf(os.Stderr, "netpol: %v\n", err)
+		return 1
+	}
+	return 0
+}
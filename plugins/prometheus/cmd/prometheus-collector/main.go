@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	prometheusV1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2/google"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -35,18 +37,108 @@ const outputFile = "/output/prometheus-metrics.json"
 const outputTempFile = "/output/prometheus-metrics-tmp.json"
 const monitoringReadScope = "https://www.googleapis.com/auth/monitoring.read"
 const monitoringGoogleApis = "monitoring.googleapis.com"
+const defaultStateConfigMapNamespace = "insights-agent"
+const defaultStateConfigMapName = "insights-agent-prometheus-collector-state"
+const aggregationModeRaw = "raw"
+const aggregationModePercentiles = "percentiles"
 
 func main() {
 	setLogLevel()
 	address := os.Getenv("PROMETHEUS_ADDRESS")
-	if address == "" {
-		panic("prometheus-metrics.address must be set")
-	}
 	clusterName := os.Getenv("CLUSTER_NAME")
 	skipNonZeroMetricsValidation := strings.ToLower(os.Getenv("SKIP_NON_ZERO_METRICS_CHECK")) == "true"
 	skipKSMNonZeroMetricsValidation := strings.ToLower(os.Getenv("SKIP_KSM_NON_ZERO_METRICS_CHECK")) == "" || strings.ToLower(os.Getenv("SKIP_KSM_NON_ZERO_METRICS_CHECK")) == "true"
+	aggregationMode := strings.ToLower(os.Getenv("AGGREGATION_MODE"))
+	if aggregationMode != "" && aggregationMode != aggregationModeRaw && aggregationMode != aggregationModePercentiles {
+		panic(fmt.Errorf("invalid AGGREGATION_MODE %q (should be one of %s, %s)", aggregationMode, aggregationModeRaw, aggregationModePercentiles))
+	}
+
+	dynamic, restMapper, kube, err := getKubeClient()
+	if err != nil {
+		panic(err)
+	}
+
+	var res []data.CombinedRequest
+	var nodesMetrics *data.NodesMetrics
+	var collectedEnd time.Time
+	stateConfigMapNamespace := getEnvWithDefault("STATE_CONFIGMAP_NAMESPACE", defaultStateConfigMapNamespace)
+	stateConfigMapName := getEnvWithDefault("STATE_CONFIGMAP_NAME", defaultStateConfigMapName)
+	if address == "" {
+		// Without Prometheus there is no history to backfill from.
+		stateConfigMapName = ""
+		opts := data.KubeletOptions{
+			Duration: getDurationEnv("KUBELET_SCRAPE_DURATION", data.DefaultKubeletScrapeDuration),
+			Interval: getDurationEnv("KUBELET_SCRAPE_INTERVAL", data.DefaultKubeletScrapeInterval),
+		}
+		logrus.Infof("PROMETHEUS_ADDRESS is not set, sampling the kubelet Summary API every %s for %s", opts.Interval, opts.Duration)
+		res, nodesMetrics, err = data.GetMetricsFromKubelet(context.Background(), dynamic, restMapper, kube, opts)
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		client := getPrometheusClient(address)
+
+		// An empty STATE_CONFIGMAP_NAME disables backfill: every run collects the usual window.
+		var lastCollected time.Time
+		if stateConfigMapName != "" {
+			lastCollected, err = data.LoadLastCollected(context.Background(), kube, stateConfigMapNamespace, stateConfigMapName)
+			if err != nil {
+				logrus.Warnf("Unable to read the last collected window, missed runs will not be backfilled: %v", err)
+			}
+		}
+		r := data.CollectionRange(lastCollected, time.Now(), getDurationEnv("MAX_LOOKBACK", data.DefaultMaxLookback))
+		collectedEnd = r.End
+		logrus.Infof("Collecting metrics from %s to %s", r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339))
+
+		res, err = data.GetMetrics(context.Background(), dynamic, restMapper, client, r, clusterName, address, skipNonZeroMetricsValidation, skipKSMNonZeroMetricsValidation)
+		if err != nil {
+			panic(err)
+		}
+
+		nodesMetrics, err = data.GetNodesMetrics(context.Background(), dynamic, restMapper, client, clusterName)
+		if err != nil {
+			panic(err)
+		}
+	}
+	logrus.Infof("Got %d metrics", len(res))
+	stats := data.CalculateStatistics(res)
+
+	output := map[string]any{
+		"Values": stats,
+		"Nodes":  nodesMetrics,
+	}
+	if aggregationMode == aggregationModePercentiles {
+		percentiles, remaining := data.AggregatePercentiles(stats, data.MetricCPU, data.MetricMemory)
+		logrus.Infof("Aggregated %d CPU and memory statistics to %d percentile statistics", len(stats)-len(remaining), len(percentiles))
+		output["Values"] = remaining
+		output["Percentiles"] = percentiles
+		stats = remaining
+	}
+	outputJSON, err := json.Marshal(output)
+	if err != nil {
+		panic(err)
+	}
+	logrus.Infof("Aggregated to %d statistics", len(stats))
+	err = os.WriteFile(outputTempFile, outputJSON, 0644)
+	if err != nil {
+		panic(err)
+	}
+	err = os.Rename(outputTempFile, outputFile)
+	if err != nil {
+		panic(err)
+	}
+	if stateConfigMapName != "" {
+		err = data.SaveLastCollected(context.Background(), kube, stateConfigMapNamespace, stateConfigMapName, collectedEnd)
+		if err != nil {
+			logrus.Warnf("Unable to save the last collected window: %v", err)
+		}
+	}
+	logrus.Infof("Done!")
+}
 
-	// Build client options based on environment configuration
+// getPrometheusClient builds the Prometheus client for address from the authentication,
+// tenant and query chunking environment variables.
+func getPrometheusClient(address string) prometheusV1.API {
 	var clientOpts []data.ClientOption
 
 	// Handle bearer token authentication (Google Cloud Monitoring or explicit token)
@@ -78,39 +170,28 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	return data.WithQueryChunking(client, getDurationEnv("QUERY_CHUNK_DURATION", data.DefaultQueryChunk))
+}
 
-	dynamic, restMapper, err := getKubeClient()
-	if err != nil {
-		panic(err)
-	}
-	res, err := data.GetMetrics(context.Background(), dynamic, restMapper, client, clusterName, address, skipNonZeroMetricsValidation, skipKSMNonZeroMetricsValidation)
-	if err != nil {
-		panic(err)
+// getEnvWithDefault returns the value of the environment variable key, or defaultValue when
+// it is unset. A variable set to an empty string returns an empty string.
+func getEnvWithDefault(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
 	}
-	logrus.Infof("Got %d metrics", len(res))
-	stats := data.CalculateStatistics(res)
+	return defaultValue
+}
 
-	nodesMetrics, err := data.GetNodesMetrics(context.Background(), dynamic, restMapper, client, clusterName)
-	if err != nil {
-		panic(err)
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
 	}
-	data, err := json.Marshal(map[string]any{
-		"Values": stats,
-		"Nodes":  nodesMetrics,
-	})
+	d, err := time.ParseDuration(value)
 	if err != nil {
-		panic(err)
+		panic(fmt.Errorf("invalid %s %q (should be a duration such as 6h or 30m): %v", key, value, err))
 	}
-	logrus.Infof("Aggregated to %d statistics", len(stats))
-	err = os.WriteFile(outputTempFile, data, 0644)
-	if err != nil {
-		panic(err)
-	}
-	err = os.Rename(outputTempFile, outputFile)
-	if err != nil {
-		panic(err)
-	}
-	logrus.Infof("Done!")
+	return d
 }
 
 func setLogLevel() {
@@ -125,32 +206,32 @@ func setLogLevel() {
 	}
 }
 
-func getKubeClient() (dynamic.Interface, meta.RESTMapper, error) {
+func getKubeClient() (dynamic.Interface, meta.RESTMapper, kubernetes.Interface, error) {
 	var restMapper meta.RESTMapper
 	var dynamicClient dynamic.Interface
 	kubeConf, configError := ctrl.GetConfig()
 	if configError != nil {
 		logrus.Errorf("Error fetching KubeConfig: %v", configError)
-		return dynamicClient, restMapper, configError
+		return dynamicClient, restMapper, nil, configError
 	}
 
 	api, err := kubernetes.NewForConfig(kubeConf)
 	if err != nil {
 		logrus.Errorf("Error creating Kubernetes client: %v", err)
-		return dynamicClient, restMapper, err
+		return dynamicClient, restMapper, nil, err
 	}
 
 	dynamicClient, err = dynamic.NewForConfig(kubeConf)
 	if err != nil {
 		logrus.Errorf("Error creating Dynamic client: %v", err)
-		return dynamicClient, restMapper, err
+		return dynamicClient, restMapper, api, err
 	}
 
 	resources, err := restmapper.GetAPIGroupResources(api.Discovery())
 	if err != nil {
 		logrus.Errorf("Error getting API Group resources: %v", err)
-		return dynamicClient, restMapper, err
+		return dynamicClient, restMapper, api, err
 	}
 	restMapper = restmapper.NewDiscoveryRESTMapper(resources)
-	return dynamicClient, restMapper, nil
+	return dynamicClient, restMapper, api, nil
 }
@@ -14,6 +14,8 @@
 package data
 
 import (
+	"math"
+	"slices"
 	"time"
 )
 
@@ -81,6 +83,46 @@ func CalculateStatistics(values []CombinedRequest) []Statistics {
 			})
 		}
 
+		for _, storageUsed := range value.storageUsed {
+			timestamp := time.Unix(int64(storageUsed.Timestamp)/1000, 0)
+			stats = append(stats, Statistics{
+				StartTime: timestamp,
+				Owner:     value.Owner,
+				Metric:    MetricStorageUsed,
+				Value:     int64(storageUsed.Value),
+			})
+		}
+
+		for _, inodesUsed := range value.storageInodesUsed {
+			timestamp := time.Unix(int64(inodesUsed.Timestamp)/1000, 0)
+			stats = append(stats, Statistics{
+				StartTime: timestamp,
+				Owner:     value.Owner,
+				Metric:    MetricStorageInodesUsed,
+				Value:     int64(inodesUsed.Value),
+			})
+		}
+
+		for _, inodes := range value.storageInodes {
+			timestamp := time.Unix(int64(inodes.Timestamp)/1000, 0)
+			stats = append(stats, Statistics{
+				StartTime: timestamp,
+				Owner:     value.Owner,
+				Metric:    MetricStorageInodes,
+				Value:     int64(inodes.Value),
+			})
+		}
+
+		for _, ephemeral := range value.ephemeralStorage {
+			timestamp := time.Unix(int64(ephemeral.Timestamp)/1000, 0)
+			stats = append(stats, Statistics{
+				StartTime: timestamp,
+				Owner:     value.Owner,
+				Metric:    MetricEphemeralStorage,
+				Value:     int64(ephemeral.Value),
+			})
+		}
+
 		// GPU metrics
 		// - Usage: 0-1 float from PromQL → 0-100 integer (percentage)
 		// - Request/Limit: GPU count → milli-GPUs (×1000) for fractional GPU support
@@ -118,7 +160,124 @@ func CalculateStatistics(values []CombinedRequest) []Statistics {
 			})
 		}
 
+		// Sizing signals
+		// - CPUThrottling: throttled share of CFS periods 0-1 → 0-100 (percentage), with CPU request/limit in millicores
+		// - MemoryWorkingSet: bytes, with memory request/limit
+		// - OOMKilled / Restarts: as reported by kube-state-metrics
+		for _, throttling := range value.cpuThrottling {
+			if math.IsNaN(float64(throttling.Value)) {
+				continue
+			}
+			timestamp := time.Unix(int64(throttling.Timestamp)/1000, 0)
+			var throttlingValue int64
+			if throttling.Value > 0 && throttling.Value < 0.01 {
+				throttlingValue = 1
+			} else {
+				throttlingValue = int64(throttling.Value * 100)
+			}
+			stats = append(stats, Statistics{
+				StartTime:  timestamp,
+				Owner:      value.Owner,
+				Metric:     MetricCPUThrottling,
+				Value:      throttlingValue,
+				Request:    int64(value.cpuRequest * 1000),
+				LimitValue: int64(value.cpuLimit * 1000),
+			})
+		}
+		for _, workingSet := range value.memoryWorkingSet {
+			timestamp := time.Unix(int64(workingSet.Timestamp)/1000, 0)
+			stats = append(stats, Statistics{
+				StartTime:  timestamp,
+				Owner:      value.Owner,
+				Metric:     MetricMemoryWorkingSet,
+				Value:      int64(workingSet.Value),
+				Request:    int64(value.memoryRequest),
+				LimitValue: int64(value.memoryLimit),
+			})
+		}
+		for _, oomKilled := range value.oomKilled {
+			timestamp := time.Unix(int64(oomKilled.Timestamp)/1000, 0)
+			stats = append(stats, Statistics{
+				StartTime: timestamp,
+				Owner:     value.Owner,
+				Metric:    MetricOOMKilled,
+				Value:     int64(oomKilled.Value),
+			})
+		}
+		for _, restarts := range value.restarts {
+			timestamp := time.Unix(int64(restarts.Timestamp)/1000, 0)
+			stats = append(stats, Statistics{
+				StartTime: timestamp,
+				Owner:     value.Owner,
+				Metric:    MetricRestarts,
+				Value:     int64(restarts.Value),
+			})
+		}
 	}
 
 	return stats
 }
+
+// AggregatePercentiles replaces the rows of the given metrics with one PercentileStatistics
+// per controller, container and metric: p50/p90/p95/p99 (nearest rank) and max over the
+// samples of all of the controller's pods in the window, with the sample count. PodName is
+// left empty on the aggregated rows. Rows of other metrics are returned unchanged, in their
+// original order.
+func AggregatePercentiles(stats []Statistics, metrics ...string) ([]PercentileStatistics, []Statistics) {
+	type ownerMetric struct {
+		Owner
+		metric string
+	}
+	remaining := make([]Statistics, 0)
+	samples := make(map[ownerMetric][]int64)
+	aggregated := make(map[ownerMetric]*PercentileStatistics)
+	order := make([]ownerMetric, 0)
+	for _, stat := range stats {
+		if !slices.Contains(metrics, stat.Metric) {
+			remaining = append(remaining, stat)
+			continue
+		}
+		owner := stat.Owner
+		owner.PodName = ""
+		key := ownerMetric{Owner: owner, metric: stat.Metric}
+		agg, ok := aggregated[key]
+		if !ok {
+			agg = &PercentileStatistics{Owner: owner, Metric: stat.Metric, StartTime: stat.StartTime, EndTime: stat.StartTime}
+			aggregated[key] = agg
+			order = append(order, key)
+		}
+		if stat.StartTime.Before(agg.StartTime) {
+			agg.StartTime = stat.StartTime
+		}
+		if stat.StartTime.After(agg.EndTime) {
+			agg.EndTime = stat.StartTime
+		}
+		agg.Request = stat.Request
+		agg.LimitValue = stat.LimitValue
+		samples[key] = append(samples[key], stat.Value)
+	}
+
+	percentiles := make([]PercentileStatistics, 0, len(order))
+	for _, key := range order {
+		values := samples[key]
+		slices.Sort(values)
+		agg := aggregated[key]
+		agg.SampleCount = len(values)
+		agg.P50 = nearestRank(values, 50)
+		agg.P90 = nearestRank(values, 90)
+		agg.P95 = nearestRank(values, 95)
+		agg.P99 = nearestRank(values, 99)
+		agg.Max = values[len(values)-1]
+		percentiles = append(percentiles, *agg)
+	}
+	return percentiles, remaining
+}
+
+// nearestRank returns the p-th percentile of sorted, non-empty values using the nearest-rank method.
+func nearestRank(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -14,7 +14,9 @@
 package data
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
@@ -325,3 +327,140 @@ func TestCalculateStatistics_CPUScalingComparison(t *testing.T) {
 	assert.Equal(t, int64(1000), gpuStat.Request, "GPU request in milli-GPUs")
 	assert.Equal(t, int64(2000), gpuStat.LimitValue, "GPU limit in milli-GPUs")
 }
+
+func TestCalculateStatistics_SizingSignals(t *testing.T) {
+	ts := model.Time(1674153900000)
+	input := []CombinedRequest{
+		{
+			Owner: Owner{
+				Container:           "api",
+				PodName:             "api-abc",
+				ControllerNamespace: "default",
+				ControllerName:      "api",
+				ControllerKind:      "Deployment",
+			},
+			cpuRequest:    0.25,
+			cpuLimit:      0.5,
+			memoryRequest: 128 * 1024 * 1024,
+			memoryLimit:   256 * 1024 * 1024,
+			cpuThrottling: []model.SamplePair{
+				{Timestamp: ts, Value: 0.42},
+				{Timestamp: ts + 30000, Value: 0.004},
+				{Timestamp: ts + 60000, Value: model.SampleValue(math.NaN())},
+			},
+			memoryWorkingSet: []model.SamplePair{{Timestamp: ts, Value: 200 * 1024 * 1024}},
+			oomKilled:        []model.SamplePair{{Timestamp: ts, Value: 1}},
+			restarts:         []model.SamplePair{{Timestamp: ts, Value: 3}},
+		},
+	}
+
+	byMetric := map[string][]Statistics{}
+	for _, stat := range CalculateStatistics(input) {
+		byMetric[stat.Metric] = append(byMetric[stat.Metric], stat)
+	}
+
+	throttling := byMetric[MetricCPUThrottling]
+	assert.Len(t, throttling, 2, "NaN throttling ratios should be skipped")
+	assert.Equal(t, int64(42), throttling[0].Value)
+	assert.Equal(t, int64(1), throttling[1].Value, "small non-zero throttling should round up to 1%")
+	assert.Equal(t, int64(250), throttling[0].Request)
+	assert.Equal(t, int64(500), throttling[0].LimitValue)
+
+	workingSet := byMetric[MetricMemoryWorkingSet]
+	assert.Len(t, workingSet, 1)
+	assert.Equal(t, int64(200*1024*1024), workingSet[0].Value)
+	assert.Equal(t, int64(256*1024*1024), workingSet[0].LimitValue)
+
+	assert.Equal(t, int64(1), byMetric[MetricOOMKilled][0].Value)
+	assert.Equal(t, int64(3), byMetric[MetricRestarts][0].Value)
+	assert.Equal(t, time.Unix(1674153900, 0), byMetric[MetricRestarts][0].StartTime)
+}
+
+func TestCalculateStatistics_StorageUsage(t *testing.T) {
+	ts := model.Time(1674153900000)
+	input := []CombinedRequest{
+		{
+			Owner:             Owner{Container: "postgres", PodName: "db-0", ControllerNamespace: "default", ControllerName: "db", ControllerKind: "StatefulSet"},
+			storageCapacity:   []model.SamplePair{{Timestamp: ts, Value: 10737418240}},
+			storageUsed:       []model.SamplePair{{Timestamp: ts, Value: 536870912}},
+			storageInodesUsed: []model.SamplePair{{Timestamp: ts, Value: 1200}},
+			storageInodes:     []model.SamplePair{{Timestamp: ts, Value: 655360}},
+		},
+	}
+
+	values := map[string]int64{}
+	for _, stat := range CalculateStatistics(input) {
+		values[stat.Metric] = stat.Value
+	}
+	assert.Equal(t, map[string]int64{
+		MetricStorageCapacity:   10737418240,
+		MetricStorageUsed:       536870912,
+		MetricStorageInodesUsed: 1200,
+		MetricStorageInodes:     655360,
+	}, values)
+}
+
+func TestAggregatePercentiles(t *testing.T) {
+	owner := Owner{Container: "api", PodName: "api-abc", ControllerNamespace: "default", ControllerName: "api", ControllerKind: "Deployment"}
+	start := time.Unix(1674153900, 0)
+	stats := make([]Statistics, 0)
+	// CPU samples 100 down to 1 millicores, out of order in time
+	for i := 100; i >= 1; i-- {
+		stats = append(stats, Statistics{Owner: owner, StartTime: start.Add(time.Duration(100-i) * 30 * time.Second), Metric: MetricCPU, Value: int64(i), Request: 250, LimitValue: 500})
+	}
+	stats = append(stats,
+		Statistics{Owner: owner, StartTime: start, Metric: MetricMemory, Value: 300},
+		Statistics{Owner: owner, StartTime: start, Metric: MetricNetworkReceive, Value: 42},
+		Statistics{Owner: owner, StartTime: start.Add(30 * time.Second), Metric: MetricMemory, Value: 100},
+	)
+
+	percentiles, remaining := AggregatePercentiles(stats, MetricCPU, MetricMemory)
+	assert.Equal(t, []Statistics{{Owner: owner, StartTime: start, Metric: MetricNetworkReceive, Value: 42}}, remaining)
+	controllerOwner := owner
+	controllerOwner.PodName = ""
+	assert.Equal(t, []PercentileStatistics{
+		{
+			Owner: controllerOwner, Metric: MetricCPU,
+			StartTime: start, EndTime: start.Add(99 * 30 * time.Second),
+			SampleCount: 100, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100,
+			Request: 250, LimitValue: 500,
+		},
+		{
+			Owner: controllerOwner, Metric: MetricMemory,
+			StartTime: start, EndTime: start.Add(30 * time.Second),
+			SampleCount: 2, P50: 100, P90: 300, P95: 300, P99: 300, Max: 300,
+		},
+	}, percentiles)
+
+	percentiles, remaining = AggregatePercentiles(nil, MetricCPU)
+	assert.Empty(t, percentiles)
+	assert.Empty(t, remaining)
+}
+
+func TestAggregatePercentilesAcrossPods(t *testing.T) {
+	start := time.Unix(1674153900, 0)
+	api := Owner{Container: "api", ControllerNamespace: "default", ControllerName: "api", ControllerKind: "Deployment"}
+	sidecar := api
+	sidecar.Container = "proxy"
+	stats := make([]Statistics, 0)
+	for i, pod := range []string{"api-abc", "api-def", "api-ghi"} {
+		podAPI, podSidecar := api, sidecar
+		podAPI.PodName, podSidecar.PodName = pod, pod
+		stats = append(stats,
+			Statistics{Owner: podAPI, StartTime: start, Metric: MetricCPU, Value: int64(100 * (i + 1))},
+			Statistics{Owner: podSidecar, StartTime: start, Metric: MetricCPU, Value: 5},
+		)
+	}
+
+	percentiles, _ := AggregatePercentiles(stats, MetricCPU)
+	assert.Equal(t, []PercentileStatistics{
+		{
+			Owner: api, Metric: MetricCPU, StartTime: start, EndTime: start,
+			SampleCount: 3, P50: 200, P90: 300, P95: 300, P99: 300, Max: 300,
+		},
+		{
+			Owner: sidecar, Metric: MetricCPU, StartTime: start, EndTime: start,
+			SampleCount: 3, P50: 5, P90: 5, P95: 5, P99: 5, Max: 5,
+		},
+	}, percentiles)
+}
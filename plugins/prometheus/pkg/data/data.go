@@ -43,13 +43,17 @@ func GetClient(address, bearerToken string) (prometheusV1.API, error) {
 	return GetClientWithOptions(address)
 }
 
-// TODO make configurable
 var timestep time.Duration = time.Minute * 5
 
 func getRange() prometheusV1.Range {
+	return getRangeAt(time.Now())
+}
+
+// getRangeAt returns the usual 15-minute window ending at the last full timestep before now.
+func getRangeAt(now time.Time) prometheusV1.Range {
 	return prometheusV1.Range{
-		Start: time.Now().Truncate(timestep).Add(timestep * -3),
-		End:   time.Now().Truncate(timestep),
+		Start: now.Truncate(timestep).Add(timestep * -3),
+		End:   now.Truncate(timestep),
 		Step:  30 * time.Second,
 	}
 }
@@ -145,11 +149,11 @@ func IsGMP(prometheusAddress string) bool {
 	return host == gmpHost
 }
 
-// GetMetrics returns the memory/cpu and requests for each container running in the cluster.
+// GetMetrics returns the memory/cpu and requests for each container running in the cluster
+// over r (see CollectionRange).
 // When prometheusAddress is GMP and clusterName is set, request/limit metrics that return 0 from
 // kube-state-metrics are retried using GKE system metrics (kubernetes.io/container/*).
-func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper meta.RESTMapper, api prometheusV1.API, clusterName string, prometheusAddress string, skipCAdvisorNonZeroMetricsValidation, skipKSMNonZeroMetricsValidation bool) ([]CombinedRequest, error) {
-	r := getRange()
+func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper meta.RESTMapper, api prometheusV1.API, r prometheusV1.Range, clusterName string, prometheusAddress string, skipCAdvisorNonZeroMetricsValidation, skipKSMNonZeroMetricsValidation bool) ([]CombinedRequest, error) {
 	useGKEFallback := IsGMP(prometheusAddress) && clusterName != ""
 
 	memory, err := getMemory(ctx, api, r, clusterName)
@@ -276,22 +280,143 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 	}
 	logrus.Infof("Found %d metrics for GPU limits", len(gpuLimits))
 
+	// Sizing signals are optional - don't fail if CFS or kube-state-metrics status metrics are not collected.
+	// GKE system metrics have no throttling or termination reason equivalent.
+	cpuThrottling, err := getCPUThrottling(ctx, api, r, clusterName)
+	if err != nil {
+		logrus.Warnf("CPU throttling metrics not available: %v", err)
+		cpuThrottling = model.Matrix{}
+	}
+	logrus.Infof("Found %d metrics for CPU throttling", len(cpuThrottling))
+
+	memoryWorkingSet, err := getMemoryWorkingSet(ctx, api, r, clusterName)
+	if err != nil {
+		logrus.Warnf("Memory working set metrics not available: %v", err)
+		memoryWorkingSet = model.Matrix{}
+	}
+	if len(memoryWorkingSet) == 0 && useGKEFallback {
+		if gke, gkeErr := getMemoryWorkingSetGKE(ctx, api, r, clusterName); gkeErr == nil && len(gke) > 0 {
+			logrus.Infof("Using GKE system metrics for memory working set (cAdvisor returned no data)")
+			memoryWorkingSet = gke
+		}
+	}
+	logrus.Infof("Found %d metrics for memory working set", len(memoryWorkingSet))
+
+	oomKilled, err := getOOMKilled(ctx, api, r, clusterName)
+	if err != nil {
+		logrus.Warnf("OOM kill metrics not available: %v", err)
+		oomKilled = model.Matrix{}
+	}
+	logrus.Infof("Found %d metrics for OOM kills", len(oomKilled))
+
+	restarts, err := getRestarts(ctx, api, r, clusterName)
+	if err != nil {
+		logrus.Warnf("Restart metrics not available: %v", err)
+		restarts = model.Matrix{}
+	}
+	if len(restarts) == 0 && useGKEFallback {
+		if gke, gkeErr := getRestartsGKE(ctx, api, r, clusterName); gkeErr == nil && len(gke) > 0 {
+			logrus.Infof("Using GKE system metrics for restarts (kube-state-metrics returned no data)")
+			restarts = gke
+		}
+	}
+	logrus.Infof("Found %d metrics for restarts", len(restarts))
+
+	// Kubelet volume stats give actual PVC usage; without them, capacity falls back to the PVC status capacity.
+	volumeCapacity, err := getVolumeStats(ctx, api, r, kubeletVolumeStatsCapacityBytes, clusterName)
+	if err != nil {
+		logrus.Warnf("kubelet volume stats not available, using PersistentVolumeClaim capacity: %v", err)
+		volumeCapacity = model.Matrix{}
+	}
+	var volumeUsed, volumeInodesUsed, volumeInodes model.Matrix
+	if len(volumeCapacity) > 0 {
+		for _, stat := range []struct {
+			metric string
+			dest   *model.Matrix
+		}{
+			{kubeletVolumeStatsUsedBytes, &volumeUsed},
+			{kubeletVolumeStatsInodesUsed, &volumeInodesUsed},
+			{kubeletVolumeStatsInodes, &volumeInodes},
+		} {
+			volumeStats, err := getVolumeStats(ctx, api, r, stat.metric, clusterName)
+			if err != nil {
+				logrus.Warnf("%s not available: %v", stat.metric, err)
+				continue
+			}
+			*stat.dest = volumeStats
+		}
+	}
+	logrus.Infof("Found %d metrics for kubelet volume stats", len(volumeCapacity))
+
+	return combineMetrics(ctx, dynamicClient, restMapper, r, metricSeries{
+		cpu:              cpu,
+		memory:           memory,
+		cpuRequest:       cpuRequest,
+		memoryRequest:    memoryRequest,
+		cpuLimit:         cpuLimits,
+		memoryLimit:      memoryLimits,
+		gpuRequest:       gpuRequests,
+		gpuLimit:         gpuLimits,
+		cpuThrottling:    cpuThrottling,
+		memoryWorkingSet: memoryWorkingSet,
+		oomKilled:        oomKilled,
+		restarts:         restarts,
+		networkTransmit:  networkTransmit,
+		networkReceive:   networkReceive,
+		gpu:              gpuUsage,
+		volumeCapacity:   volumeCapacity,
+		volumeUsed:       volumeUsed,
+		volumeInodesUsed: volumeInodesUsed,
+		volumeInodes:     volumeInodes,
+	})
+}
+
+// metricSeries are the series combined into CombinedRequests, whichever backend produced them.
+// Container series are labeled by namespace, pod and container; pod series (network, GPU usage)
+// by namespace and pod, and are split across the pod's containers; volume series by namespace
+// and persistentvolumeclaim.
+type metricSeries struct {
+	cpu              model.Matrix
+	memory           model.Matrix
+	cpuRequest       model.Matrix
+	memoryRequest    model.Matrix
+	cpuLimit         model.Matrix
+	memoryLimit      model.Matrix
+	gpuRequest       model.Matrix
+	gpuLimit         model.Matrix
+	cpuThrottling    model.Matrix
+	memoryWorkingSet model.Matrix
+	oomKilled        model.Matrix
+	restarts         model.Matrix
+	ephemeralStorage model.Matrix
+	networkTransmit  model.Matrix
+	networkReceive   model.Matrix
+	gpu              model.Matrix
+	volumeCapacity   model.Matrix
+	volumeUsed       model.Matrix
+	volumeInodesUsed model.Matrix
+	volumeInodes     model.Matrix
+}
+
+// combineMetrics joins series per pod/container, maps pods to their top controllers and
+// PersistentVolumeClaims, and splits pod-level series across containers.
+func combineMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper meta.RESTMapper, r prometheusV1.Range, series metricSeries) ([]CombinedRequest, error) {
 	combinedRequests := make(map[string]CombinedRequest)
-	for _, cpuVal := range cpu {
+	for _, cpuVal := range series.cpu {
 		key := getKey(cpuVal)
 		request := combinedRequests[key]
 		request.cpu = cpuVal.Values
 		request.Owner = getOwner(cpuVal)
 		combinedRequests[key] = request
 	}
-	for _, memVal := range memory {
+	for _, memVal := range series.memory {
 		key := getKey(memVal)
 		request := combinedRequests[key]
 		request.memory = memVal.Values
 		request.Owner = getOwner(memVal)
 		combinedRequests[key] = request
 	}
-	for _, cpuVal := range cpuRequest {
+	for _, cpuVal := range series.cpuRequest {
 		if len(cpuVal.Values) == 0 {
 			continue
 		}
@@ -301,7 +426,7 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		request.cpuRequest = averageSampleValues(cpuVal.Values)
 		combinedRequests[key] = request
 	}
-	for _, memVal := range memoryRequest {
+	for _, memVal := range series.memoryRequest {
 		if len(memVal.Values) == 0 {
 			continue
 		}
@@ -311,7 +436,7 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		request.memoryRequest = averageSampleValues(memVal.Values)
 		combinedRequests[key] = request
 	}
-	for _, cpuVal := range cpuLimits {
+	for _, cpuVal := range series.cpuLimit {
 		if len(cpuVal.Values) == 0 {
 			continue
 		}
@@ -321,7 +446,7 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		request.cpuLimit = averageSampleValues(cpuVal.Values)
 		combinedRequests[key] = request
 	}
-	for _, memVal := range memoryLimits {
+	for _, memVal := range series.memoryLimit {
 		if len(memVal.Values) == 0 {
 			continue
 		}
@@ -331,7 +456,7 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		request.memoryLimit = averageSampleValues(memVal.Values)
 		combinedRequests[key] = request
 	}
-	for _, gpuVal := range gpuRequests {
+	for _, gpuVal := range series.gpuRequest {
 		key := getKey(gpuVal)
 		request := combinedRequests[key]
 		request.Owner = getOwner(gpuVal)
@@ -340,7 +465,7 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		}
 		combinedRequests[key] = request
 	}
-	for _, gpuVal := range gpuLimits {
+	for _, gpuVal := range series.gpuLimit {
 		key := getKey(gpuVal)
 		request := combinedRequests[key]
 		request.Owner = getOwner(gpuVal)
@@ -349,6 +474,41 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		}
 		combinedRequests[key] = request
 	}
+	for _, throttlingVal := range series.cpuThrottling {
+		key := getKey(throttlingVal)
+		request := combinedRequests[key]
+		request.cpuThrottling = throttlingVal.Values
+		request.Owner = getOwner(throttlingVal)
+		combinedRequests[key] = request
+	}
+	for _, workingSetVal := range series.memoryWorkingSet {
+		key := getKey(workingSetVal)
+		request := combinedRequests[key]
+		request.memoryWorkingSet = workingSetVal.Values
+		request.Owner = getOwner(workingSetVal)
+		combinedRequests[key] = request
+	}
+	for _, oomVal := range series.oomKilled {
+		key := getKey(oomVal)
+		request := combinedRequests[key]
+		request.oomKilled = oomVal.Values
+		request.Owner = getOwner(oomVal)
+		combinedRequests[key] = request
+	}
+	for _, restartsVal := range series.restarts {
+		key := getKey(restartsVal)
+		request := combinedRequests[key]
+		request.restarts = restartsVal.Values
+		request.Owner = getOwner(restartsVal)
+		combinedRequests[key] = request
+	}
+	for _, ephemeralVal := range series.ephemeralStorage {
+		key := getKey(ephemeralVal)
+		request := combinedRequests[key]
+		request.ephemeralStorage = ephemeralVal.Values
+		request.Owner = getOwner(ephemeralVal)
+		combinedRequests[key] = request
+	}
 
 	// Information about pods is required to lookup the number of containers
 	// to manipulate per-pod network metrics to appear to be
@@ -387,7 +547,16 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		}
 	}
 	logrus.Debugf("PersistentVolumeClaims with pod references are: %v", storage.PVCsAsString())
+
+	// Without kubelet volume stats, capacity falls back to the PVC status capacity.
 	storageCapacity := storage.ManufactureMetrics(r)
+	if len(series.volumeCapacity) > 0 {
+		storageCapacity = storage.PodVolumeMetrics(series.volumeCapacity, r, true)
+	}
+	storageUsed := storage.PodVolumeMetrics(series.volumeUsed, r, false)
+	storageInodesUsed := storage.PodVolumeMetrics(series.volumeInodesUsed, r, false)
+	storageInodes := storage.PodVolumeMetrics(series.volumeInodes, r, false)
+
 	storageCapacity = adjustMetricsForMultiContainerPods(storageCapacity, workloadMap)
 	for _, storageVal := range storageCapacity {
 		key := getKey(storageVal)
@@ -396,8 +565,32 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		request.Owner = getOwner(storageVal)
 		combinedRequests[key] = request
 	}
+	storageUsed = adjustMetricsForMultiContainerPods(storageUsed, workloadMap)
+	for _, storageVal := range storageUsed {
+		key := getKey(storageVal)
+		request := combinedRequests[key]
+		request.storageUsed = storageVal.Values
+		request.Owner = getOwner(storageVal)
+		combinedRequests[key] = request
+	}
+	storageInodesUsed = adjustMetricsForMultiContainerPods(storageInodesUsed, workloadMap)
+	for _, storageVal := range storageInodesUsed {
+		key := getKey(storageVal)
+		request := combinedRequests[key]
+		request.storageInodesUsed = storageVal.Values
+		request.Owner = getOwner(storageVal)
+		combinedRequests[key] = request
+	}
+	storageInodes = adjustMetricsForMultiContainerPods(storageInodes, workloadMap)
+	for _, storageVal := range storageInodes {
+		key := getKey(storageVal)
+		request := combinedRequests[key]
+		request.storageInodes = storageVal.Values
+		request.Owner = getOwner(storageVal)
+		combinedRequests[key] = request
+	}
 
-	networkTransmit = adjustMetricsForMultiContainerPods(networkTransmit, workloadMap)
+	networkTransmit := adjustMetricsForMultiContainerPods(series.networkTransmit, workloadMap)
 	for _, networkVal := range networkTransmit {
 		key := getKey(networkVal)
 		request := combinedRequests[key]
@@ -406,7 +599,7 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 		combinedRequests[key] = request
 	}
 
-	networkReceive = adjustMetricsForMultiContainerPods(networkReceive, workloadMap)
+	networkReceive := adjustMetricsForMultiContainerPods(series.networkReceive, workloadMap)
 	for _, networkVal := range networkReceive {
 		key := getKey(networkVal)
 		request := combinedRequests[key]
@@ -419,7 +612,7 @@ func GetMetrics(ctx context.Context, dynamicClient dynamic.Interface, restMapper
 	// We split it across containers like network metrics to avoid double-counting in reports.
 	// Note: For fractional GPU utilization (0-1), floor division means the first container
 	// gets the full value and others get 0. The total sum is preserved for accurate reporting.
-	gpuUsage = adjustMetricsForMultiContainerPods(gpuUsage, workloadMap)
+	gpuUsage := adjustMetricsForMultiContainerPods(series.gpu, workloadMap)
 	for _, gpuVal := range gpuUsage {
 		key := getKey(gpuVal)
 		request := combinedRequests[key]
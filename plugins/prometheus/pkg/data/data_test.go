@@ -1,6 +1,7 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	prometheusV1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -440,6 +442,55 @@ func TestStorageCapacity(t *testing.T) {
 	assert.Equal(t, model.SampleValue(954437177.0), adjustedMetrics[2].Values[0].Value, "the metric value for pod1 and container name container3forpod1")
 }
 
+func TestPodVolumeMetrics(t *testing.T) {
+	pvc := func(namespace, name, capacity string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]any{
+			"kind":     "PersistentVolumeClaim",
+			"metadata": map[string]any{"name": name, "namespace": namespace},
+			"status":   map[string]any{"capacity": map[string]any{"storage": capacity}},
+		}}
+	}
+	storageInfo := pluginmodels.NewStorageInfoFromUnstructuredPVCs([]unstructured.Unstructured{
+		pvc("a", "data", "10Gi"),
+		pvc("b", "data", "20Gi"),
+		pvc("a", "logs", "1Gi"),
+	})
+	storageInfo.AddPVCRef("data", "a/db-0")
+	storageInfo.AddPVCRef("logs", "a/db-0")
+	storageInfo.AddPVCRef("data", "b/db-0")
+
+	r := prometheusV1.Range{
+		Start: time.Date(2023, time.January, 19, 18, 45, 0, 0, time.UTC),
+		End:   time.Date(2023, time.January, 19, 18, 46, 0, 0, time.UTC),
+		Step:  30 * time.Second,
+	}
+	t0 := model.Time(r.Start.UnixMilli())
+	t1 := t0 + 30000
+	used := model.Matrix{
+		{
+			Metric: model.Metric{"namespace": "a", "persistentvolumeclaim": "data"},
+			Values: []model.SamplePair{{Timestamp: t0, Value: 100}, {Timestamp: t1, Value: 150}},
+		},
+		{
+			Metric: model.Metric{"namespace": "b", "persistentvolumeclaim": "data"},
+			Values: []model.SamplePair{{Timestamp: t0, Value: 7}},
+		},
+	}
+
+	// used bytes: PVCs without kubelet stats are skipped
+	metrics := storageInfo.PodVolumeMetrics(used, r, false)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, model.Metric{"namespace": "a", "pod": "db-0"}, metrics[0].Metric)
+	assert.Equal(t, []model.SamplePair{{Timestamp: t0, Value: 100}, {Timestamp: t1, Value: 150}}, metrics[0].Values)
+	assert.Equal(t, model.Metric{"namespace": "b", "pod": "db-0"}, metrics[1].Metric)
+	assert.Equal(t, []model.SamplePair{{Timestamp: t0, Value: 7}}, metrics[1].Values)
+
+	// capacity: a/logs has no kubelet stats and falls back to its PVC capacity
+	metrics = storageInfo.PodVolumeMetrics(used, r, true)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, []model.SamplePair{{Timestamp: t0, Value: 100 + 1073741824}, {Timestamp: t1, Value: 150 + 1073741824}}, metrics[0].Values)
+}
+
 // =============================================================================
 // GPU TESTS
 // =============================================================================
@@ -683,6 +734,38 @@ func TestNormalizeGKEContainerMatrix(t *testing.T) {
 	assert.Equal(t, model.LabelValue("coredns"), out2[0].Metric["container"])
 }
 
+// queryRecordingAPI records queries and returns an empty matrix.
+type queryRecordingAPI struct {
+	prometheusV1.API
+	queries []string
+}
+
+func (a *queryRecordingAPI) QueryRange(_ context.Context, query string, _ prometheusV1.Range, _ ...prometheusV1.Option) (model.Value, prometheusV1.Warnings, error) {
+	a.queries = append(a.queries, query)
+	return model.Matrix{}, nil, nil
+}
+
+func TestSizingSignalQueries(t *testing.T) {
+	ctx := context.Background()
+	api := &queryRecordingAPI{}
+	r := getRange()
+
+	_, err := getCPUThrottling(ctx, api, r, "prod")
+	assert.NoError(t, err)
+	_, err = getOOMKilled(ctx, api, r, "")
+	assert.NoError(t, err)
+	_, err = getMemoryWorkingSetGKE(ctx, api, r, "prod")
+	assert.NoError(t, err)
+	_, err = getRestartsGKE(ctx, api, r, "prod")
+	assert.NoError(t, err)
+
+	assert.Contains(t, api.queries[0], `rate(container_cpu_cfs_throttled_periods_total{image!="", container!="POD", container!="", cluster="prod"}[5m])`)
+	assert.Contains(t, api.queries[0], `rate(container_cpu_cfs_periods_total{image!="", container!="POD", container!="", cluster="prod"}[5m])) > 0)`)
+	assert.Equal(t, `kube_pod_container_status_last_terminated_reason{container!="", reason="OOMKilled"}`, api.queries[1])
+	assert.Equal(t, `sum by (namespace_name, pod_name, container_name) (kubernetes_io:container_memory_used_bytes{monitored_resource="k8s_container", cluster_name="prod", memory_type="non-evictable"})`, api.queries[2])
+	assert.Equal(t, `kubernetes_io:container_restart_count{monitored_resource="k8s_container", cluster_name="prod"}`, api.queries[3])
+}
+
 func requireLen(t *testing.T, m model.Matrix, n int) {
 	t.Helper()
 	if len(m) != n {
@@ -0,0 +1,510 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	prometheusV1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultKubeletScrapeDuration is shorter than the window collected from Prometheus, since
+	// the run blocks for the whole duration and has to finish within the job's deadline.
+	DefaultKubeletScrapeDuration = 5 * time.Minute
+	// DefaultKubeletScrapeInterval matches the Prometheus query step.
+	DefaultKubeletScrapeInterval = 30 * time.Second
+)
+
+var podMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// gpuResourceRegexp matches GPU resource names once "/" and "." are converted to "_", as
+// kube-state-metrics does.
+var gpuResourceRegexp = regexp.MustCompile(`^(` + gpuResourcePattern + `)$`)
+
+// KubeletOptions configures the kubelet Summary API backend.
+type KubeletOptions struct {
+	Duration time.Duration // how long to sample for
+	Interval time.Duration // time between samples
+}
+
+// The subset of the kubelet Summary API (/stats/summary) used by the collector.
+type kubeletSummary struct {
+	Node kubeletNodeStats  `json:"node"`
+	Pods []kubeletPodStats `json:"pods"`
+}
+
+type kubeletNodeStats struct {
+	NodeName string              `json:"nodeName"`
+	CPU      *kubeletCPUStats    `json:"cpu,omitempty"`
+	Memory   *kubeletMemoryStats `json:"memory,omitempty"`
+}
+
+type kubeletPodReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type kubeletPodStats struct {
+	PodRef     kubeletPodReference     `json:"podRef"`
+	Containers []kubeletContainerStats `json:"containers"`
+	Network    *kubeletNetworkStats    `json:"network,omitempty"`
+	Volumes    []kubeletVolumeStats    `json:"volume,omitempty"`
+}
+
+type kubeletContainerStats struct {
+	Name   string              `json:"name"`
+	CPU    *kubeletCPUStats    `json:"cpu,omitempty"`
+	Memory *kubeletMemoryStats `json:"memory,omitempty"`
+	Rootfs *kubeletFsStats     `json:"rootfs,omitempty"`
+	Logs   *kubeletFsStats     `json:"logs,omitempty"`
+}
+
+type kubeletCPUStats struct {
+	UsageNanoCores *uint64 `json:"usageNanoCores,omitempty"`
+}
+
+type kubeletMemoryStats struct {
+	AvailableBytes  *uint64 `json:"availableBytes,omitempty"`
+	UsageBytes      *uint64 `json:"usageBytes,omitempty"`
+	WorkingSetBytes *uint64 `json:"workingSetBytes,omitempty"`
+}
+
+// kubeletNetworkStats are the counters of the pod's default interface.
+type kubeletNetworkStats struct {
+	RxBytes *uint64 `json:"rxBytes,omitempty"`
+	TxBytes *uint64 `json:"txBytes,omitempty"`
+}
+
+type kubeletFsStats struct {
+	CapacityBytes *uint64 `json:"capacityBytes,omitempty"`
+	UsedBytes     *uint64 `json:"usedBytes,omitempty"`
+	Inodes        *uint64 `json:"inodes,omitempty"`
+	InodesUsed    *uint64 `json:"inodesUsed,omitempty"`
+}
+
+type kubeletVolumeStats struct {
+	kubeletFsStats
+	Name   string               `json:"name"`
+	PVCRef *kubeletPodReference `json:"pvcRef,omitempty"`
+}
+
+// seriesSet accumulates samples into one stream per label set.
+type seriesSet map[model.Fingerprint]*model.SampleStream
+
+func (s seriesSet) add(metric model.Metric, t model.Time, v float64) {
+	fingerprint := metric.Fingerprint()
+	stream, ok := s[fingerprint]
+	if !ok {
+		stream = &model.SampleStream{Metric: metric}
+		s[fingerprint] = stream
+	}
+	stream.Values = append(stream.Values, model.SamplePair{Timestamp: t, Value: model.SampleValue(v)})
+}
+
+func (s seriesSet) matrix() model.Matrix {
+	m := make(model.Matrix, 0, len(s))
+	for _, stream := range s {
+		m = append(m, stream)
+	}
+	sort.Slice(m, func(i, j int) bool { return m[i].Metric.Before(m[j].Metric) })
+	return m
+}
+
+type networkCounter struct {
+	at     time.Time
+	rx, tx uint64
+}
+
+// nodeTotals sums one sample of node and container usage over the nodes that reported.
+type nodeTotals struct {
+	cpuCapacity, memoryCapacity float64 // cores, bytes
+	nodeCPU, nodeMemoryUsed     float64
+	nodeMemoryAvailable         float64
+	containerCPU                float64
+	containerMemory             float64
+}
+
+// kubeletSampler turns successive Summary API and metrics.k8s.io samples into the series the
+// Prometheus backend queries.
+type kubeletSampler struct {
+	cpu              seriesSet
+	memory           seriesSet
+	memoryWorkingSet seriesSet
+	ephemeralStorage seriesSet
+	networkReceive   seriesSet
+	networkTransmit  seriesSet
+	volumeCapacity   seriesSet
+	volumeUsed       seriesSet
+	volumeInodesUsed seriesSet
+	volumeInodes     seriesSet
+	lastNetwork      map[string]networkCounter // keyed by namespace/pod
+	nodeSamples      []nodeTotals
+	first, last      time.Time
+}
+
+func newKubeletSampler() *kubeletSampler {
+	return &kubeletSampler{
+		cpu:              seriesSet{},
+		memory:           seriesSet{},
+		memoryWorkingSet: seriesSet{},
+		ephemeralStorage: seriesSet{},
+		networkReceive:   seriesSet{},
+		networkTransmit:  seriesSet{},
+		volumeCapacity:   seriesSet{},
+		volumeUsed:       seriesSet{},
+		volumeInodesUsed: seriesSet{},
+		volumeInodes:     seriesSet{},
+		lastNetwork:      map[string]networkCounter{},
+	}
+}
+
+func containerMetric(namespace, pod, container string) model.Metric {
+	return model.Metric{"namespace": model.LabelValue(namespace), "pod": model.LabelValue(pod), "container": model.LabelValue(container)}
+}
+
+func podMetric(namespace, pod string) model.Metric {
+	return model.Metric{"namespace": model.LabelValue(namespace), "pod": model.LabelValue(pod)}
+}
+
+func (s *kubeletSampler) observe(at time.Time) {
+	if s.first.IsZero() || at.Before(s.first) {
+		s.first = at
+	}
+	if at.After(s.last) {
+		s.last = at
+	}
+}
+
+// addSummary records one node's Summary API sample taken at at. Pods are added to seen, and
+// the node and container usage are added to totals.
+func (s *kubeletSampler) addSummary(summary *kubeletSummary, at time.Time, seen map[string]bool, volumesSeen map[string]bool, totals *nodeTotals) {
+	s.observe(at)
+	t := model.TimeFromUnixNano(at.UnixNano())
+	for _, pod := range summary.Pods {
+		namespace, name := pod.PodRef.Namespace, pod.PodRef.Name
+		seen[namespace+"/"+name] = true
+		for _, c := range pod.Containers {
+			if c.CPU != nil && c.CPU.UsageNanoCores != nil {
+				cores := float64(*c.CPU.UsageNanoCores) / 1e9
+				s.cpu.add(containerMetric(namespace, name, c.Name), t, cores)
+				totals.containerCPU += cores
+			}
+			if c.Memory != nil && c.Memory.UsageBytes != nil {
+				s.memory.add(containerMetric(namespace, name, c.Name), t, float64(*c.Memory.UsageBytes))
+				totals.containerMemory += float64(*c.Memory.UsageBytes)
+			}
+			if c.Memory != nil && c.Memory.WorkingSetBytes != nil {
+				s.memoryWorkingSet.add(containerMetric(namespace, name, c.Name), t, float64(*c.Memory.WorkingSetBytes))
+			}
+			if c.Rootfs != nil || c.Logs != nil {
+				s.ephemeralStorage.add(containerMetric(namespace, name, c.Name), t, float64(fsUsedBytes(c.Rootfs)+fsUsedBytes(c.Logs)))
+			}
+		}
+		if pod.Network != nil && pod.Network.RxBytes != nil && pod.Network.TxBytes != nil {
+			s.addNetwork(namespace, name, at, *pod.Network.RxBytes, *pod.Network.TxBytes)
+		}
+		for _, volume := range pod.Volumes {
+			if volume.PVCRef == nil {
+				continue
+			}
+			// a claim mounted by several pods is reported once per pod
+			volumeKey := volume.PVCRef.Namespace + "/" + volume.PVCRef.Name
+			if volumesSeen[volumeKey] {
+				continue
+			}
+			volumesSeen[volumeKey] = true
+			for _, stat := range []struct {
+				set   seriesSet
+				value *uint64
+			}{
+				{s.volumeCapacity, volume.CapacityBytes},
+				{s.volumeUsed, volume.UsedBytes},
+				{s.volumeInodes, volume.Inodes},
+				{s.volumeInodesUsed, volume.InodesUsed},
+			} {
+				if stat.value != nil {
+					metric := model.Metric{"namespace": model.LabelValue(volume.PVCRef.Namespace), "persistentvolumeclaim": model.LabelValue(volume.PVCRef.Name)}
+					stat.set.add(metric, t, float64(*stat.value))
+				}
+			}
+		}
+	}
+	if summary.Node.CPU != nil && summary.Node.CPU.UsageNanoCores != nil {
+		totals.nodeCPU += float64(*summary.Node.CPU.UsageNanoCores) / 1e9
+	}
+	if summary.Node.Memory != nil && summary.Node.Memory.AvailableBytes != nil {
+		totals.nodeMemoryAvailable += float64(*summary.Node.Memory.AvailableBytes)
+	}
+}
+
+func fsUsedBytes(fs *kubeletFsStats) uint64 {
+	if fs == nil || fs.UsedBytes == nil {
+		return 0
+	}
+	return *fs.UsedBytes
+}
+
+// addNetwork converts cumulative pod network counters into bytes per 30 seconds, as the
+// Prometheus backend reports them. The first sample of a pod and counter resets only
+// record the counters.
+func (s *kubeletSampler) addNetwork(namespace, pod string, at time.Time, rx, tx uint64) {
+	key := namespace + "/" + pod
+	previous, found := s.lastNetwork[key]
+	s.lastNetwork[key] = networkCounter{at: at, rx: rx, tx: tx}
+	if !found || !at.After(previous.at) || rx < previous.rx || tx < previous.tx {
+		return
+	}
+	scale := float64(30*time.Second) / float64(at.Sub(previous.at))
+	t := model.TimeFromUnixNano(at.UnixNano())
+	s.networkReceive.add(podMetric(namespace, pod), t, float64(rx-previous.rx)*scale)
+	s.networkTransmit.add(podMetric(namespace, pod), t, float64(tx-previous.tx)*scale)
+}
+
+// addPodMetrics records metrics.k8s.io PodMetrics for pods not already in seen. metrics-server
+// reports the working set as memory usage, so it fills both memory series.
+func (s *kubeletSampler) addPodMetrics(items []unstructured.Unstructured, at time.Time, seen map[string]bool) {
+	t := model.TimeFromUnixNano(at.UnixNano())
+	added := false
+	for _, item := range items {
+		namespace, name := item.GetNamespace(), item.GetName()
+		if seen[namespace+"/"+name] {
+			continue
+		}
+		containers, _, err := unstructured.NestedSlice(item.Object, "containers")
+		if err != nil {
+			logrus.Warnf("cannot read containers of PodMetrics %s/%s: %v", namespace, name, err)
+			continue
+		}
+		for _, c := range containers {
+			container, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			containerName, _, _ := unstructured.NestedString(container, "name")
+			if cpu, found, _ := unstructured.NestedString(container, "usage", "cpu"); found {
+				if q, err := resource.ParseQuantity(cpu); err == nil {
+					s.cpu.add(containerMetric(namespace, name, containerName), t, q.AsApproximateFloat64())
+					added = true
+				}
+			}
+			if memory, found, _ := unstructured.NestedString(container, "usage", "memory"); found {
+				if q, err := resource.ParseQuantity(memory); err == nil {
+					s.memory.add(containerMetric(namespace, name, containerName), t, q.AsApproximateFloat64())
+					s.memoryWorkingSet.add(containerMetric(namespace, name, containerName), t, q.AsApproximateFloat64())
+					added = true
+				}
+			}
+		}
+	}
+	if added {
+		s.observe(at)
+	}
+}
+
+// nodesMetrics averages the per-sample node totals into the idle and overhead percentages the
+// Prometheus backend reports.
+func (s *kubeletSampler) nodesMetrics() *NodesMetrics {
+	response := &NodesMetrics{}
+	var samples float64
+	for _, totals := range s.nodeSamples {
+		if totals.cpuCapacity == 0 || totals.memoryCapacity == 0 {
+			continue
+		}
+		samples++
+		response.IdleCPU += model.SampleValue(100 * (1 - totals.nodeCPU/totals.cpuCapacity))
+		response.IdleMemory += model.SampleValue(100 * totals.nodeMemoryAvailable / totals.memoryCapacity)
+		response.OverheadCPU += model.SampleValue(100 * (totals.nodeCPU - totals.containerCPU) / totals.cpuCapacity)
+		response.OverheadMemory += model.SampleValue(100 * (totals.nodeMemoryUsed - totals.containerMemory) / totals.memoryCapacity)
+	}
+	if samples == 0 {
+		return response
+	}
+	response.IdleCPU /= model.SampleValue(samples)
+	response.IdleMemory /= model.SampleValue(samples)
+	response.OverheadCPU /= model.SampleValue(samples)
+	response.OverheadMemory /= model.SampleValue(samples)
+	return response
+}
+
+// podResourceSeries returns one sample at t of the requests and limits of each container of
+// running pods, in the units kube-state-metrics uses (cores, bytes, GPU count).
+func podResourceSeries(pods []corev1.Pod, t model.Time) (series metricSeries) {
+	cpuRequest, memoryRequest, cpuLimit, memoryLimit := seriesSet{}, seriesSet{}, seriesSet{}, seriesSet{}
+	gpuRequest, gpuLimit := seriesSet{}, seriesSet{}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			metric := func() model.Metric { return containerMetric(pod.Namespace, pod.Name, c.Name) }
+			if q, ok := c.Resources.Requests[corev1.ResourceCPU]; ok {
+				cpuRequest.add(metric(), t, q.AsApproximateFloat64())
+			}
+			if q, ok := c.Resources.Requests[corev1.ResourceMemory]; ok {
+				memoryRequest.add(metric(), t, q.AsApproximateFloat64())
+			}
+			if q, ok := c.Resources.Limits[corev1.ResourceCPU]; ok {
+				cpuLimit.add(metric(), t, q.AsApproximateFloat64())
+			}
+			if q, ok := c.Resources.Limits[corev1.ResourceMemory]; ok {
+				memoryLimit.add(metric(), t, q.AsApproximateFloat64())
+			}
+			if gpus := gpuCount(c.Resources.Requests); gpus > 0 {
+				gpuRequest.add(metric(), t, gpus)
+			}
+			if gpus := gpuCount(c.Resources.Limits); gpus > 0 {
+				gpuLimit.add(metric(), t, gpus)
+			}
+		}
+	}
+	series.cpuRequest = cpuRequest.matrix()
+	series.memoryRequest = memoryRequest.matrix()
+	series.cpuLimit = cpuLimit.matrix()
+	series.memoryLimit = memoryLimit.matrix()
+	series.gpuRequest = gpuRequest.matrix()
+	series.gpuLimit = gpuLimit.matrix()
+	return series
+}
+
+func gpuCount(resources corev1.ResourceList) float64 {
+	var count float64
+	for name, q := range resources {
+		if gpuResourceRegexp.MatchString(strings.NewReplacer("/", "_", ".", "_").Replace(string(name))) {
+			count += q.AsApproximateFloat64()
+		}
+	}
+	return count
+}
+
+func getKubeletSummary(ctx context.Context, kube kubernetes.Interface, nodeName string) (*kubeletSummary, error) {
+	raw, err := kube.CoreV1().RESTClient().Get().Resource("nodes").Name(nodeName).SubResource("proxy").Suffix("stats", "summary").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching /stats/summary from node %s: %w", nodeName, err)
+	}
+	var summary kubeletSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return nil, fmt.Errorf("parsing /stats/summary from node %s: %w", nodeName, err)
+	}
+	return &summary, nil
+}
+
+// sample scrapes every node's Summary API once. Pods on nodes whose kubelet cannot be reached
+// are read from metrics.k8s.io instead.
+func (s *kubeletSampler) sample(ctx context.Context, kube kubernetes.Interface, dynamicClient dynamic.Interface, at time.Time) error {
+	nodes, err := kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+	seen := map[string]bool{}
+	volumesSeen := map[string]bool{}
+	totals := nodeTotals{}
+	failed := 0
+	for _, node := range nodes.Items {
+		summary, err := getKubeletSummary(ctx, kube, node.Name)
+		if err != nil {
+			logrus.Warnf("%v", err)
+			failed++
+			continue
+		}
+		memoryCapacity := node.Status.Capacity.Memory().AsApproximateFloat64()
+		totals.cpuCapacity += node.Status.Capacity.Cpu().AsApproximateFloat64()
+		totals.memoryCapacity += memoryCapacity
+		s.addSummary(summary, at, seen, volumesSeen, &totals)
+		if summary.Node.Memory != nil && summary.Node.Memory.AvailableBytes != nil {
+			totals.nodeMemoryUsed += memoryCapacity - float64(*summary.Node.Memory.AvailableBytes)
+		}
+	}
+	if failed < len(nodes.Items) {
+		s.nodeSamples = append(s.nodeSamples, totals)
+	}
+	if failed > 0 || len(nodes.Items) == 0 {
+		podMetrics, err := dynamicClient.Resource(podMetricsGVR).Namespace("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			logrus.Warnf("metrics.k8s.io not available for the %d nodes whose kubelet could not be reached: %v", failed, err)
+			return nil
+		}
+		s.addPodMetrics(podMetrics.Items, at, seen)
+	}
+	return nil
+}
+
+// GetMetricsFromKubelet collects the same CombinedRequests as GetMetrics without Prometheus, by
+// scraping each node's kubelet Summary API through the API server proxy every opts.Interval
+// for opts.Duration. Requests and limits come from pod specs. CPU throttling, OOM kills and
+// restarts are not available from the Summary API.
+func GetMetricsFromKubelet(ctx context.Context, dynamicClient dynamic.Interface, restMapper meta.RESTMapper, kube kubernetes.Interface, opts KubeletOptions) ([]CombinedRequest, *NodesMetrics, error) {
+	sampler := newKubeletSampler()
+	start := time.Now().Truncate(time.Second)
+	for i := 0; ; i++ {
+		at := start.Add(time.Duration(i) * opts.Interval)
+		if at.After(start.Add(opts.Duration)) || (i > 0 && opts.Interval <= 0) {
+			break
+		}
+		if wait := time.Until(at); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		if err := sampler.sample(ctx, kube, dynamicClient, at); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(sampler.cpu) == 0 && len(sampler.memory) == 0 {
+		return nil, nil, fmt.Errorf("No cpu or memory metrics found. Verify the collector can get nodes/proxy or list metrics.k8s.io pods")
+	}
+	logrus.Infof("Found %d metrics for cpu and %d for memory from the kubelet", len(sampler.cpu), len(sampler.memory))
+
+	pods, err := kube.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing pods: %w", err)
+	}
+	series := podResourceSeries(pods.Items, model.TimeFromUnixNano(sampler.last.UnixNano()))
+	series.cpu = sampler.cpu.matrix()
+	series.memory = sampler.memory.matrix()
+	series.memoryWorkingSet = sampler.memoryWorkingSet.matrix()
+	series.ephemeralStorage = sampler.ephemeralStorage.matrix()
+	series.networkReceive = sampler.networkReceive.matrix()
+	series.networkTransmit = sampler.networkTransmit.matrix()
+	series.volumeCapacity = sampler.volumeCapacity.matrix()
+	series.volumeUsed = sampler.volumeUsed.matrix()
+	series.volumeInodesUsed = sampler.volumeInodesUsed.matrix()
+	series.volumeInodes = sampler.volumeInodes.matrix()
+
+	r := prometheusV1.Range{Start: sampler.first, End: sampler.last, Step: opts.Interval}
+	res, err := combineMetrics(ctx, dynamicClient, restMapper, r, series)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, sampler.nodesMetrics(), nil
+}
@@ -0,0 +1,189 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testKubeletSummary = `{
+  "node": {
+    "nodeName": "node-1",
+    "cpu": {"usageNanoCores": 1000000000},
+    "memory": {"availableBytes": 6000000000, "usageBytes": 3000000000, "workingSetBytes": 2500000000}
+  },
+  "pods": [
+    {
+      "podRef": {"name": "web-1", "namespace": "default"},
+      "containers": [
+        {
+          "name": "web",
+          "cpu": {"usageNanoCores": 250000000},
+          "memory": {"usageBytes": 1000000000, "workingSetBytes": 800000000},
+          "rootfs": {"usedBytes": 4096},
+          "logs": {"usedBytes": 1024}
+        },
+        {
+          "name": "sidecar",
+          "cpu": {"usageNanoCores": 50000000},
+          "memory": {"usageBytes": 500000000, "workingSetBytes": 400000000}
+        }
+      ],
+      "network": {"name": "eth0", "rxBytes": %d, "txBytes": %d},
+      "volume": [
+        {"name": "data", "pvcRef": {"name": "data", "namespace": "default"}, "usedBytes": 100, "capacityBytes": 1000, "inodesUsed": 10, "inodes": 100},
+        {"name": "tmp", "usedBytes": 5}
+      ]
+    },
+    {
+      "podRef": {"name": "web-2", "namespace": "default"},
+      "containers": [{"name": "web", "cpu": {"usageNanoCores": 0}}],
+      "volume": [
+        {"name": "data", "pvcRef": {"name": "data", "namespace": "default"}, "usedBytes": 100, "capacityBytes": 1000, "inodesUsed": 10, "inodes": 100}
+      ]
+    }
+  ]
+}`
+
+func parseTestKubeletSummary(t *testing.T, rx, tx uint64) *kubeletSummary {
+	var summary kubeletSummary
+	require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(testKubeletSummary, rx, tx)), &summary))
+	return &summary
+}
+
+func streamFor(t *testing.T, m model.Matrix, metric model.Metric) *model.SampleStream {
+	for _, stream := range m {
+		if stream.Metric.Equal(metric) {
+			return stream
+		}
+	}
+	require.Failf(t, "series not found", "%v", metric)
+	return nil
+}
+
+func TestKubeletSampler(t *testing.T) {
+	t.Parallel()
+	sampler := newKubeletSampler()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, counters := range [][2]uint64{{1000, 2000}, {1600, 2300}, {100, 50}} {
+		at := start.Add(time.Duration(i) * time.Minute)
+		totals := nodeTotals{cpuCapacity: 4, memoryCapacity: 8000000000, nodeMemoryUsed: 2000000000}
+		sampler.addSummary(parseTestKubeletSummary(t, counters[0], counters[1]), at, map[string]bool{}, map[string]bool{}, &totals)
+		sampler.nodeSamples = append(sampler.nodeSamples, totals)
+	}
+	assert.Equal(t, start, sampler.first)
+	assert.Equal(t, start.Add(2*time.Minute), sampler.last)
+
+	web := containerMetric("default", "web-1", "web")
+	cpu := sampler.cpu.matrix()
+	require.Len(t, cpu, 3)
+	assert.Len(t, streamFor(t, cpu, web).Values, 3)
+	assert.Equal(t, model.SampleValue(0.25), streamFor(t, cpu, web).Values[0].Value)
+	assert.Equal(t, model.SampleValue(0), streamFor(t, cpu, containerMetric("default", "web-2", "web")).Values[0].Value)
+	assert.Equal(t, model.SampleValue(1000000000), streamFor(t, sampler.memory.matrix(), web).Values[0].Value)
+	assert.Equal(t, model.SampleValue(800000000), streamFor(t, sampler.memoryWorkingSet.matrix(), web).Values[0].Value)
+
+	// rootfs plus logs; the sidecar reports neither
+	ephemeral := sampler.ephemeralStorage.matrix()
+	require.Len(t, ephemeral, 1)
+	assert.Equal(t, model.SampleValue(5120), streamFor(t, ephemeral, web).Values[0].Value)
+
+	// 600 and 300 bytes over a minute is 300 and 150 bytes per 30s; the counter reset is skipped
+	receive := sampler.networkReceive.matrix()
+	require.Len(t, receive, 1)
+	require.Len(t, receive[0].Values, 1)
+	assert.Equal(t, podMetric("default", "web-1"), receive[0].Metric)
+	assert.Equal(t, model.SampleValue(300), receive[0].Values[0].Value)
+	assert.Equal(t, model.TimeFromUnixNano(start.Add(time.Minute).UnixNano()), receive[0].Values[0].Timestamp)
+	assert.Equal(t, model.SampleValue(150), sampler.networkTransmit.matrix()[0].Values[0].Value)
+
+	// the claim shared by both pods is counted once per sample; emptyDir volumes are ignored
+	capacity := sampler.volumeCapacity.matrix()
+	require.Len(t, capacity, 1)
+	assert.Equal(t, model.Metric{"namespace": "default", "persistentvolumeclaim": "data"}, capacity[0].Metric)
+	assert.Len(t, capacity[0].Values, 3)
+	assert.Equal(t, model.SampleValue(100), sampler.volumeUsed.matrix()[0].Values[0].Value)
+	assert.Equal(t, model.SampleValue(10), sampler.volumeInodesUsed.matrix()[0].Values[0].Value)
+	assert.Equal(t, model.SampleValue(100), sampler.volumeInodes.matrix()[0].Values[0].Value)
+
+	nodes := sampler.nodesMetrics()
+	assert.InDelta(t, 75, float64(nodes.IdleCPU), 0.001)
+	assert.InDelta(t, 75, float64(nodes.IdleMemory), 0.001)
+	assert.InDelta(t, 17.5, float64(nodes.OverheadCPU), 0.001)
+	assert.InDelta(t, 6.25, float64(nodes.OverheadMemory), 0.001)
+}
+
+func TestKubeletSamplerPodMetrics(t *testing.T) {
+	t.Parallel()
+	sampler := newKubeletSampler()
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	podMetrics := func(namespace, name, cpu, memory string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{"namespace": namespace, "name": name},
+			"containers": []any{
+				map[string]any{"name": "app", "usage": map[string]any{"cpu": cpu, "memory": memory}},
+			},
+		}}
+	}
+	sampler.addPodMetrics([]unstructured.Unstructured{
+		podMetrics("default", "scraped", "1", "1Gi"),
+		podMetrics("default", "unreachable", "250m", "128Mi"),
+	}, at, map[string]bool{"default/scraped": true})
+
+	cpu := sampler.cpu.matrix()
+	require.Len(t, cpu, 1)
+	assert.Equal(t, containerMetric("default", "unreachable", "app"), cpu[0].Metric)
+	assert.Equal(t, model.SampleValue(0.25), cpu[0].Values[0].Value)
+	assert.Equal(t, model.SampleValue(128*1024*1024), sampler.memory.matrix()[0].Values[0].Value)
+	assert.Equal(t, model.SampleValue(128*1024*1024), sampler.memoryWorkingSet.matrix()[0].Values[0].Value)
+	assert.Equal(t, at, sampler.first)
+}
+
+func TestPodResourceSeries(t *testing.T) {
+	t.Parallel()
+	container := corev1.Container{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+				"nvidia.com/gpu":      resource.MustParse("1"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+				"nvidia.com/gpu":      resource.MustParse("1"),
+			},
+		},
+	}
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "running"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "done"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+			Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+	}
+	series := podResourceSeries(pods, model.Time(1000))
+	metric := containerMetric("default", "running", "app")
+	require.Len(t, series.cpuRequest, 1)
+	assert.Equal(t, metric, series.cpuRequest[0].Metric)
+	assert.Equal(t, []model.SamplePair{{Timestamp: 1000, Value: 0.5}}, series.cpuRequest[0].Values)
+	assert.Equal(t, model.SampleValue(256*1024*1024), series.memoryRequest[0].Values[0].Value)
+	assert.Empty(t, series.cpuLimit)
+	assert.Equal(t, model.SampleValue(512*1024*1024), series.memoryLimit[0].Values[0].Value)
+	assert.Equal(t, model.SampleValue(1), series.gpuRequest[0].Values[0].Value)
+	assert.Equal(t, model.SampleValue(1), series.gpuLimit[0].Values[0].Value)
+}
@@ -30,6 +30,13 @@ const (
 	containerNetworkTransmitBytesTotal = "container_network_transmit_bytes_total"
 )
 
+const (
+	kubeletVolumeStatsUsedBytes     = "kubelet_volume_stats_used_bytes"
+	kubeletVolumeStatsCapacityBytes = "kubelet_volume_stats_capacity_bytes"
+	kubeletVolumeStatsInodesUsed    = "kubelet_volume_stats_inodes_used"
+	kubeletVolumeStatsInodes        = "kubelet_volume_stats_inodes"
+)
+
 const gkeSystemMetricsPrefix = "kubernetes_io:container_"
 
 func getMemory(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
@@ -165,6 +172,17 @@ func get30sIncreaseMetric(ctx context.Context, api prometheusV1.API, r prometheu
 	return adjusted, nil
 }
 
+// getVolumeStats returns a kubelet volume stats metric per PersistentVolumeClaim, labeled by
+// namespace and persistentvolumeclaim. max() collapses series from kubelets scraped more than once.
+func getVolumeStats(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, metric string, clusterName string) (model.Matrix, error) {
+	clusterFilter := ""
+	if clusterName != "" {
+		clusterFilter = fmt.Sprintf(`, cluster="%s"`, clusterName)
+	}
+	query := fmt.Sprintf(`max by (namespace, persistentvolumeclaim) (%s{persistentvolumeclaim!=""%s})`, metric, clusterFilter)
+	return queryPrometheus(ctx, api, r, query)
+}
+
 func getNodesIdleMemory(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
 	clusterFilter := ""
 	if clusterName != "" {
@@ -259,15 +277,20 @@ func validateClusterNameForPromQL(clusterName string) error {
 // resource), with cluster filter. Returns matrix with labels that may use
 // namespace_name/pod_name/container_name; call normalizeGKEContainerMatrix before use.
 // See: https://cloud.google.com/monitoring/api/resources#tag_k8s_container (cluster_name is a resource label).
-func queryGKEContainerMetric(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string, metricName string, sumByContainer bool) (model.Matrix, error) {
+// Extra label matchers (e.g. `memory_type="non-evictable"`) are added to the selector.
+func queryGKEContainerMetric(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string, metricName string, sumByContainer bool, extraMatchers ...string) (model.Matrix, error) {
 	if clusterName == "" {
 		return nil, fmt.Errorf("cluster name required for GKE system metrics query")
 	}
 	if err := validateClusterNameForPromQL(clusterName); err != nil {
 		return nil, err
 	}
+	extraFilter := ""
+	for _, matcher := range extraMatchers {
+		extraFilter += ", " + matcher
+	}
 	// GKE k8s_container resource uses cluster_name; monitored_resource disambiguates.
-	selector := fmt.Sprintf(`%s%s{monitored_resource="k8s_container", cluster_name="%s"}`, gkeSystemMetricsPrefix, metricName, clusterName)
+	selector := fmt.Sprintf(`%s%s{monitored_resource="k8s_container", cluster_name="%s"%s}`, gkeSystemMetricsPrefix, metricName, clusterName, extraFilter)
 	query := selector
 	if sumByContainer {
 		// Multiple series per container (e.g. accelerator per resource_name); sum to one per container.
@@ -369,6 +392,71 @@ func getGPULimitsGKE(ctx context.Context, api prometheusV1.API, r prometheusV1.R
 	return normalizeGKEContainerMatrix(m), nil
 }
 
+// =============================================================================
+// SIZING SIGNALS - CPU throttling, working set, OOM kills and restarts
+// =============================================================================
+// These explain bad sizing that average usage hides. All are optional: a backend
+// that does not expose them leaves the corresponding statistics empty.
+
+// getCPUThrottling returns, per container, the share of CFS periods in which the container
+// was throttled (0-1). Containers without a CPU limit have no CFS periods and are omitted.
+func getCPUThrottling(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
+	clusterFilter := ""
+	if clusterName != "" {
+		clusterFilter = fmt.Sprintf(`, cluster="%s"`, clusterName)
+	}
+	query := fmt.Sprintf(`sum by (namespace, pod, container) (rate(container_cpu_cfs_throttled_periods_total{image!="", container!="POD", container!=""%s}[5m]))
+		/ (sum by (namespace, pod, container) (rate(container_cpu_cfs_periods_total{image!="", container!="POD", container!=""%s}[5m])) > 0)`,
+		clusterFilter, clusterFilter)
+	return queryPrometheus(ctx, api, r, query)
+}
+
+func getMemoryWorkingSet(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
+	clusterFilter := ""
+	if clusterName != "" {
+		clusterFilter = fmt.Sprintf(`, cluster="%s"`, clusterName)
+	}
+	query := fmt.Sprintf(`container_memory_working_set_bytes{image!="", container!="POD", container!=""%s}`, clusterFilter)
+	return queryPrometheus(ctx, api, r, query)
+}
+
+// getOOMKilled returns 1 for containers whose last termination reason was OOMKilled.
+// kube-state-metrics only exposes the series for containers that have terminated at least once.
+func getOOMKilled(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
+	clusterFilter := ""
+	if clusterName != "" {
+		clusterFilter = fmt.Sprintf(`, cluster="%s"`, clusterName)
+	}
+	query := fmt.Sprintf(`kube_pod_container_status_last_terminated_reason{container!="", reason="OOMKilled"%s}`, clusterFilter)
+	return queryPrometheus(ctx, api, r, query)
+}
+
+func getRestarts(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
+	clusterFilter := ""
+	if clusterName != "" {
+		clusterFilter = fmt.Sprintf(`, cluster="%s"`, clusterName)
+	}
+	query := fmt.Sprintf(`kube_pod_container_status_restarts_total{container!=""%s}`, clusterFilter)
+	return queryPrometheus(ctx, api, r, query)
+}
+
+// getMemoryWorkingSetGKE uses non-evictable memory, which is how GKE reports the working set.
+func getMemoryWorkingSetGKE(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
+	m, err := queryGKEContainerMetric(ctx, api, r, clusterName, "memory_used_bytes", true, `memory_type="non-evictable"`)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeGKEContainerMatrix(m), nil
+}
+
+func getRestartsGKE(ctx context.Context, api prometheusV1.API, r prometheusV1.Range, clusterName string) (model.Matrix, error) {
+	m, err := queryGKEContainerMetric(ctx, api, r, clusterName, "restart_count", false)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeGKEContainerMatrix(m), nil
+}
+
 // =============================================================================
 // GPU METRICS - Multi-vendor support
 // =============================================================================
@@ -0,0 +1,78 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const lastCollectedKey = "lastCollectedEnd"
+
+// LoadLastCollected returns the end of the last successfully collected window, stored in the
+// ConfigMap namespace/name. A missing ConfigMap or key returns a zero time.
+func LoadLastCollected(ctx context.Context, kube kubernetes.Interface, namespace, name string) (time.Time, error) {
+	configMap, err := kube.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to get state ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	value, ok := configMap.Data[lastCollectedKey]
+	if !ok {
+		return time.Time{}, nil
+	}
+	lastCollected, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse %s %q in state ConfigMap %s/%s: %w", lastCollectedKey, value, namespace, name, err)
+	}
+	return lastCollected, nil
+}
+
+// SaveLastCollected stores the end of the last successfully collected window in the ConfigMap
+// namespace/name, creating it if needed.
+func SaveLastCollected(ctx context.Context, kube kubernetes.Interface, namespace, name string, lastCollected time.Time) error {
+	value := lastCollected.UTC().Format(time.RFC3339)
+	configMaps := kube.CoreV1().ConfigMaps(namespace)
+	configMap, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string]string{lastCollectedKey: value},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("unable to create state ConfigMap %s/%s: %w", namespace, name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get state ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[lastCollectedKey] = value
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update state ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
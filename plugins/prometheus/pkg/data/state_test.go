@@ -0,0 +1,33 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLastCollectedState(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	kube := fake.NewSimpleClientset()
+
+	lastCollected, err := LoadLastCollected(ctx, kube, "insights-agent", "state")
+	require.NoError(t, err)
+	assert.True(t, lastCollected.IsZero())
+
+	first := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, SaveLastCollected(ctx, kube, "insights-agent", "state", first))
+	lastCollected, err = LoadLastCollected(ctx, kube, "insights-agent", "state")
+	require.NoError(t, err)
+	assert.True(t, first.Equal(lastCollected))
+
+	second := first.Add(15 * time.Minute)
+	require.NoError(t, SaveLastCollected(ctx, kube, "insights-agent", "state", second))
+	lastCollected, err = LoadLastCollected(ctx, kube, "insights-agent", "state")
+	require.NoError(t, err)
+	assert.True(t, second.Equal(lastCollected))
+}
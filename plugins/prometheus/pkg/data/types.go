@@ -26,6 +26,21 @@ const (
 	MetricNetworkReceive  = "NetworkReceive"
 	MetricNetworkTransmit = "NetworkTransmit"
 	MetricStorageCapacity = "StorageCapacity"
+	// MetricStorageUsed, MetricStorageInodesUsed and MetricStorageInodes come from kubelet
+	// volume stats, summed over the PersistentVolumeClaims a pod references.
+	MetricStorageUsed       = "StorageUsed"
+	MetricStorageInodesUsed = "StorageInodesUsed"
+	MetricStorageInodes     = "StorageInodes"
+	// MetricEphemeralStorage is container rootfs and log usage in bytes (kubelet Summary API backend only).
+	MetricEphemeralStorage = "EphemeralStorage"
+	// MetricCPUThrottling is the share of CFS periods in which the container was throttled (0-100).
+	MetricCPUThrottling = "CPUThrottling"
+	// MetricMemoryWorkingSet is the container working set in bytes, the value the OOM killer acts on.
+	MetricMemoryWorkingSet = "MemoryWorkingSet"
+	// MetricOOMKilled is 1 when the container's last termination was an OOM kill, 0 otherwise.
+	MetricOOMKilled = "OOMKilled"
+	// MetricRestarts is the container's cumulative restart count.
+	MetricRestarts = "Restarts"
 )
 
 // Owner is the information about a pod that a set of metrics belongs to.
@@ -47,6 +62,23 @@ type Statistics struct {
 	LimitValue int64
 }
 
+// PercentileStatistics summarizes the distribution of a metric for a pod/container over the
+// collection window. Values use the same units as Statistics.
+type PercentileStatistics struct {
+	Owner
+	StartTime   time.Time // first sample
+	EndTime     time.Time // last sample
+	Metric      string
+	SampleCount int
+	P50         int64
+	P90         int64
+	P95         int64
+	P99         int64
+	Max         int64
+	Request     int64
+	LimitValue  int64
+}
+
 // CombinedRequest is the cpu/memory and requests for a given pod/container
 type CombinedRequest struct {
 	Owner
@@ -59,11 +91,21 @@ type CombinedRequest struct {
 	networkTransmit []model.SamplePair
 	networkReceive  []model.SamplePair
 	storageCapacity []model.SamplePair
+	// Storage usage from kubelet volume stats - empty when the kubelet metrics are not collected
+	storageUsed       []model.SamplePair
+	storageInodesUsed []model.SamplePair
+	storageInodes     []model.SamplePair
+	ephemeralStorage  []model.SamplePair
 	// GPU fields - utilization from vendor-specific exporters (DCGM, AMD SMI, Intel, Habana),
 	// requests/limits from kube-state-metrics for all GPU vendors
 	gpu        []model.SamplePair // GPU utilization (0-1 normalized, pod-level from GPU exporters)
 	gpuRequest model.SampleValue  // GPU resource requests (all vendors via kube-state-metrics)
 	gpuLimit   model.SampleValue  // GPU resource limits (all vendors via kube-state-metrics)
+	// Sizing signals - optional, empty when the backend does not expose them
+	cpuThrottling    []model.SamplePair // throttled / total CFS periods (0-1)
+	memoryWorkingSet []model.SamplePair
+	oomKilled        []model.SamplePair // 1 when the last termination reason was OOMKilled
+	restarts         []model.SamplePair // cumulative restart count
 }
 
 type NodesMetrics struct {
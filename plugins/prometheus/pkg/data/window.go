@@ -0,0 +1,110 @@
+// Copyright 2020 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	prometheusV1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// DefaultMaxLookback caps how far back a run backfills usage after missed runs.
+	DefaultMaxLookback = 6 * time.Hour
+	// DefaultQueryChunk is the longest range requested by a single QueryRange call.
+	DefaultQueryChunk = time.Hour
+)
+
+// CollectionRange returns the range to collect usage for. It is the usual window ending at
+// the last full timestep, extended back to lastCollected (the end of the last successfully
+// collected window) when runs were missed, but no further than maxLookback.
+// A zero lastCollected returns the usual window.
+func CollectionRange(lastCollected, now time.Time, maxLookback time.Duration) prometheusV1.Range {
+	r := getRangeAt(now)
+	if lastCollected.IsZero() || !lastCollected.Before(r.Start) {
+		return r
+	}
+	start := lastCollected
+	if earliest := r.End.Add(-maxLookback); start.Before(earliest) {
+		start = earliest
+	}
+	if start.Before(r.Start) {
+		r.Start = start
+	}
+	return r
+}
+
+// chunkedAPI splits QueryRange calls spanning more than chunk into consecutive calls so a
+// backfilled range does not exceed the backend's max-samples limit. Series returned by each
+// call are merged by label set.
+type chunkedAPI struct {
+	prometheusV1.API
+	chunk time.Duration
+}
+
+// WithQueryChunking wraps api so that QueryRange calls longer than chunk are split.
+// A chunk <= 0 returns api unchanged.
+func WithQueryChunking(api prometheusV1.API, chunk time.Duration) prometheusV1.API {
+	if chunk <= 0 {
+		return api
+	}
+	return &chunkedAPI{API: api, chunk: chunk}
+}
+
+// QueryRange implements prometheusV1.API.
+func (c *chunkedAPI) QueryRange(ctx context.Context, query string, r prometheusV1.Range, opts ...prometheusV1.Option) (model.Value, prometheusV1.Warnings, error) {
+	if r.Step <= 0 || r.End.Sub(r.Start) <= c.chunk {
+		return c.API.QueryRange(ctx, query, r, opts...)
+	}
+	// chunks are a whole number of steps so every chunk stays on the same evaluation grid
+	steps := c.chunk / r.Step
+	if steps < 1 {
+		steps = 1
+	}
+	chunk := steps * r.Step
+
+	merged := model.Matrix{}
+	streams := map[model.Fingerprint]*model.SampleStream{}
+	var allWarnings prometheusV1.Warnings
+	for start := r.Start; !start.After(r.End); {
+		end := start.Add(chunk)
+		if end.After(r.End) {
+			end = r.End
+		}
+		values, warnings, err := c.API.QueryRange(ctx, query, prometheusV1.Range{Start: start, End: end, Step: r.Step}, opts...)
+		allWarnings = append(allWarnings, warnings...)
+		if err != nil {
+			return nil, allWarnings, err
+		}
+		matrix, ok := values.(model.Matrix)
+		if !ok {
+			return nil, allWarnings, fmt.Errorf("expected Matrix from QueryRange, got %T", values)
+		}
+		for _, stream := range matrix {
+			fingerprint := stream.Metric.Fingerprint()
+			if existing, ok := streams[fingerprint]; ok {
+				existing.Values = append(existing.Values, stream.Values...)
+				continue
+			}
+			streams[fingerprint] = stream
+			merged = append(merged, stream)
+		}
+		start = end.Add(r.Step)
+	}
+	return merged, allWarnings, nil
+}
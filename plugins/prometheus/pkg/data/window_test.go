@@ -0,0 +1,106 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	prometheusV1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeRecordingAPI returns one sample per step for two series and records every range queried.
+type rangeRecordingAPI struct {
+	prometheusV1.API
+	ranges []prometheusV1.Range
+	err    error
+}
+
+func (a *rangeRecordingAPI) QueryRange(_ context.Context, _ string, r prometheusV1.Range, _ ...prometheusV1.Option) (model.Value, prometheusV1.Warnings, error) {
+	a.ranges = append(a.ranges, r)
+	if a.err != nil {
+		return nil, nil, a.err
+	}
+	matrix := model.Matrix{}
+	for _, pod := range []string{"web-1", "web-2"} {
+		stream := &model.SampleStream{Metric: model.Metric{"namespace": "default", "pod": model.LabelValue(pod), "container": "web"}}
+		for t := r.Start; !t.After(r.End); t = t.Add(r.Step) {
+			stream.Values = append(stream.Values, model.SamplePair{Timestamp: model.TimeFromUnixNano(t.UnixNano()), Value: 1})
+		}
+		matrix = append(matrix, stream)
+	}
+	return matrix, prometheusV1.Warnings{"partial"}, nil
+}
+
+func TestCollectionRange(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 1, 12, 7, 30, 0, time.UTC)
+	end := time.Date(2026, 1, 1, 12, 5, 0, 0, time.UTC)
+
+	r := CollectionRange(time.Time{}, now, DefaultMaxLookback)
+	assert.Equal(t, end.Add(-15*time.Minute), r.Start)
+	assert.Equal(t, end, r.End)
+	assert.Equal(t, 30*time.Second, r.Step)
+
+	// the previous run covered the usual window: nothing to backfill
+	r = CollectionRange(end.Add(-5*time.Minute), now, DefaultMaxLookback)
+	assert.Equal(t, end.Add(-15*time.Minute), r.Start)
+
+	// missed runs are backfilled from the end of the last collected window
+	r = CollectionRange(end.Add(-2*time.Hour), now, DefaultMaxLookback)
+	assert.Equal(t, end.Add(-2*time.Hour), r.Start)
+	assert.Equal(t, end, r.End)
+
+	// capped at maxLookback
+	r = CollectionRange(end.Add(-48*time.Hour), now, DefaultMaxLookback)
+	assert.Equal(t, end.Add(-DefaultMaxLookback), r.Start)
+
+	// a maxLookback shorter than the usual window never shrinks it
+	r = CollectionRange(end.Add(-2*time.Hour), now, 5*time.Minute)
+	assert.Equal(t, end.Add(-15*time.Minute), r.Start)
+}
+
+func TestWithQueryChunking(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	end := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	api := &rangeRecordingAPI{}
+	chunked := WithQueryChunking(api, time.Hour)
+	short := prometheusV1.Range{Start: end.Add(-15 * time.Minute), End: end, Step: 30 * time.Second}
+	_, _, err := chunked.QueryRange(ctx, "up", short)
+	require.NoError(t, err)
+	assert.Equal(t, []prometheusV1.Range{short}, api.ranges)
+
+	api = &rangeRecordingAPI{}
+	chunked = WithQueryChunking(api, time.Hour)
+	long := prometheusV1.Range{Start: end.Add(-150 * time.Minute), End: end, Step: 30 * time.Second}
+	values, warnings, err := chunked.QueryRange(ctx, "up", long)
+	require.NoError(t, err)
+	assert.Equal(t, []prometheusV1.Range{
+		{Start: long.Start, End: long.Start.Add(time.Hour), Step: long.Step},
+		{Start: long.Start.Add(time.Hour + 30*time.Second), End: long.Start.Add(2*time.Hour + 30*time.Second), Step: long.Step},
+		{Start: long.Start.Add(2*time.Hour + time.Minute), End: end, Step: long.Step},
+	}, api.ranges)
+	assert.Len(t, warnings, 3)
+
+	matrix := values.(model.Matrix)
+	require.Len(t, matrix, 2)
+	for _, stream := range matrix {
+		// one sample per step, no duplicates at chunk boundaries
+		require.Len(t, stream.Values, 301)
+		for i := 1; i < len(stream.Values); i++ {
+			assert.Equal(t, model.Time(30_000), stream.Values[i].Timestamp-stream.Values[i-1].Timestamp)
+		}
+	}
+
+	api = &rangeRecordingAPI{err: errors.New("boom")}
+	_, _, err = WithQueryChunking(api, time.Hour).QueryRange(ctx, "up", long)
+	assert.EqualError(t, err, "boom")
+	assert.Len(t, api.ranges, 1)
+
+	assert.Same(t, api, WithQueryChunking(api, 0))
+}
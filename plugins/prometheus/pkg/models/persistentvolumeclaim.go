@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/fairwindsops/controller-utils/pkg/controller"
@@ -15,9 +16,10 @@ import (
 // persistentVolumeClaim holds minimal information about a PVC to track
 // capacity and how many pods share a claim.
 type persistentVolumeClaim struct {
-	name     string
-	capacity int64    // Represented in bytes.
-	refs     []string // Pods that reference this PVC, of the form namespace/name.
+	namespace string
+	name      string
+	capacity  int64    // Represented in bytes.
+	refs      []string // Pods that reference this PVC, of the form namespace/name.
 }
 
 // numRefs returns the number of references that pods have to this persistentVolumeClaim.
@@ -57,6 +59,7 @@ func NewStorageInfoFromUnstructuredPVCs(unstructuredPVCs []unstructured.Unstruct
 			logrus.Warnf("skipping PersistentVolumeClaim, unable to get metadata.name from unstructured resource: %#v", unstructuredPVC.UnstructuredContent())
 			continue
 		}
+		PVCNamespace, _, _ := unstructured.NestedString(unstructuredPVC.UnstructuredContent(), "metadata", "namespace")
 		PVCCapacityStr, foundCapacity, err := unstructured.NestedString(unstructuredPVC.UnstructuredContent(), "status", "capacity", "storage")
 		if err != nil {
 			logrus.Warnf("skipping PersistentVolumeClaim, unable to get status.capacity.storage from unstructured resource: error=%v, PVC=%#v", err, unstructuredPVC.UnstructuredContent())
@@ -72,8 +75,9 @@ func NewStorageInfoFromUnstructuredPVCs(unstructuredPVCs []unstructured.Unstruct
 			continue
 		}
 		PVC := &persistentVolumeClaim{
-			name:     PVCName,
-			capacity: PVCCapacityInt,
+			namespace: PVCNamespace,
+			name:      PVCName,
+			capacity:  PVCCapacityInt,
 		}
 		s.pvcs = append(s.pvcs, PVC)
 	}
@@ -108,6 +112,18 @@ func (s StorageInfo) PVCByName(name string) *persistentVolumeClaim {
 	return nil
 }
 
+// pvcByNamespacedName returns the persistentVolumeClaim with the specified
+// namespace and name. A PVC without a namespace matches on name only. If no PVC
+// is found, nil is returned.
+func (s StorageInfo) pvcByNamespacedName(namespace, name string) *persistentVolumeClaim {
+	for _, PVC := range s.pvcs {
+		if PVC.name == name && (PVC.namespace == namespace || PVC.namespace == "") {
+			return PVC
+		}
+	}
+	return nil
+}
+
 // AddPVCRef associates the specified Pod name, with the specified
 // persistentVolumeClaim, and adds the PVC capacity to the total capacity for
 // the Pod.
@@ -123,7 +139,8 @@ func (s *StorageInfo) AddPVCRef(PVCName, podKey string) {
 		logrus.Warnf("cannot add reference %q to PersistentVolumeClaim %q  when either the pod-key (reference) or PVC name are empty", podKey, PVCName)
 		return
 	}
-	PVC := s.PVCByName(PVCName)
+	podNamespace, _, _ := strings.Cut(podKey, "/")
+	PVC := s.pvcByNamespacedName(podNamespace, PVCName)
 	if PVC == nil {
 		logrus.Warnf("cannot add reference %q to PersistentVolumeClaim %q because the PVC was not found in-cluster", podKey, PVCName)
 		return
@@ -162,6 +179,61 @@ func (s *StorageInfo) ManufactureMetrics(r prometheusV1.Range) model.Matrix {
 	return newMetrics
 }
 
+// PodVolumeMetrics sums kubelet volume stats (kubelet_volume_stats_*, labeled
+// by namespace and persistentvolumeclaim) across the PersistentVolumeClaims
+// each Pod references, returning one series per Pod labeled by namespace and
+// pod. A shared PVC counts fully towards every Pod that references it, as in
+// ManufactureMetrics.
+// PVCs without a stats series use their capacity for every step in the range
+// when capacityFallback is true, and are skipped otherwise.
+func (s *StorageInfo) PodVolumeMetrics(volumeStats model.Matrix, r prometheusV1.Range, capacityFallback bool) model.Matrix {
+	statsByPVC := make(map[string][]model.SamplePair)
+	for _, stream := range volumeStats {
+		key := fmt.Sprintf("%s/%s", stream.Metric["namespace"], stream.Metric["persistentvolumeclaim"])
+		statsByPVC[key] = stream.Values
+	}
+	valuesPerPod := make(map[string]map[model.Time]model.SampleValue)
+	var podKeys []string
+	for _, pvc := range s.pvcs {
+		for _, ref := range pvc.refs {
+			refNamespace, _, _ := strings.Cut(ref, "/")
+			values, found := statsByPVC[fmt.Sprintf("%s/%s", refNamespace, pvc.name)]
+			if !found {
+				if !capacityFallback {
+					continue
+				}
+				logrus.Debugf("no kubelet volume stats for PersistentVolumeClaim %s/%s, using its capacity %d", refNamespace, pvc.name, pvc.capacity)
+				values = useThisValueForEveryStepInRange(model.SampleValue(pvc.capacity), r)
+			}
+			if valuesPerPod[ref] == nil {
+				valuesPerPod[ref] = make(map[model.Time]model.SampleValue)
+				podKeys = append(podKeys, ref)
+			}
+			for _, v := range values {
+				valuesPerPod[ref][v.Timestamp] += v.Value
+			}
+		}
+	}
+	newMetrics := make(model.Matrix, 0, len(podKeys))
+	for _, ref := range podKeys {
+		refFields := strings.Split(ref, "/")
+		if len(refFields) < 2 {
+			logrus.Warnf("cannot split PersistentVolumeClaim ref %q by slash, to get namespace and name, this PersistentVolumeClaim reference will not have metrics", ref)
+			continue
+		}
+		newSample := &model.SampleStream{Metric: model.Metric{
+			"namespace": model.LabelValue(refFields[0]),
+			"pod":       model.LabelValue(refFields[1]),
+		}}
+		for timestamp, value := range valuesPerPod[ref] {
+			newSample.Values = append(newSample.Values, model.SamplePair{Timestamp: timestamp, Value: value})
+		}
+		sort.Slice(newSample.Values, func(i, j int) bool { return newSample.Values[i].Timestamp < newSample.Values[j].Timestamp })
+		newMetrics = append(newMetrics, newSample)
+	}
+	return newMetrics
+}
+
 // capacityString2Int uses a resource.Quantity type to convert a capacity with
 // units (5 Gi) into bytes.
 func capacityString2Int(capacityStr string) (capacityInt int64, err error) {
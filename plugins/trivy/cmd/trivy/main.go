@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 
 	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/config"
 	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/image"
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
 	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/util"
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/vex"
 	"github.com/sirupsen/logrus"
 )
 
@@ -43,6 +46,15 @@ func main() {
 		}
 	}
 
+	var vexStatements []vex.Statement
+	if cfg.VEXDir != "" {
+		vexStatements, err = vex.LoadDir(cfg.VEXDir)
+		if err != nil {
+			logrus.Fatalf("could not load VEX documents: %v", err)
+		}
+		logrus.Infof("Loaded %d VEX statements from %s", len(vexStatements), cfg.VEXDir)
+	}
+
 	host := os.Getenv("FAIRWINDS_INSIGHTS_HOST")
 	org := os.Getenv("FAIRWINDS_ORG")
 	cluster := os.Getenv("FAIRWINDS_CLUSTER")
@@ -68,27 +80,61 @@ func main() {
 		logrus.Debugf("%v - %v", i.Name, i.ID)
 	}
 
+	var sbomStore *image.SBOMStore
+	if cfg.SBOMDir != "" {
+		sbomStore, err = image.NewSBOMStore(cfg.SBOMDir)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		err = sbomStore.Prune(inClusterImages)
+		if err != nil {
+			logrus.Warnf("could not prune stored SBOMs: %v", err)
+		}
+	}
+
 	if len(cfg.ImagesToScan) > 0 {
 		logrus.Infof("Images to scan is set on the environment, we will only scan those images")
 		inClusterImages = util.FilterImagesByName(inClusterImages, cfg.ImagesToScan)
 	}
 
-	imagesToScan := image.GetUnscannedImagesToScan(inClusterImages, lastReport.Images, cfg.MaxImagesToScan)
+	image.ScoreImages(inClusterImages, cfg.CriticalNamespaces)
+
+	// Running on each node, only the images on this node are scanned; the whole cluster is
+	// still used to prune the report
+	candidateImages := inClusterImages
+	if cfg.NodeName != "" {
+		candidateImages = util.FilterImagesByNode(inClusterImages, cfg.NodeName)
+		logrus.Infof("Found %d images on node %s", len(candidateImages), cfg.NodeName)
+	}
+
+	imagesToScan := image.GetUnscannedImagesToScan(candidateImages, lastReport.Images, cfg.MaxImagesToScan)
 	unscannedCount := len(imagesToScan)
 	logrus.Infof("Found %d images that have never been scanned", unscannedCount)
-	imagesToScan = image.GetImagesToReScan(inClusterImages, *lastReport, imagesToScan, cfg.MaxImagesToScan)
+	// Images with a stored SBOM are re-scanned without pulling them, up to their own limit
+	rescanCandidates := candidateImages
+	var sbomImagesToScan []models.Image
+	if sbomStore != nil {
+		var withSBOM []models.Image
+		withSBOM, rescanCandidates = sbomStore.Partition(candidateImages)
+		sbomImagesToScan = image.GetImagesToReScan(withSBOM, *lastReport, nil, cfg.MaxSBOMRescans)
+		logrus.Infof("Will re-scan %d images from stored SBOMs", len(sbomImagesToScan))
+	}
+	imagesToScan = image.GetImagesToReScan(rescanCandidates, *lastReport, imagesToScan, cfg.MaxImagesToScan)
 	logrus.Infof("Will re-scan %d additional images", len(imagesToScan)-unscannedCount)
 	for _, i := range imagesToScan {
 		logrus.Debugf("%v - %v", i.Name, i.ID)
 	}
 
+	nextScanOrder := image.SetScanOrder(imagesToScan, 1)
+	image.SetScanOrder(sbomImagesToScan, nextScanOrder)
+
 	// Owners info from latest report might be out-of-date, we need to update it using the cluster info
 	lastReport.Images = image.UpdateOwnersReferenceOnMatchingImages(lastReport.Images, inClusterImages)
 	// Remove any images from the report that are no longer in the cluster
 	lastReport.Images = image.GetMatchingImages(lastReport.Images, inClusterImages, false)
 	logrus.Infof("%d images after removing images no longer in cluster", len(lastReport.Images))
 	// Remove any images from the report that we're going to re-scan now
-	lastReport.Images = image.GetUnmatchingImages(lastReport.Images, imagesToScan, false)
+	lastReport.Images = image.GetUnmatchingImages(lastReport.Images, slices.Concat(imagesToScan, sbomImagesToScan), false)
 	logrus.Infof("%d images after removing images to be scanned", len(lastReport.Images))
 	// Remove any recommendations from the report that no longer have a corresponding image in the cluster
 	lastReport.Images = image.GetMatchingImages(lastReport.Images, inClusterImages, true)
@@ -106,11 +152,26 @@ func main() {
 	}
 
 	logrus.Infof("Starting image scans")
-	allReports := image.ScanImages(image.ScanImage, imagesToScan, cfg.MaxConcurrentScans, cfg.ExtraFlags, cfg.TrivyServerURL, registryOAuth2AccessTokenMap)
+	fromContainerd := cfg.ImageSource == config.ImageSourceContainerd
+	scanner := image.ScanImage
+	if fromContainerd {
+		scanner = image.ContainerdScanner(imagesToScan)
+	}
+	if sbomStore != nil {
+		scanner = sbomStore.ImageScanner(imagesToScan, fromContainerd)
+	}
+	allReports := image.ScanImages(scanner, imagesToScan, cfg.MaxConcurrentScans, cfg.ExtraFlags, cfg.TrivyServerURL, registryOAuth2AccessTokenMap)
+	if len(sbomImagesToScan) > 0 {
+		logrus.Infof("Scanning %d stored SBOMs", len(sbomImagesToScan))
+		sbomReports := image.ScanImages(sbomStore.SBOMScanner(sbomImagesToScan), sbomImagesToScan, cfg.MaxConcurrentScans, cfg.ExtraFlags, cfg.TrivyServerURL, registryOAuth2AccessTokenMap)
+		allReports = append(allReports, sbomReports...)
+		imagesToScan = append(imagesToScan, sbomImagesToScan...)
+	}
 
+	var recommendationsToScan []models.Image
 	if noRecommendations == "" {
 		logrus.Infof("Scanning recommendations")
-		recommendationsToScan := image.GetNewestVersionsToScan(ctx, allReports, imagesToScan, registryOAuth2AccessTokenMap)
+		recommendationsToScan = image.GetNewestVersionsToScan(ctx, allReports, imagesToScan, registryOAuth2AccessTokenMap)
 		// Remove any recommendations from the report that we're going to re-scan now
 		lastReport.Images = image.GetUnmatchingImages(lastReport.Images, recommendationsToScan, true)
 		logrus.Infof("%d images after removing recommendations that will be scanned", len(lastReport.Images))
@@ -119,6 +180,24 @@ func main() {
 		logrus.Infof("Done scanning recommendations")
 		allReports = append(allReports, recommendationReport...)
 	}
+	if cfg.NodeName != "" {
+		// The other nodes upload their own scans while this one runs; rebase on the latest
+		// report so that only this node's images are replaced.
+		latestReport, err := image.FetchLastReport(ctx, host, org, cluster, token)
+		if err != nil {
+			logrus.Warnf("could not refresh the last report, results uploaded by other nodes during this run may be dropped: %v", err)
+		} else {
+			lastReport = latestReport
+			lastReport.Images = image.RetainedImages(lastReport.Images, inClusterImages, imagesToScan, recommendationsToScan)
+			logrus.Infof("%d images kept from the latest report", len(lastReport.Images))
+		}
+	}
+	if cfg.VEXDir != "" || cfg.VEXFromReferrers {
+		image.ApplyVEX(ctx, allReports, lastReport.Images, vexStatements, cfg.VEXFromReferrers, registryOAuth2AccessTokenMap)
+	}
+	if noRecommendations == "" {
+		image.AddRecommendations(allReports)
+	}
 	logrus.Infof("Done with all scans, minimizing report")
 	minimizedReport := image.Minimize(allReports, *lastReport)
 	data, err := json.Marshal(minimizedReport)
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -12,6 +13,14 @@ import (
 const (
 	MAX_CONCURRENT_SCANS = 5
 	MAX_IMAGES_TO_SCAN   = 10
+	MAX_SBOM_RESCANS     = 100
+)
+
+const (
+	// ImageSourceRegistry pulls images from their registry with skopeo.
+	ImageSourceRegistry = "registry"
+	// ImageSourceContainerd exports images from the node's containerd image store.
+	ImageSourceContainerd = "containerd"
 )
 
 type config struct {
@@ -21,9 +30,16 @@ type config struct {
 	ExtraFlags         string
 	NamespaceBlocklist []string
 	NamespaceAllowlist []string
+	CriticalNamespaces []string
 	HasGKESAAnnotation bool
 	ImagesToScan       []string
 	TrivyServerURL     string
+	SBOMDir            string
+	MaxSBOMRescans     int
+	VEXDir             string
+	VEXFromReferrers   bool
+	ImageSource        string
+	NodeName           string
 }
 
 func LoadFromEnvironment() (*config, error) {
@@ -47,6 +63,16 @@ func LoadFromEnvironment() (*config, error) {
 		}
 	}
 
+	maxSBOMRescans := MAX_SBOM_RESCANS
+	maxSBOMRescansEnvVar := os.Getenv("MAX_SBOM_RESCANS")
+	if maxSBOMRescansEnvVar != "" {
+		var err error
+		maxSBOMRescans, err = strconv.Atoi(maxSBOMRescansEnvVar)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var extraFlags string
 	ignoreUnfixedStr := os.Getenv("IGNORE_UNFIXED")
 	if ignoreUnfixedStr != "" {
@@ -74,7 +100,7 @@ func LoadFromEnvironment() (*config, error) {
 		}
 	}
 
-	var namespaceBlocklist, namespaceAllowlist, imagesToScan []string
+	var namespaceBlocklist, namespaceAllowlist, criticalNamespaces, imagesToScan []string
 	if os.Getenv("NAMESPACE_BLACKLIST") != "" {
 		namespaceBlocklist = strings.Split(os.Getenv("NAMESPACE_BLACKLIST"), ",")
 	}
@@ -84,6 +110,9 @@ func LoadFromEnvironment() (*config, error) {
 	if os.Getenv("NAMESPACE_ALLOWLIST") != "" {
 		namespaceAllowlist = strings.Split(os.Getenv("NAMESPACE_ALLOWLIST"), ",")
 	}
+	if os.Getenv("CRITICAL_NAMESPACES") != "" {
+		criticalNamespaces = strings.Split(os.Getenv("CRITICAL_NAMESPACES"), ",")
+	}
 	if os.Getenv("IMAGES_TO_SCAN") != "" {
 		imagesToScan = strings.Split(os.Getenv("IMAGES_TO_SCAN"), ",")
 	}
@@ -94,6 +123,33 @@ func LoadFromEnvironment() (*config, error) {
 		trivyServerURL = os.Getenv("TRIVY_SERVER_URL")
 	}
 
+	sbomDir := os.Getenv("SBOM_DIR")
+	if sbomDir != "" && trivyServerURL != "" {
+		logrus.Warnf("SBOM_DIR is ignored when TRIVY_SERVER_URL is set, images are not pulled locally")
+		sbomDir = ""
+	}
+
+	var vexFromReferrers bool
+	if vexFromReferrersStr := os.Getenv("VEX_FROM_REFERRERS"); vexFromReferrersStr != "" {
+		var err error
+		vexFromReferrers, err = strconv.ParseBool(vexFromReferrersStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	imageSource := os.Getenv("IMAGE_SOURCE")
+	switch imageSource {
+	case "":
+		imageSource = ImageSourceRegistry
+	case ImageSourceRegistry, ImageSourceContainerd:
+	default:
+		return nil, fmt.Errorf("IMAGE_SOURCE must be registry or containerd, got %q", imageSource)
+	}
+	if imageSource == ImageSourceContainerd && trivyServerURL != "" {
+		return nil, fmt.Errorf("IMAGE_SOURCE=containerd cannot be combined with TRIVY_SERVER_URL")
+	}
+
 	hasGKESAAnnotation := false
 	if _, ok := serviceAccountAnnotations["iam.gke.io/gcp-service-account"]; ok {
 		hasGKESAAnnotation = true
@@ -106,8 +162,15 @@ func LoadFromEnvironment() (*config, error) {
 		ExtraFlags:         extraFlags,
 		NamespaceBlocklist: namespaceBlocklist,
 		NamespaceAllowlist: namespaceAllowlist,
+		CriticalNamespaces: criticalNamespaces,
 		HasGKESAAnnotation: hasGKESAAnnotation,
 		ImagesToScan:       imagesToScan,
 		TrivyServerURL:     trivyServerURL,
+		SBOMDir:            sbomDir,
+		MaxSBOMRescans:     maxSBOMRescans,
+		VEXDir:             os.Getenv("VEX_DIR"),
+		VEXFromReferrers:   vexFromReferrers,
+		ImageSource:        imageSource,
+		NodeName:           os.Getenv("NODE_NAME"),
 	}, nil
 }
@@ -18,6 +18,12 @@ func TestLoadFromEnvironmentDefault(t *testing.T) {
 	assert.Empty(t, cfg.ExtraFlags)
 	assert.False(t, cfg.Offline)
 	assert.Empty(t, cfg.ImagesToScan)
+	assert.Empty(t, cfg.SBOMDir)
+	assert.Equal(t, 100, cfg.MaxSBOMRescans)
+	assert.Empty(t, cfg.VEXDir)
+	assert.False(t, cfg.VEXFromReferrers)
+	assert.Equal(t, ImageSourceRegistry, cfg.ImageSource)
+	assert.Empty(t, cfg.NodeName)
 }
 
 func TestLoadFromEnvironment(t *testing.T) {
@@ -28,7 +34,12 @@ func TestLoadFromEnvironment(t *testing.T) {
 	t.Setenv("OFFLINE", "true")
 	t.Setenv("NAMESPACE_BLOCKLIST", "kube-system,kube-public")
 	t.Setenv("NAMESPACE_ALLOWLIST", "default,fw-insights")
+	t.Setenv("CRITICAL_NAMESPACES", "payments")
 	t.Setenv("IMAGES_TO_SCAN", "nginx:latest,redis:alpine")
+	t.Setenv("SBOM_DIR", "/var/lib/trivy-sbom")
+	t.Setenv("MAX_SBOM_RESCANS", "500")
+	t.Setenv("VEX_DIR", "/etc/vex")
+	t.Setenv("VEX_FROM_REFERRERS", "true")
 
 	cfg, err := LoadFromEnvironment()
 	assert.NoError(t, err)
@@ -36,9 +47,41 @@ func TestLoadFromEnvironment(t *testing.T) {
 	assert.True(t, cfg.HasGKESAAnnotation, 1)
 	assert.Len(t, cfg.NamespaceBlocklist, 2)
 	assert.Len(t, cfg.NamespaceAllowlist, 2)
+	assert.Equal(t, []string{"payments"}, cfg.CriticalNamespaces)
 	assert.Equal(t, 99, cfg.MaxConcurrentScans)
 	assert.Equal(t, 88, cfg.MaxImagesToScan)
 	assert.Equal(t, "--ignore-unfixed", cfg.ExtraFlags)
 	assert.True(t, cfg.Offline)
 	assert.Equal(t, []string{"nginx:latest", "redis:alpine"}, cfg.ImagesToScan)
+	assert.Equal(t, "/var/lib/trivy-sbom", cfg.SBOMDir)
+	assert.Equal(t, 500, cfg.MaxSBOMRescans)
+	assert.Equal(t, "/etc/vex", cfg.VEXDir)
+	assert.True(t, cfg.VEXFromReferrers)
+}
+
+func TestLoadFromEnvironmentSBOMDirIgnoredWithTrivyServer(t *testing.T) {
+	t.Setenv("SBOM_DIR", "/var/lib/trivy-sbom")
+	t.Setenv("TRIVY_SERVER_URL", "http://trivy-server:4954")
+
+	cfg, err := LoadFromEnvironment()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.SBOMDir)
+}
+
+func TestLoadFromEnvironmentContainerdImageSource(t *testing.T) {
+	t.Setenv("IMAGE_SOURCE", "containerd")
+	t.Setenv("NODE_NAME", "node-1")
+
+	cfg, err := LoadFromEnvironment()
+	assert.NoError(t, err)
+	assert.Equal(t, ImageSourceContainerd, cfg.ImageSource)
+	assert.Equal(t, "node-1", cfg.NodeName)
+
+	t.Setenv("TRIVY_SERVER_URL", "http://trivy-server:4954")
+	_, err = LoadFromEnvironment()
+	assert.Error(t, err)
+
+	t.Setenv("IMAGE_SOURCE", "docker")
+	_, err = LoadFromEnvironment()
+	assert.Error(t, err)
 }
@@ -0,0 +1,79 @@
+package image
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/util"
+)
+
+const (
+	defaultContainerdAddress   = "/run/containerd/containerd.sock"
+	defaultContainerdNamespace = "k8s.io"
+)
+
+var containerdAddress = os.Getenv("CONTAINERD_ADDRESS")
+var containerdNamespace = os.Getenv("CONTAINERD_NAMESPACE")
+
+// ContainerdScanner returns a scanner that exports the given images, which run on this node,
+// from the node's containerd image store instead of pulling them. Any other image, such as a
+// recommended version, is not in the store and is pulled from its registry like ScanImage.
+func ContainerdScanner(images []models.Image) ImageScannerFunc {
+	onNode := pullRefs(images)
+	return func(extraFlags, pullRef string, trivyServerURL string, registryOAuth2AccessTokenMap map[string]string) (*models.TrivyResults, error) {
+		return scanImage(extraFlags, pullRef, trivyServerURL, registryOAuth2AccessTokenMap, "", onNode[pullRef])
+	}
+}
+
+func pullRefs(images []models.Image) map[string]bool {
+	refs := map[string]bool{}
+	for _, img := range images {
+		refs[img.PullRef] = true
+	}
+	return refs
+}
+
+// exportFromContainerd exports the image with ctr to an OCI archive that Trivy can scan. The
+// kubelet records the running digest as a repo digest reference (registry/repo@sha256:...),
+// so the pod's pull ref names the exact image in the node's store.
+func exportFromContainerd(pullRef string) (string, error) {
+	address := containerdAddress
+	if address == "" {
+		address = defaultContainerdAddress
+	}
+	namespace := containerdNamespace
+	if namespace == "" {
+		namespace = defaultContainerdNamespace
+	}
+	imageID := nonWordRegexp.ReplaceAllString(pullRef, "_")
+	dest := TempDir + "/" + imageID + ".oci.tar"
+	ref := containerdReference(pullRef)
+
+	args := []string{
+		"--address", address, "--namespace", namespace,
+		"images", "export", "--platform", runtime.GOOS + "/" + runtime.GOARCH, dest, ref,
+	}
+	_, err := util.RunCommand(exec.Command("ctr", args...), "exporting image "+ref+" from containerd")
+	if err != nil {
+		os.Remove(dest)
+		return "", fmt.Errorf("error exporting %s from containerd: %w", ref, err)
+	}
+	return dest, nil
+}
+
+// containerdReference expands a reference to the fully qualified name containerd stores it
+// under, e.g. nginx@sha256:... becomes docker.io/library/nginx@sha256:...
+func containerdReference(ref string) string {
+	first, rest, found := strings.Cut(ref, "/")
+	if !found {
+		return "docker.io/library/" + ref
+	}
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return ref
+	}
+	return "docker.io/" + first + "/" + rest
+}
@@ -0,0 +1,15 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerdReference(t *testing.T) {
+	assert.Equal(t, "docker.io/library/nginx@sha256:abc", containerdReference("nginx@sha256:abc"))
+	assert.Equal(t, "docker.io/bitnami/redis:7.2", containerdReference("bitnami/redis:7.2"))
+	assert.Equal(t, "quay.io/fairwinds/polaris:9.0", containerdReference("quay.io/fairwinds/polaris:9.0"))
+	assert.Equal(t, "localhost/app:dev", containerdReference("localhost/app:dev"))
+	assert.Equal(t, "registry:5000/app:dev", containerdReference("registry:5000/app:dev"))
+}
@@ -12,7 +12,10 @@ import (
 	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
 	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/util"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 )
 
 func namespaceIsBlocked(ns string, namespaceBlocklist, namespaceAllowlist []string) bool {
@@ -47,11 +50,15 @@ func GetImages(ctx context.Context, namespaceBlocklist, namespaceAllowlist []str
 		return nil, fmt.Errorf("could not retrieve top controllers with pods: %w", err)
 	}
 
+	exposure := getExposure(ctx, kubeClientResources.Client)
+
 	// TODO: we're deduping by owner, which works in most cases, but might cause us
 	// to miss certain images. E.g. mid-release, the new pods and the old pods
 	// will exist under the same owner.
 	keyToImage := map[string]models.Image{}
 	imageOwners := map[string]map[models.Resource]struct{}{}
+	imageWorkloads := map[string]models.WorkloadContext{}
+	imageNodes := map[string]map[string]struct{}{}
 	for _, controller := range controllers {
 		if namespaceIsBlocked(controller.TopController.GetNamespace(), namespaceBlocklist, namespaceAllowlist) {
 			logrus.Debugf("Namespace %s blocked", controller.TopController.GetNamespace())
@@ -72,6 +79,7 @@ func GetImages(ctx context.Context, namespaceBlocklist, namespaceAllowlist []str
 				logrus.Warnf("Unable to retrieve structured pod data: %v", err)
 			}
 
+			podExposed := exposure.podIsExposed(pod)
 			for _, containerStatus := range pod.Status.ContainerStatuses {
 				var imageName string
 				if strings.HasPrefix(containerStatus.Image, "sha256") {
@@ -91,6 +99,18 @@ func GetImages(ctx context.Context, namespaceBlocklist, namespaceAllowlist []str
 					imageOwners[imgKey] = map[models.Resource]struct{}{}
 				}
 				imageOwners[imgKey][owner] = struct{}{}
+				workload := imageWorkloads[imgKey]
+				workload.Privileged = workload.Privileged || containerIsPrivileged(pod, containerStatus.Name)
+				workload.HostNetwork = workload.HostNetwork || pod.Spec.HostNetwork
+				workload.Exposed = workload.Exposed || podExposed
+				workload.Replicas++
+				imageWorkloads[imgKey] = workload
+				if pod.Spec.NodeName != "" {
+					if imageNodes[imgKey] == nil {
+						imageNodes[imgKey] = map[string]struct{}{}
+					}
+					imageNodes[imgKey][pod.Spec.NodeName] = struct{}{}
+				}
 				if _, found := keyToImage[imgKey]; found {
 					continue
 				}
@@ -107,12 +127,83 @@ func GetImages(ctx context.Context, namespaceBlocklist, namespaceAllowlist []str
 		}
 	}
 
-	// add owners to images
+	// add owners, workload context and nodes to images
 	for key, image := range keyToImage {
 		if owners, ok := imageOwners[key]; ok {
 			image.Owners = lo.Keys(owners)
-			keyToImage[key] = image
 		}
+		image.Workload = imageWorkloads[key]
+		image.Nodes = lo.Keys(imageNodes[key])
+		keyToImage[key] = image
 	}
 	return lo.Values(keyToImage), nil
 }
+
+func containerIsPrivileged(pod corev1.Pod, containerName string) bool {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == containerName {
+			return container.SecurityContext != nil && container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged
+		}
+	}
+	return false
+}
+
+// exposure holds the Services and Ingresses that make pods reachable from outside the cluster.
+type exposure struct {
+	services        map[string][]corev1.Service // by namespace
+	ingressBackends map[string]map[string]bool  // Service names used by Ingresses, by namespace
+}
+
+// getExposure lists Services and Ingresses. Failures are logged and treated as no exposure,
+// since they only affect scan priority.
+func getExposure(ctx context.Context, client kubernetes.Interface) exposure {
+	e := exposure{services: map[string][]corev1.Service{}, ingressBackends: map[string]map[string]bool{}}
+	services, err := client.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("Unable to list services, exposure will not be used to prioritise scans: %v", err)
+	} else {
+		for _, svc := range services.Items {
+			e.services[svc.Namespace] = append(e.services[svc.Namespace], svc)
+		}
+	}
+	ingresses, err := client.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("Unable to list ingresses, exposure will not be used to prioritise scans: %v", err)
+		return e
+	}
+	for _, ingress := range ingresses.Items {
+		backends := e.ingressBackends[ingress.Namespace]
+		if backends == nil {
+			backends = map[string]bool{}
+			e.ingressBackends[ingress.Namespace] = backends
+		}
+		if ingress.Spec.DefaultBackend != nil && ingress.Spec.DefaultBackend.Service != nil {
+			backends[ingress.Spec.DefaultBackend.Service.Name] = true
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil {
+					backends[path.Backend.Service.Name] = true
+				}
+			}
+		}
+	}
+	return e
+}
+
+// podIsExposed returns true when the pod is selected by a LoadBalancer Service or by a Service
+// that an Ingress routes to.
+func (e exposure) podIsExposed(pod corev1.Pod) bool {
+	for _, svc := range e.services[pod.Namespace] {
+		if len(svc.Spec.Selector) == 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer || e.ingressBackends[pod.Namespace][svc.Name] {
+			return true
+		}
+	}
+	return false
+}
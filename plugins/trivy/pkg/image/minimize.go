@@ -40,6 +40,10 @@ func Minimize(images []models.ImageReport, lastReport models.MinimizedReport) mo
 			LastScan:           &timestamp,
 			RecommendationOnly: imageDetails.RecommendationOnly,
 			Error:              imageDetails.Error,
+			RiskScore:          imageDetails.RiskScore,
+			RiskFactors:        imageDetails.RiskFactors,
+			ScanOrder:          imageDetails.ScanOrder,
+			Recommendations:    imageDetails.Recommendations,
 		}
 		for _, vulnList := range imageDetails.Reports {
 			vulnRefList := models.VulnerabilityRefList{
@@ -55,10 +59,14 @@ func Minimize(images []models.ImageReport, lastReport models.MinimizedReport) mo
 				}
 				vulnerabilityExists[vuln.VulnerabilityID] = true
 				vulnRefList.Vulnerabilities = append(vulnRefList.Vulnerabilities, models.VulnerabilityInstance{
-					InstalledVersion: vuln.InstalledVersion,
-					PkgName:          vuln.PkgName,
-					VulnerabilityID:  vuln.VulnerabilityID,
-					FixedVersion:     vuln.FixedVersion,
+					InstalledVersion:   vuln.InstalledVersion,
+					PkgName:            vuln.PkgName,
+					VulnerabilityID:    vuln.VulnerabilityID,
+					FixedVersion:       vuln.FixedVersion,
+					VEXStatus:          vuln.VEXStatus,
+					VEXJustification:   vuln.VEXJustification,
+					VEXImpactStatement: vuln.VEXImpactStatement,
+					VEXSource:          vuln.VEXSource,
 				})
 			}
 			imageDetailsWithRefs.Report = append(imageDetailsWithRefs.Report, vulnRefList)
@@ -16,21 +16,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-var knownPreReleases = []string{
-	"centos-7",
-	"debian-8",
-	"debian-9",
-	"debian-10",
-	"ol-7",
-	"ubuntu",
-	"amd64",
-	"alpine",
-	"bullseye",
-}
-
-const MaxNewestVersionsToScan = 1
-
-// GetNewestVersions returns newest versions and newest version within same major version
+// GetNewestVersions returns the newest patch within the current minor version and the newest
+// version overall, of the same variant as tag
 func GetNewestVersions(ctx context.Context, repo, tag string, registryOAuth2AccessTokenMap map[string]string) ([]string, error) {
 	logrus.Infof("started retrieving newest versions for %s:%s", repo, tag)
 	tags, err := fetchTags(ctx, repo, registryOAuth2AccessTokenMap)
@@ -42,10 +29,7 @@ func GetNewestVersions(ctx context.Context, repo, tag string, registryOAuth2Acce
 		return nil, err
 	}
 	logrus.Infof("finished retrieving newest versions for %s:%s", repo, tag)
-	if len(newest) <= MaxNewestVersionsToScan {
-		return newest, nil
-	}
-	return newest[len(newest)-MaxNewestVersionsToScan:], nil
+	return selectNewest(newest, tag), nil
 }
 
 func fetchTags(ctx context.Context, imageRepoName string, registryOAuth2AccessTokenMap map[string]string) ([]string, error) {
@@ -75,10 +59,7 @@ func filterAndSort(suggestedTags []string, curTagStr string) ([]string, error) {
 		return []string{}, nil
 	}
 
-	constraint, err := semver.NewConstraint(">" + curTagStr)
-	if err != nil {
-		return nil, err
-	}
+	_, curVariant := splitPrerelease(curVersion.Prerelease())
 
 	newest := []*semver.Version{}
 	for _, tag := range suggestedTags {
@@ -93,18 +74,18 @@ func filterAndSort(suggestedTags []string, curTagStr string) ([]string, error) {
 			continue
 		}
 
-		cPre := curVersion.Prerelease()
-		vPre := v.Prerelease()
-		if cPre != "" && vPre != "" && cPre != vPre {
-			logrus.Debugf("pre-releases does not match: %s != %s", cPre, vPre)
+		preRelease, variant := splitPrerelease(v.Prerelease())
+		if preRelease != "" {
+			logrus.Debugf("skipping pre-release %s", tag)
+			continue
+		}
+		if variant != curVariant {
+			logrus.Debugf("variants does not match: %s != %s", curVariant, variant)
 			continue
 		}
 
-		check, errors := constraint.Validate(v)
-		if check {
+		if v.GreaterThan(curVersion) {
 			newest = append(newest, v)
-		} else {
-			logrus.Debug(errors) // for debugging only
 		}
 	}
 	Sort(newest)
@@ -119,22 +100,6 @@ func isShortShaTag(tag string) bool {
 	return intTag > 1_000
 }
 
-func GetSpecificToken(tag string) string {
-	for _, v := range knownPreReleases {
-		if strings.Contains(tag, v) {
-			return v
-		}
-	}
-	return ""
-}
-
-func GetRecommendationKey(repoName, specific string) string {
-	if specific == "" {
-		return repoName
-	}
-	return repoName + "/" + specific
-}
-
 type NewestVersions struct {
 	repo     string
 	versions []string
@@ -162,6 +127,7 @@ func GetNewestVersionsToScan(ctx context.Context, allReports []models.ImageRepor
 	}
 
 	newImagesToScan := []models.Image{}
+	added := map[string]bool{}
 	for i := 0; i < len(imageWithVulns); i++ {
 		vc := <-versionsChan
 		if vc.err != nil {
@@ -169,6 +135,10 @@ func GetNewestVersionsToScan(ctx context.Context, allReports []models.ImageRepor
 		}
 		logrus.Infof("received newer versions for image %s - %v", vc.repo, vc.versions)
 		for _, v := range vc.versions {
+			if added[vc.repo+":"+v] {
+				continue
+			}
+			added[vc.repo+":"+v] = true
 			newImagesToScan = append(newImagesToScan, models.Image{
 				ID:                 fmt.Sprintf("%v:%v", vc.repo, v),
 				Name:               fmt.Sprintf("%v:%v", vc.repo, v),
@@ -181,15 +151,13 @@ func GetNewestVersionsToScan(ctx context.Context, allReports []models.ImageRepor
 }
 
 func getNewestVersions(versionsChan chan NewestVersions, ctx context.Context, img models.ImageReport, registryOAuth2AccessTokenMap map[string]string) {
-	parts := strings.Split(img.Name, ":")
-	if len(parts) != 2 {
+	repo, tag, ok := splitImageTag(img.Name)
+	if !ok {
 		versionsChan <- NewestVersions{
 			err: fmt.Errorf("cannot find tag while getting newest version for image %q", img.Name),
 		}
 		return
 	}
-	repo := parts[0]
-	tag := parts[1]
 	if strings.Contains(strings.ToLower(img.Name), "@sha256:") {
 		// Do not try to find newer versions when the tag is a sha256.
 		repo = strings.Split(repo, "@")[0]
@@ -100,3 +100,10 @@ func TestGetNewestVersionsToScan(t *testing.T) {
 	images := GetNewestVersionsToScan(context.Background(), allReports, allImages, map[string]string{})
 	assert.NotZero(t, images)
 }
+
+func TestFilterAndSortKeepsVariant(t *testing.T) {
+	tags := []string{"1.25.3", "1.25.3-bookworm", "1.25.4-bookworm", "1.26.0-bookworm", "1.26.0-alpine", "1.27.0-rc.1-bookworm", "1.26.1-slim-bookworm"}
+	newestTags, err := filterAndSort(tags, "1.25.2-bookworm")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.25.3-bookworm", "1.25.4-bookworm", "1.26.0-bookworm"}, newestTags)
+}
@@ -0,0 +1,99 @@
+package image
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Weights of the workload risk factors. An image scores the sum of the factors that apply to
+// any of the pods running it.
+const (
+	privilegedWeight        = 40
+	hostNetworkWeight       = 30
+	exposedWeight           = 30
+	criticalNamespaceWeight = 20
+	maxReplicasWeight       = 20 // one point per replica beyond the first
+)
+
+// stalenessPointsPerDay is added to the risk score of previously scanned images for each day
+// since their last scan, so that low-risk images are still re-scanned eventually.
+const stalenessPointsPerDay = 10
+
+// ScoreImages sets the risk score and factors of each image from its workload context.
+func ScoreImages(images []models.Image, criticalNamespaces []string) {
+	for i := range images {
+		images[i].RiskScore, images[i].RiskFactors = riskScore(images[i], criticalNamespaces)
+	}
+}
+
+func riskScore(img models.Image, criticalNamespaces []string) (int, []string) {
+	score := 0
+	factors := []string{}
+	if img.Workload.Privileged {
+		score += privilegedWeight
+		factors = append(factors, "privileged")
+	}
+	if img.Workload.HostNetwork {
+		score += hostNetworkWeight
+		factors = append(factors, "host-network")
+	}
+	if img.Workload.Exposed {
+		score += exposedWeight
+		factors = append(factors, "exposed")
+	}
+	for _, owner := range img.Owners {
+		if namespaceIsCritical(owner.Namespace, criticalNamespaces) {
+			score += criticalNamespaceWeight
+			factors = append(factors, "critical-namespace")
+			break
+		}
+	}
+	if img.Workload.Replicas > 1 {
+		score += min(img.Workload.Replicas-1, maxReplicasWeight)
+		factors = append(factors, fmt.Sprintf("replicas=%d", img.Workload.Replicas))
+	}
+	return score, factors
+}
+
+func namespaceIsCritical(ns string, criticalNamespaces []string) bool {
+	for _, namespace := range criticalNamespaces {
+		if strings.EqualFold(strings.TrimSpace(ns), strings.TrimSpace(namespace)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByRiskScore orders images by descending risk score, keeping the existing order for ties.
+func sortByRiskScore(images []models.Image) {
+	slices.SortStableFunc(images, func(a, b models.Image) int {
+		return cmp.Compare(b.RiskScore, a.RiskScore)
+	})
+}
+
+// rescanPriority is the risk score plus points for each day since the last scan. Images that
+// were never successfully scanned come first.
+func rescanPriority(img models.Image, lastScan *time.Time, now time.Time) int {
+	if lastScan == nil {
+		return math.MaxInt
+	}
+	days := int(now.Sub(*lastScan).Hours() / 24)
+	return img.RiskScore + days*stalenessPointsPerDay
+}
+
+// SetScanOrder numbers images in the order they were chosen for scanning, starting at first,
+// logs that order and returns the next number.
+func SetScanOrder(images []models.Image, first int) int {
+	for i := range images {
+		images[i].ScanOrder = first + i
+		logrus.Infof("Scan order %d: %s (risk score %d: %s)", images[i].ScanOrder, images[i].Name, images[i].RiskScore, strings.Join(images[i].RiskFactors, ","))
+	}
+	return first + len(images)
+}
@@ -0,0 +1,104 @@
+package image
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreImages(t *testing.T) {
+	images := []models.Image{{
+		Name:     "nginx:1.27",
+		Owners:   []models.Resource{{Namespace: "payments", Kind: "Deployment", Name: "web"}},
+		Workload: models.WorkloadContext{Exposed: true, Replicas: 3},
+	}, {
+		Name:     "calico/node:v3.28",
+		Owners:   []models.Resource{{Namespace: "kube-system", Kind: "DaemonSet", Name: "calico-node"}},
+		Workload: models.WorkloadContext{Privileged: true, HostNetwork: true, Replicas: 50},
+	}, {
+		Name:     "redis:alpine",
+		Workload: models.WorkloadContext{Replicas: 1},
+	}}
+
+	ScoreImages(images, []string{"Payments"})
+
+	assert.Equal(t, exposedWeight+criticalNamespaceWeight+2, images[0].RiskScore)
+	assert.Equal(t, []string{"exposed", "critical-namespace", "replicas=3"}, images[0].RiskFactors)
+	assert.Equal(t, privilegedWeight+hostNetworkWeight+maxReplicasWeight, images[1].RiskScore)
+	assert.Equal(t, []string{"privileged", "host-network", "replicas=50"}, images[1].RiskFactors)
+	assert.Equal(t, 0, images[2].RiskScore)
+	assert.Empty(t, images[2].RiskFactors)
+}
+
+func TestNamespaceIsCriticalIgnoresCase(t *testing.T) {
+	assert.True(t, namespaceIsCritical("Payments", []string{" payments"}))
+	assert.True(t, namespaceIsCritical("payments", []string{"PAYMENTS"}))
+	assert.False(t, namespaceIsCritical("payments-staging", []string{"payments"}))
+}
+
+func TestUpdateOwnersReferenceRefreshesRiskOfRetainedImages(t *testing.T) {
+	retained := []models.ImageDetailsWithRefs{
+		{Name: "nginx:1.27", ID: "nginx@sha256:1", RiskScore: 70, RiskFactors: []string{"privileged"}, ScanOrder: 4},
+		{Name: "gone:1", ID: "gone@sha256:2", RiskScore: 30, ScanOrder: 1},
+	}
+	inCluster := []models.Image{{
+		Name:        "nginx:1.27",
+		ID:          "nginx@sha256:1",
+		RiskScore:   exposedWeight,
+		RiskFactors: []string{"exposed"},
+		Owners:      []models.Resource{{Namespace: "web", Kind: "Deployment", Name: "nginx"}},
+	}}
+
+	updated := UpdateOwnersReferenceOnMatchingImages(retained, inCluster)
+	assert.Equal(t, exposedWeight, updated[0].RiskScore)
+	assert.Equal(t, []string{"exposed"}, updated[0].RiskFactors)
+	assert.Zero(t, updated[0].ScanOrder)
+	assert.Equal(t, "nginx", updated[0].Owners[0].Name)
+	// images no longer in the cluster are left alone, and removed later
+	assert.Equal(t, 30, updated[1].RiskScore)
+}
+
+func TestGetUnscannedImagesToScanByRiskScore(t *testing.T) {
+	inCluster := []models.Image{
+		{Name: "low:1", ID: "low@sha256:1", RiskScore: 0},
+		{Name: "high:1", ID: "high@sha256:2", RiskScore: 70},
+		{Name: "medium:1", ID: "medium@sha256:3", RiskScore: 30},
+	}
+
+	toScan := GetUnscannedImagesToScan(inCluster, nil, 2)
+	assert.Len(t, toScan, 2)
+	assert.Equal(t, "high:1", toScan[0].Name)
+	assert.Equal(t, "medium:1", toScan[1].Name)
+}
+
+func TestGetImagesToReScanByRiskAndStaleness(t *testing.T) {
+	now := time.Now()
+	fiveDaysAgo := now.Add(-5 * 24 * time.Hour)
+	oneDayAgo := now.Add(-25 * time.Hour)
+	inCluster := []models.Image{
+		{Name: "old:1", ID: "old@sha256:1"},
+		{Name: "risky:1", ID: "risky@sha256:2", RiskScore: 70},
+		{Name: "recent:1", ID: "recent@sha256:3"},
+	}
+	lastReport := models.MinimizedReport{Images: []models.ImageDetailsWithRefs{
+		{Name: "recent:1", ID: "recent@sha256:3", LastScan: &now},
+		{Name: "risky:1", ID: "risky@sha256:2", LastScan: &oneDayAgo},
+		{Name: "old:1", ID: "old@sha256:1", LastScan: &fiveDaysAgo},
+	}}
+
+	toScan := GetImagesToReScan(inCluster, lastReport, nil, 2)
+	assert.Len(t, toScan, 2)
+	// risky: 70 + 1 day, old: 0 + 5 days
+	assert.Equal(t, "risky:1", toScan[0].Name)
+	assert.Equal(t, "old:1", toScan[1].Name)
+}
+
+func TestSetScanOrder(t *testing.T) {
+	images := []models.Image{{Name: "a"}, {Name: "b"}}
+	next := SetScanOrder(images, 3)
+	assert.Equal(t, 5, next)
+	assert.Equal(t, 3, images[0].ScanOrder)
+	assert.Equal(t, 4, images[1].ScanOrder)
+}
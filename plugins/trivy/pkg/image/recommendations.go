@@ -0,0 +1,167 @@
+package image
+
+import (
+	"regexp"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	UpgradePatch = "patch"
+	UpgradeMinor = "minor"
+	UpgradeMajor = "major"
+)
+
+// preReleaseRegexp matches the pre-release marker at the start of a semver pre-release, e.g.
+// beta.1 in 1.2.0-beta.1 or alpha.0 in 0.15.0-alpha.0-ubi
+var preReleaseRegexp = regexp.MustCompile(`(?i)^(alpha|beta|rc|pre|preview|dev|snapshot)([.]?[0-9]+)*(-|$)`)
+
+// splitPrerelease separates the pre-release marker of a semver pre-release from the image
+// variant, e.g. alpine or slim-bookworm.
+func splitPrerelease(prerelease string) (string, string) {
+	loc := preReleaseRegexp.FindStringIndex(prerelease)
+	if loc == nil {
+		return "", prerelease
+	}
+	return strings.TrimSuffix(prerelease[:loc[1]], "-"), prerelease[loc[1]:]
+}
+
+// GetSpecificToken returns the variant of a tag, e.g. alpine for 1.27-alpine, so recommendations
+// keep the variant of the running image.
+func GetSpecificToken(tag string) string {
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return ""
+	}
+	_, variant := splitPrerelease(v.Prerelease())
+	return variant
+}
+
+func GetRecommendationKey(repoName, specific string) string {
+	if specific == "" {
+		return repoName
+	}
+	return repoName + "/" + specific
+}
+
+// selectNewest picks, from newer versions sorted in ascending order, the newest patch within
+// the current minor version and the newest version overall.
+func selectNewest(newer []string, curTagStr string) []string {
+	if len(newer) == 0 {
+		return newer
+	}
+	selected := []string{}
+	if curVersion, err := semver.NewVersion(curTagStr); err == nil {
+		for i := len(newer) - 1; i >= 0; i-- {
+			v, err := semver.NewVersion(newer[i])
+			if err == nil && v.Major() == curVersion.Major() && v.Minor() == curVersion.Minor() {
+				if i != len(newer)-1 {
+					selected = append(selected, newer[i])
+				}
+				break
+			}
+		}
+	}
+	return append(selected, newer[len(newer)-1])
+}
+
+func upgradeKind(from, to *semver.Version) string {
+	switch {
+	case from.Major() != to.Major():
+		return UpgradeMajor
+	case from.Minor() != to.Minor():
+		return UpgradeMinor
+	}
+	return UpgradePatch
+}
+
+// AddRecommendations sets the recommended upgrades scanned in this run on each scanned image,
+// with the change in vulnerabilities by severity compared with the running image.
+func AddRecommendations(reports []models.ImageReport) {
+	recommendations := map[string][]models.ImageReport{}
+	for _, r := range reports {
+		repo, tag, ok := splitImageTag(r.Name)
+		if !r.RecommendationOnly || r.Error != "" || !ok {
+			continue
+		}
+		key := GetRecommendationKey(repo, GetSpecificToken(tag))
+		recommendations[key] = append(recommendations[key], r)
+	}
+	for i := range reports {
+		report := &reports[i]
+		repo, curTag, ok := splitImageTag(report.Name)
+		if report.RecommendationOnly || report.Error != "" || !ok {
+			continue
+		}
+		curVersion, err := semver.NewVersion(curTag)
+		if err != nil {
+			continue
+		}
+		byTag := map[string]models.ImageReport{}
+		newer := []*semver.Version{}
+		for _, r := range recommendations[GetRecommendationKey(repo, GetSpecificToken(curTag))] {
+			_, tag, _ := splitImageTag(r.Name)
+			v, err := semver.NewVersion(tag)
+			if err != nil || !v.GreaterThan(curVersion) {
+				continue
+			}
+			if _, found := byTag[tag]; !found {
+				newer = append(newer, v)
+			}
+			byTag[tag] = r
+		}
+		Sort(newer)
+		versionByTag := map[string]*semver.Version{}
+		for _, v := range newer {
+			versionByTag[v.Original()] = v
+		}
+		current := countVulnerabilities(*report)
+		report.Recommendations = nil
+		for _, tag := range selectNewest(Versions(newer).ToStringSlice(), curTag) {
+			recommended := byTag[tag]
+			delta := vulnerabilityDelta(current, countVulnerabilities(recommended))
+			report.Recommendations = append(report.Recommendations, models.Recommendation{
+				Name:               recommended.Name,
+				Upgrade:            upgradeKind(curVersion, versionByTag[tag]),
+				VulnerabilityDelta: delta,
+			})
+			if delta["CRITICAL"] < 0 {
+				logrus.Infof("upgrading %s to %s removes %d critical vulnerabilities", report.Name, recommended.Name, -delta["CRITICAL"])
+			}
+		}
+	}
+}
+
+// countVulnerabilities counts an image's vulnerabilities by severity, leaving out those a VEX
+// statement declares not_affected or fixed.
+func countVulnerabilities(report models.ImageReport) map[string]int {
+	counts := map[string]int{}
+	for _, vulnList := range report.Reports {
+		for _, vuln := range vulnList.Vulnerabilities {
+			if vuln.VEXStatus == "" {
+				counts[vuln.Severity]++
+			}
+		}
+	}
+	return counts
+}
+
+// vulnerabilityDelta returns the recommended image's vulnerability counts minus the running
+// image's, so a negative count is vulnerabilities the upgrade removes.
+func vulnerabilityDelta(current, recommended map[string]int) map[string]int {
+	severities := map[string]bool{}
+	for severity := range current {
+		severities[severity] = true
+	}
+	for severity := range recommended {
+		severities[severity] = true
+	}
+	delta := map[string]int{}
+	for severity := range severities {
+		delta[severity] = recommended[severity] - current[severity]
+	}
+	return delta
+}
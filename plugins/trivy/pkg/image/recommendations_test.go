@@ -0,0 +1,73 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSpecificToken(t *testing.T) {
+	assert.Equal(t, "alpine", GetSpecificToken("1.27-alpine"))
+	assert.Equal(t, "slim-bookworm", GetSpecificToken("3.12.1-slim-bookworm"))
+	assert.Equal(t, "ubi", GetSpecificToken("v0.15.0-alpha.0-ubi"))
+	assert.Equal(t, "", GetSpecificToken("v1.2.0-beta.1"))
+	assert.Equal(t, "", GetSpecificToken("1.27.0"))
+	assert.Equal(t, "", GetSpecificToken("latest"))
+}
+
+func TestSelectNewest(t *testing.T) {
+	assert.Equal(t, []string{"1.25.4", "1.27.1"}, selectNewest([]string{"1.25.3", "1.25.4", "1.26.0", "1.27.1"}, "1.25.2"))
+	assert.Equal(t, []string{"1.25.4"}, selectNewest([]string{"1.25.3", "1.25.4"}, "1.25.2"))
+	assert.Equal(t, []string{"1.27.1"}, selectNewest([]string{"1.26.0", "1.27.1"}, "1.25.2"))
+	assert.Empty(t, selectNewest([]string{}, "1.25.2"))
+}
+
+func vulnerabilities(severities ...string) []models.VulnerabilityList {
+	vulns := []models.Vulnerability{}
+	for _, severity := range severities {
+		vulns = append(vulns, models.Vulnerability{Severity: severity})
+	}
+	return []models.VulnerabilityList{{Target: "image", Vulnerabilities: vulns}}
+}
+
+func TestAddRecommendations(t *testing.T) {
+	reports := []models.ImageReport{
+		{Name: "nginx:1.25.2", Reports: vulnerabilities("CRITICAL", "CRITICAL", "HIGH", "LOW")},
+		{Name: "nginx:1.25.4", RecommendationOnly: true, Reports: vulnerabilities("CRITICAL", "HIGH", "LOW")},
+		{Name: "nginx:1.26.0", RecommendationOnly: true, Reports: vulnerabilities("HIGH")},
+		{Name: "nginx:1.27.1", RecommendationOnly: true, Reports: vulnerabilities("LOW", "MEDIUM")},
+		{Name: "nginx:1.27.1-alpine", RecommendationOnly: true},
+		{Name: "nginx:1.24.0", RecommendationOnly: true},
+	}
+	reports[0].Reports[0].Vulnerabilities[2].VEXStatus = "not_affected"
+
+	AddRecommendations(reports)
+
+	assert.Equal(t, []models.Recommendation{{
+		Name:               "nginx:1.25.4",
+		Upgrade:            UpgradePatch,
+		VulnerabilityDelta: map[string]int{"CRITICAL": -1, "HIGH": 1, "LOW": 0},
+	}, {
+		Name:               "nginx:1.27.1",
+		Upgrade:            UpgradeMinor,
+		VulnerabilityDelta: map[string]int{"CRITICAL": -2, "MEDIUM": 1, "LOW": 0},
+	}}, reports[0].Recommendations)
+	assert.Empty(t, reports[1].Recommendations)
+}
+
+func TestAddRecommendationsRegistryWithPort(t *testing.T) {
+	reports := []models.ImageReport{
+		{Name: "registry.local:5000/nginx:1.25.2-alpine3.19"},
+		{Name: "registry.local:5000/nginx:1.25.4-alpine3.19", RecommendationOnly: true},
+		{Name: "registry.local:5000/nginx:1.25.4-alpine3.20", RecommendationOnly: true},
+	}
+
+	AddRecommendations(reports)
+
+	assert.Equal(t, []models.Recommendation{{
+		Name:               "registry.local:5000/nginx:1.25.4-alpine3.19",
+		Upgrade:            UpgradePatch,
+		VulnerabilityDelta: map[string]int{},
+	}}, reports[0].Recommendations)
+}
@@ -0,0 +1,261 @@
+package image
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sbomSuffix     = ".cdx.json"
+	metadataSuffix = ".metadata.json"
+)
+
+// SBOMStore persists a CycloneDX SBOM per image digest, so that images whose digest has not
+// changed can be re-scanned against a new vulnerability database without being pulled again.
+type SBOMStore struct {
+	dir string
+}
+
+// NewSBOMStore returns a store that keeps SBOMs in dir, creating it if needed.
+func NewSBOMStore(dir string) (*SBOMStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating SBOM directory %s: %w", dir, err)
+	}
+	return &SBOMStore{dir: dir}, nil
+}
+
+// Has returns true when an SBOM is stored for the image digest.
+func (s *SBOMStore) Has(img models.Image) bool {
+	digest := imageDigest(img)
+	if digest == "" {
+		return false
+	}
+	for _, path := range []string{s.sbomPath(digest), s.metadataPath(digest)} {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Partition splits images into those with a stored SBOM and those without.
+func (s *SBOMStore) Partition(images []models.Image) (withSBOM, withoutSBOM []models.Image) {
+	for _, img := range images {
+		if s.Has(img) {
+			withSBOM = append(withSBOM, img)
+		} else {
+			withoutSBOM = append(withoutSBOM, img)
+		}
+	}
+	return withSBOM, withoutSBOM
+}
+
+// Prune removes stored SBOMs for digests that are no longer running in the cluster.
+func (s *SBOMStore) Prune(inClusterImages []models.Image) error {
+	keep := map[string]bool{}
+	for _, img := range inClusterImages {
+		if digest := imageDigest(img); digest != "" {
+			keep[fileKey(digest)] = true
+		}
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("error listing SBOM directory %s: %w", s.dir, err)
+	}
+	removed := 0
+	for _, entry := range entries {
+		key, ok := strings.CutSuffix(entry.Name(), sbomSuffix)
+		if !ok {
+			key, ok = strings.CutSuffix(entry.Name(), metadataSuffix)
+		}
+		if !ok || keep[key] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			return fmt.Errorf("error removing %s: %w", entry.Name(), err)
+		}
+		removed++
+	}
+	logrus.Infof("Removed %d stored SBOM files for images no longer in cluster", removed)
+	return nil
+}
+
+// ImageScanner returns a scanner that pulls and scans images like ScanImage, or exports them
+// from containerd like ContainerdScanner when fromContainerd is set, and also stores an SBOM
+// for each image digest so later re-scans can use SBOMScanner.
+func (s *SBOMStore) ImageScanner(images []models.Image, fromContainerd bool) ImageScannerFunc {
+	digests := digestsByPullRef(images)
+	return func(extraFlags, pullRef string, trivyServerURL string, registryOAuth2AccessTokenMap map[string]string) (*models.TrivyResults, error) {
+		digest := digests[pullRef]
+		if digest == "" {
+			return scanImage(extraFlags, pullRef, trivyServerURL, registryOAuth2AccessTokenMap, "", fromContainerd)
+		}
+		// Concurrent scans of the same digest each write their own temporary SBOM.
+		tmpFile, err := s.createTemp(digest)
+		if err != nil {
+			logrus.Warnf("could not create temporary SBOM file for %s: %v", pullRef, err)
+			return scanImage(extraFlags, pullRef, trivyServerURL, registryOAuth2AccessTokenMap, "", fromContainerd)
+		}
+		defer os.Remove(tmpFile)
+		report, err := scanImage(extraFlags, pullRef, trivyServerURL, registryOAuth2AccessTokenMap, tmpFile, fromContainerd)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.save(digest, tmpFile, report.Metadata); err != nil {
+			logrus.Warnf("could not store SBOM for %s: %v", pullRef, err)
+		}
+		return report, nil
+	}
+}
+
+// SBOMScanner returns a scanner that re-scans the stored SBOM of each image instead of
+// pulling it. The trivy server URL and registry credentials are not used.
+func (s *SBOMStore) SBOMScanner(images []models.Image) ImageScannerFunc {
+	digests := digestsByPullRef(images)
+	return func(extraFlags, pullRef string, _ string, _ map[string]string) (*models.TrivyResults, error) {
+		digest := digests[pullRef]
+		if digest == "" {
+			return nil, fmt.Errorf("no stored SBOM for %s", pullRef)
+		}
+		metadata, err := s.metadata(digest)
+		if err != nil {
+			return nil, err
+		}
+		report, err := scanSBOM(extraFlags, pullRef, s.sbomPath(digest))
+		if err != nil {
+			// A stored SBOM that Trivy cannot scan would fail on every run; drop it so the
+			// next run pulls and scans the image again.
+			s.remove(digest)
+			return nil, err
+		}
+		report.Metadata = metadata
+		return report, nil
+	}
+}
+
+func (s *SBOMStore) save(digest, sbomFile string, metadata models.TrivyMetadata) error {
+	if info, err := os.Stat(sbomFile); errors.Is(err, os.ErrNotExist) || (err == nil && info.Size() == 0) {
+		return nil // SBOM generation failed and was already logged
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	metadataFile, err := s.createTemp(digest)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metadataFile)
+	if err := os.WriteFile(metadataFile, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(metadataFile, s.metadataPath(digest)); err != nil {
+		return err
+	}
+	return os.Rename(sbomFile, s.sbomPath(digest))
+}
+
+// createTemp creates an empty, uniquely named file for digest in the store directory, so
+// it can be renamed into place without colliding with another scan of the same digest.
+func (s *SBOMStore) createTemp(digest string) (string, error) {
+	f, err := os.CreateTemp(s.dir, fileKey(digest)+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (s *SBOMStore) metadata(digest string) (models.TrivyMetadata, error) {
+	metadata := models.TrivyMetadata{}
+	data, err := os.ReadFile(s.metadataPath(digest))
+	if err != nil {
+		return metadata, fmt.Errorf("error reading stored SBOM metadata for %s: %w", digest, err)
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, fmt.Errorf("error decoding stored SBOM metadata for %s: %w", digest, err)
+	}
+	return metadata, nil
+}
+
+func (s *SBOMStore) remove(digest string) {
+	os.Remove(s.sbomPath(digest))
+	os.Remove(s.metadataPath(digest))
+}
+
+func (s *SBOMStore) sbomPath(digest string) string {
+	return filepath.Join(s.dir, fileKey(digest)+sbomSuffix)
+}
+
+func (s *SBOMStore) metadataPath(digest string) string {
+	return filepath.Join(s.dir, fileKey(digest)+metadataSuffix)
+}
+
+func fileKey(digest string) string {
+	return strings.ReplaceAll(digest, ":", "-")
+}
+
+// imageDigest returns the sha256 digest of the image, or an empty string when the image was
+// not reported with one (and so cannot be matched to a stored SBOM).
+func imageDigest(img models.Image) string {
+	digest := img.GetSha()
+	if !strings.HasPrefix(digest, "sha256:") {
+		return ""
+	}
+	return digest
+}
+
+func digestsByPullRef(images []models.Image) map[string]string {
+	digests := map[string]string{}
+	for _, img := range images {
+		digests[img.PullRef] = imageDigest(img)
+	}
+	return digests
+}
+
+func generateSBOM(pullRef, imageFile, sbomFile string) error {
+	args := []string{"-d", "image", "--skip-db-update", "--skip-java-db-update", "--format", "cyclonedx", "-o", sbomFile, "--input", imageFile}
+	if os.Getenv("OFFLINE") != "" {
+		args = append(args, "--offline-scan")
+	}
+	_, err := util.RunCommand(exec.Command("trivy", args...), "generating SBOM for "+pullRef)
+	return err
+}
+
+// scanSBOM scans a stored CycloneDX SBOM with Trivy and returns the results.
+func scanSBOM(extraFlags, pullRef, sbomFile string) (*models.TrivyResults, error) {
+	imageID := nonWordRegexp.ReplaceAllString(pullRef, "_")
+	reportFile := TempDir + "/trivy-sbom-report-" + imageID + ".json"
+	args := []string{"-d", "sbom", "--skip-db-update", "--skip-java-db-update", "--scanners", "vuln", "-f", "json", "-o", reportFile}
+	if extraFlags != "" {
+		args = append(args, extraFlags)
+	}
+	if os.Getenv("OFFLINE") != "" {
+		args = append(args, "--offline-scan")
+	}
+	args = append(args, sbomFile)
+	_, err := util.RunCommand(exec.Command("trivy", args...), "scanning SBOM of "+pullRef)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning SBOM of %s: %w", pullRef, err)
+	}
+	defer os.Remove(reportFile)
+
+	report, err := readReport(reportFile, imageID)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Successfully scanned SBOM of %s", imageID)
+	return report, nil
+}
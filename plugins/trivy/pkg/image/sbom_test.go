@@ -0,0 +1,87 @@
+package image
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const storedDigest = "sha256:93b15e948cae979539e152659edfd16549e3009140cc8a9ea2b91ffbd80a07f6"
+
+func newStoreWithSBOM(t *testing.T) *SBOMStore {
+	store, err := NewSBOMStore(filepath.Join(t.TempDir(), "sbom"))
+	assert.NoError(t, err)
+	tmpFile, err := store.createTemp(storedDigest)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(tmpFile, []byte(`{"bomFormat":"CycloneDX"}`), 0644))
+	metadata := models.TrivyMetadata{ImageID: "sha256:abc", ImageConfig: models.TrivyImageConfig{OS: "linux", Architecture: "amd64"}}
+	assert.NoError(t, store.save(storedDigest, tmpFile, metadata))
+	return store
+}
+
+func TestSBOMStorePartition(t *testing.T) {
+	store := newStoreWithSBOM(t)
+	stored := models.Image{Name: "paulbouwer/hello-kubernetes:1.7", ID: "paulbouwer/hello-kubernetes@" + storedDigest, PullRef: "a"}
+	other := models.Image{Name: "nginx:1.27", ID: "nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000", PullRef: "b"}
+	noDigest := models.Image{Name: "redis:alpine", PullRef: "c"}
+
+	withSBOM, withoutSBOM := store.Partition([]models.Image{stored, other, noDigest})
+	assert.Equal(t, []models.Image{stored}, withSBOM)
+	assert.Equal(t, []models.Image{other, noDigest}, withoutSBOM)
+
+	metadata, err := store.metadata(storedDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, "linux/amd64", getOsArch(metadata.ImageConfig))
+}
+
+func TestSBOMStoreSaveWithoutSBOM(t *testing.T) {
+	store, err := NewSBOMStore(t.TempDir())
+	assert.NoError(t, err)
+	assert.NoError(t, store.save(storedDigest, store.sbomPath(storedDigest)+".tmp", models.TrivyMetadata{}))
+	assert.False(t, store.Has(models.Image{ID: "nginx@" + storedDigest}))
+
+	// an SBOM file that was reserved but never written by Trivy
+	tmpFile, err := store.createTemp(storedDigest)
+	assert.NoError(t, err)
+	assert.NoError(t, store.save(storedDigest, tmpFile, models.TrivyMetadata{}))
+	assert.False(t, store.Has(models.Image{ID: "nginx@" + storedDigest}))
+}
+
+func TestSBOMStoreCreateTempIsUnique(t *testing.T) {
+	store, err := NewSBOMStore(t.TempDir())
+	assert.NoError(t, err)
+	first, err := store.createTemp(storedDigest)
+	assert.NoError(t, err)
+	second, err := store.createTemp(storedDigest)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, store.dir, filepath.Dir(first))
+}
+
+func TestSBOMStorePrune(t *testing.T) {
+	store := newStoreWithSBOM(t)
+	img := models.Image{Name: "paulbouwer/hello-kubernetes:1.7", ID: "paulbouwer/hello-kubernetes@" + storedDigest}
+
+	assert.NoError(t, store.Prune([]models.Image{img}))
+	assert.True(t, store.Has(img))
+
+	assert.NoError(t, store.Prune([]models.Image{{Name: "nginx:1.27"}}))
+	assert.False(t, store.Has(img))
+	entries, err := os.ReadDir(store.dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSBOMScannerWithoutStoredSBOM(t *testing.T) {
+	store, err := NewSBOMStore(t.TempDir())
+	assert.NoError(t, err)
+	images := []models.Image{{Name: "nginx:1.27", ID: "nginx@" + storedDigest, PullRef: "nginx127"}}
+
+	_, err = store.SBOMScanner(images)("", "nginx127", "", nil)
+	assert.Error(t, err)
+	_, err = store.SBOMScanner(images)("", "unknown", "", nil)
+	assert.Error(t, err)
+}
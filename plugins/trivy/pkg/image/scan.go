@@ -51,7 +51,14 @@ func ScanImages(imgScanner ImageScannerFunc, images []models.Image, maxConcurren
 		errorsByRef[image.PullRef] = nil
 	}
 	semaphore := make(chan bool, maxConcurrentScans)
-	for pullRef := range reportByRef {
+	started := map[string]bool{}
+	// start scans in the order images were chosen, so the riskiest images are scanned first
+	for _, image := range images {
+		pullRef := image.PullRef
+		if started[pullRef] {
+			continue
+		}
+		started[pullRef] = true
 		semaphore <- true
 		go func(pullRef string) {
 			defer func() {
@@ -111,6 +118,9 @@ func ConvertTrivyResultsToImageReport(images []models.Image, reportResultByRef m
 				Owners:             image.Owners,
 				RecommendationOnly: image.RecommendationOnly,
 				Error:              extractLastError(image.PullRef, trivyErrors),
+				RiskScore:          image.RiskScore,
+				RiskFactors:        image.RiskFactors,
+				ScanOrder:          image.ScanOrder,
 			})
 			continue
 		}
@@ -128,6 +138,9 @@ func ConvertTrivyResultsToImageReport(images []models.Image, reportResultByRef m
 			Owners:             image.Owners,
 			Reports:            trivyResult.Results,
 			RecommendationOnly: image.RecommendationOnly,
+			RiskScore:          image.RiskScore,
+			RiskFactors:        image.RiskFactors,
+			ScanOrder:          image.ScanOrder,
 		})
 	}
 	logrus.Infof("Done converting results to image report")
@@ -153,6 +166,13 @@ func getOsArch(imageCfg models.TrivyImageConfig) string {
 
 // ScanImage will scan a single image with Trivy and return the results.
 func ScanImage(extraFlags, pullRef string, trivyServerURL string, registryOAuth2AccessTokenMap map[string]string) (*models.TrivyResults, error) {
+	return scanImage(extraFlags, pullRef, trivyServerURL, registryOAuth2AccessTokenMap, "", false)
+}
+
+// scanImage scans a single image with Trivy. When sbomFile is set and the image is pulled
+// locally, a CycloneDX SBOM of the downloaded image is also written to sbomFile. With
+// fromContainerd, the image is exported from the node's containerd store rather than pulled.
+func scanImage(extraFlags, pullRef string, trivyServerURL string, registryOAuth2AccessTokenMap map[string]string, sbomFile string, fromContainerd bool) (*models.TrivyResults, error) {
 	imageID := nonWordRegexp.ReplaceAllString(pullRef, "_")
 	reportFile := TempDir + "/trivy-report-" + imageID + ".json"
 	var args []string
@@ -170,28 +190,45 @@ func ScanImage(extraFlags, pullRef string, trivyServerURL string, registryOAuth2
 	}
 
 	if trivyServerURL == "" {
-		if refReplacements := os.Getenv("PULL_REF_REPLACEMENTS"); refReplacements != "" {
-			replacements := strings.SplitSeq(refReplacements, ";")
-			for replacement := range replacements {
-				parts := strings.Split(replacement, ",")
-				if len(parts) != 2 {
-					logrus.Errorf("PULL_REF_REPLACEMENTS is badly formatted, can't interpret %s", replacement)
-					continue
-				}
-				pullRef = strings.ReplaceAll(pullRef, parts[0], parts[1])
-				logrus.Infof("Replaced %s with %s, pullRef is now %s", parts[0], parts[1], pullRef)
+		var imageFile string
+		if fromContainerd {
+			var err error
+			imageFile, err = exportFromContainerd(pullRef)
+			if err != nil {
+				logrus.Warnf("could not export %s from containerd, pulling it from the registry instead: %v", pullRef, err)
 			}
 		}
-		logrus.Infof("Downloading image %s", pullRef)
-		imageFile, err := downloadPullRef(pullRef, registryOAuth2AccessTokenMap)
-		if err != nil {
-			return nil, fmt.Errorf("error while downloading image: %w", err)
+		if imageFile == "" {
+			if refReplacements := os.Getenv("PULL_REF_REPLACEMENTS"); refReplacements != "" {
+				replacements := strings.SplitSeq(refReplacements, ";")
+				for replacement := range replacements {
+					parts := strings.Split(replacement, ",")
+					if len(parts) != 2 {
+						logrus.Errorf("PULL_REF_REPLACEMENTS is badly formatted, can't interpret %s", replacement)
+						continue
+					}
+					pullRef = strings.ReplaceAll(pullRef, parts[0], parts[1])
+					logrus.Infof("Replaced %s with %s, pullRef is now %s", parts[0], parts[1], pullRef)
+				}
+			}
+			logrus.Infof("Downloading image %s", pullRef)
+			var err error
+			imageFile, err = downloadPullRef(pullRef, registryOAuth2AccessTokenMap)
+			if err != nil {
+				return nil, fmt.Errorf("error while downloading image: %w", err)
+			}
 		}
 		defer func() {
 			logrus.Infof("removing image file %s", imageFile)
 			os.Remove(imageFile)
 		}()
 		args = append(args, "--input", imageFile)
+		if sbomFile != "" {
+			// The SBOM is best-effort: without it the image is pulled again on the next re-scan.
+			if err := generateSBOM(pullRef, imageFile, sbomFile); err != nil {
+				logrus.Warnf("could not generate SBOM for %s: %v", pullRef, err)
+			}
+		}
 	}
 	cmd := exec.Command("trivy", args...)
 	_, err := util.RunCommand(cmd, "scanning "+pullRef)
@@ -202,6 +239,15 @@ func ScanImage(extraFlags, pullRef string, trivyServerURL string, registryOAuth2
 		os.Remove(reportFile)
 	}()
 
+	report, err := readReport(reportFile, imageID)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Successfully scanned %s", imageID)
+	return report, nil
+}
+
+func readReport(reportFile, imageID string) (*models.TrivyResults, error) {
 	report := models.TrivyResults{}
 	data, err := os.ReadFile(reportFile)
 	if err != nil {
@@ -211,8 +257,6 @@ func ScanImage(extraFlags, pullRef string, trivyServerURL string, registryOAuth2
 	if err != nil {
 		return nil, fmt.Errorf("error decoding report %s: %w", imageID, err)
 	}
-
-	logrus.Infof("Successfully scanned %s", imageID)
 	return &report, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
 )
@@ -29,8 +30,8 @@ func getImages(baseImages []models.ImageDetailsWithRefs, toMatch []models.Image,
 			}
 		} else {
 			// For recommendations, we match only on repo name, not on full image ID
-			parts := strings.Split(im.Name, ":")
-			key := GetRecommendationKey(parts[0], GetSpecificToken(parts[1]))
+			repo, tag, _ := splitImageTag(im.Name)
+			key := GetRecommendationKey(repo, GetSpecificToken(tag))
 			if !im.RecommendationOnly || isRepoMatch[key] == match {
 				filtered = append(filtered, im)
 			}
@@ -39,6 +40,18 @@ func getImages(baseImages []models.ImageDetailsWithRefs, toMatch []models.Image,
 	return filtered
 }
 
+// RetainedImages returns the images of an earlier report to keep next to this run's results:
+// images still in the cluster that were not scanned in this run, with their owners and risk
+// scores refreshed, and recommendations for images still in the cluster that were not
+// scanned in this run.
+func RetainedImages(images []models.ImageDetailsWithRefs, inClusterImages, scanned, recommendations []models.Image) []models.ImageDetailsWithRefs {
+	images = UpdateOwnersReferenceOnMatchingImages(images, inClusterImages)
+	images = GetMatchingImages(images, inClusterImages, false)
+	images = GetUnmatchingImages(images, scanned, false)
+	images = GetMatchingImages(images, inClusterImages, true)
+	return GetUnmatchingImages(images, recommendations, true)
+}
+
 func GetUnscannedImagesToScan(imagesInCluster []models.Image, lastReportImages []models.ImageDetailsWithRefs, maxScans int) []models.Image {
 	alreadyAdded := map[string]bool{}
 	alreadyScanned := convertImagesWithRefsToMap(lastReportImages)
@@ -49,32 +62,47 @@ func GetUnscannedImagesToScan(imagesInCluster []models.Image, lastReportImages [
 			alreadyAdded[img.GetUniqueID()] = true
 		}
 	}
+	sortByRiskScore(imagesToScan)
 	if len(imagesToScan) > maxScans {
 		imagesToScan = imagesToScan[:maxScans]
 	}
 	return imagesToScan
 }
 
+// GetImagesToReScan adds previously scanned images to imagesToScan, up to maxScans, in order
+// of their risk score plus the time since their last scan.
 func GetImagesToReScan(images []models.Image, lastReport models.MinimizedReport, imagesToScan []models.Image, maxScans int) []models.Image {
 	sort.Slice(lastReport.Images, func(a, b int) bool {
 		return lastReport.Images[a].LastScan == nil || lastReport.Images[b].LastScan != nil && lastReport.Images[a].LastScan.Before(*lastReport.Images[b].LastScan)
 	})
+	type candidate struct {
+		image    models.Image
+		priority int
+	}
+	now := time.Now()
+	candidates := []candidate{}
 	for _, report := range lastReport.Images {
 		reportID := report.GetUniqueID()
 		if !report.RecommendationOnly {
 			for _, img := range images {
 				imageID := img.GetUniqueID()
 				if report.Name == img.Name && reportID == imageID {
-					if len(imagesToScan) < maxScans {
-						imagesToScan = append(imagesToScan, img)
-						break
-					} else {
-						return imagesToScan
-					}
+					candidates = append(candidates, candidate{image: img, priority: rescanPriority(img, report.LastScan, now)})
+					break
 				}
 			}
 		}
 	}
+	// stable, so equal priorities keep the oldest scan first
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].priority > candidates[b].priority
+	})
+	for _, c := range candidates {
+		if len(imagesToScan) >= maxScans {
+			break
+		}
+		imagesToScan = append(imagesToScan, c.image)
+	}
 	return imagesToScan
 }
 
@@ -97,25 +125,39 @@ func convertImagesWithRefsToMap(list []models.ImageDetailsWithRefs) map[string]b
 func imagesRepositoryMap(list []models.Image) map[string]bool {
 	m := map[string]bool{}
 	for _, img := range list {
-		parts := strings.Split(img.Name, ":")
-		if len(parts) == 2 {
-			key := GetRecommendationKey(parts[0], GetSpecificToken(parts[1]))
-			m[key] = true
+		if repo, tag, ok := splitImageTag(img.Name); ok {
+			m[GetRecommendationKey(repo, GetSpecificToken(tag))] = true
 		}
 	}
 	return m
 }
 
+// UpdateOwnersReferenceOnMatchingImages refreshes the owners and risk score of report images
+// from the matching cluster images. The scan order is cleared, as retained images are not
+// scanned in this run.
+// splitImageTag splits an image name into its repository and tag at the last ':' after the
+// last '/', so that a registry port (host:5000/repo:tag) stays part of the repository.
+func splitImageTag(name string) (string, string, bool) {
+	i := strings.LastIndex(name, ":")
+	if i < 0 || i < strings.LastIndex(name, "/") {
+		return name, "", false
+	}
+	return name[:i], name[i+1:], true
+}
+
 func UpdateOwnersReferenceOnMatchingImages(baseImages []models.ImageDetailsWithRefs, clusterImages []models.Image) []models.ImageDetailsWithRefs {
-	imageKeyToMap := map[string][]models.Resource{}
+	imageKeyToMap := map[string]models.Image{}
 	for _, i := range clusterImages {
-		imageKeyToMap[i.GetUniqueID()] = i.Owners
+		imageKeyToMap[i.GetUniqueID()] = i
 	}
 
 	for i, img := range baseImages {
-		if owners, ok := imageKeyToMap[img.GetUniqueID()]; ok {
+		if clusterImage, ok := imageKeyToMap[img.GetUniqueID()]; ok {
+			baseImages[i].RiskScore = clusterImage.RiskScore
+			baseImages[i].RiskFactors = clusterImage.RiskFactors
+			baseImages[i].ScanOrder = 0
 			v2owners := []models.Resource{}
-			for _, o := range owners {
+			for _, o := range clusterImage.Owners {
 				v2owners = append(v2owners, models.Resource{
 					Name:      o.Name,
 					Kind:      o.Kind,
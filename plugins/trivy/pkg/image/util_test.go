@@ -28,6 +28,23 @@ func getOrigReportForTest() []models.ImageDetailsWithRefs {
 	}}
 }
 
+func TestSplitImageTag(t *testing.T) {
+	for _, tc := range []struct {
+		name, repo, tag string
+		ok              bool
+	}{
+		{"nginx:1.25", "nginx", "1.25", true},
+		{"registry.local:5000/nginx:1.25", "registry.local:5000/nginx", "1.25", true},
+		{"registry.local:5000/nginx", "registry.local:5000/nginx", "", false},
+		{"nginx", "nginx", "", false},
+	} {
+		repo, tag, ok := splitImageTag(tc.name)
+		assert.Equal(t, tc.repo, repo, tc.name)
+		assert.Equal(t, tc.tag, tag, tc.name)
+		assert.Equal(t, tc.ok, ok, tc.name)
+	}
+}
+
 func TestInClusterMatches(t *testing.T) {
 	inClusterAll := []models.Image{{
 		ID:                 "quay.io/fairwinds/sample-1@sha256:abcde",
@@ -68,6 +85,29 @@ func TestToScanMatches(t *testing.T) {
 	assert.Equal(t, "quay.io/fairwinds/sample-1:1.2.3", matching[0].Name)
 }
 
+func TestRetainedImages(t *testing.T) {
+	inCluster := []models.Image{{
+		ID:        "quay.io/fairwinds/sample-1@sha256:abcde",
+		Name:      "quay.io/fairwinds/sample-1:1.2.3",
+		RiskScore: 30,
+	}, {
+		ID:   "quay.io/fairwinds/sample-2@sha256:12345",
+		Name: "quay.io/fairwinds/sample-2:4.5",
+	}}
+	scanned := inCluster[1:]
+	recommendations := []models.Image{{
+		ID:                 "quay.io/fairwinds/sample-1@sha256:feg",
+		Name:               "quay.io/fairwinds/sample-1:2.0.0",
+		RecommendationOnly: true,
+	}}
+
+	retained := RetainedImages(getOrigReportForTest(), inCluster, scanned, recommendations)
+	assert.Equal(t, 2, len(retained))
+	assert.Equal(t, "quay.io/fairwinds/sample-1:1.2.3", retained[0].Name)
+	assert.Equal(t, 30, retained[0].RiskScore)
+	assert.Equal(t, "quay.io/fairwinds/sample-2:5.0", retained[1].Name)
+}
+
 func TestShouldBeAbleToReadOldReports(t *testing.T) {
 	v1Body, err := os.ReadFile("testdata/v0.26/latest.json")
 	assert.NoError(t, err)
@@ -0,0 +1,116 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/vex"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+)
+
+// vexArtifactTypes are the OCI referrer artifact types read as VEX documents.
+var vexArtifactTypes = map[string]bool{
+	"application/vnd.openvex+json": true,
+	"application/csaf+json":        true,
+}
+
+const maxVEXDocumentBytes = 4 << 20
+
+// ApplyVEX marks the vulnerabilities that VEX statements declare not_affected or fixed, both
+// in the reports scanned in this run and in the images retained from the last report.
+// Statements come from the given documents and, when fromReferrers is set, from VEX
+// documents attached to each image as OCI referrers.
+func ApplyVEX(ctx context.Context, reports []models.ImageReport, retained []models.ImageDetailsWithRefs, statements []vex.Statement, fromReferrers bool, registryOAuth2AccessTokenMap map[string]string) {
+	index := vex.NewIndex(statements)
+	for i := range reports {
+		imageIndex := index
+		if fromReferrers && len(reports[i].Reports) > 0 && reports[i].GetSha() != reports[i].ID {
+			imageIndex = withVEXReferrers(ctx, index, statements, reports[i].ID, registryOAuth2AccessTokenMap)
+		}
+		if marked := vex.Annotate(&reports[i], imageIndex); marked > 0 {
+			logrus.Infof("VEX statements mark %d vulnerabilities of %s as not affected or fixed", marked, reports[i].Name)
+		}
+	}
+	for i := range retained {
+		imageIndex := index
+		if fromReferrers && len(retained[i].Report) > 0 && retained[i].GetSha() != retained[i].ID {
+			imageIndex = withVEXReferrers(ctx, index, statements, retained[i].ID, registryOAuth2AccessTokenMap)
+		}
+		if marked := vex.AnnotateRefs(&retained[i], imageIndex); marked > 0 {
+			logrus.Infof("VEX statements mark %d vulnerabilities of retained image %s as not affected or fixed", marked, retained[i].Name)
+		}
+	}
+}
+
+// withVEXReferrers returns index extended with the VEX referrers of imageID, or index itself
+// when there are none or they cannot be fetched.
+func withVEXReferrers(ctx context.Context, index vex.Index, statements []vex.Statement, imageID string, registryOAuth2AccessTokenMap map[string]string) vex.Index {
+	referrerStatements, err := fetchVEXReferrers(ctx, imageID, registryOAuth2AccessTokenMap)
+	if err != nil {
+		logrus.Warnf("could not fetch VEX referrers for %s: %v", imageID, err)
+		return index
+	}
+	if len(referrerStatements) == 0 {
+		return index
+	}
+	return vex.NewIndex(append(referrerStatements, statements...))
+}
+
+func fetchVEXReferrers(ctx context.Context, imageID string, registryOAuth2AccessTokenMap map[string]string) ([]vex.Statement, error) {
+	digest, err := name.NewDigest(imageID)
+	if err != nil {
+		return nil, err
+	}
+	auth := remote.WithAuthFromKeychain(authn.DefaultKeychain)
+	if token, ok := hasOAuth2AccessToken(registryOAuth2AccessTokenMap, imageID); ok {
+		auth = remote.WithAuth(&authn.Bearer{Token: token})
+	}
+	options := []remote.Option{auth, remote.WithContext(ctx)}
+
+	referrers, err := remote.Referrers(digest, options...)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := referrers.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	statements := []vex.Statement{}
+	for _, desc := range manifest.Manifests {
+		if !vexArtifactTypes[desc.ArtifactType] {
+			continue
+		}
+		source := digest.Context().Digest(desc.Digest.String()).String()
+		img, err := remote.Image(digest.Context().Digest(desc.Digest.String()), options...)
+		if err != nil {
+			return nil, fmt.Errorf("error reading VEX referrer %s: %w", source, err)
+		}
+		layers, err := img.Layers()
+		if err != nil {
+			return nil, fmt.Errorf("error reading VEX referrer %s: %w", source, err)
+		}
+		for _, layer := range layers {
+			rc, err := layer.Compressed()
+			if err != nil {
+				return nil, fmt.Errorf("error reading VEX referrer %s: %w", source, err)
+			}
+			data, err := io.ReadAll(io.LimitReader(rc, maxVEXDocumentBytes))
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("error reading VEX referrer %s: %w", source, err)
+			}
+			parsed, err := vex.Parse(data, source)
+			if err != nil {
+				logrus.Warnf("skipping VEX referrer layer: %v", err)
+				continue
+			}
+			statements = append(statements, parsed...)
+		}
+	}
+	return statements, nil
+}
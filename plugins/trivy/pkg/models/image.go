@@ -17,6 +17,19 @@ type Image struct {
 	PullRef            string // paulbouwerhellokubernetes17
 	Owners             []Resource
 	RecommendationOnly bool
+	Workload           WorkloadContext // how the image runs in the cluster, used to prioritise scans
+	RiskScore          int
+	RiskFactors        []string
+	ScanOrder          int      // position in this run's scan order, starting at 1
+	Nodes              []string // nodes running the image
+}
+
+// WorkloadContext describes the pods an image runs in.
+type WorkloadContext struct {
+	Privileged  bool // any container running the image is privileged
+	HostNetwork bool // any pod running the image uses the host network
+	Exposed     bool // any pod running the image is behind an Ingress or LoadBalancer Service
+	Replicas    int  // number of pods running the image
 }
 
 // Resource represents a Kubernetes resource
@@ -37,6 +50,17 @@ type ImageReport struct {
 	Reports            []VulnerabilityList `json:"Report"`
 	RecommendationOnly bool
 	Error              string
+	RiskScore          int
+	RiskFactors        []string
+	ScanOrder          int
+	Recommendations    []Recommendation
+}
+
+// Recommendation is a newer tag of an image, scanned to compare its vulnerabilities.
+type Recommendation struct {
+	Name               string         // repository:tag of the recommended image
+	Upgrade            string         // patch, minor or major
+	VulnerabilityDelta map[string]int // vulnerabilities by severity in the recommended image minus the running image
 }
 
 type TrivyResults struct {
@@ -71,6 +95,11 @@ type Vulnerability struct {
 	Severity         string
 	VulnerabilityID  string
 	References       []string
+	// VEX fields are set when a VEX statement declares the vulnerability not_affected or fixed
+	VEXStatus          string `json:"VEXStatus,omitempty"`
+	VEXJustification   string `json:"VEXJustification,omitempty"`
+	VEXImpactStatement string `json:"VEXImpactStatement,omitempty"`
+	VEXSource          string `json:"VEXSource,omitempty"`
 }
 
 // MinimizedReport is the results in a compressed format.
@@ -92,7 +121,11 @@ type ImageDetailsWithRefs struct {
 	LastScan           *time.Time
 	Report             []VulnerabilityRefList
 	RecommendationOnly bool
-	Error              string `json:"Error,omitempty"`
+	Error              string           `json:"Error,omitempty"`
+	RiskScore          int              `json:"RiskScore,omitempty"`
+	RiskFactors        []string         `json:"RiskFactors,omitempty"`
+	ScanOrder          int              `json:"ScanOrder,omitempty"`
+	Recommendations    []Recommendation `json:"Recommendations,omitempty"`
 }
 
 // VulnerabilityRefList is a list of vulnerability references.
@@ -112,10 +145,14 @@ type VulnerabilityDetails struct {
 
 // VulnerabilityInstance is a single instance of a given vulnerability
 type VulnerabilityInstance struct {
-	InstalledVersion string
-	PkgName          string
-	VulnerabilityID  string
-	FixedVersion     string
+	InstalledVersion   string
+	PkgName            string
+	VulnerabilityID    string
+	FixedVersion       string
+	VEXStatus          string `json:"VEXStatus,omitempty"`
+	VEXJustification   string `json:"VEXJustification,omitempty"`
+	VEXImpactStatement string `json:"VEXImpactStatement,omitempty"`
+	VEXSource          string `json:"VEXSource,omitempty"`
 }
 
 func getShaFromID(id string) string {
@@ -130,3 +130,10 @@ func FilterImagesByName(inClusterImages []models.Image, imagesToMatch []string)
 		return slices.Contains(imagesToMatch, i.Name)
 	})
 }
+
+// FilterImagesByNode returns the images running on the given node.
+func FilterImagesByNode(inClusterImages []models.Image, nodeName string) []models.Image {
+	return lo.Filter(inClusterImages, func(i models.Image, _ int) bool {
+		return slices.Contains(i.Nodes, nodeName)
+	})
+}
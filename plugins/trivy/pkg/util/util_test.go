@@ -51,3 +51,21 @@ func TestFilterImagesByName(t *testing.T) {
 	matched = FilterImagesByName(inClusterImages, []string{"docker.io/library/alpine:3.13.0", "docker.io/library/busybox:1.35", "quay.io/fairwinds/sample-1:1.2.3", "quay.io/fairwinds/sample-2:4.5"})
 	assert.Equal(t, 3, len(matched))
 }
+
+func TestFilterImagesByNode(t *testing.T) {
+	inClusterImages := []models.Image{
+		{Name: "docker.io/library/alpine:3.13.0", Nodes: []string{"node-1", "node-2"}},
+		{Name: "docker.io/library/busybox:1.35", Nodes: []string{"node-2"}},
+		{Name: "quay.io/fairwinds/sample-1:1.2.3"},
+	}
+
+	matched := FilterImagesByNode(inClusterImages, "node-1")
+	assert.Equal(t, 1, len(matched))
+	assert.Equal(t, inClusterImages[0], matched[0])
+
+	matched = FilterImagesByNode(inClusterImages, "node-2")
+	assert.Equal(t, 2, len(matched))
+
+	matched = FilterImagesByNode(inClusterImages, "node-3")
+	assert.Equal(t, 0, len(matched))
+}
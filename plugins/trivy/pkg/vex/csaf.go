@@ -0,0 +1,147 @@
+package vex
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+type csafDocument struct {
+	Document struct {
+		Tracking struct {
+			CurrentReleaseDate *time.Time `json:"current_release_date"`
+		} `json:"tracking"`
+	} `json:"document"`
+	ProductTree struct {
+		Branches         []csafBranch      `json:"branches"`
+		FullProductNames []csafProductName `json:"full_product_names"`
+		Relationships    []struct {
+			FullProductName           csafProductName `json:"full_product_name"`
+			ProductReference          string          `json:"product_reference"`
+			RelatesToProductReference string          `json:"relates_to_product_reference"`
+		} `json:"relationships"`
+	} `json:"product_tree"`
+	Vulnerabilities []struct {
+		CVE string `json:"cve"`
+		IDs []struct {
+			Text string `json:"text"`
+		} `json:"ids"`
+		ProductStatus map[string][]string `json:"product_status"`
+		Flags         []struct {
+			Label      string   `json:"label"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"flags"`
+		Threats []struct {
+			Category   string   `json:"category"`
+			Details    string   `json:"details"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"threats"`
+	} `json:"vulnerabilities"`
+}
+
+type csafBranch struct {
+	Branches []csafBranch     `json:"branches"`
+	Product  *csafProductName `json:"product"`
+}
+
+type csafProductName struct {
+	Name                        string `json:"name"`
+	ProductID                   string `json:"product_id"`
+	ProductIdentificationHelper *struct {
+		PURL string `json:"purl"`
+	} `json:"product_identification_helper"`
+}
+
+func (p csafProductName) identifier() string {
+	if p.ProductIdentificationHelper != nil && p.ProductIdentificationHelper.PURL != "" {
+		return p.ProductIdentificationHelper.PURL
+	}
+	return p.Name
+}
+
+// csafStatuses maps CSAF product status groups to VEX statuses.
+var csafStatuses = map[string]Status{
+	"known_not_affected":  StatusNotAffected,
+	"fixed":               StatusFixed,
+	"known_affected":      StatusAffected,
+	"under_investigation": StatusUnderInvestigation,
+}
+
+func parseCSAF(data []byte, source string) ([]Statement, error) {
+	var doc csafDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	identifiers := map[string]string{}
+	var walk func(branches []csafBranch)
+	walk = func(branches []csafBranch) {
+		for _, branch := range branches {
+			if branch.Product != nil {
+				identifiers[branch.Product.ProductID] = branch.Product.identifier()
+			}
+			walk(branch.Branches)
+		}
+	}
+	walk(doc.ProductTree.Branches)
+	for _, p := range doc.ProductTree.FullProductNames {
+		identifiers[p.ProductID] = p.identifier()
+	}
+	products := map[string]Product{}
+	for id, identifier := range identifiers {
+		products[id] = Product{ID: identifier}
+	}
+	// A relationship names a package (product_reference) inside an image (relates_to).
+	for _, r := range doc.ProductTree.Relationships {
+		products[r.FullProductName.ProductID] = Product{
+			ID:            identifiers[r.RelatesToProductReference],
+			Subcomponents: []string{identifiers[r.ProductReference]},
+		}
+	}
+
+	var timestamp time.Time
+	if doc.Document.Tracking.CurrentReleaseDate != nil {
+		timestamp = *doc.Document.Tracking.CurrentReleaseDate
+	}
+	statements := []Statement{}
+	for _, v := range doc.Vulnerabilities {
+		ids := []string{}
+		if v.CVE != "" {
+			ids = append(ids, v.CVE)
+		}
+		for _, id := range v.IDs {
+			ids = append(ids, id.Text)
+		}
+		for group, productIDs := range v.ProductStatus {
+			status, ok := csafStatuses[group]
+			if !ok {
+				continue
+			}
+			for _, productID := range productIDs {
+				product, ok := products[productID]
+				if !ok || product.ID == "" {
+					continue
+				}
+				statement := Statement{
+					Vulnerabilities: ids,
+					Products:        []Product{product},
+					Status:          status,
+					Timestamp:       timestamp,
+					Source:          source,
+				}
+				for _, flag := range v.Flags {
+					if slices.Contains(flag.ProductIDs, productID) {
+						statement.Justification = flag.Label
+					}
+				}
+				for _, threat := range v.Threats {
+					if threat.Category == "impact" && slices.Contains(threat.ProductIDs, productID) {
+						statement.ImpactStatement = threat.Details
+					}
+				}
+				statements = append(statements, statement)
+			}
+		}
+	}
+	return statements, nil
+}
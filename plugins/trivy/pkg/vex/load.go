@@ -0,0 +1,83 @@
+package vex
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Parse reads the statements of an OpenVEX or CSAF VEX document. OpenVEX documents wrapped in
+// an in-toto statement or DSSE envelope, as attached by attestation tools, are unwrapped.
+func Parse(data []byte, source string) ([]Statement, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("error decoding VEX document %s: %w", source, err)
+	}
+	switch {
+	case fields["payload"] != nil && fields["payloadType"] != nil:
+		var envelope struct {
+			Payload string `json:"payload"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, fmt.Errorf("error decoding DSSE envelope %s: %w", source, err)
+		}
+		payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding DSSE payload %s: %w", source, err)
+		}
+		return Parse(payload, source)
+	case fields["predicate"] != nil:
+		return Parse(fields["predicate"], source)
+	case fields["statements"] != nil:
+		statements, err := parseOpenVEX(data, source)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding OpenVEX document %s: %w", source, err)
+		}
+		return statements, nil
+	case fields["document"] != nil && fields["vulnerabilities"] != nil:
+		statements, err := parseCSAF(data, source)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding CSAF document %s: %w", source, err)
+		}
+		return statements, nil
+	}
+	return nil, fmt.Errorf("%s is not an OpenVEX or CSAF document", source)
+}
+
+// LoadDir parses every JSON file under dir. Kubernetes volume bookkeeping entries (..data)
+// are skipped so ConfigMap and Secret mounts are read once.
+func LoadDir(dir string) ([]Statement, error) {
+	statements := []Statement{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), "..") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		parsed, err := Parse(data, path)
+		if err != nil {
+			return err
+		}
+		statements = append(statements, parsed...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading VEX documents from %s: %w", dir, err)
+	}
+	return statements, nil
+}
@@ -0,0 +1,105 @@
+package vex
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type openVEXDocument struct {
+	Timestamp  *time.Time         `json:"timestamp"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	Vulnerability   openVEXVulnerability `json:"vulnerability"`
+	Products        []openVEXProduct     `json:"products"`
+	Subcomponents   []openVEXProduct     `json:"subcomponents"` // OpenVEX v0.0.1
+	Status          string               `json:"status"`
+	Justification   string               `json:"justification"`
+	ImpactStatement string               `json:"impact_statement"`
+	Timestamp       *time.Time           `json:"timestamp"`
+}
+
+// openVEXVulnerability is a vulnerability name (v0.0.1) or object with aliases (v0.2).
+type openVEXVulnerability struct {
+	ID      string   `json:"@id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+func (v *openVEXVulnerability) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		v.Name = name
+		return nil
+	}
+	type plain openVEXVulnerability
+	return json.Unmarshal(data, (*plain)(v))
+}
+
+func (v openVEXVulnerability) ids() []string {
+	ids := []string{}
+	for _, id := range append([]string{v.Name, v.ID}, v.Aliases...) {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// openVEXProduct is a product identifier (v0.0.1) or object with subcomponents (v0.2).
+type openVEXProduct struct {
+	ID          string `json:"@id"`
+	Identifiers struct {
+		PURL string `json:"purl"`
+	} `json:"identifiers"`
+	Subcomponents []openVEXProduct `json:"subcomponents"`
+}
+
+func (p *openVEXProduct) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		p.ID = id
+		return nil
+	}
+	type plain openVEXProduct
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+func (p openVEXProduct) id() string {
+	if p.ID != "" {
+		return p.ID
+	}
+	return p.Identifiers.PURL
+}
+
+func parseOpenVEX(data []byte, source string) ([]Statement, error) {
+	var doc openVEXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	statements := make([]Statement, 0, len(doc.Statements))
+	for _, s := range doc.Statements {
+		statement := Statement{
+			Vulnerabilities: s.Vulnerability.ids(),
+			Status:          Status(s.Status),
+			Justification:   s.Justification,
+			ImpactStatement: s.ImpactStatement,
+			Source:          source,
+		}
+		if s.Timestamp != nil {
+			statement.Timestamp = *s.Timestamp
+		} else if doc.Timestamp != nil {
+			statement.Timestamp = *doc.Timestamp
+		}
+		for _, p := range s.Products {
+			product := Product{ID: p.id()}
+			for _, sub := range append(p.Subcomponents, s.Subcomponents...) {
+				product.Subcomponents = append(product.Subcomponents, sub.id())
+			}
+			statement.Products = append(statement.Products, product)
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
@@ -0,0 +1,219 @@
+// Package vex reads OpenVEX and CSAF VEX documents and marks the vulnerabilities they declare
+// not exploitable in a scanned image.
+package vex
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+)
+
+// Status is the impact of a vulnerability on a product, as stated in a VEX document.
+type Status string
+
+const (
+	StatusNotAffected        Status = "not_affected"
+	StatusAffected           Status = "affected"
+	StatusFixed              Status = "fixed"
+	StatusUnderInvestigation Status = "under_investigation"
+)
+
+// Statement is a single VEX statement, normalised from OpenVEX or CSAF.
+type Statement struct {
+	Vulnerabilities []string // vulnerability ID and aliases
+	Products        []Product
+	Status          Status
+	Justification   string
+	ImpactStatement string
+	Timestamp       time.Time
+	Source          string // file or referrer the statement was read from
+}
+
+// Product is an image the statement applies to, optionally narrowed to some of its packages.
+type Product struct {
+	ID            string // purl (pkg:oci/...) or image reference
+	Subcomponents []string
+}
+
+// Index looks up statements by vulnerability ID.
+type Index map[string][]Statement
+
+// NewIndex indexes statements by each of their vulnerability IDs and aliases.
+func NewIndex(statements []Statement) Index {
+	index := Index{}
+	for _, statement := range statements {
+		for _, id := range statement.Vulnerabilities {
+			key := strings.ToUpper(id)
+			index[key] = append(index[key], statement)
+		}
+	}
+	return index
+}
+
+// Annotate sets the VEX status of each vulnerability in report that a statement declares
+// not_affected or fixed, and returns how many were marked. When several statements apply,
+// the most recent one wins, so a later affected statement overrides an earlier not_affected.
+func Annotate(report *models.ImageReport, index Index) int {
+	if len(index) == 0 {
+		return 0
+	}
+	image := newTarget(report.Name, report.GetSha())
+	marked := 0
+	for i := range report.Reports {
+		vulns := report.Reports[i].Vulnerabilities
+		for j := range vulns {
+			statement := index.exploitability(image, vulns[j].VulnerabilityID, vulns[j].PkgName)
+			if statement == nil {
+				continue
+			}
+			vulns[j].VEXStatus = string(statement.Status)
+			vulns[j].VEXJustification = statement.Justification
+			vulns[j].VEXImpactStatement = statement.ImpactStatement
+			vulns[j].VEXSource = statement.Source
+			marked++
+		}
+	}
+	return marked
+}
+
+// AnnotateRefs is Annotate for an image kept from an earlier report. The VEX status set by
+// earlier runs is cleared first, so withdrawn or superseded statements no longer apply.
+func AnnotateRefs(image *models.ImageDetailsWithRefs, index Index) int {
+	target := newTarget(image.Name, image.GetSha())
+	marked := 0
+	for i := range image.Report {
+		vulns := image.Report[i].Vulnerabilities
+		for j := range vulns {
+			vulns[j].VEXStatus = ""
+			vulns[j].VEXJustification = ""
+			vulns[j].VEXImpactStatement = ""
+			vulns[j].VEXSource = ""
+			statement := index.exploitability(target, vulns[j].VulnerabilityID, vulns[j].PkgName)
+			if statement == nil {
+				continue
+			}
+			vulns[j].VEXStatus = string(statement.Status)
+			vulns[j].VEXJustification = statement.Justification
+			vulns[j].VEXImpactStatement = statement.ImpactStatement
+			vulns[j].VEXSource = statement.Source
+			marked++
+		}
+	}
+	return marked
+}
+
+// exploitability returns the statement that applies to a vulnerability when it declares it
+// not_affected or fixed, and nil otherwise.
+func (index Index) exploitability(image target, vulnerabilityID, pkgName string) *Statement {
+	statement := index.find(image, vulnerabilityID, pkgName)
+	if statement == nil || (statement.Status != StatusNotAffected && statement.Status != StatusFixed) {
+		return nil
+	}
+	return statement
+}
+
+func (index Index) find(image target, vulnerabilityID, pkgName string) *Statement {
+	var found *Statement
+	candidates := index[strings.ToUpper(vulnerabilityID)]
+	for i := range candidates {
+		if !candidates[i].appliesTo(image, pkgName) {
+			continue
+		}
+		if found == nil || !candidates[i].Timestamp.Before(found.Timestamp) {
+			found = &candidates[i]
+		}
+	}
+	return found
+}
+
+func (s Statement) appliesTo(image target, pkgName string) bool {
+	for _, product := range s.Products {
+		if !image.matches(product.ID) {
+			continue
+		}
+		if len(product.Subcomponents) == 0 {
+			return true
+		}
+		for _, subcomponent := range product.Subcomponents {
+			if packageMatches(subcomponent, pkgName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// target is the scanned image a statement is matched against.
+type target struct {
+	repository string // registry and repository, without tag or digest
+	digest     string
+}
+
+func newTarget(name, digest string) target {
+	if !strings.HasPrefix(digest, "sha256:") {
+		digest = ""
+	}
+	return target{repository: normalizeRepository(name), digest: digest}
+}
+
+func (t target) matches(productID string) bool {
+	if strings.HasPrefix(productID, "pkg:oci/") {
+		_, version, qualifiers := parsePURL(strings.TrimPrefix(productID, "pkg:oci/"))
+		if version != "" {
+			return version == t.digest
+		}
+		if repositoryURL := qualifiers.Get("repository_url"); repositoryURL != "" {
+			return normalizeRepository(repositoryURL) == t.repository
+		}
+		// Without a digest or repository_url the name alone could be any registry's
+		// repository, so the purl is too ambiguous to match.
+		return false
+	}
+	if _, digest, ok := strings.Cut(productID, "@"); ok {
+		return digest == t.digest
+	}
+	return normalizeRepository(productID) == t.repository
+}
+
+// normalizeRepository strips the tag, digest and Docker Hub defaults from an image reference.
+func normalizeRepository(ref string) string {
+	ref, _, _ = strings.Cut(ref, "@")
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	for _, prefix := range []string{"docker.io/", "index.docker.io/"} {
+		ref = strings.TrimPrefix(ref, prefix)
+	}
+	return strings.TrimPrefix(ref, "library/")
+}
+
+// packageMatches compares a subcomponent (purl or package name) with a Trivy package name.
+// Maven packages are named group:artifact by Trivy and pkg:maven/group/artifact in purls.
+func packageMatches(subcomponent, pkgName string) bool {
+	if !strings.HasPrefix(subcomponent, "pkg:") {
+		return subcomponent == pkgName
+	}
+	_, rest, _ := strings.Cut(strings.TrimPrefix(subcomponent, "pkg:"), "/")
+	path, _, _ := parsePURL(rest)
+	name := path[strings.LastIndex(path, "/")+1:]
+	if name == pkgName {
+		return true
+	}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]+":"+name == pkgName || path == pkgName
+	}
+	return false
+}
+
+// parsePURL splits the part of a purl after its type into path, version and qualifiers.
+func parsePURL(s string) (string, string, url.Values) {
+	s, _, _ = strings.Cut(s, "#")
+	s, rawQualifiers, _ := strings.Cut(s, "?")
+	path, version, _ := strings.Cut(s, "@")
+	path, _ = url.PathUnescape(path)
+	version, _ = url.PathUnescape(version)
+	qualifiers, _ := url.ParseQuery(rawQualifiers)
+	return path, version, qualifiers
+}
@@ -0,0 +1,135 @@
+package vex
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fairwindsops/insights-plugins/plugins/trivy/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const nginxDigest = "sha256:93b15e948cae979539e152659edfd16549e3009140cc8a9ea2b91ffbd80a07f6"
+
+func nginxReport() models.ImageReport {
+	return models.ImageReport{
+		ID:   "nginx@" + nginxDigest,
+		Name: "nginx:1.27",
+		Reports: []models.VulnerabilityList{{
+			Target: "nginx (debian 12)",
+			Vulnerabilities: []models.Vulnerability{
+				{VulnerabilityID: "CVE-2023-0001", PkgName: "openssl"},
+				{VulnerabilityID: "CVE-2023-0001", PkgName: "libssl3"},
+				{VulnerabilityID: "CVE-2023-0002", PkgName: "zlib"},
+				{VulnerabilityID: "CVE-2023-0003", PkgName: "curl"},
+				{VulnerabilityID: "CVE-2023-9999", PkgName: "bash"},
+			},
+		}},
+	}
+}
+
+func TestAnnotateOpenVEX(t *testing.T) {
+	statements, err := LoadDir("testdata")
+	assert.NoError(t, err)
+	report := nginxReport()
+
+	marked := Annotate(&report, NewIndex(statements))
+	assert.Equal(t, 2, marked)
+	vulns := report.Reports[0].Vulnerabilities
+	assert.Equal(t, "not_affected", vulns[0].VEXStatus)
+	assert.Equal(t, "vulnerable_code_not_in_execute_path", vulns[0].VEXJustification)
+	assert.Equal(t, "nginx does not call the affected API", vulns[0].VEXImpactStatement)
+	assert.Equal(t, filepath.Join("testdata", "openvex.json"), vulns[0].VEXSource)
+	assert.Empty(t, vulns[1].VEXStatus, "statement is scoped to the openssl subcomponent")
+	assert.Equal(t, "fixed", vulns[2].VEXStatus)
+	assert.Empty(t, vulns[3].VEXStatus, "the later affected statement wins")
+	assert.Empty(t, vulns[4].VEXStatus)
+}
+
+func TestAnnotateOtherImage(t *testing.T) {
+	statements, err := LoadDir("testdata")
+	assert.NoError(t, err)
+	report := nginxReport()
+	report.ID = "nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	report.Name = "quay.io/other/nginx:1.27"
+
+	assert.Equal(t, 0, Annotate(&report, NewIndex(statements)))
+}
+
+func TestAnnotateRefsClearsWithdrawnStatements(t *testing.T) {
+	statements, err := LoadDir("testdata")
+	assert.NoError(t, err)
+	image := models.ImageDetailsWithRefs{
+		ID:   "nginx@" + nginxDigest,
+		Name: "nginx:1.27",
+		Report: []models.VulnerabilityRefList{{
+			Target: "nginx (debian 12)",
+			Vulnerabilities: []models.VulnerabilityInstance{
+				{VulnerabilityID: "CVE-2023-0001", PkgName: "openssl"},
+				{VulnerabilityID: "CVE-2023-9999", PkgName: "bash", VEXStatus: "not_affected", VEXSource: "removed.json"},
+			},
+		}},
+	}
+
+	assert.Equal(t, 1, AnnotateRefs(&image, NewIndex(statements)))
+	vulns := image.Report[0].Vulnerabilities
+	assert.Equal(t, "not_affected", vulns[0].VEXStatus)
+	assert.Empty(t, vulns[1].VEXStatus)
+	assert.Empty(t, vulns[1].VEXSource)
+}
+
+func TestTargetMatchesOCIPurl(t *testing.T) {
+	image := newTarget("ghcr.io/acme/api:2.3.0", "sha256:abc")
+	assert.True(t, image.matches("pkg:oci/api@sha256%3Aabc"))
+	assert.True(t, image.matches("pkg:oci/api?repository_url=ghcr.io/acme/api"))
+	assert.False(t, image.matches("pkg:oci/api?repository_url=quay.io/other/api"))
+	assert.False(t, image.matches("pkg:oci/api"), "a name without repository_url is ambiguous")
+}
+
+func TestAnnotateCSAF(t *testing.T) {
+	statements, err := LoadDir("testdata")
+	assert.NoError(t, err)
+	report := models.ImageReport{
+		ID:   "ghcr.io/acme/api@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+		Name: "ghcr.io/acme/api:2.3.0",
+		Reports: []models.VulnerabilityList{{
+			Target: "Java",
+			Vulnerabilities: []models.Vulnerability{
+				{VulnerabilityID: "CVE-2023-1000", PkgName: "com.fasterxml.jackson.core:jackson-databind"},
+			},
+		}},
+	}
+
+	assert.Equal(t, 1, Annotate(&report, NewIndex(statements)))
+	vuln := report.Reports[0].Vulnerabilities[0]
+	assert.Equal(t, "not_affected", vuln.VEXStatus)
+	assert.Equal(t, "vulnerable_code_cannot_be_controlled_by_adversary", vuln.VEXJustification)
+	assert.Equal(t, "Polymorphic typing is disabled", vuln.VEXImpactStatement)
+}
+
+func TestParseAttestation(t *testing.T) {
+	doc, err := os.ReadFile(filepath.Join("testdata", "openvex.json"))
+	assert.NoError(t, err)
+	statement := `{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://openvex.dev/ns/v0.2.0","predicate":` + string(doc) + `}`
+	envelope := `{"payloadType":"application/vnd.in-toto+json","payload":"` + base64.StdEncoding.EncodeToString([]byte(statement)) + `","signatures":[]}`
+
+	statements, err := Parse([]byte(envelope), "referrer")
+	assert.NoError(t, err)
+	assert.Len(t, statements, 4)
+	assert.Equal(t, []string{"CVE-2023-0001", "GHSA-aaaa-bbbb-cccc"}, statements[0].Vulnerabilities)
+}
+
+func TestParseOpenVEXv001(t *testing.T) {
+	statements, err := Parse([]byte(`{"@context":"https://openvex.dev/ns","statements":[{"vulnerability":"CVE-2022-1","products":["pkg:oci/app@sha256%3Aabc"],"subcomponents":["pkg:golang/golang.org/x/net@v0.1.0"],"status":"not_affected","justification":"inline_mitigations_already_exist"}]}`), "v001.json")
+	assert.NoError(t, err)
+	assert.Len(t, statements, 1)
+	assert.Equal(t, []string{"CVE-2022-1"}, statements[0].Vulnerabilities)
+	assert.Equal(t, []Product{{ID: "pkg:oci/app@sha256%3Aabc", Subcomponents: []string{"pkg:golang/golang.org/x/net@v0.1.0"}}}, statements[0].Products)
+	assert.True(t, packageMatches(statements[0].Products[0].Subcomponents[0], "golang.org/x/net"))
+}
+
+func TestParseUnknownDocument(t *testing.T) {
+	_, err := Parse([]byte(`{"bomFormat":"CycloneDX"}`), "sbom.json")
+	assert.Error(t, err)
+}
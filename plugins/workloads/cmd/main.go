@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	workloads "github.com/fairwindsops/insights-plugins/plugins/workloads/pkg"
 	"github.com/sirupsen/logrus"
@@ -21,6 +22,8 @@ const tempFile = "/output/workloads-temp.json"
 func main() {
 	ctx := context.Background()
 	auditOutputFile := flag.String("output-file", "", "Destination file for audit results")
+	stateFile := flag.String("state-file", "", "File (e.g. on a persistent volume) keeping the previous report snapshot for change detection")
+	stateConfigMap := flag.String("state-configmap", "", "ConfigMap, as namespace/name, keeping the previous report snapshot for change detection")
 	flag.Parse()
 
 	dynamic, restMapper, kube, clusterName, err := getKubeClient()
@@ -35,6 +38,28 @@ func main() {
 	}
 	logrus.Info("got resources")
 
+	var snapshotStore workloads.SnapshotStore
+	switch {
+	case *stateFile != "":
+		snapshotStore = workloads.NewFileSnapshotStore(*stateFile)
+	case *stateConfigMap != "":
+		namespace, name, ok := strings.Cut(*stateConfigMap, "/")
+		if !ok || namespace == "" || name == "" {
+			logrus.Fatalf("invalid --state-configmap %q, expected namespace/name", *stateConfigMap)
+		}
+		snapshotStore = workloads.NewConfigMapSnapshotStore(kube, namespace, name)
+	}
+	snapshot := workloads.NewReportSnapshot(resources)
+	if snapshotStore != nil {
+		previous, err := snapshotStore.Load(ctx)
+		if err != nil {
+			logrus.Warnf("unable to load previous report snapshot, skipping change detection: %v", err)
+		} else if previous == nil {
+			logrus.Info("no previous report snapshot, changes will be reported from the next run")
+		}
+		resources.Changes = workloads.DetectChanges(previous, snapshot)
+	}
+
 	var outputBytes []byte
 	outputBytes, err = json.MarshalIndent(resources, "", "  ")
 	if err != nil {
@@ -51,6 +76,13 @@ func main() {
 			logrus.Fatalf("error renaming output file: %v", err)
 		}
 	}
+
+	// saved only once the report is written, so a failed run does not hide its changes
+	if snapshotStore != nil {
+		if err := snapshotStore.Save(ctx, snapshot); err != nil {
+			logrus.Warnf("unable to save report snapshot: %v", err)
+		}
+	}
 }
 
 func getKubeClient() (dynamic.Interface, meta.RESTMapper, kubernetes.Interface, string, error) {
@@ -0,0 +1,728 @@
+package workloads
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	KindHorizontalPodAutoscaler = "HorizontalPodAutoscaler"
+	KindVerticalPodAutoscaler   = "VerticalPodAutoscaler"
+	KindScaledObject            = "ScaledObject"
+	KindScaledJob               = "ScaledJob"
+	KindPodDisruptionBudget     = "PodDisruptionBudget"
+
+	hpaAPIVersion  = "autoscaling/v2"
+	vpaAPIVersion  = "autoscaling.k8s.io/v1"
+	kedaAPIVersion = "keda.sh/v1alpha1"
+	pdbAPIVersion  = "policy/v1"
+)
+
+var (
+	vpaGVR = schema.GroupVersionResource{
+		Group: "autoscaling.k8s.io", Version: "v1", Resource: "verticalpodautoscalers",
+	}
+	scaledObjectGVR = schema.GroupVersionResource{
+		Group: "keda.sh", Version: "v1alpha1", Resource: "scaledobjects",
+	}
+	scaledJobGVR = schema.GroupVersionResource{
+		Group: "keda.sh", Version: "v1alpha1", Resource: "scaledjobs",
+	}
+)
+
+// ScaleTargetRef is the workload an autoscaler scales.
+type ScaleTargetRef struct {
+	APIVersion string `json:",omitempty"`
+	Kind       string `json:",omitempty"`
+	Name       string `json:",omitempty"`
+}
+
+// AutoscalingCondition is a status condition summary.
+type AutoscalingCondition struct {
+	Type               string `json:",omitempty"`
+	Status             string `json:",omitempty"`
+	Reason             string `json:",omitempty"`
+	Message            string `json:",omitempty"`
+	LastTransitionTime string `json:",omitempty"`
+}
+
+// HPAMetric is an HPA metric with its target and, when reported, its current value.
+type HPAMetric struct {
+	Type       string // Resource, ContainerResource, Pods, Object or External
+	Name       string `json:",omitempty"` // resource or metric name
+	Container  string `json:",omitempty"`
+	TargetType string `json:",omitempty"` // Utilization, AverageValue or Value
+	Target     string `json:",omitempty"`
+	Current    string `json:",omitempty"`
+}
+
+// HorizontalPodAutoscaler is an autoscaling/v2 HPA inventory object.
+type HorizontalPodAutoscaler struct {
+	Kind            string
+	Name            string
+	Namespace       string
+	Annotations     map[string]string
+	Labels          map[string]string
+	UID             string
+	APIVersion      string
+	ScaleTargetRef  ScaleTargetRef
+	TargetUID       string `json:",omitempty"` // UID of the scaled top controller
+	MinReplicas     *int32 `json:",omitempty"`
+	MaxReplicas     int32
+	CurrentReplicas int32
+	DesiredReplicas int32
+	Metrics         []HPAMetric            `json:",omitempty"`
+	Conditions      []AutoscalingCondition `json:",omitempty"`
+	// MissingRequests lists resources with a Utilization target that some target container
+	// does not request, so the HPA cannot compute utilization for them.
+	MissingRequests []string `json:",omitempty"`
+	// VPAConflict is set when a VPA that updates pods targets the same workload while this
+	// HPA scales on CPU or memory, so the two fight over the same signal.
+	VPAConflict bool `json:",omitempty"`
+}
+
+// VPAContainerRecommendation is a VPA recommendation for one container.
+type VPAContainerRecommendation struct {
+	ContainerName  string
+	Target         map[string]string `json:",omitempty"`
+	LowerBound     map[string]string `json:",omitempty"`
+	UpperBound     map[string]string `json:",omitempty"`
+	UncappedTarget map[string]string `json:",omitempty"`
+}
+
+// VerticalPodAutoscaler is a VPA inventory object (namespaced).
+type VerticalPodAutoscaler struct {
+	Kind            string
+	Name            string
+	Namespace       string
+	Annotations     map[string]string
+	Labels          map[string]string
+	UID             string
+	APIVersion      string
+	TargetRef       *ScaleTargetRef              `json:",omitempty"`
+	TargetUID       string                       `json:",omitempty"`
+	UpdateMode      string                       `json:",omitempty"`
+	Recommendations []VPAContainerRecommendation `json:",omitempty"`
+	Conditions      []AutoscalingCondition       `json:",omitempty"`
+}
+
+// VPA is optional VerticalPodAutoscaler inventory nested under ClusterWorkloadReport.
+// Omitted (nil) when autoscaling.k8s.io CRDs are not installed.
+type VPA struct {
+	VerticalPodAutoscalers []VerticalPodAutoscaler
+}
+
+// KEDATrigger is a ScaledObject/ScaledJob trigger summary; trigger metadata is not reported
+// since it can hold connection strings.
+type KEDATrigger struct {
+	Type       string `json:",omitempty"`
+	Name       string `json:",omitempty"`
+	MetricType string `json:",omitempty"`
+}
+
+// ScaledObject is a KEDA ScaledObject inventory object (namespaced).
+type ScaledObject struct {
+	Kind            string
+	Name            string
+	Namespace       string
+	Annotations     map[string]string
+	Labels          map[string]string
+	UID             string
+	APIVersion      string
+	ScaleTargetRef  *ScaleTargetRef        `json:",omitempty"`
+	TargetUID       string                 `json:",omitempty"`
+	MinReplicaCount *int32                 `json:",omitempty"`
+	MaxReplicaCount *int32                 `json:",omitempty"`
+	Triggers        []KEDATrigger          `json:",omitempty"`
+	HPAName         string                 `json:",omitempty"` // HPA KEDA manages for the target
+	Conditions      []AutoscalingCondition `json:",omitempty"`
+}
+
+// ScaledJob is a KEDA ScaledJob inventory object (namespaced). Its Jobs are owned by the
+// ScaledJob, so TargetUID is the ScaledJob's own UID when it runs as a top controller.
+type ScaledJob struct {
+	Kind            string
+	Name            string
+	Namespace       string
+	Annotations     map[string]string
+	Labels          map[string]string
+	UID             string
+	APIVersion      string
+	TargetUID       string                 `json:",omitempty"`
+	MinReplicaCount *int32                 `json:",omitempty"`
+	MaxReplicaCount *int32                 `json:",omitempty"`
+	Triggers        []KEDATrigger          `json:",omitempty"`
+	Conditions      []AutoscalingCondition `json:",omitempty"`
+}
+
+// KEDA is optional KEDA inventory nested under ClusterWorkloadReport.
+// Omitted (nil) when keda.sh CRDs are not installed.
+type KEDA struct {
+	ScaledObjects []ScaledObject
+	ScaledJobs    []ScaledJob
+}
+
+// PodDisruptionBudget is a policy/v1 PDB inventory object.
+type PodDisruptionBudget struct {
+	Kind                       string
+	Name                       string
+	Namespace                  string
+	Annotations                map[string]string
+	Labels                     map[string]string
+	UID                        string
+	APIVersion                 string
+	MinAvailable               string   `json:",omitempty"`
+	MaxUnavailable             string   `json:",omitempty"`
+	Selector                   string   `json:",omitempty"`
+	TargetUIDs                 []string `json:",omitempty"` // top controllers whose pods the selector matches
+	UnhealthyPodEvictionPolicy string   `json:",omitempty"`
+	CurrentHealthy             int32
+	DesiredHealthy             int32
+	ExpectedPods               int32
+	DisruptionsAllowed         int32
+}
+
+// controllerIndex finds top controllers by namespace, kind and name.
+type controllerIndex map[string]*ControllerResult
+
+func newControllerIndex(controllers []ControllerResult) controllerIndex {
+	index := controllerIndex{}
+	for i := range controllers {
+		c := &controllers[i]
+		index[c.Namespace+"/"+c.Kind+"/"+c.Name] = c
+	}
+	return index
+}
+
+func (index controllerIndex) find(namespace string, ref *ScaleTargetRef) *ControllerResult {
+	if ref == nil {
+		return nil
+	}
+	return index[namespace+"/"+ref.Kind+"/"+ref.Name]
+}
+
+func (index controllerIndex) uid(namespace string, ref *ScaleTargetRef) string {
+	if c := index.find(namespace, ref); c != nil {
+		return c.UID
+	}
+	return ""
+}
+
+func isAutoscalingCRDAbsent(err error) bool {
+	return apierrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+// listHorizontalPodAutoscalers lists HPAs linked to their target controllers. A failed list
+// is logged and reported as empty.
+func listHorizontalPodAutoscalers(ctx context.Context, kube kubernetes.Interface, controllers controllerIndex) []HorizontalPodAutoscaler {
+	out := []HorizontalPodAutoscaler{}
+	list, err := kube.AutoscalingV2().HorizontalPodAutoscalers(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("error listing HorizontalPodAutoscalers, continuing with empty HorizontalPodAutoscalers: %v", err)
+		return out
+	}
+	for _, item := range list.Items {
+		hpa := formatHorizontalPodAutoscaler(item)
+		if target := controllers.find(hpa.Namespace, &hpa.ScaleTargetRef); target != nil {
+			hpa.TargetUID = target.UID
+			hpa.MissingRequests = hpaMissingRequests(hpa.Metrics, target.Containers)
+		}
+		out = append(out, hpa)
+	}
+	return out
+}
+
+func formatHorizontalPodAutoscaler(item autoscalingv2.HorizontalPodAutoscaler) HorizontalPodAutoscaler {
+	apiVersion := item.APIVersion
+	if apiVersion == "" {
+		apiVersion = hpaAPIVersion
+	}
+	return HorizontalPodAutoscaler{
+		Kind:        KindHorizontalPodAutoscaler,
+		Name:        item.Name,
+		Namespace:   item.Namespace,
+		Annotations: item.Annotations,
+		Labels:      item.Labels,
+		UID:         string(item.UID),
+		APIVersion:  apiVersion,
+		ScaleTargetRef: ScaleTargetRef{
+			APIVersion: item.Spec.ScaleTargetRef.APIVersion,
+			Kind:       item.Spec.ScaleTargetRef.Kind,
+			Name:       item.Spec.ScaleTargetRef.Name,
+		},
+		MinReplicas:     item.Spec.MinReplicas,
+		MaxReplicas:     item.Spec.MaxReplicas,
+		CurrentReplicas: item.Status.CurrentReplicas,
+		DesiredReplicas: item.Status.DesiredReplicas,
+		Metrics:         formatHPAMetrics(item.Spec.Metrics, item.Status.CurrentMetrics),
+		Conditions:      formatHPAConditions(item.Status.Conditions),
+	}
+}
+
+func formatHPAMetrics(specs []autoscalingv2.MetricSpec, statuses []autoscalingv2.MetricStatus) []HPAMetric {
+	if len(specs) == 0 {
+		return nil
+	}
+	current := map[string]string{}
+	for _, status := range statuses {
+		var name, container string
+		var value autoscalingv2.MetricValueStatus
+		switch {
+		case status.Resource != nil:
+			name, value = string(status.Resource.Name), status.Resource.Current
+		case status.ContainerResource != nil:
+			name, container, value = string(status.ContainerResource.Name), status.ContainerResource.Container, status.ContainerResource.Current
+		case status.Pods != nil:
+			name, value = status.Pods.Metric.Name, status.Pods.Current
+		case status.Object != nil:
+			name, value = status.Object.Metric.Name, status.Object.Current
+		case status.External != nil:
+			name, value = status.External.Metric.Name, status.External.Current
+		default:
+			continue
+		}
+		current[string(status.Type)+"/"+name+"/"+container] = formatMetricValue(value.AverageUtilization, value.AverageValue, value.Value)
+	}
+	out := make([]HPAMetric, 0, len(specs))
+	for _, spec := range specs {
+		metric := HPAMetric{Type: string(spec.Type)}
+		var target autoscalingv2.MetricTarget
+		switch {
+		case spec.Resource != nil:
+			metric.Name, target = string(spec.Resource.Name), spec.Resource.Target
+		case spec.ContainerResource != nil:
+			metric.Name, metric.Container, target = string(spec.ContainerResource.Name), spec.ContainerResource.Container, spec.ContainerResource.Target
+		case spec.Pods != nil:
+			metric.Name, target = spec.Pods.Metric.Name, spec.Pods.Target
+		case spec.Object != nil:
+			metric.Name, target = spec.Object.Metric.Name, spec.Object.Target
+		case spec.External != nil:
+			metric.Name, target = spec.External.Metric.Name, spec.External.Target
+		}
+		metric.TargetType = string(target.Type)
+		metric.Target = formatMetricValue(target.AverageUtilization, target.AverageValue, target.Value)
+		metric.Current = current[metric.Type+"/"+metric.Name+"/"+metric.Container]
+		out = append(out, metric)
+	}
+	return out
+}
+
+// formatMetricValue renders utilization as a percentage, otherwise the quantity set.
+func formatMetricValue(utilization *int32, averageValue, value *resource.Quantity) string {
+	switch {
+	case utilization != nil:
+		return strconv.FormatInt(int64(*utilization), 10) + "%"
+	case averageValue != nil:
+		return averageValue.String()
+	case value != nil:
+		return value.String()
+	}
+	return ""
+}
+
+func formatHPAConditions(conditions []autoscalingv2.HorizontalPodAutoscalerCondition) []AutoscalingCondition {
+	if len(conditions) == 0 {
+		return nil
+	}
+	out := make([]AutoscalingCondition, 0, len(conditions))
+	for _, c := range conditions {
+		out = append(out, AutoscalingCondition{
+			Type:               string(c.Type),
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: formatConditionTime(c.LastTransitionTime),
+		})
+	}
+	return out
+}
+
+func formatConditionTime(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// hpaMissingRequests returns the resources with a Utilization target that a target container
+// does not request. Utilization is relative to requests, so the HPA cannot act on them.
+func hpaMissingRequests(metrics []HPAMetric, containers []ContainerResult) []string {
+	missing := map[string]struct{}{}
+	for _, metric := range metrics {
+		if metric.TargetType != string(autoscalingv2.UtilizationMetricType) {
+			continue
+		}
+		if metric.Type != string(autoscalingv2.ResourceMetricSourceType) && metric.Type != string(autoscalingv2.ContainerResourceMetricSourceType) {
+			continue
+		}
+		for _, container := range containers {
+			if metric.Container != "" && metric.Container != container.Name {
+				continue
+			}
+			if !containerRequests(container, metric.Name) {
+				missing[metric.Name] = struct{}{}
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(missing))
+	for name := range missing {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func containerRequests(container ContainerResult, resourceName string) bool {
+	var request string
+	switch corev1.ResourceName(resourceName) {
+	case corev1.ResourceCPU:
+		request = container.Resource.Requests.CPU
+	case corev1.ResourceMemory:
+		request = container.Resource.Requests.Memory
+	default:
+		return true
+	}
+	return request != "" && request != "0"
+}
+
+// markVPAConflicts flags HPAs scaling on CPU or memory whose target is also updated by a VPA.
+func markVPAConflicts(hpas []HorizontalPodAutoscaler, vpa *VPA) {
+	if vpa == nil {
+		return
+	}
+	updating := map[string]struct{}{}
+	for _, v := range vpa.VerticalPodAutoscalers {
+		if v.TargetUID != "" && v.UpdateMode != "Off" && v.UpdateMode != "Initial" {
+			updating[v.TargetUID] = struct{}{}
+		}
+	}
+	for i := range hpas {
+		if _, ok := updating[hpas[i].TargetUID]; !ok || hpas[i].TargetUID == "" {
+			continue
+		}
+		for _, metric := range hpas[i].Metrics {
+			if metric.Name == string(corev1.ResourceCPU) || metric.Name == string(corev1.ResourceMemory) {
+				hpas[i].VPAConflict = true
+				break
+			}
+		}
+	}
+}
+
+// listVPAInventory returns nil when the VPA CRDs are not installed. Forbidden list is
+// treated as present-but-unreadable (warn + empty array).
+func listVPAInventory(ctx context.Context, dynamicClient dynamic.Interface, controllers controllerIndex) *VPA {
+	items, err := listNamespacedUnstructured(ctx, dynamicClient, vpaGVR)
+	if err != nil {
+		if isAutoscalingCRDAbsent(err) {
+			return nil
+		}
+		logrus.Warnf("error listing VerticalPodAutoscalers, continuing with empty VerticalPodAutoscalers: %v", err)
+		return &VPA{VerticalPodAutoscalers: []VerticalPodAutoscaler{}}
+	}
+	out := make([]VerticalPodAutoscaler, 0, len(items))
+	for _, item := range items {
+		vpa := formatVerticalPodAutoscaler(item)
+		vpa.TargetUID = controllers.uid(vpa.Namespace, vpa.TargetRef)
+		out = append(out, vpa)
+	}
+	return &VPA{VerticalPodAutoscalers: out}
+}
+
+func formatVerticalPodAutoscaler(item unstructured.Unstructured) VerticalPodAutoscaler {
+	apiVersion := item.GetAPIVersion()
+	if apiVersion == "" {
+		apiVersion = vpaAPIVersion
+	}
+	updateMode := nestedString(item.Object, "spec", "updatePolicy", "updateMode")
+	if updateMode == "" {
+		updateMode = "Auto" // VPA default
+	}
+	return VerticalPodAutoscaler{
+		Kind:            KindVerticalPodAutoscaler,
+		Name:            item.GetName(),
+		Namespace:       item.GetNamespace(),
+		Annotations:     item.GetAnnotations(),
+		Labels:          item.GetLabels(),
+		UID:             string(item.GetUID()),
+		APIVersion:      apiVersion,
+		TargetRef:       formatScaleTargetRef(nestedMap(item.Object, "spec", "targetRef"), ""),
+		UpdateMode:      updateMode,
+		Recommendations: formatVPARecommendations(nestedSlice(item.Object, "status", "recommendation", "containerRecommendations")),
+		Conditions:      formatAutoscalingConditions(nestedSlice(item.Object, "status", "conditions")),
+	}
+}
+
+func formatVPARecommendations(raw []any) []VPAContainerRecommendation {
+	if len(raw) == 0 {
+		return nil
+	}
+	out := make([]VPAContainerRecommendation, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		out = append(out, VPAContainerRecommendation{
+			ContainerName:  asString(m["containerName"]),
+			Target:         asStringMap(m["target"]),
+			LowerBound:     asStringMap(m["lowerBound"]),
+			UpperBound:     asStringMap(m["upperBound"]),
+			UncappedTarget: asStringMap(m["uncappedTarget"]),
+		})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// listKEDAInventory returns nil when keda.sh is not installed (ScaledObject and ScaledJob
+// lists both report absent CRDs). When KEDA is present, nested arrays are always set.
+func listKEDAInventory(ctx context.Context, dynamicClient dynamic.Interface, controllers controllerIndex) *KEDA {
+	scaledObjectItems, objectsErr := listNamespacedUnstructured(ctx, dynamicClient, scaledObjectGVR)
+	scaledJobItems, jobsErr := listNamespacedUnstructured(ctx, dynamicClient, scaledJobGVR)
+
+	if objectsErr != nil && jobsErr != nil && isAutoscalingCRDAbsent(objectsErr) && isAutoscalingCRDAbsent(jobsErr) {
+		return nil
+	}
+
+	scaledObjects := make([]ScaledObject, 0, len(scaledObjectItems))
+	if objectsErr != nil {
+		logrus.Warnf("error listing KEDA ScaledObjects, continuing with empty ScaledObjects: %v", objectsErr)
+	}
+	for _, item := range scaledObjectItems {
+		so := formatScaledObject(item)
+		so.TargetUID = controllers.uid(so.Namespace, so.ScaleTargetRef)
+		scaledObjects = append(scaledObjects, so)
+	}
+
+	scaledJobs := make([]ScaledJob, 0, len(scaledJobItems))
+	if jobsErr != nil {
+		logrus.Warnf("error listing KEDA ScaledJobs, continuing with empty ScaledJobs: %v", jobsErr)
+	}
+	for _, item := range scaledJobItems {
+		sj := formatScaledJob(item)
+		sj.TargetUID = controllers.uid(sj.Namespace, &ScaleTargetRef{Kind: KindScaledJob, Name: sj.Name})
+		scaledJobs = append(scaledJobs, sj)
+	}
+
+	return &KEDA{
+		ScaledObjects: scaledObjects,
+		ScaledJobs:    scaledJobs,
+	}
+}
+
+func formatScaledObject(item unstructured.Unstructured) ScaledObject {
+	apiVersion := item.GetAPIVersion()
+	if apiVersion == "" {
+		apiVersion = kedaAPIVersion
+	}
+	return ScaledObject{
+		Kind:            KindScaledObject,
+		Name:            item.GetName(),
+		Namespace:       item.GetNamespace(),
+		Annotations:     item.GetAnnotations(),
+		Labels:          item.GetLabels(),
+		UID:             string(item.GetUID()),
+		APIVersion:      apiVersion,
+		ScaleTargetRef:  formatScaleTargetRef(nestedMap(item.Object, "spec", "scaleTargetRef"), "Deployment"),
+		MinReplicaCount: nestedInt32(item.Object, "spec", "minReplicaCount"),
+		MaxReplicaCount: nestedInt32(item.Object, "spec", "maxReplicaCount"),
+		Triggers:        formatKEDATriggers(nestedSlice(item.Object, "spec", "triggers")),
+		HPAName:         nestedString(item.Object, "status", "hpaName"),
+		Conditions:      formatAutoscalingConditions(nestedSlice(item.Object, "status", "conditions")),
+	}
+}
+
+func formatScaledJob(item unstructured.Unstructured) ScaledJob {
+	apiVersion := item.GetAPIVersion()
+	if apiVersion == "" {
+		apiVersion = kedaAPIVersion
+	}
+	return ScaledJob{
+		Kind:            KindScaledJob,
+		Name:            item.GetName(),
+		Namespace:       item.GetNamespace(),
+		Annotations:     item.GetAnnotations(),
+		Labels:          item.GetLabels(),
+		UID:             string(item.GetUID()),
+		APIVersion:      apiVersion,
+		MinReplicaCount: nestedInt32(item.Object, "spec", "minReplicaCount"),
+		MaxReplicaCount: nestedInt32(item.Object, "spec", "maxReplicaCount"),
+		Triggers:        formatKEDATriggers(nestedSlice(item.Object, "spec", "triggers")),
+		Conditions:      formatAutoscalingConditions(nestedSlice(item.Object, "status", "conditions")),
+	}
+}
+
+// formatScaleTargetRef reads a scale target reference, defaulting its kind as KEDA does.
+func formatScaleTargetRef(raw map[string]any, defaultKind string) *ScaleTargetRef {
+	if len(raw) == 0 {
+		return nil
+	}
+	ref := &ScaleTargetRef{
+		APIVersion: asString(raw["apiVersion"]),
+		Kind:       asString(raw["kind"]),
+		Name:       asString(raw["name"]),
+	}
+	if ref.Kind == "" {
+		ref.Kind = defaultKind
+	}
+	if ref.Name == "" {
+		return nil
+	}
+	return ref
+}
+
+func formatKEDATriggers(raw []any) []KEDATrigger {
+	if len(raw) == 0 {
+		return nil
+	}
+	out := make([]KEDATrigger, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		out = append(out, KEDATrigger{
+			Type:       asString(m["type"]),
+			Name:       asString(m["name"]),
+			MetricType: asString(m["metricType"]),
+		})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func formatAutoscalingConditions(raw []any) []AutoscalingCondition {
+	if len(raw) == 0 {
+		return nil
+	}
+	out := make([]AutoscalingCondition, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		out = append(out, AutoscalingCondition{
+			Type:               asString(m["type"]),
+			Status:             asString(m["status"]),
+			Reason:             asString(m["reason"]),
+			Message:            asString(m["message"]),
+			LastTransitionTime: asString(m["lastTransitionTime"]),
+		})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func nestedInt32(obj map[string]any, fields ...string) *int32 {
+	v, ok, err := unstructured.NestedInt64(obj, fields...)
+	if err != nil || !ok {
+		return nil
+	}
+	i := int32(v)
+	return &i
+}
+
+// listPodDisruptionBudgets lists PDBs linked to the top controllers whose pod template labels
+// their selector matches. A failed list is logged and reported as empty.
+func listPodDisruptionBudgets(ctx context.Context, kube kubernetes.Interface, controllers []ControllerResult) []PodDisruptionBudget {
+	out := []PodDisruptionBudget{}
+	list, err := kube.PolicyV1().PodDisruptionBudgets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("error listing PodDisruptionBudgets, continuing with empty PodDisruptionBudgets: %v", err)
+		return out
+	}
+	for _, item := range list.Items {
+		pdb := formatPodDisruptionBudget(item)
+		pdb.TargetUIDs = pdbTargetUIDs(item, controllers)
+		out = append(out, pdb)
+	}
+	return out
+}
+
+func formatPodDisruptionBudget(item policyv1.PodDisruptionBudget) PodDisruptionBudget {
+	apiVersion := item.APIVersion
+	if apiVersion == "" {
+		apiVersion = pdbAPIVersion
+	}
+	unhealthyPodEvictionPolicy := ""
+	if item.Spec.UnhealthyPodEvictionPolicy != nil {
+		unhealthyPodEvictionPolicy = string(*item.Spec.UnhealthyPodEvictionPolicy)
+	}
+	selector := ""
+	if item.Spec.Selector != nil {
+		selector = metav1.FormatLabelSelector(item.Spec.Selector)
+	}
+	return PodDisruptionBudget{
+		Kind:                       KindPodDisruptionBudget,
+		Name:                       item.Name,
+		Namespace:                  item.Namespace,
+		Annotations:                item.Annotations,
+		Labels:                     item.Labels,
+		UID:                        string(item.UID),
+		APIVersion:                 apiVersion,
+		MinAvailable:               intOrStringPtrString(item.Spec.MinAvailable),
+		MaxUnavailable:             intOrStringPtrString(item.Spec.MaxUnavailable),
+		Selector:                   selector,
+		UnhealthyPodEvictionPolicy: unhealthyPodEvictionPolicy,
+		CurrentHealthy:             item.Status.CurrentHealthy,
+		DesiredHealthy:             item.Status.DesiredHealthy,
+		ExpectedPods:               item.Status.ExpectedPods,
+		DisruptionsAllowed:         item.Status.DisruptionsAllowed,
+	}
+}
+
+// intOrStringPtrString keeps an explicit 0 (e.g. maxUnavailable: 0), unlike intOrStringString.
+func intOrStringPtrString(value *intstr.IntOrString) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func pdbTargetUIDs(item policyv1.PodDisruptionBudget, controllers []ControllerResult) []string {
+	if item.Spec.Selector == nil {
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(item.Spec.Selector)
+	if err != nil {
+		logrus.Warnf("invalid selector on PodDisruptionBudget %s/%s: %v", item.Namespace, item.Name, err)
+		return nil
+	}
+	var out []string
+	for _, c := range controllers {
+		if c.Namespace != item.Namespace || len(c.PodLabels) == 0 {
+			continue
+		}
+		if selector.Matches(labels.Set(c.PodLabels)) {
+			out = append(out, c.UID)
+		}
+	}
+	return out
+}
@@ -0,0 +1,235 @@
+package workloads
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+)
+
+func autoscalingTestControllers() []ControllerResult {
+	return []ControllerResult{
+		{
+			Kind:      "Deployment",
+			Name:      "web",
+			Namespace: "default",
+			UID:       "deploy-1",
+			PodLabels: map[string]string{"app": "web"},
+			Containers: []ContainerResult{
+				{Name: "web", Resource: ResourceResult{Requests: ResourcesInfo{CPU: "100m", Memory: "0"}}},
+			},
+		},
+		{
+			Kind:      "ScaledJob",
+			Name:      "consumer",
+			Namespace: "default",
+			UID:       "sj-1",
+			PodLabels: map[string]string{"app": "consumer"},
+		},
+	}
+}
+
+func TestListHorizontalPodAutoscalers(t *testing.T) {
+	kube := fake.NewSimpleClientset(&autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "hpa-1"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			MinReplicas:    ptr.To(int32(2)),
+			MaxReplicas:    10,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name:   "cpu",
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: ptr.To(int32(70))},
+					},
+				},
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name:   "memory",
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: ptr.To(int32(80))},
+					},
+				},
+				{
+					Type: autoscalingv2.ExternalMetricSourceType,
+					External: &autoscalingv2.ExternalMetricSource{
+						Metric: autoscalingv2.MetricIdentifier{Name: "queue_depth"},
+						Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: ptr.To(resource.MustParse("30"))},
+					},
+				},
+			},
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: 3,
+			DesiredReplicas: 5,
+			CurrentMetrics: []autoscalingv2.MetricStatus{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricStatus{
+					Name:    "cpu",
+					Current: autoscalingv2.MetricValueStatus{AverageUtilization: ptr.To(int32(95))},
+				},
+			}},
+		},
+	})
+
+	got := listHorizontalPodAutoscalers(context.Background(), kube, newControllerIndex(autoscalingTestControllers()))
+	require.Len(t, got, 1)
+	hpa := got[0]
+	require.Equal(t, KindHorizontalPodAutoscaler, hpa.Kind)
+	require.Equal(t, "autoscaling/v2", hpa.APIVersion)
+	require.Equal(t, "deploy-1", hpa.TargetUID)
+	require.Equal(t, int32(2), *hpa.MinReplicas)
+	require.Equal(t, int32(10), hpa.MaxReplicas)
+	require.Equal(t, int32(3), hpa.CurrentReplicas)
+	require.Equal(t, int32(5), hpa.DesiredReplicas)
+	require.Equal(t, []HPAMetric{
+		{Type: "Resource", Name: "cpu", TargetType: "Utilization", Target: "70%", Current: "95%"},
+		{Type: "Resource", Name: "memory", TargetType: "Utilization", Target: "80%"},
+		{Type: "External", Name: "queue_depth", TargetType: "AverageValue", Target: "30"},
+	}, hpa.Metrics)
+	require.Equal(t, []string{"memory"}, hpa.MissingRequests)
+}
+
+func TestListHorizontalPodAutoscalersSoftFailForbidden(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	kube.Fake.PrependReactor("list", "horizontalpodautoscalers", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "autoscaling", Resource: "horizontalpodautoscalers"}, "", nil)
+	})
+
+	got := listHorizontalPodAutoscalers(context.Background(), kube, newControllerIndex(nil))
+	require.NotNil(t, got)
+	require.Empty(t, got)
+}
+
+func autoscalingListKinds() map[schema.GroupVersionResource]string {
+	return map[schema.GroupVersionResource]string{
+		vpaGVR:          "VerticalPodAutoscalerList",
+		scaledObjectGVR: "ScaledObjectList",
+		scaledJobGVR:    "ScaledJobList",
+	}
+}
+
+func TestListVPAInventory(t *testing.T) {
+	vpa := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata":   map[string]any{"name": "web", "namespace": "default", "uid": "vpa-1"},
+		"spec": map[string]any{
+			"targetRef": map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"},
+		},
+		"status": map[string]any{
+			"recommendation": map[string]any{
+				"containerRecommendations": []any{
+					map[string]any{
+						"containerName": "web",
+						"target":        map[string]any{"cpu": "250m", "memory": "256Mi"},
+						"lowerBound":    map[string]any{"cpu": "100m", "memory": "128Mi"},
+					},
+				},
+			},
+		},
+	}}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), autoscalingListKinds(), vpa)
+
+	got := listVPAInventory(context.Background(), client, newControllerIndex(autoscalingTestControllers()))
+	require.NotNil(t, got)
+	require.Len(t, got.VerticalPodAutoscalers, 1)
+	v := got.VerticalPodAutoscalers[0]
+	require.Equal(t, "deploy-1", v.TargetUID)
+	require.Equal(t, "Auto", v.UpdateMode)
+	require.Len(t, v.Recommendations, 1)
+	require.Equal(t, "250m", v.Recommendations[0].Target["cpu"])
+	require.Equal(t, "128Mi", v.Recommendations[0].LowerBound["memory"])
+
+	hpas := []HorizontalPodAutoscaler{
+		{Name: "web", TargetUID: "deploy-1", Metrics: []HPAMetric{{Type: "Resource", Name: "cpu"}}},
+		{Name: "other", TargetUID: "other-1", Metrics: []HPAMetric{{Type: "Resource", Name: "cpu"}}},
+	}
+	markVPAConflicts(hpas, got)
+	require.True(t, hpas[0].VPAConflict)
+	require.False(t, hpas[1].VPAConflict)
+}
+
+func TestListVPAInventoryOmitsWhenNotInstalled(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), autoscalingListKinds())
+	client.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+
+	require.Nil(t, listVPAInventory(context.Background(), client, newControllerIndex(nil)))
+	require.Nil(t, listKEDAInventory(context.Background(), client, newControllerIndex(nil)))
+}
+
+func TestListKEDAInventory(t *testing.T) {
+	so := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "keda.sh/v1alpha1",
+		"kind":       "ScaledObject",
+		"metadata":   map[string]any{"name": "web", "namespace": "default", "uid": "so-1"},
+		"spec": map[string]any{
+			"scaleTargetRef":  map[string]any{"name": "web"},
+			"minReplicaCount": int64(1),
+			"maxReplicaCount": int64(20),
+			"triggers": []any{
+				map[string]any{"type": "prometheus", "metricType": "AverageValue", "metadata": map[string]any{"serverAddress": "http://prometheus"}},
+			},
+		},
+		"status": map[string]any{"hpaName": "keda-hpa-web"},
+	}}
+	sj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "keda.sh/v1alpha1",
+		"kind":       "ScaledJob",
+		"metadata":   map[string]any{"name": "consumer", "namespace": "default", "uid": "sj-1"},
+		"spec": map[string]any{
+			"maxReplicaCount": int64(5),
+			"triggers":        []any{map[string]any{"type": "rabbitmq"}},
+		},
+	}}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), autoscalingListKinds(), so, sj)
+
+	got := listKEDAInventory(context.Background(), client, newControllerIndex(autoscalingTestControllers()))
+	require.NotNil(t, got)
+	require.Len(t, got.ScaledObjects, 1)
+	require.Equal(t, "Deployment", got.ScaledObjects[0].ScaleTargetRef.Kind)
+	require.Equal(t, "deploy-1", got.ScaledObjects[0].TargetUID)
+	require.Equal(t, int32(20), *got.ScaledObjects[0].MaxReplicaCount)
+	require.Equal(t, []KEDATrigger{{Type: "prometheus", MetricType: "AverageValue"}}, got.ScaledObjects[0].Triggers)
+	require.Equal(t, "keda-hpa-web", got.ScaledObjects[0].HPAName)
+	require.Len(t, got.ScaledJobs, 1)
+	require.Equal(t, "sj-1", got.ScaledJobs[0].TargetUID)
+	require.Nil(t, got.ScaledJobs[0].MinReplicaCount)
+}
+
+func TestListPodDisruptionBudgets(t *testing.T) {
+	kube := fake.NewSimpleClientset(&policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "pdb-1"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: ptr.To(intstr.FromInt32(0)),
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 3, DesiredHealthy: 3, ExpectedPods: 3},
+	})
+
+	got := listPodDisruptionBudgets(context.Background(), kube, autoscalingTestControllers())
+	require.Len(t, got, 1)
+	require.Equal(t, KindPodDisruptionBudget, got[0].Kind)
+	require.Equal(t, "policy/v1", got[0].APIVersion)
+	require.Equal(t, "0", got[0].MaxUnavailable)
+	require.Empty(t, got[0].MinAvailable)
+	require.Equal(t, "app=web", got[0].Selector)
+	require.Equal(t, []string{"deploy-1"}, got[0].TargetUIDs)
+	require.Equal(t, int32(0), got[0].DisruptionsAllowed)
+}
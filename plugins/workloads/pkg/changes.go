@@ -0,0 +1,370 @@
+package workloads
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const snapshotConfigMapKey = "snapshot.json.gz"
+
+// ObjectRef identifies a namespaced object in Changes.
+type ObjectRef struct {
+	Kind      string
+	Name      string
+	Namespace string
+	UID       string `json:",omitempty"`
+}
+
+// ContainerChange is a changed container field, e.g. Image or Requests.CPU. Old is empty
+// for added containers and New is empty for removed ones.
+type ContainerChange struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Container string
+	Field     string
+	Old       string
+	New       string
+}
+
+// ReplicaChange is a change in a controller's running pod count.
+type ReplicaChange struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Old       float64
+	New       float64
+}
+
+// WorkloadChanges lists what changed since the previous report.
+type WorkloadChanges struct {
+	PreviousCreationTime time.Time
+	AddedControllers     []ObjectRef
+	RemovedControllers   []ObjectRef
+	ImageChanges         []ContainerChange
+	ResourceChanges      []ContainerChange
+	ReplicaChanges       []ReplicaChange
+	AddedServices        []ObjectRef
+	RemovedServices      []ObjectRef
+	AddedIngresses       []ObjectRef
+	RemovedIngresses     []ObjectRef
+}
+
+// SnapshotContainer is the part of a container compared between reports.
+type SnapshotContainer struct {
+	Name     string
+	Image    string
+	Requests ResourcesInfo
+	Limits   ResourcesInfo
+}
+
+// SnapshotController is the part of a controller compared between reports.
+type SnapshotController struct {
+	ObjectRef
+	PodCount   float64
+	Containers []SnapshotContainer
+}
+
+// ReportSnapshot is the compact form of a report kept between runs for change detection.
+type ReportSnapshot struct {
+	CreationTime time.Time
+	Controllers  []SnapshotController
+	Services     []ObjectRef
+	Ingresses    []ObjectRef
+}
+
+// SnapshotStore persists the previous run's snapshot.
+type SnapshotStore interface {
+	// Load returns nil when no snapshot has been saved yet.
+	Load(ctx context.Context) (*ReportSnapshot, error)
+	Save(ctx context.Context, snapshot *ReportSnapshot) error
+}
+
+// NewReportSnapshot builds the snapshot of a report.
+func NewReportSnapshot(report *ClusterWorkloadReport) *ReportSnapshot {
+	snapshot := &ReportSnapshot{
+		CreationTime: report.CreationTime,
+		Controllers:  make([]SnapshotController, 0, len(report.Controllers)),
+		Services:     make([]ObjectRef, 0, len(report.Services)),
+		Ingresses:    make([]ObjectRef, 0, len(report.Ingresses)),
+	}
+	for _, c := range report.Controllers {
+		controller := SnapshotController{
+			ObjectRef: ObjectRef{Kind: c.Kind, Name: c.Name, Namespace: c.Namespace, UID: c.UID},
+			PodCount:  c.PodCount,
+		}
+		for _, container := range c.Containers {
+			controller.Containers = append(controller.Containers, SnapshotContainer{
+				Name:     container.Name,
+				Image:    container.Image,
+				Requests: container.Resource.Requests,
+				Limits:   container.Resource.Limits,
+			})
+		}
+		snapshot.Controllers = append(snapshot.Controllers, controller)
+	}
+	for _, svc := range report.Services {
+		snapshot.Services = append(snapshot.Services, ObjectRef{Kind: svc.Kind, Name: svc.Name, Namespace: svc.Namespace, UID: svc.UID})
+	}
+	for _, ingress := range report.Ingresses {
+		snapshot.Ingresses = append(snapshot.Ingresses, ObjectRef{Kind: ingress.Kind, Name: ingress.Name, Namespace: ingress.Namespace, UID: ingress.UID})
+	}
+	return snapshot
+}
+
+// DetectChanges compares two snapshots. Objects are matched by namespace, kind and name,
+// so a recreated controller is reported as changed rather than removed and added.
+// Returns nil when there is no previous snapshot.
+func DetectChanges(previous, current *ReportSnapshot) *WorkloadChanges {
+	if previous == nil || current == nil {
+		return nil
+	}
+	changes := &WorkloadChanges{
+		PreviousCreationTime: previous.CreationTime,
+		AddedControllers:     []ObjectRef{},
+		RemovedControllers:   []ObjectRef{},
+		ImageChanges:         []ContainerChange{},
+		ResourceChanges:      []ContainerChange{},
+		ReplicaChanges:       []ReplicaChange{},
+	}
+
+	previousControllers := map[string]SnapshotController{}
+	for _, c := range previous.Controllers {
+		previousControllers[c.key()] = c
+	}
+	currentKeys := map[string]bool{}
+	for _, c := range current.Controllers {
+		currentKeys[c.key()] = true
+		old, ok := previousControllers[c.key()]
+		if !ok {
+			changes.AddedControllers = append(changes.AddedControllers, c.ObjectRef)
+			continue
+		}
+		if old.PodCount != c.PodCount {
+			changes.ReplicaChanges = append(changes.ReplicaChanges, ReplicaChange{Kind: c.Kind, Name: c.Name, Namespace: c.Namespace, Old: old.PodCount, New: c.PodCount})
+		}
+		diffContainers(changes, c.ObjectRef, old.Containers, c.Containers)
+	}
+	for _, c := range previous.Controllers {
+		if !currentKeys[c.key()] {
+			changes.RemovedControllers = append(changes.RemovedControllers, c.ObjectRef)
+		}
+	}
+
+	changes.AddedServices, changes.RemovedServices = diffObjectRefs(previous.Services, current.Services)
+	changes.AddedIngresses, changes.RemovedIngresses = diffObjectRefs(previous.Ingresses, current.Ingresses)
+	sortObjectRefs(changes.AddedControllers)
+	sortObjectRefs(changes.RemovedControllers)
+	return changes
+}
+
+func (ref ObjectRef) key() string {
+	return ref.Namespace + "/" + ref.Kind + "/" + ref.Name
+}
+
+func diffContainers(changes *WorkloadChanges, controller ObjectRef, previous, current []SnapshotContainer) {
+	newChange := func(container, field, old, new string) ContainerChange {
+		return ContainerChange{Kind: controller.Kind, Name: controller.Name, Namespace: controller.Namespace, Container: container, Field: field, Old: old, New: new}
+	}
+	previousByName := map[string]SnapshotContainer{}
+	for _, c := range previous {
+		previousByName[c.Name] = c
+	}
+	currentNames := map[string]bool{}
+	for _, c := range current {
+		currentNames[c.Name] = true
+		old, ok := previousByName[c.Name]
+		if !ok {
+			changes.ImageChanges = append(changes.ImageChanges, newChange(c.Name, "Image", "", c.Image))
+			continue
+		}
+		if old.Image != c.Image {
+			changes.ImageChanges = append(changes.ImageChanges, newChange(c.Name, "Image", old.Image, c.Image))
+		}
+		for _, field := range []struct {
+			name     string
+			old, new string
+		}{
+			{"Requests.CPU", old.Requests.CPU, c.Requests.CPU},
+			{"Requests.Memory", old.Requests.Memory, c.Requests.Memory},
+			{"Limits.CPU", old.Limits.CPU, c.Limits.CPU},
+			{"Limits.Memory", old.Limits.Memory, c.Limits.Memory},
+		} {
+			if field.old != field.new {
+				changes.ResourceChanges = append(changes.ResourceChanges, newChange(c.Name, field.name, field.old, field.new))
+			}
+		}
+	}
+	for _, c := range previous {
+		if !currentNames[c.Name] {
+			changes.ImageChanges = append(changes.ImageChanges, newChange(c.Name, "Image", c.Image, ""))
+		}
+	}
+}
+
+func diffObjectRefs(previous, current []ObjectRef) ([]ObjectRef, []ObjectRef) {
+	previousKeys := map[string]bool{}
+	for _, ref := range previous {
+		previousKeys[ref.key()] = true
+	}
+	currentKeys := map[string]bool{}
+	added := []ObjectRef{}
+	for _, ref := range current {
+		currentKeys[ref.key()] = true
+		if !previousKeys[ref.key()] {
+			added = append(added, ref)
+		}
+	}
+	removed := []ObjectRef{}
+	for _, ref := range previous {
+		if !currentKeys[ref.key()] {
+			removed = append(removed, ref)
+		}
+	}
+	sortObjectRefs(added)
+	sortObjectRefs(removed)
+	return added, removed
+}
+
+func sortObjectRefs(refs []ObjectRef) {
+	sort.Slice(refs, func(i, j int) bool { return refs[i].key() < refs[j].key() })
+}
+
+func encodeSnapshot(snapshot *ReportSnapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(snapshot); err != nil {
+		return nil, fmt.Errorf("encoding snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compressing snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeSnapshot(data []byte) (*ReportSnapshot, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing snapshot: %w", err)
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing snapshot: %w", err)
+	}
+	var snapshot ReportSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// FileSnapshotStore keeps the snapshot in a gzipped JSON file, e.g. on a persistent volume.
+type FileSnapshotStore struct {
+	Path string
+}
+
+// NewFileSnapshotStore returns a store backed by the file at path.
+func NewFileSnapshotStore(path string) *FileSnapshotStore {
+	return &FileSnapshotStore{Path: path}
+}
+
+// Load implements SnapshotStore.
+func (s *FileSnapshotStore) Load(_ context.Context) (*ReportSnapshot, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.Path, err)
+	}
+	return decodeSnapshot(data)
+}
+
+// Save implements SnapshotStore. The file is replaced atomically.
+func (s *FileSnapshotStore) Save(_ context.Context, snapshot *ReportSnapshot) error {
+	data, err := encodeSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		return fmt.Errorf("renaming %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// ConfigMapSnapshotStore keeps the snapshot in the binaryData of a ConfigMap.
+type ConfigMapSnapshotStore struct {
+	Kube      kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+// NewConfigMapSnapshotStore returns a store backed by the ConfigMap namespace/name.
+func NewConfigMapSnapshotStore(kube kubernetes.Interface, namespace, name string) *ConfigMapSnapshotStore {
+	return &ConfigMapSnapshotStore{Kube: kube, Namespace: namespace, Name: name}
+}
+
+// Load implements SnapshotStore.
+func (s *ConfigMapSnapshotStore) Load(ctx context.Context) (*ReportSnapshot, error) {
+	configMap, err := s.Kube.CoreV1().ConfigMaps(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching ConfigMap %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	data, ok := configMap.BinaryData[snapshotConfigMapKey]
+	if !ok {
+		return nil, nil
+	}
+	return decodeSnapshot(data)
+}
+
+// Save implements SnapshotStore, creating the ConfigMap if needed.
+func (s *ConfigMapSnapshotStore) Save(ctx context.Context, snapshot *ReportSnapshot) error {
+	data, err := encodeSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	configMaps := s.Kube.CoreV1().ConfigMaps(s.Namespace)
+	configMap, err := configMaps.Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.Name, Namespace: s.Namespace},
+			BinaryData: map[string][]byte{snapshotConfigMapKey: data},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("creating ConfigMap %s/%s: %w", s.Namespace, s.Name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fetching ConfigMap %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	if configMap.BinaryData == nil {
+		configMap.BinaryData = map[string][]byte{}
+	}
+	configMap.BinaryData[snapshotConfigMapKey] = data
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("updating ConfigMap %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	return nil
+}
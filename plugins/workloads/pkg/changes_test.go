@@ -0,0 +1,112 @@
+package workloads
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func changesTestReport(creationTime time.Time, image, cpu string, replicas float64, withWorker bool, serviceName string) *ClusterWorkloadReport {
+	report := &ClusterWorkloadReport{
+		CreationTime: creationTime,
+		Controllers: []ControllerResult{
+			{
+				Kind: "Deployment", Name: "web", Namespace: "default", UID: "web-1", PodCount: replicas,
+				Containers: []ContainerResult{
+					{Name: "web", Image: image, Resource: ResourceResult{
+						Requests: ResourcesInfo{CPU: cpu, Memory: "128Mi"},
+						Limits:   ResourcesInfo{Memory: "256Mi"},
+					}},
+				},
+			},
+		},
+		Services:  []Service{{Kind: "Service", Name: serviceName, Namespace: "default", UID: serviceName}},
+		Ingresses: []Ingress{{Kind: "Ingress", Name: "web", Namespace: "default", UID: "ing-1"}},
+	}
+	if withWorker {
+		report.Controllers = append(report.Controllers, ControllerResult{
+			Kind: "Deployment", Name: "worker", Namespace: "jobs", UID: "worker-1", PodCount: 1,
+			Containers: []ContainerResult{{Name: "worker", Image: "worker:1"}},
+		})
+	}
+	return report
+}
+
+func TestDetectChanges(t *testing.T) {
+	previousTime := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	previous := NewReportSnapshot(changesTestReport(previousTime, "web:1.0", "100m", 2, true, "web"))
+	current := NewReportSnapshot(changesTestReport(previousTime.Add(time.Hour), "web:1.1", "250m", 3, false, "web-v2"))
+	current.Controllers = append(current.Controllers, SnapshotController{
+		ObjectRef: ObjectRef{Kind: "StatefulSet", Name: "db", Namespace: "default", UID: "db-1"},
+	})
+
+	require.Nil(t, DetectChanges(nil, current))
+
+	got := DetectChanges(previous, current)
+	require.Equal(t, previousTime, got.PreviousCreationTime)
+	require.Equal(t, []ObjectRef{{Kind: "StatefulSet", Name: "db", Namespace: "default", UID: "db-1"}}, got.AddedControllers)
+	require.Equal(t, []ObjectRef{{Kind: "Deployment", Name: "worker", Namespace: "jobs", UID: "worker-1"}}, got.RemovedControllers)
+	require.Equal(t, []ContainerChange{
+		{Kind: "Deployment", Name: "web", Namespace: "default", Container: "web", Field: "Image", Old: "web:1.0", New: "web:1.1"},
+	}, got.ImageChanges)
+	require.Equal(t, []ContainerChange{
+		{Kind: "Deployment", Name: "web", Namespace: "default", Container: "web", Field: "Requests.CPU", Old: "100m", New: "250m"},
+	}, got.ResourceChanges)
+	require.Equal(t, []ReplicaChange{{Kind: "Deployment", Name: "web", Namespace: "default", Old: 2, New: 3}}, got.ReplicaChanges)
+	require.Equal(t, []ObjectRef{{Kind: "Service", Name: "web-v2", Namespace: "default", UID: "web-v2"}}, got.AddedServices)
+	require.Equal(t, []ObjectRef{{Kind: "Service", Name: "web", Namespace: "default", UID: "web"}}, got.RemovedServices)
+	require.Empty(t, got.AddedIngresses)
+	require.Empty(t, got.RemovedIngresses)
+}
+
+func TestDetectChangesContainers(t *testing.T) {
+	previous := &ReportSnapshot{Controllers: []SnapshotController{{
+		ObjectRef:  ObjectRef{Kind: "Deployment", Name: "web", Namespace: "default"},
+		Containers: []SnapshotContainer{{Name: "web", Image: "web:1"}, {Name: "proxy", Image: "envoy:1"}},
+	}}}
+	current := &ReportSnapshot{Controllers: []SnapshotController{{
+		ObjectRef:  ObjectRef{Kind: "Deployment", Name: "web", Namespace: "default"},
+		Containers: []SnapshotContainer{{Name: "web", Image: "web:1"}, {Name: "istio-proxy", Image: "istio/proxyv2:1"}},
+	}}}
+
+	got := DetectChanges(previous, current)
+	require.Equal(t, []ContainerChange{
+		{Kind: "Deployment", Name: "web", Namespace: "default", Container: "istio-proxy", Field: "Image", New: "istio/proxyv2:1"},
+		{Kind: "Deployment", Name: "web", Namespace: "default", Container: "proxy", Field: "Image", Old: "envoy:1"},
+	}, got.ImageChanges)
+	require.Empty(t, got.AddedControllers)
+	require.Empty(t, got.ResourceChanges)
+}
+
+func TestSnapshotStores(t *testing.T) {
+	ctx := context.Background()
+	snapshot := NewReportSnapshot(changesTestReport(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC), "web:1.0", "100m", 2, true, "web"))
+
+	stores := map[string]SnapshotStore{
+		"file":      NewFileSnapshotStore(filepath.Join(t.TempDir(), "snapshot.json.gz")),
+		"configmap": NewConfigMapSnapshotStore(fake.NewSimpleClientset(), "insights-agent", "insights-agent-workloads-state"),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			got, err := store.Load(ctx)
+			require.NoError(t, err)
+			require.Nil(t, got)
+
+			require.NoError(t, store.Save(ctx, snapshot))
+			got, err = store.Load(ctx)
+			require.NoError(t, err)
+			require.Equal(t, snapshot, got)
+
+			// second save updates in place
+			snapshot.Controllers[0].PodCount = 5
+			require.NoError(t, store.Save(ctx, snapshot))
+			got, err = store.Load(ctx)
+			require.NoError(t, err)
+			require.Equal(t, float64(5), got.Controllers[0].PodCount)
+		})
+	}
+}
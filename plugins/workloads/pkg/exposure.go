@@ -0,0 +1,310 @@
+package workloads
+
+import (
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ExposureRoute is one way traffic reaches a controller: an Ingress or HTTPRoute
+// backed by one of its Services, or a LoadBalancer / NodePort / external-IP Service.
+type ExposureRoute struct {
+	Kind             string // Ingress, HTTPRoute or Service
+	Name             string
+	Namespace        string
+	Service          string             // backing Service name
+	ServiceNamespace string             `json:",omitempty"` // set when a cross-namespace backendRef differs from Namespace
+	ServicePort      string             `json:",omitempty"`
+	ServiceType      string             `json:",omitempty"`
+	Hostnames        []string           `json:",omitempty"`
+	Paths            []string           `json:",omitempty"`
+	Addresses        []string           `json:",omitempty"` // load-balancer / Gateway / external IPs and hostnames
+	NodePorts        []int32            `json:",omitempty"`
+	Gateways         []GatewayObjectRef `json:",omitempty"` // HTTPRoute parent Gateways
+}
+
+// ControllerExposure lists the hostnames, paths and external addresses that reach a controller.
+type ControllerExposure struct {
+	ControllerUID     string
+	Kind              string
+	Name              string
+	Namespace         string
+	Services          []string `json:",omitempty"`
+	Hostnames         []string `json:",omitempty"`
+	Paths             []string `json:",omitempty"`
+	ExternalAddresses []string `json:",omitempty"`
+	LoadBalancer      bool     // reached through a LoadBalancer Service
+	NodePort          bool     // reached through a NodePort (or LoadBalancer) node port
+	Routes            []ExposureRoute
+}
+
+// buildExposure resolves Ingress backends and HTTPRoute backendRefs to Services and
+// Services to controllers by matching selectors against pod template labels. Only
+// controllers with at least one route are returned, sorted by namespace, kind and name.
+func buildExposure(controllers []ControllerResult, services []Service, ingresses []Ingress, gatewayAPI *GatewayAPI) []ControllerExposure {
+	servicesByName := map[string]Service{}
+	for _, svc := range services {
+		servicesByName[svc.Namespace+"/"+svc.Name] = svc
+	}
+
+	var routes []ExposureRoute
+	for _, ingress := range ingresses {
+		routes = append(routes, ingressExposureRoutes(ingress)...)
+	}
+	if gatewayAPI != nil {
+		gateways := map[string]Gateway{}
+		for _, gw := range gatewayAPI.Gateways {
+			gateways[gw.Namespace+"/"+gw.Name] = gw
+		}
+		for _, route := range gatewayAPI.HTTPRoutes {
+			routes = append(routes, httpRouteExposureRoutes(route, gateways)...)
+		}
+	}
+	for _, svc := range services {
+		if route, ok := serviceExposureRoute(svc); ok {
+			routes = append(routes, route)
+		}
+	}
+
+	byController := map[string]*ControllerExposure{}
+	for _, route := range routes {
+		serviceNamespace := route.ServiceNamespace
+		if serviceNamespace == "" {
+			serviceNamespace = route.Namespace
+		}
+		svc, ok := servicesByName[serviceNamespace+"/"+route.Service]
+		if !ok {
+			continue
+		}
+		if route.ServiceType == "" {
+			route.ServiceType = svc.Type
+		}
+		for i := range controllers {
+			c := &controllers[i]
+			if !serviceSelectsController(svc, c) {
+				continue
+			}
+			exposure, ok := byController[c.UID]
+			if !ok {
+				exposure = &ControllerExposure{
+					ControllerUID: c.UID,
+					Kind:          c.Kind,
+					Name:          c.Name,
+					Namespace:     c.Namespace,
+				}
+				byController[c.UID] = exposure
+			}
+			exposure.add(route)
+		}
+	}
+
+	out := make([]ControllerExposure, 0, len(byController))
+	for _, exposure := range byController {
+		exposure.Services = sortedUnique(exposure.Services)
+		exposure.Hostnames = sortedUnique(exposure.Hostnames)
+		exposure.Paths = sortedUnique(exposure.Paths)
+		exposure.ExternalAddresses = sortedUnique(exposure.ExternalAddresses)
+		out = append(out, *exposure)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (e *ControllerExposure) add(route ExposureRoute) {
+	e.Routes = append(e.Routes, route)
+	e.Services = append(e.Services, route.Service)
+	e.Hostnames = append(e.Hostnames, route.Hostnames...)
+	e.Paths = append(e.Paths, route.Paths...)
+	e.ExternalAddresses = append(e.ExternalAddresses, route.Addresses...)
+	if route.ServiceType == string(corev1.ServiceTypeLoadBalancer) {
+		e.LoadBalancer = true
+	}
+	if len(route.NodePorts) > 0 {
+		e.NodePort = true
+	}
+}
+
+// serviceSelectsController reports whether a Service selector matches the controller's
+// pod template labels. Services without a selector (manual Endpoints) match nothing.
+func serviceSelectsController(svc Service, c *ControllerResult) bool {
+	if svc.Namespace != c.Namespace || len(svc.Selector) == 0 || len(c.PodLabels) == 0 {
+		return false
+	}
+	for key, value := range svc.Selector {
+		if podValue, ok := c.PodLabels[key]; !ok || podValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+func ingressExposureRoutes(ingress Ingress) []ExposureRoute {
+	var addresses []string
+	for _, lb := range ingress.LoadBalancer {
+		addresses = appendLoadBalancerAddress(addresses, lb)
+	}
+	newRoute := func(backend IngressBackendSummary) ExposureRoute {
+		return ExposureRoute{
+			Kind:        KindIngress,
+			Name:        ingress.Name,
+			Namespace:   ingress.Namespace,
+			Service:     backend.ServiceName,
+			ServicePort: backend.ServicePort,
+			Addresses:   addresses,
+		}
+	}
+
+	var routes []ExposureRoute
+	for _, rule := range ingress.Rules {
+		for _, path := range rule.Paths {
+			if path.Backend.ServiceName == "" {
+				continue
+			}
+			route := newRoute(path.Backend)
+			if rule.Host != "" {
+				route.Hostnames = []string{rule.Host}
+			}
+			if path.Path != "" {
+				route.Paths = []string{path.Path}
+			}
+			routes = append(routes, route)
+		}
+	}
+	if ingress.DefaultBackend != nil && ingress.DefaultBackend.ServiceName != "" {
+		routes = append(routes, newRoute(*ingress.DefaultBackend))
+	}
+	return routes
+}
+
+func httpRouteExposureRoutes(route HTTPRoute, gateways map[string]Gateway) []ExposureRoute {
+	var parents []GatewayObjectRef
+	var addresses []string
+	var listenerHostnames []string
+	for _, parent := range route.ParentRefs {
+		if parent.Kind != "" && parent.Kind != KindGateway {
+			continue
+		}
+		namespace := parent.Namespace
+		if namespace == "" {
+			namespace = route.Namespace
+		}
+		parents = append(parents, GatewayObjectRef{Kind: KindGateway, Name: parent.Name, Namespace: namespace, SectionName: parent.SectionName})
+		gw, ok := gateways[namespace+"/"+parent.Name]
+		if !ok {
+			continue
+		}
+		for _, address := range gw.Addresses {
+			if address.Value != "" {
+				addresses = append(addresses, address.Value)
+			}
+		}
+		for _, listener := range gw.Listeners {
+			if listener.Hostname != "" && (parent.SectionName == "" || parent.SectionName == listener.Name) {
+				listenerHostnames = append(listenerHostnames, listener.Hostname)
+			}
+		}
+	}
+	hostnames := route.Hostnames
+	if len(hostnames) == 0 {
+		hostnames = sortedUnique(listenerHostnames)
+	}
+
+	var routes []ExposureRoute
+	for _, rule := range route.Rules {
+		var paths []string
+		for _, match := range rule.Matches {
+			if match.Path != "" {
+				paths = append(paths, match.Path)
+			}
+		}
+		for _, backend := range rule.BackendRefs {
+			if (backend.Group != "" && backend.Group != "core") || (backend.Kind != "" && backend.Kind != KindService) {
+				continue
+			}
+			exposureRoute := ExposureRoute{
+				Kind:      KindHTTPRoute,
+				Name:      route.Name,
+				Namespace: route.Namespace,
+				Service:   backend.Name,
+				Hostnames: hostnames,
+				Paths:     paths,
+				Addresses: addresses,
+				Gateways:  parents,
+			}
+			if backend.Namespace != "" && backend.Namespace != route.Namespace {
+				exposureRoute.ServiceNamespace = backend.Namespace
+			}
+			if backend.Port != nil {
+				exposureRoute.ServicePort = strconv.FormatInt(int64(*backend.Port), 10)
+			}
+			routes = append(routes, exposureRoute)
+		}
+	}
+	return routes
+}
+
+// serviceExposureRoute returns a route for Services reachable from outside the cluster
+// on their own: LoadBalancer and NodePort Services, and Services with external IPs.
+func serviceExposureRoute(svc Service) (ExposureRoute, bool) {
+	isLoadBalancer := svc.Type == string(corev1.ServiceTypeLoadBalancer)
+	isNodePort := svc.Type == string(corev1.ServiceTypeNodePort)
+	if !isLoadBalancer && !isNodePort && len(svc.ExternalIPs) == 0 {
+		return ExposureRoute{}, false
+	}
+	route := ExposureRoute{
+		Kind:        KindService,
+		Name:        svc.Name,
+		Namespace:   svc.Namespace,
+		Service:     svc.Name,
+		ServiceType: svc.Type,
+	}
+	route.Addresses = append(route.Addresses, svc.ExternalIPs...)
+	for _, lb := range svc.LoadBalancer {
+		route.Addresses = appendLoadBalancerAddress(route.Addresses, lb)
+	}
+	for _, port := range svc.Ports {
+		if port.NodePort != 0 {
+			route.NodePorts = append(route.NodePorts, port.NodePort)
+		}
+	}
+	return route, true
+}
+
+func appendLoadBalancerAddress(addresses []string, lb IngressLoadBalancerEntry) []string {
+	if lb.IP != "" {
+		addresses = append(addresses, lb.IP)
+	}
+	if lb.Hostname != "" {
+		addresses = append(addresses, lb.Hostname)
+	}
+	return addresses
+}
+
+func sortedUnique(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,113 @@
+package workloads
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func exposureTestControllers() []ControllerResult {
+	return []ControllerResult{
+		{Kind: "Deployment", Name: "web", Namespace: "default", UID: "web-1", PodLabels: map[string]string{"app": "web", "tier": "frontend"}},
+		{Kind: "Deployment", Name: "api", Namespace: "default", UID: "api-1", PodLabels: map[string]string{"app": "api"}},
+		{Kind: "StatefulSet", Name: "db", Namespace: "default", UID: "db-1", PodLabels: map[string]string{"app": "db"}},
+		{Kind: "Deployment", Name: "web", Namespace: "other", UID: "web-2", PodLabels: map[string]string{"app": "web"}},
+	}
+}
+
+func TestBuildExposureIngress(t *testing.T) {
+	services := []Service{
+		{Name: "web", Namespace: "default", Type: "ClusterIP", Selector: map[string]string{"app": "web"}},
+		{Name: "db", Namespace: "default", Type: "ClusterIP", Selector: map[string]string{"app": "db"}},
+	}
+	ingresses := []Ingress{{
+		Name:      "web",
+		Namespace: "default",
+		Rules: []IngressRuleSummary{{
+			Host: "www.example.com",
+			Paths: []IngressPathSummary{
+				{Path: "/", Backend: IngressBackendSummary{ServiceName: "web", ServicePort: "80"}},
+				{Path: "/static", Backend: IngressBackendSummary{ServiceName: "web", ServicePort: "80"}},
+				{Path: "/bucket", Backend: IngressBackendSummary{Kind: "StorageBucket", Name: "assets"}},
+			},
+		}},
+		LoadBalancer: []IngressLoadBalancerEntry{{IP: "203.0.113.5"}, {Hostname: "lb.example.net"}},
+	}}
+
+	got := buildExposure(exposureTestControllers(), services, ingresses, nil)
+	require.Len(t, got, 1)
+	require.Equal(t, "web-1", got[0].ControllerUID)
+	require.Equal(t, []string{"web"}, got[0].Services)
+	require.Equal(t, []string{"www.example.com"}, got[0].Hostnames)
+	require.Equal(t, []string{"/", "/static"}, got[0].Paths)
+	require.Equal(t, []string{"203.0.113.5", "lb.example.net"}, got[0].ExternalAddresses)
+	require.False(t, got[0].LoadBalancer)
+	require.Len(t, got[0].Routes, 2)
+	require.Equal(t, KindIngress, got[0].Routes[0].Kind)
+	require.Equal(t, "ClusterIP", got[0].Routes[0].ServiceType)
+}
+
+func TestBuildExposureHTTPRoute(t *testing.T) {
+	port := int32(8080)
+	services := []Service{
+		{Name: "api", Namespace: "default", Type: "ClusterIP", Selector: map[string]string{"app": "api"}},
+	}
+	gatewayAPI := &GatewayAPI{
+		Gateways: []Gateway{{
+			Name:      "public",
+			Namespace: "infra",
+			Listeners: []GatewayListener{
+				{Name: "https", Hostname: "*.example.com"},
+				{Name: "internal", Hostname: "internal.example.com"},
+			},
+			Addresses: []GatewayAddress{{Type: "IPAddress", Value: "198.51.100.7"}},
+		}},
+		HTTPRoutes: []HTTPRoute{{
+			Name:       "api",
+			Namespace:  "default",
+			ParentRefs: []GatewayObjectRef{{Name: "public", Namespace: "infra", SectionName: "https"}},
+			Rules: []HTTPRouteRule{{
+				Matches:     []HTTPRouteMatch{{PathType: "PathPrefix", Path: "/v1"}},
+				BackendRefs: []GatewayObjectRef{{Name: "api", Port: &port}},
+			}},
+		}},
+	}
+
+	got := buildExposure(exposureTestControllers(), services, nil, gatewayAPI)
+	require.Len(t, got, 1)
+	require.Equal(t, "api-1", got[0].ControllerUID)
+	require.Equal(t, []string{"*.example.com"}, got[0].Hostnames)
+	require.Equal(t, []string{"/v1"}, got[0].Paths)
+	require.Equal(t, []string{"198.51.100.7"}, got[0].ExternalAddresses)
+	require.Len(t, got[0].Routes, 1)
+	require.Equal(t, KindHTTPRoute, got[0].Routes[0].Kind)
+	require.Equal(t, "8080", got[0].Routes[0].ServicePort)
+	require.Equal(t, []GatewayObjectRef{{Kind: KindGateway, Name: "public", Namespace: "infra", SectionName: "https"}}, got[0].Routes[0].Gateways)
+}
+
+func TestBuildExposureServiceTypes(t *testing.T) {
+	services := []Service{
+		{
+			Name:         "web",
+			Namespace:    "default",
+			Type:         "LoadBalancer",
+			Selector:     map[string]string{"app": "web"},
+			Ports:        []ServicePortSummary{{Port: 443, NodePort: 31443}},
+			LoadBalancer: []IngressLoadBalancerEntry{{Hostname: "abc.elb.amazonaws.com"}},
+		},
+		{Name: "db", Namespace: "default", Type: "NodePort", Selector: map[string]string{"app": "db"}, Ports: []ServicePortSummary{{Port: 5432, NodePort: 30432}}},
+		{Name: "manual", Namespace: "default", Type: "LoadBalancer"},
+		{Name: "internal", Namespace: "default", Type: "ClusterIP", Selector: map[string]string{"app": "api"}},
+	}
+
+	got := buildExposure(exposureTestControllers(), services, nil, nil)
+	require.Len(t, got, 2)
+	require.Equal(t, "db-1", got[1].ControllerUID)
+	require.True(t, got[1].NodePort)
+	require.False(t, got[1].LoadBalancer)
+	require.Equal(t, []int32{30432}, got[1].Routes[0].NodePorts)
+	require.Equal(t, "web-1", got[0].ControllerUID)
+	require.True(t, got[0].LoadBalancer)
+	require.True(t, got[0].NodePort)
+	require.Equal(t, []string{"abc.elb.amazonaws.com"}, got[0].ExternalAddresses)
+}
@@ -243,6 +243,7 @@ type NodeSummary struct {
 	Addresses          []NodeAddressSummary `json:",omitempty"`
 	ProviderID         string               `json:",omitempty"`
 	NodeInfo           NodeInfoSummary
+	NodeGroup          string `json:",omitempty"` // NodeGroups[].ID
 }
 
 // NamespaceCounts holds per-namespace inventory object counts.
@@ -278,6 +279,26 @@ type ClusterWorkloadReport struct {
 	// gateway.networking.k8s.io is not installed. When present, nested arrays are always
 	// emitted (including empty).
 	GatewayAPI *GatewayAPI `json:",omitempty"`
+	// HorizontalPodAutoscalers and PodDisruptionBudgets (2.17+) are linked to their target
+	// controllers by UID. Empty when the list is forbidden.
+	HorizontalPodAutoscalers []HorizontalPodAutoscaler
+	PodDisruptionBudgets     []PodDisruptionBudget
+	// VPA and KEDA are optional inventory of autoscaler CRDs (2.17+). Nil/omitted when
+	// autoscaling.k8s.io / keda.sh are not installed.
+	VPA  *VPA  `json:",omitempty"`
+	KEDA *KEDA `json:",omitempty"`
+	// Exposure (2.18+) is computed from Ingresses, HTTPRoutes and Services: one entry per
+	// controller reachable from outside the cluster.
+	Exposure []ControllerExposure
+	// NodeGroups (2.19+) groups nodes by managed node group / node pool labels, with Cluster
+	// Autoscaler min/max from the cluster-autoscaler-status ConfigMap when present.
+	NodeGroups []NodeGroup
+	// ServiceMesh is optional Istio / Linkerd inventory (2.20+). Nil/omitted when neither
+	// mesh's CRDs are installed.
+	ServiceMesh *ServiceMesh `json:",omitempty"`
+	// Changes (2.21+) lists what changed since the previous run. Nil/omitted unless a
+	// snapshot store is configured and a previous snapshot exists.
+	Changes *WorkloadChanges `json:",omitempty"`
 }
 
 func getOwnerUID(ownerReferences []metav1.OwnerReference) string {
@@ -881,6 +902,7 @@ func CreateResourceProviderFromAPI(ctx context.Context, dynamicClient dynamic.In
 		node.Utilization = utilization
 		nodesSummaries = append(nodesSummaries, node)
 	}
+	nodeGroups := buildNodeGroups(nodesSummaries, getClusterAutoscalerNodeGroups(ctx, kube))
 
 	// Namespaces
 	namespaces, err := kube.CoreV1().Namespaces().List(ctx, listOpts)
@@ -931,21 +953,37 @@ func CreateResourceProviderFromAPI(ctx context.Context, dynamicClient dynamic.In
 	karpenter := listKarpenterInventory(ctx, dynamicClient)
 	gatewayAPI := listGatewayAPIInventory(ctx, dynamicClient)
 
+	controllersByRef := newControllerIndex(interfaces)
+	hpas := listHorizontalPodAutoscalers(ctx, kube, controllersByRef)
+	vpa := listVPAInventory(ctx, dynamicClient, controllersByRef)
+	markVPAConflicts(hpas, vpa)
+	keda := listKEDAInventory(ctx, dynamicClient, controllersByRef)
+	pdbs := listPodDisruptionBudgets(ctx, kube, interfaces)
+	exposure := buildExposure(interfaces, services, ingresses, gatewayAPI)
+	serviceMesh := listServiceMeshInventory(ctx, dynamicClient, interfaces, namespaces.Items, services)
+
 	clusterWorkloadReport := ClusterWorkloadReport{
-		ServerVersion:          serverVersion.Major + "." + serverVersion.Minor,
-		SourceType:             "Cluster",
-		SourceName:             clusterName,
-		CreationTime:           time.Now(),
-		Nodes:                  nodesSummaries,
-		Namespaces:             namespaces.Items,
-		NamespaceCounts:        namespaceCounts,
-		Controllers:            interfaces,
-		Ingresses:              ingresses,
-		Services:               services,
-		PersistentVolumeClaims: pvcs,
-		Images:                 images,
-		Karpenter:              karpenter,
-		GatewayAPI:             gatewayAPI,
+		ServerVersion:            serverVersion.Major + "." + serverVersion.Minor,
+		SourceType:               "Cluster",
+		SourceName:               clusterName,
+		CreationTime:             time.Now(),
+		Nodes:                    nodesSummaries,
+		Namespaces:               namespaces.Items,
+		NamespaceCounts:          namespaceCounts,
+		Controllers:              interfaces,
+		Ingresses:                ingresses,
+		Services:                 services,
+		PersistentVolumeClaims:   pvcs,
+		Images:                   images,
+		Karpenter:                karpenter,
+		GatewayAPI:               gatewayAPI,
+		HorizontalPodAutoscalers: hpas,
+		PodDisruptionBudgets:     pdbs,
+		VPA:                      vpa,
+		KEDA:                     keda,
+		Exposure:                 exposure,
+		NodeGroups:               nodeGroups,
+		ServiceMesh:              serviceMesh,
 	}
 	return &clusterWorkloadReport, nil
 }
@@ -0,0 +1,683 @@
+package workloads
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	KindVirtualService             = "VirtualService"
+	KindDestinationRule            = "DestinationRule"
+	KindPeerAuthentication         = "PeerAuthentication"
+	KindAuthorizationPolicy        = "AuthorizationPolicy"
+	KindLinkerdServer              = "Server"
+	KindLinkerdServerAuthorization = "ServerAuthorization"
+
+	MeshIstio   = "istio"
+	MeshLinkerd = "linkerd"
+
+	DataPlaneSidecar = "sidecar"
+	DataPlaneAmbient = "ambient"
+
+	// istioRootNamespace holds mesh-wide PeerAuthentications in a default install.
+	istioRootNamespace = "istio-system"
+	istioDefaultMTLS   = "PERMISSIVE"
+)
+
+// Each mesh resource lists the served versions to try, newest first; older mesh
+// releases only serve the beta versions.
+var (
+	virtualServiceGVRs = []schema.GroupVersionResource{
+		{Group: "networking.istio.io", Version: "v1", Resource: "virtualservices"},
+		{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"},
+	}
+	destinationRuleGVRs = []schema.GroupVersionResource{
+		{Group: "networking.istio.io", Version: "v1", Resource: "destinationrules"},
+		{Group: "networking.istio.io", Version: "v1beta1", Resource: "destinationrules"},
+	}
+	peerAuthenticationGVRs = []schema.GroupVersionResource{
+		{Group: "security.istio.io", Version: "v1", Resource: "peerauthentications"},
+		{Group: "security.istio.io", Version: "v1beta1", Resource: "peerauthentications"},
+	}
+	authorizationPolicyGVRs = []schema.GroupVersionResource{
+		{Group: "security.istio.io", Version: "v1", Resource: "authorizationpolicies"},
+		{Group: "security.istio.io", Version: "v1beta1", Resource: "authorizationpolicies"},
+	}
+	linkerdServerGVRs = []schema.GroupVersionResource{
+		{Group: "policy.linkerd.io", Version: "v1beta3", Resource: "servers"},
+		{Group: "policy.linkerd.io", Version: "v1beta1", Resource: "servers"},
+	}
+	linkerdServerAuthorizationGVRs = []schema.GroupVersionResource{
+		{Group: "policy.linkerd.io", Version: "v1beta1", Resource: "serverauthorizations"},
+	}
+	linkerdHTTPRouteGVRs = []schema.GroupVersionResource{
+		{Group: "policy.linkerd.io", Version: "v1beta3", Resource: "httproutes"},
+		{Group: "policy.linkerd.io", Version: "v1beta1", Resource: "httproutes"},
+	}
+)
+
+// IstioDestination is a VirtualService route destination.
+type IstioDestination struct {
+	Host   string `json:",omitempty"`
+	Subset string `json:",omitempty"`
+	Port   *int32 `json:",omitempty"`
+	Weight *int32 `json:",omitempty"`
+}
+
+// IstioVirtualService is an Istio VirtualService inventory object (namespaced).
+type IstioVirtualService struct {
+	Kind         string
+	Name         string
+	Namespace    string
+	Annotations  map[string]string
+	Labels       map[string]string
+	UID          string
+	APIVersion   string
+	Hosts        []string           `json:",omitempty"`
+	Gateways     []string           `json:",omitempty"`
+	ExportTo     []string           `json:",omitempty"`
+	Destinations []IstioDestination `json:",omitempty"` // http, tls and tcp route destinations
+}
+
+// IstioDestinationRule is an Istio DestinationRule inventory object (namespaced).
+type IstioDestinationRule struct {
+	Kind        string
+	Name        string
+	Namespace   string
+	Annotations map[string]string
+	Labels      map[string]string
+	UID         string
+	APIVersion  string
+	Host        string   `json:",omitempty"`
+	TLSMode     string   `json:",omitempty"` // trafficPolicy.tls.mode
+	Subsets     []string `json:",omitempty"`
+	ExportTo    []string `json:",omitempty"`
+}
+
+// IstioPeerAuthentication is an Istio PeerAuthentication inventory object (namespaced).
+type IstioPeerAuthentication struct {
+	Kind          string
+	Name          string
+	Namespace     string
+	Annotations   map[string]string
+	Labels        map[string]string
+	UID           string
+	APIVersion    string
+	Selector      map[string]string `json:",omitempty"`
+	MTLSMode      string            `json:",omitempty"`
+	PortLevelMTLS map[string]string `json:",omitempty"`
+}
+
+// IstioAuthorizationPolicy is an Istio AuthorizationPolicy inventory object (namespaced).
+type IstioAuthorizationPolicy struct {
+	Kind        string
+	Name        string
+	Namespace   string
+	Annotations map[string]string
+	Labels      map[string]string
+	UID         string
+	APIVersion  string
+	Selector    map[string]string `json:",omitempty"`
+	Action      string
+	RuleCount   int
+}
+
+// Istio is optional Istio inventory nested under ServiceMesh.
+type Istio struct {
+	VirtualServices       []IstioVirtualService
+	DestinationRules      []IstioDestinationRule
+	PeerAuthentications   []IstioPeerAuthentication
+	AuthorizationPolicies []IstioAuthorizationPolicy
+}
+
+// LinkerdServer is a Linkerd policy Server inventory object (namespaced).
+type LinkerdServer struct {
+	Kind          string
+	Name          string
+	Namespace     string
+	Annotations   map[string]string
+	Labels        map[string]string
+	UID           string
+	APIVersion    string
+	PodSelector   map[string]string `json:",omitempty"`
+	Port          string            `json:",omitempty"`
+	ProxyProtocol string            `json:",omitempty"`
+	AccessPolicy  string            `json:",omitempty"`
+}
+
+// LinkerdServerAuthorization is a Linkerd ServerAuthorization inventory object (namespaced).
+type LinkerdServerAuthorization struct {
+	Kind            string
+	Name            string
+	Namespace       string
+	Annotations     map[string]string
+	Labels          map[string]string
+	UID             string
+	APIVersion      string
+	Server          string            `json:",omitempty"`
+	ServerSelector  map[string]string `json:",omitempty"`
+	Unauthenticated bool
+	Identities      []string `json:",omitempty"` // meshTLS identities and service account names
+}
+
+// Linkerd is optional Linkerd policy inventory nested under ServiceMesh.
+type Linkerd struct {
+	Servers              []LinkerdServer
+	ServerAuthorizations []LinkerdServerAuthorization
+	HTTPRoutes           []HTTPRoute // policy.linkerd.io HTTPRoutes
+}
+
+// MeshWorkload is the mesh membership, mTLS mode and routing objects of a controller.
+type MeshWorkload struct {
+	ControllerUID         string
+	Kind                  string
+	Name                  string
+	Namespace             string
+	Mesh                  string   // istio or linkerd
+	DataPlaneMode         string   // sidecar or ambient
+	MTLSMode              string   `json:",omitempty"` // Istio: effective PeerAuthentication mode
+	PeerAuthentication    string   `json:",omitempty"` // namespace/name of the PeerAuthentication that sets MTLSMode
+	DefaultInboundPolicy  string   `json:",omitempty"` // Linkerd: config.linkerd.io/default-inbound-policy
+	VirtualServices       []string `json:",omitempty"`
+	DestinationRules      []string `json:",omitempty"`
+	AuthorizationPolicies []string `json:",omitempty"`
+	Servers               []string `json:",omitempty"`
+	HTTPRoutes            []string `json:",omitempty"`
+}
+
+// ServiceMesh is optional service mesh inventory nested under ClusterWorkloadReport.
+// Omitted (nil) when neither Istio nor Linkerd CRDs are installed.
+type ServiceMesh struct {
+	Istio     *Istio   `json:",omitempty"`
+	Linkerd   *Linkerd `json:",omitempty"`
+	Workloads []MeshWorkload
+}
+
+// listServiceMeshInventory returns nil when neither Istio nor Linkerd CRDs are installed.
+// Forbidden lists are treated as present-but-unreadable (warn + empty array).
+func listServiceMeshInventory(ctx context.Context, dynamicClient dynamic.Interface, controllers []ControllerResult, namespaces []corev1.Namespace, services []Service) *ServiceMesh {
+	istio := listIstioInventory(ctx, dynamicClient)
+	linkerd := listLinkerdInventory(ctx, dynamicClient)
+	if istio == nil && linkerd == nil {
+		return nil
+	}
+	return &ServiceMesh{
+		Istio:     istio,
+		Linkerd:   linkerd,
+		Workloads: buildMeshWorkloads(controllers, namespaces, services, istio, linkerd),
+	}
+}
+
+func isMeshCRDAbsent(err error) bool {
+	return apierrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+// listMeshResource lists the first served version of a mesh resource.
+func listMeshResource(ctx context.Context, dynamicClient dynamic.Interface, gvrs []schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	var err error
+	for _, gvr := range gvrs {
+		var items []unstructured.Unstructured
+		items, err = listNamespacedUnstructured(ctx, dynamicClient, gvr)
+		if err == nil || !isMeshCRDAbsent(err) {
+			return items, err
+		}
+	}
+	return nil, err
+}
+
+func listIstioInventory(ctx context.Context, dynamicClient dynamic.Interface) *Istio {
+	virtualServices, vsErr := listMeshResource(ctx, dynamicClient, virtualServiceGVRs)
+	destinationRules, drErr := listMeshResource(ctx, dynamicClient, destinationRuleGVRs)
+	peerAuthentications, paErr := listMeshResource(ctx, dynamicClient, peerAuthenticationGVRs)
+	authorizationPolicies, apErr := listMeshResource(ctx, dynamicClient, authorizationPolicyGVRs)
+
+	if vsErr != nil && drErr != nil && paErr != nil && apErr != nil &&
+		isMeshCRDAbsent(vsErr) && isMeshCRDAbsent(drErr) && isMeshCRDAbsent(paErr) && isMeshCRDAbsent(apErr) {
+		return nil
+	}
+
+	istio := &Istio{
+		VirtualServices:       []IstioVirtualService{},
+		DestinationRules:      []IstioDestinationRule{},
+		PeerAuthentications:   []IstioPeerAuthentication{},
+		AuthorizationPolicies: []IstioAuthorizationPolicy{},
+	}
+	if vsErr != nil {
+		logrus.Warnf("error listing VirtualServices, continuing with empty VirtualServices: %v", vsErr)
+	}
+	for _, item := range virtualServices {
+		istio.VirtualServices = append(istio.VirtualServices, formatIstioVirtualService(item))
+	}
+	if drErr != nil {
+		logrus.Warnf("error listing DestinationRules, continuing with empty DestinationRules: %v", drErr)
+	}
+	for _, item := range destinationRules {
+		istio.DestinationRules = append(istio.DestinationRules, formatIstioDestinationRule(item))
+	}
+	if paErr != nil {
+		logrus.Warnf("error listing PeerAuthentications, continuing with empty PeerAuthentications: %v", paErr)
+	}
+	for _, item := range peerAuthentications {
+		istio.PeerAuthentications = append(istio.PeerAuthentications, formatIstioPeerAuthentication(item))
+	}
+	if apErr != nil {
+		logrus.Warnf("error listing AuthorizationPolicies, continuing with empty AuthorizationPolicies: %v", apErr)
+	}
+	for _, item := range authorizationPolicies {
+		istio.AuthorizationPolicies = append(istio.AuthorizationPolicies, formatIstioAuthorizationPolicy(item))
+	}
+	return istio
+}
+
+func listLinkerdInventory(ctx context.Context, dynamicClient dynamic.Interface) *Linkerd {
+	servers, serversErr := listMeshResource(ctx, dynamicClient, linkerdServerGVRs)
+	authorizations, authzErr := listMeshResource(ctx, dynamicClient, linkerdServerAuthorizationGVRs)
+	routes, routesErr := listMeshResource(ctx, dynamicClient, linkerdHTTPRouteGVRs)
+
+	if serversErr != nil && authzErr != nil && routesErr != nil &&
+		isMeshCRDAbsent(serversErr) && isMeshCRDAbsent(authzErr) && isMeshCRDAbsent(routesErr) {
+		return nil
+	}
+
+	linkerd := &Linkerd{
+		Servers:              []LinkerdServer{},
+		ServerAuthorizations: []LinkerdServerAuthorization{},
+		HTTPRoutes:           []HTTPRoute{},
+	}
+	if serversErr != nil {
+		logrus.Warnf("error listing Linkerd Servers, continuing with empty Servers: %v", serversErr)
+	}
+	for _, item := range servers {
+		linkerd.Servers = append(linkerd.Servers, formatLinkerdServer(item))
+	}
+	if authzErr != nil {
+		logrus.Warnf("error listing Linkerd ServerAuthorizations, continuing with empty ServerAuthorizations: %v", authzErr)
+	}
+	for _, item := range authorizations {
+		linkerd.ServerAuthorizations = append(linkerd.ServerAuthorizations, formatLinkerdServerAuthorization(item))
+	}
+	if routesErr != nil {
+		logrus.Warnf("error listing Linkerd HTTPRoutes, continuing with empty HTTPRoutes: %v", routesErr)
+	}
+	for _, item := range routes {
+		linkerd.HTTPRoutes = append(linkerd.HTTPRoutes, formatHTTPRoute(item))
+	}
+	return linkerd
+}
+
+func formatIstioVirtualService(item unstructured.Unstructured) IstioVirtualService {
+	hosts, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "hosts")
+	gateways, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "gateways")
+	exportTo, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "exportTo")
+	var destinations []IstioDestination
+	for _, routeType := range []string{"http", "tls", "tcp"} {
+		for _, route := range nestedSlice(item.Object, "spec", routeType) {
+			m, ok := route.(map[string]any)
+			if !ok {
+				continue
+			}
+			routes, _ := m["route"].([]any)
+			for _, r := range routes {
+				rm, ok := r.(map[string]any)
+				if !ok {
+					continue
+				}
+				dest, _ := rm["destination"].(map[string]any)
+				d := IstioDestination{
+					Host:   asString(dest["host"]),
+					Subset: asString(dest["subset"]),
+					Weight: asInt32Ptr(rm["weight"]),
+				}
+				if port, ok := dest["port"].(map[string]any); ok {
+					d.Port = asInt32Ptr(port["number"])
+				}
+				if d.Host == "" {
+					continue
+				}
+				destinations = append(destinations, d)
+			}
+		}
+	}
+	return IstioVirtualService{
+		Kind:         KindVirtualService,
+		Name:         item.GetName(),
+		Namespace:    item.GetNamespace(),
+		Annotations:  item.GetAnnotations(),
+		Labels:       item.GetLabels(),
+		UID:          string(item.GetUID()),
+		APIVersion:   item.GetAPIVersion(),
+		Hosts:        emptyToNil(hosts),
+		Gateways:     emptyToNil(gateways),
+		ExportTo:     emptyToNil(exportTo),
+		Destinations: destinations,
+	}
+}
+
+func formatIstioDestinationRule(item unstructured.Unstructured) IstioDestinationRule {
+	exportTo, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "exportTo")
+	var subsets []string
+	for _, subset := range nestedSlice(item.Object, "spec", "subsets") {
+		if m, ok := subset.(map[string]any); ok && asString(m["name"]) != "" {
+			subsets = append(subsets, asString(m["name"]))
+		}
+	}
+	return IstioDestinationRule{
+		Kind:        KindDestinationRule,
+		Name:        item.GetName(),
+		Namespace:   item.GetNamespace(),
+		Annotations: item.GetAnnotations(),
+		Labels:      item.GetLabels(),
+		UID:         string(item.GetUID()),
+		APIVersion:  item.GetAPIVersion(),
+		Host:        nestedString(item.Object, "spec", "host"),
+		TLSMode:     nestedString(item.Object, "spec", "trafficPolicy", "tls", "mode"),
+		Subsets:     subsets,
+		ExportTo:    emptyToNil(exportTo),
+	}
+}
+
+func formatIstioPeerAuthentication(item unstructured.Unstructured) IstioPeerAuthentication {
+	var portLevel map[string]string
+	if raw, ok, _ := unstructured.NestedMap(item.Object, "spec", "portLevelMtls"); ok && len(raw) > 0 {
+		portLevel = map[string]string{}
+		for port, v := range raw {
+			if m, ok := v.(map[string]any); ok {
+				portLevel[port] = asString(m["mode"])
+			}
+		}
+	}
+	return IstioPeerAuthentication{
+		Kind:          KindPeerAuthentication,
+		Name:          item.GetName(),
+		Namespace:     item.GetNamespace(),
+		Annotations:   item.GetAnnotations(),
+		Labels:        item.GetLabels(),
+		UID:           string(item.GetUID()),
+		APIVersion:    item.GetAPIVersion(),
+		Selector:      nestedStringMapTolerant(item.Object, "spec", "selector", "matchLabels"),
+		MTLSMode:      nestedString(item.Object, "spec", "mtls", "mode"),
+		PortLevelMTLS: portLevel,
+	}
+}
+
+func formatIstioAuthorizationPolicy(item unstructured.Unstructured) IstioAuthorizationPolicy {
+	action := nestedString(item.Object, "spec", "action")
+	if action == "" {
+		action = "ALLOW"
+	}
+	return IstioAuthorizationPolicy{
+		Kind:        KindAuthorizationPolicy,
+		Name:        item.GetName(),
+		Namespace:   item.GetNamespace(),
+		Annotations: item.GetAnnotations(),
+		Labels:      item.GetLabels(),
+		UID:         string(item.GetUID()),
+		APIVersion:  item.GetAPIVersion(),
+		Selector:    nestedStringMapTolerant(item.Object, "spec", "selector", "matchLabels"),
+		Action:      action,
+		RuleCount:   len(nestedSlice(item.Object, "spec", "rules")),
+	}
+}
+
+func formatLinkerdServer(item unstructured.Unstructured) LinkerdServer {
+	port, _, _ := unstructured.NestedFieldNoCopy(item.Object, "spec", "port")
+	return LinkerdServer{
+		Kind:          KindLinkerdServer,
+		Name:          item.GetName(),
+		Namespace:     item.GetNamespace(),
+		Annotations:   item.GetAnnotations(),
+		Labels:        item.GetLabels(),
+		UID:           string(item.GetUID()),
+		APIVersion:    item.GetAPIVersion(),
+		PodSelector:   nestedStringMapTolerant(item.Object, "spec", "podSelector", "matchLabels"),
+		Port:          asString(port),
+		ProxyProtocol: nestedString(item.Object, "spec", "proxyProtocol"),
+		AccessPolicy:  nestedString(item.Object, "spec", "accessPolicy"),
+	}
+}
+
+func formatLinkerdServerAuthorization(item unstructured.Unstructured) LinkerdServerAuthorization {
+	unauthenticated, _, _ := unstructured.NestedBool(item.Object, "spec", "client", "unauthenticated")
+	identities, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "client", "meshTLS", "identities")
+	for _, sa := range nestedSlice(item.Object, "spec", "client", "meshTLS", "serviceAccounts") {
+		if m, ok := sa.(map[string]any); ok && asString(m["name"]) != "" {
+			name := asString(m["name"])
+			if ns := asString(m["namespace"]); ns != "" {
+				name = ns + "/" + name
+			}
+			identities = append(identities, name)
+		}
+	}
+	return LinkerdServerAuthorization{
+		Kind:            KindLinkerdServerAuthorization,
+		Name:            item.GetName(),
+		Namespace:       item.GetNamespace(),
+		Annotations:     item.GetAnnotations(),
+		Labels:          item.GetLabels(),
+		UID:             string(item.GetUID()),
+		APIVersion:      item.GetAPIVersion(),
+		Server:          nestedString(item.Object, "spec", "server", "name"),
+		ServerSelector:  nestedStringMapTolerant(item.Object, "spec", "server", "selector", "matchLabels"),
+		Unauthenticated: unauthenticated,
+		Identities:      emptyToNil(identities),
+	}
+}
+
+// buildMeshWorkloads detects sidecar or ambient membership per controller from pod template
+// and namespace labels/annotations, then links the mesh objects that apply to it. Only
+// meshed controllers are returned.
+func buildMeshWorkloads(controllers []ControllerResult, namespaces []corev1.Namespace, services []Service, istio *Istio, linkerd *Linkerd) []MeshWorkload {
+	namespacesByName := map[string]corev1.Namespace{}
+	for _, ns := range namespaces {
+		namespacesByName[ns.Name] = ns
+	}
+
+	out := []MeshWorkload{}
+	for i := range controllers {
+		c := &controllers[i]
+		ns := namespacesByName[c.Namespace]
+		workload := MeshWorkload{ControllerUID: c.UID, Kind: c.Kind, Name: c.Name, Namespace: c.Namespace}
+		switch {
+		case istio != nil && istioSidecarInjected(c, ns):
+			workload.Mesh, workload.DataPlaneMode = MeshIstio, DataPlaneSidecar
+		case istio != nil && istioAmbientEnrolled(c, ns):
+			workload.Mesh, workload.DataPlaneMode = MeshIstio, DataPlaneAmbient
+		case linkerd != nil && linkerdInjected(c, ns):
+			workload.Mesh, workload.DataPlaneMode = MeshLinkerd, DataPlaneSidecar
+		default:
+			continue
+		}
+
+		if workload.Mesh == MeshIstio {
+			workload.MTLSMode, workload.PeerAuthentication = istioEffectiveMTLS(c, istio.PeerAuthentications)
+			for _, vs := range istio.VirtualServices {
+				for _, d := range vs.Destinations {
+					if meshHostSelectsController(d.Host, vs.Namespace, services, c) {
+						workload.VirtualServices = append(workload.VirtualServices, vs.Namespace+"/"+vs.Name)
+						break
+					}
+				}
+			}
+			for _, dr := range istio.DestinationRules {
+				if meshHostSelectsController(dr.Host, dr.Namespace, services, c) {
+					workload.DestinationRules = append(workload.DestinationRules, dr.Namespace+"/"+dr.Name)
+				}
+			}
+			for _, ap := range istio.AuthorizationPolicies {
+				if (ap.Namespace == c.Namespace || ap.Namespace == istioRootNamespace) && labelsMatch(ap.Selector, c.PodLabels) {
+					workload.AuthorizationPolicies = append(workload.AuthorizationPolicies, ap.Namespace+"/"+ap.Name)
+				}
+			}
+		} else {
+			workload.DefaultInboundPolicy = c.PodAnnotations["config.linkerd.io/default-inbound-policy"]
+			if workload.DefaultInboundPolicy == "" {
+				workload.DefaultInboundPolicy = ns.Annotations["config.linkerd.io/default-inbound-policy"]
+			}
+			servers := map[string]bool{}
+			for _, server := range linkerd.Servers {
+				if server.Namespace == c.Namespace && len(server.PodSelector) > 0 && labelsMatch(server.PodSelector, c.PodLabels) {
+					servers[server.Name] = true
+					workload.Servers = append(workload.Servers, server.Namespace+"/"+server.Name)
+				}
+			}
+			for _, route := range linkerd.HTTPRoutes {
+				for _, parent := range route.ParentRefs {
+					if linkerdParentSelectsController(parent, route.Namespace, servers, services, c) {
+						workload.HTTPRoutes = append(workload.HTTPRoutes, route.Namespace+"/"+route.Name)
+						break
+					}
+				}
+			}
+		}
+		out = append(out, workload)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// istioSidecarInjected follows the injector's precedence: the pod sidecar.istio.io/inject
+// label (or legacy annotation) wins, then the namespace istio-injection label, then an
+// istio.io/rev revision label on the pod or namespace.
+func istioSidecarInjected(c *ControllerResult, ns corev1.Namespace) bool {
+	if hasContainer(c, "istio-proxy") {
+		return true
+	}
+	inject := c.PodLabels["sidecar.istio.io/inject"]
+	if inject == "" {
+		inject = c.PodAnnotations["sidecar.istio.io/inject"]
+	}
+	switch inject {
+	case "false":
+		return false
+	case "true":
+		return true
+	}
+	switch ns.Labels["istio-injection"] {
+	case "disabled":
+		return false
+	case "enabled":
+		return true
+	}
+	return c.PodLabels["istio.io/rev"] != "" || ns.Labels["istio.io/rev"] != ""
+}
+
+func istioAmbientEnrolled(c *ControllerResult, ns corev1.Namespace) bool {
+	switch c.PodLabels["istio.io/dataplane-mode"] {
+	case "none":
+		return false
+	case DataPlaneAmbient:
+		return true
+	}
+	return ns.Labels["istio.io/dataplane-mode"] == DataPlaneAmbient
+}
+
+func linkerdInjected(c *ControllerResult, ns corev1.Namespace) bool {
+	if hasContainer(c, "linkerd-proxy") {
+		return true
+	}
+	inject := c.PodAnnotations["linkerd.io/inject"]
+	if inject == "" {
+		inject = ns.Annotations["linkerd.io/inject"]
+	}
+	return inject == "enabled" || inject == "ingress"
+}
+
+func hasContainer(c *ControllerResult, name string) bool {
+	for _, container := range c.Containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// istioEffectiveMTLS resolves PeerAuthentication precedence: workload selector, then
+// namespace-wide, then mesh-wide in the root namespace. UNSET inherits from the next level.
+func istioEffectiveMTLS(c *ControllerResult, peerAuthentications []IstioPeerAuthentication) (string, string) {
+	var workload, namespace, mesh *IstioPeerAuthentication
+	for i := range peerAuthentications {
+		pa := &peerAuthentications[i]
+		switch {
+		case pa.Namespace == c.Namespace && len(pa.Selector) > 0 && labelsMatch(pa.Selector, c.PodLabels):
+			workload = pa
+		case pa.Namespace == c.Namespace && len(pa.Selector) == 0:
+			namespace = pa
+		case pa.Namespace == istioRootNamespace && len(pa.Selector) == 0:
+			mesh = pa
+		}
+	}
+	for _, pa := range []*IstioPeerAuthentication{workload, namespace, mesh} {
+		if pa != nil && pa.MTLSMode != "" && pa.MTLSMode != "UNSET" {
+			return pa.MTLSMode, pa.Namespace + "/" + pa.Name
+		}
+	}
+	return istioDefaultMTLS, ""
+}
+
+// meshHostSelectsController resolves a mesh host (name, name.namespace or the FQDN) to a
+// Service and reports whether that Service selects the controller.
+func meshHostSelectsController(host, defaultNamespace string, services []Service, c *ControllerResult) bool {
+	parts := strings.Split(host, ".")
+	name, namespace := parts[0], defaultNamespace
+	if len(parts) > 1 {
+		namespace = parts[1]
+	}
+	if len(parts) > 2 && parts[2] != "svc" {
+		return false
+	}
+	for _, svc := range services {
+		if svc.Name == name && svc.Namespace == namespace {
+			return serviceSelectsController(svc, c)
+		}
+	}
+	return false
+}
+
+func linkerdParentSelectsController(parent GatewayObjectRef, routeNamespace string, servers map[string]bool, services []Service, c *ControllerResult) bool {
+	namespace := parent.Namespace
+	if namespace == "" {
+		namespace = routeNamespace
+	}
+	switch parent.Kind {
+	case KindLinkerdServer:
+		return namespace == c.Namespace && servers[parent.Name]
+	case KindService:
+		return meshHostSelectsController(parent.Name+"."+namespace, namespace, services, c)
+	}
+	return false
+}
+
+// labelsMatch reports whether every selector label is present in labels. An empty
+// selector matches everything.
+func labelsMatch(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func emptyToNil(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,247 @@
+package workloads
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestFormatIstioVirtualService(t *testing.T) {
+	item := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "networking.istio.io/v1",
+		"kind":       "VirtualService",
+		"metadata":   map[string]any{"name": "reviews", "namespace": "shop", "uid": "vs-uid"},
+		"spec": map[string]any{
+			"hosts":    []any{"reviews"},
+			"gateways": []any{"mesh"},
+			"http": []any{
+				map[string]any{
+					"route": []any{
+						map[string]any{
+							"destination": map[string]any{"host": "reviews", "subset": "v1", "port": map[string]any{"number": int64(9080)}},
+							"weight":      int64(90),
+						},
+						map[string]any{
+							"destination": map[string]any{"host": "reviews", "subset": "v2"},
+							"weight":      int64(10),
+						},
+					},
+				},
+			},
+		},
+	}}
+
+	got := formatIstioVirtualService(item)
+	require.Equal(t, KindVirtualService, got.Kind)
+	require.Equal(t, "networking.istio.io/v1", got.APIVersion)
+	require.Equal(t, []string{"reviews"}, got.Hosts)
+	require.Equal(t, []string{"mesh"}, got.Gateways)
+	require.Len(t, got.Destinations, 2)
+	require.Equal(t, "v1", got.Destinations[0].Subset)
+	require.Equal(t, int32(9080), *got.Destinations[0].Port)
+	require.Equal(t, int32(10), *got.Destinations[1].Weight)
+	require.Nil(t, got.Destinations[1].Port)
+}
+
+func TestFormatIstioSecurityObjects(t *testing.T) {
+	pa := formatIstioPeerAuthentication(unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "security.istio.io/v1",
+		"kind":       "PeerAuthentication",
+		"metadata":   map[string]any{"name": "web", "namespace": "shop"},
+		"spec": map[string]any{
+			"selector":      map[string]any{"matchLabels": map[string]any{"app": "web"}},
+			"mtls":          map[string]any{"mode": "STRICT"},
+			"portLevelMtls": map[string]any{"8080": map[string]any{"mode": "DISABLE"}},
+		},
+	}})
+	require.Equal(t, map[string]string{"app": "web"}, pa.Selector)
+	require.Equal(t, "STRICT", pa.MTLSMode)
+	require.Equal(t, map[string]string{"8080": "DISABLE"}, pa.PortLevelMTLS)
+
+	ap := formatIstioAuthorizationPolicy(unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "security.istio.io/v1",
+		"kind":       "AuthorizationPolicy",
+		"metadata":   map[string]any{"name": "allow-frontend", "namespace": "shop"},
+		"spec": map[string]any{
+			"rules": []any{map[string]any{}, map[string]any{}},
+		},
+	}})
+	require.Equal(t, "ALLOW", ap.Action)
+	require.Equal(t, 2, ap.RuleCount)
+	require.Nil(t, ap.Selector)
+}
+
+func TestFormatLinkerdPolicyObjects(t *testing.T) {
+	server := formatLinkerdServer(unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "policy.linkerd.io/v1beta3",
+		"kind":       "Server",
+		"metadata":   map[string]any{"name": "voting-grpc", "namespace": "emojivoto"},
+		"spec": map[string]any{
+			"podSelector":   map[string]any{"matchLabels": map[string]any{"app": "voting"}},
+			"port":          "grpc",
+			"proxyProtocol": "gRPC",
+			"accessPolicy":  "deny",
+		},
+	}})
+	require.Equal(t, KindLinkerdServer, server.Kind)
+	require.Equal(t, "grpc", server.Port)
+	require.Equal(t, "gRPC", server.ProxyProtocol)
+	require.Equal(t, "deny", server.AccessPolicy)
+
+	authz := formatLinkerdServerAuthorization(unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "policy.linkerd.io/v1beta1",
+		"kind":       "ServerAuthorization",
+		"metadata":   map[string]any{"name": "voting-grpc", "namespace": "emojivoto"},
+		"spec": map[string]any{
+			"server": map[string]any{"name": "voting-grpc"},
+			"client": map[string]any{
+				"meshTLS": map[string]any{
+					"serviceAccounts": []any{map[string]any{"name": "web"}, map[string]any{"name": "bot", "namespace": "tools"}},
+				},
+			},
+		},
+	}})
+	require.Equal(t, "voting-grpc", authz.Server)
+	require.False(t, authz.Unauthenticated)
+	require.Equal(t, []string{"web", "tools/bot"}, authz.Identities)
+}
+
+func TestBuildMeshWorkloadsIstio(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"istio-injection": "enabled"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "ledger", Labels: map[string]string{"istio.io/dataplane-mode": "ambient"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "plain"}},
+	}
+	controllers := []ControllerResult{
+		{Kind: "Deployment", Name: "web", Namespace: "shop", UID: "web-1", PodLabels: map[string]string{"app": "web"}},
+		{Kind: "Deployment", Name: "batch", Namespace: "shop", UID: "batch-1", PodLabels: map[string]string{"app": "batch", "sidecar.istio.io/inject": "false"}},
+		{Kind: "Deployment", Name: "ledger", Namespace: "ledger", UID: "ledger-1", PodLabels: map[string]string{"app": "ledger"}},
+		{Kind: "Deployment", Name: "plain", Namespace: "plain", UID: "plain-1", PodLabels: map[string]string{"app": "plain"}},
+	}
+	services := []Service{{Name: "web", Namespace: "shop", Selector: map[string]string{"app": "web"}}}
+	istio := &Istio{
+		VirtualServices: []IstioVirtualService{{Name: "web", Namespace: "shop", Destinations: []IstioDestination{{Host: "web"}}}},
+		DestinationRules: []IstioDestinationRule{
+			{Name: "web", Namespace: "shop", Host: "web.shop.svc.cluster.local"},
+			{Name: "external", Namespace: "shop", Host: "api.example.com"},
+		},
+		PeerAuthentications: []IstioPeerAuthentication{
+			{Name: "default", Namespace: istioRootNamespace, MTLSMode: "STRICT"},
+			{Name: "default", Namespace: "shop", MTLSMode: "UNSET"},
+			{Name: "ledger", Namespace: "ledger", Selector: map[string]string{"app": "ledger"}, MTLSMode: "PERMISSIVE"},
+		},
+		AuthorizationPolicies: []IstioAuthorizationPolicy{
+			{Name: "web", Namespace: "shop", Selector: map[string]string{"app": "web"}, Action: "ALLOW"},
+			{Name: "other", Namespace: "shop", Selector: map[string]string{"app": "other"}, Action: "DENY"},
+		},
+	}
+
+	got := buildMeshWorkloads(controllers, namespaces, services, istio, nil)
+	require.Len(t, got, 2)
+
+	ledger := got[0]
+	require.Equal(t, "ledger-1", ledger.ControllerUID)
+	require.Equal(t, MeshIstio, ledger.Mesh)
+	require.Equal(t, DataPlaneAmbient, ledger.DataPlaneMode)
+	require.Equal(t, "PERMISSIVE", ledger.MTLSMode)
+	require.Equal(t, "ledger/ledger", ledger.PeerAuthentication)
+
+	web := got[1]
+	require.Equal(t, "web-1", web.ControllerUID)
+	require.Equal(t, DataPlaneSidecar, web.DataPlaneMode)
+	require.Equal(t, "STRICT", web.MTLSMode)
+	require.Equal(t, "istio-system/default", web.PeerAuthentication)
+	require.Equal(t, []string{"shop/web"}, web.VirtualServices)
+	require.Equal(t, []string{"shop/web"}, web.DestinationRules)
+	require.Equal(t, []string{"shop/web"}, web.AuthorizationPolicies)
+}
+
+func TestBuildMeshWorkloadsLinkerd(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "emojivoto", Annotations: map[string]string{
+			"linkerd.io/inject":                        "enabled",
+			"config.linkerd.io/default-inbound-policy": "all-authenticated",
+		}}},
+	}
+	controllers := []ControllerResult{
+		{Kind: "Deployment", Name: "voting", Namespace: "emojivoto", UID: "voting-1", PodLabels: map[string]string{"app": "voting"}},
+		{Kind: "Deployment", Name: "vote-bot", Namespace: "emojivoto", UID: "bot-1", PodAnnotations: map[string]string{"linkerd.io/inject": "disabled"}},
+	}
+	linkerd := &Linkerd{
+		Servers: []LinkerdServer{{Name: "voting-grpc", Namespace: "emojivoto", PodSelector: map[string]string{"app": "voting"}}},
+		HTTPRoutes: []HTTPRoute{{
+			Name:       "voting-route",
+			Namespace:  "emojivoto",
+			ParentRefs: []GatewayObjectRef{{Group: "policy.linkerd.io", Kind: "Server", Name: "voting-grpc"}},
+		}},
+	}
+
+	got := buildMeshWorkloads(controllers, namespaces, nil, nil, linkerd)
+	require.Len(t, got, 1)
+	require.Equal(t, "voting-1", got[0].ControllerUID)
+	require.Equal(t, MeshLinkerd, got[0].Mesh)
+	require.Equal(t, DataPlaneSidecar, got[0].DataPlaneMode)
+	require.Equal(t, "all-authenticated", got[0].DefaultInboundPolicy)
+	require.Empty(t, got[0].MTLSMode)
+	require.Equal(t, []string{"emojivoto/voting-grpc"}, got[0].Servers)
+	require.Equal(t, []string{"emojivoto/voting-route"}, got[0].HTTPRoutes)
+}
+
+func meshListKinds() map[schema.GroupVersionResource]string {
+	kinds := map[schema.GroupVersionResource]string{}
+	for _, gvrs := range [][]schema.GroupVersionResource{
+		virtualServiceGVRs, destinationRuleGVRs, peerAuthenticationGVRs, authorizationPolicyGVRs,
+		linkerdServerGVRs, linkerdServerAuthorizationGVRs, linkerdHTTPRouteGVRs,
+	} {
+		for _, gvr := range gvrs {
+			kinds[gvr] = gvr.Resource + "List"
+		}
+	}
+	return kinds
+}
+
+func TestListServiceMeshInventory(t *testing.T) {
+	pa := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "security.istio.io/v1beta1",
+		"kind":       "PeerAuthentication",
+		"metadata":   map[string]any{"name": "default", "namespace": "istio-system"},
+		"spec":       map[string]any{"mtls": map[string]any{"mode": "STRICT"}},
+	}}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), meshListKinds(), pa)
+	// Only Istio v1beta1 is served; Linkerd is not installed.
+	client.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		gvr := action.GetResource()
+		if gvr.Group == "policy.linkerd.io" || gvr.Version == "v1" {
+			return true, nil, apierrors.NewNotFound(gvr.GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+
+	got := listServiceMeshInventory(context.Background(), client, nil, nil, nil)
+	require.NotNil(t, got)
+	require.Nil(t, got.Linkerd)
+	require.NotNil(t, got.Istio)
+	require.Len(t, got.Istio.PeerAuthentications, 1)
+	require.Equal(t, "security.istio.io/v1beta1", got.Istio.PeerAuthentications[0].APIVersion)
+	require.NotNil(t, got.Istio.VirtualServices)
+	require.Empty(t, got.Istio.VirtualServices)
+	require.NotNil(t, got.Workloads)
+}
+
+func TestListServiceMeshInventoryOmitsWhenNotInstalled(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), meshListKinds())
+	client.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+
+	require.Nil(t, listServiceMeshInventory(context.Background(), client, nil, nil, nil))
+}
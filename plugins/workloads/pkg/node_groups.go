@@ -0,0 +1,351 @@
+package workloads
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	NodeGroupProviderEKS               = "eks"
+	NodeGroupProviderGKE               = "gke"
+	NodeGroupProviderAKS               = "aks"
+	NodeGroupProviderKarpenter         = "karpenter"
+	NodeGroupProviderKops              = "kops"
+	NodeGroupProviderClusterAutoscaler = "cluster-autoscaler"
+
+	CapacityTypeSpot     = "spot"
+	CapacityTypeOnDemand = "on-demand"
+	CapacityTypeMixed    = "mixed"
+
+	clusterAutoscalerStatusNamespace = "kube-system"
+	clusterAutoscalerStatusName      = "cluster-autoscaler-status"
+)
+
+// nodeGroupLabels are the well-known labels that name a node's group, in lookup order.
+var nodeGroupLabels = []struct {
+	provider string
+	key      string
+}{
+	{NodeGroupProviderEKS, "eks.amazonaws.com/nodegroup"},
+	{NodeGroupProviderGKE, "cloud.google.com/gke-nodepool"},
+	{NodeGroupProviderAKS, "kubernetes.azure.com/agentpool"},
+	{NodeGroupProviderAKS, "agentpool"},
+	{NodeGroupProviderKarpenter, karpenterNodePoolLabelKey},
+	{NodeGroupProviderKops, "kops.k8s.io/instancegroup"},
+}
+
+var (
+	caLegacyNameRegexp   = regexp.MustCompile(`^\s*Name:\s*(\S+)`)
+	caLegacyHealthRegexp = regexp.MustCompile(`^\s*Health:\s*(\w+)`)
+	caLegacyTargetRegexp = regexp.MustCompile(`cloudProviderTarget=(\d+)`)
+	caLegacyMinRegexp    = regexp.MustCompile(`minSize=(\d+)`)
+	caLegacyMaxRegexp    = regexp.MustCompile(`maxSize=(\d+)`)
+
+	eksASGRegexp  = regexp.MustCompile(`^eks-(.+)-[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	aksVMSSRegexp = regexp.MustCompile(`^aks-(.+)-\d+-vmss$`)
+	gkeMIGRegexp  = regexp.MustCompile(`^gke-(.+)-[0-9a-f]{8}-grp$`)
+)
+
+// NodeGroupAutoscaler is Cluster Autoscaler state for a node group, summed over the
+// autoscaler groups (ASGs, MIGs, VMSSs) that back it.
+type NodeGroupAutoscaler struct {
+	NodeGroups []string // Cluster Autoscaler node group names
+	MinSize    int
+	MaxSize    int
+	TargetSize int
+	Health     string `json:",omitempty"`
+}
+
+// NodeGroup is a set of nodes sharing a managed node group / node pool label.
+type NodeGroup struct {
+	ID            string // provider/name, referenced by NodeSummary.NodeGroup
+	Name          string
+	Provider      string
+	NodeCount     int
+	NodeNames     []string             `json:",omitempty"`
+	InstanceTypes []string             `json:",omitempty"`
+	CapacityType  string               `json:",omitempty"` // spot, on-demand, reserved or mixed
+	Zones         map[string]int       `json:",omitempty"` // node count per zone
+	Autoscaler    *NodeGroupAutoscaler `json:",omitempty"`
+}
+
+// clusterAutoscalerNodeGroup is one node group from the cluster-autoscaler-status ConfigMap.
+type clusterAutoscalerNodeGroup struct {
+	Name       string
+	Health     string
+	MinSize    int
+	MaxSize    int
+	TargetSize int
+}
+
+// caStatus is the YAML status format written by Cluster Autoscaler 1.30+.
+type caStatus struct {
+	NodeGroups []struct {
+		Name   string `json:"name"`
+		Health struct {
+			Status              string `json:"status"`
+			CloudProviderTarget int    `json:"cloudProviderTarget"`
+			MinSize             int    `json:"minSize"`
+			MaxSize             int    `json:"maxSize"`
+		} `json:"health"`
+	} `json:"nodeGroups"`
+}
+
+// buildNodeGroups groups nodes by the well-known provider labels and attaches Cluster
+// Autoscaler min/max when available. It sets NodeSummary.NodeGroup on grouped nodes.
+// Autoscaler groups that match no group are reported with provider cluster-autoscaler.
+func buildNodeGroups(nodes []NodeSummary, autoscalerGroups []clusterAutoscalerNodeGroup) []NodeGroup {
+	groups := map[string]*NodeGroup{}
+	capacityTypes := map[string]map[string]bool{}
+	for i := range nodes {
+		provider, name := nodeGroupName(nodes[i].Labels)
+		if name == "" {
+			continue
+		}
+		id := provider + "/" + name
+		group, ok := groups[id]
+		if !ok {
+			group = &NodeGroup{ID: id, Name: name, Provider: provider}
+			groups[id] = group
+			capacityTypes[id] = map[string]bool{}
+		}
+		nodes[i].NodeGroup = id
+		group.NodeCount++
+		group.NodeNames = append(group.NodeNames, nodes[i].Name)
+		if instanceType := labelWithFallback(nodes[i].Labels, "node.kubernetes.io/instance-type", "beta.kubernetes.io/instance-type"); instanceType != "" {
+			group.InstanceTypes = append(group.InstanceTypes, instanceType)
+		}
+		if zone := labelWithFallback(nodes[i].Labels, "topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"); zone != "" {
+			if group.Zones == nil {
+				group.Zones = map[string]int{}
+			}
+			group.Zones[zone]++
+		}
+		if capacityType := nodeCapacityType(provider, nodes[i].Labels); capacityType != "" {
+			capacityTypes[id][capacityType] = true
+		}
+	}
+
+	for id, group := range groups {
+		sort.Strings(group.NodeNames)
+		group.InstanceTypes = sortedUnique(group.InstanceTypes)
+		switch len(capacityTypes[id]) {
+		case 0:
+		case 1:
+			for capacityType := range capacityTypes[id] {
+				group.CapacityType = capacityType
+			}
+		default:
+			group.CapacityType = CapacityTypeMixed
+		}
+	}
+
+	for _, caGroup := range autoscalerGroups {
+		group := matchAutoscalerNodeGroup(caGroup.Name, groups)
+		if group == nil {
+			id := NodeGroupProviderClusterAutoscaler + "/" + caGroup.Name
+			group = &NodeGroup{ID: id, Name: caGroup.Name, Provider: NodeGroupProviderClusterAutoscaler}
+			groups[id] = group
+		}
+		if group.Autoscaler == nil {
+			group.Autoscaler = &NodeGroupAutoscaler{}
+		}
+		group.Autoscaler.NodeGroups = append(group.Autoscaler.NodeGroups, caGroup.Name)
+		group.Autoscaler.MinSize += caGroup.MinSize
+		group.Autoscaler.MaxSize += caGroup.MaxSize
+		group.Autoscaler.TargetSize += caGroup.TargetSize
+		if group.Autoscaler.Health == "" || caGroup.Health != "Healthy" {
+			group.Autoscaler.Health = caGroup.Health
+		}
+	}
+
+	out := make([]NodeGroup, 0, len(groups))
+	for _, group := range groups {
+		out = append(out, *group)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func nodeGroupName(nodeLabels map[string]string) (string, string) {
+	for _, l := range nodeGroupLabels {
+		if name := nodeLabels[l.key]; name != "" {
+			return l.provider, name
+		}
+	}
+	return "", ""
+}
+
+func labelWithFallback(nodeLabels map[string]string, key, fallbackKey string) string {
+	if value := nodeLabels[key]; value != "" {
+		return value
+	}
+	return nodeLabels[fallbackKey]
+}
+
+// nodeCapacityType normalises provider capacity labels to spot / on-demand (Karpenter
+// values, including reserved, are kept as-is). Returns "" when unknown.
+func nodeCapacityType(provider string, nodeLabels map[string]string) string {
+	switch provider {
+	case NodeGroupProviderEKS:
+		return strings.ReplaceAll(strings.ToLower(nodeLabels["eks.amazonaws.com/capacityType"]), "_", "-")
+	case NodeGroupProviderGKE:
+		if nodeLabels["cloud.google.com/gke-spot"] == "true" || nodeLabels["cloud.google.com/gke-preemptible"] == "true" {
+			return CapacityTypeSpot
+		}
+		return CapacityTypeOnDemand
+	case NodeGroupProviderAKS:
+		if strings.EqualFold(nodeLabels["kubernetes.azure.com/scalesetpriority"], "spot") {
+			return CapacityTypeSpot
+		}
+		return CapacityTypeOnDemand
+	case NodeGroupProviderKarpenter:
+		return nodeLabels["karpenter.sh/capacity-type"]
+	}
+	return ""
+}
+
+// matchAutoscalerNodeGroup finds the labelled group backed by a Cluster Autoscaler group.
+// EKS ASGs (eks-<nodegroup>-<uuid>) and AKS VMSSs (aks-<pool>-<n>-vmss) name their group
+// exactly; when it has no nodes (e.g. scaled to zero) a node-less group is created for it.
+// GKE MIGs (gke-<cluster>-<pool>-<hash>-grp) end with the pool name. Other names fall back
+// to the longest group name appearing as a dash-delimited token. Returns nil when no group
+// matches.
+func matchAutoscalerNodeGroup(caName string, groups map[string]*NodeGroup) *NodeGroup {
+	if i := strings.LastIndex(caName, "/"); i >= 0 {
+		caName = caName[i+1:]
+	}
+	for provider, re := range map[string]*regexp.Regexp{NodeGroupProviderEKS: eksASGRegexp, NodeGroupProviderAKS: aksVMSSRegexp} {
+		if m := re.FindStringSubmatch(caName); m != nil {
+			id := provider + "/" + m[1]
+			if _, ok := groups[id]; !ok {
+				groups[id] = &NodeGroup{ID: id, Name: m[1], Provider: provider}
+			}
+			return groups[id]
+		}
+	}
+	prefix := ""
+	if m := gkeMIGRegexp.FindStringSubmatch(caName); m != nil {
+		caName = m[1]
+		prefix = NodeGroupProviderGKE + "/"
+	}
+
+	var best *NodeGroup
+	for _, group := range groups {
+		if group.Provider == NodeGroupProviderClusterAutoscaler || group.Provider == NodeGroupProviderKarpenter || !strings.HasPrefix(group.ID, prefix) {
+			continue
+		}
+		if prefix != "" && !strings.HasSuffix(caName, "-"+group.Name) {
+			continue
+		}
+		if !containsDashToken(caName, group.Name) {
+			continue
+		}
+		if best == nil || len(group.Name) > len(best.Name) || (len(group.Name) == len(best.Name) && group.ID < best.ID) {
+			best = group
+		}
+	}
+	return best
+}
+
+func containsDashToken(s, token string) bool {
+	if token == "" {
+		return false
+	}
+	for start := 0; start < len(s); {
+		i := strings.Index(s[start:], token)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(token)
+		if (i == 0 || s[i-1] == '-') && (end == len(s) || s[end] == '-') {
+			return true
+		}
+		start = i + 1
+	}
+	return false
+}
+
+// getClusterAutoscalerNodeGroups reads the cluster-autoscaler-status ConfigMap. Returns nil
+// when it does not exist; other errors are logged and treated as absent.
+func getClusterAutoscalerNodeGroups(ctx context.Context, kube kubernetes.Interface) []clusterAutoscalerNodeGroup {
+	configMap, err := kube.CoreV1().ConfigMaps(clusterAutoscalerStatusNamespace).Get(ctx, clusterAutoscalerStatusName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logrus.Warnf("error fetching %s/%s ConfigMap, continuing without Cluster Autoscaler node group sizes: %v", clusterAutoscalerStatusNamespace, clusterAutoscalerStatusName, err)
+		}
+		return nil
+	}
+	return parseClusterAutoscalerStatus(configMap.Data["status"])
+}
+
+// parseClusterAutoscalerStatus supports the YAML status (Cluster Autoscaler 1.30+) and the
+// older human-readable format.
+func parseClusterAutoscalerStatus(status string) []clusterAutoscalerNodeGroup {
+	var parsed caStatus
+	if err := yaml.Unmarshal([]byte(status), &parsed); err == nil && len(parsed.NodeGroups) > 0 {
+		out := make([]clusterAutoscalerNodeGroup, 0, len(parsed.NodeGroups))
+		for _, ng := range parsed.NodeGroups {
+			out = append(out, clusterAutoscalerNodeGroup{
+				Name:       ng.Name,
+				Health:     ng.Health.Status,
+				MinSize:    ng.Health.MinSize,
+				MaxSize:    ng.Health.MaxSize,
+				TargetSize: ng.Health.CloudProviderTarget,
+			})
+		}
+		return out
+	}
+	return parseLegacyClusterAutoscalerStatus(status)
+}
+
+func parseLegacyClusterAutoscalerStatus(status string) []clusterAutoscalerNodeGroup {
+	var out []clusterAutoscalerNodeGroup
+	inNodeGroups := false
+	scanner := bufio.NewScanner(strings.NewReader(status))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "NodeGroups:") {
+			inNodeGroups = true
+			continue
+		}
+		if !inNodeGroups {
+			continue
+		}
+		if m := caLegacyNameRegexp.FindStringSubmatch(line); m != nil {
+			out = append(out, clusterAutoscalerNodeGroup{Name: m[1]})
+			continue
+		}
+		if len(out) == 0 {
+			continue
+		}
+		if m := caLegacyHealthRegexp.FindStringSubmatch(line); m != nil {
+			current := &out[len(out)-1]
+			current.Health = m[1]
+			current.TargetSize = atoiSubmatch(caLegacyTargetRegexp, line)
+			current.MinSize = atoiSubmatch(caLegacyMinRegexp, line)
+			current.MaxSize = atoiSubmatch(caLegacyMaxRegexp, line)
+		}
+	}
+	return out
+}
+
+func atoiSubmatch(re *regexp.Regexp, s string) int {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
@@ -0,0 +1,159 @@
+package workloads
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const caStatusYAML = `time: 2026-01-01 10:00:00.000000000 +0000 UTC
+autoscalerStatus: Running
+nodeGroups:
+- name: eks-general-5ac6a4b2-1f3e-7d4c-9b1a-0e2f3a4b5c6d
+  health:
+    status: Healthy
+    nodeCounts:
+      registered:
+        total: 3
+        ready: 3
+    cloudProviderTarget: 3
+    minSize: 1
+    maxSize: 10
+  scaleUp:
+    status: NoActivity
+- name: eks-general-gpu-8d7e6f5a-4b3c-2d1e-0f9a-8b7c6d5e4f3a
+  health:
+    status: Healthy
+    cloudProviderTarget: 0
+    minSize: 0
+    maxSize: 4
+`
+
+const caStatusLegacy = `Cluster-autoscaler status at 2026-01-01 10:00:00.000000000 +0000 UTC:
+Cluster-wide:
+  Health:      Healthy (ready=5 unready=0 notStarted=0 longNotStarted=0 registered=5 longUnregistered=0)
+  ScaleUp:     NoActivity (ready=5 registered=5)
+
+NodeGroups:
+  Name:        https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a/instanceGroups/gke-prod-default-pool-1a2b3c4d-grp
+  Health:      Healthy (ready=2 unready=0 notStarted=0 longNotStarted=0 registered=2 longUnregistered=0 cloudProviderTarget=2 (minSize=1, maxSize=5))
+  ScaleUp:     NoActivity (ready=2 cloudProviderTarget=2)
+  ScaleDown:   NoCandidates (candidates=0)
+
+  Name:        https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-b/instanceGroups/gke-prod-default-pool-5e6f7a8b-grp
+  Health:      Unhealthy (ready=3 unready=1 notStarted=0 longNotStarted=0 registered=4 longUnregistered=0 cloudProviderTarget=4 (minSize=1, maxSize=5))
+`
+
+func TestParseClusterAutoscalerStatus(t *testing.T) {
+	got := parseClusterAutoscalerStatus(caStatusYAML)
+	require.Equal(t, []clusterAutoscalerNodeGroup{
+		{Name: "eks-general-5ac6a4b2-1f3e-7d4c-9b1a-0e2f3a4b5c6d", Health: "Healthy", MinSize: 1, MaxSize: 10, TargetSize: 3},
+		{Name: "eks-general-gpu-8d7e6f5a-4b3c-2d1e-0f9a-8b7c6d5e4f3a", Health: "Healthy", MinSize: 0, MaxSize: 4, TargetSize: 0},
+	}, got)
+
+	got = parseClusterAutoscalerStatus(caStatusLegacy)
+	require.Len(t, got, 2)
+	require.Equal(t, clusterAutoscalerNodeGroup{
+		Name:       "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a/instanceGroups/gke-prod-default-pool-1a2b3c4d-grp",
+		Health:     "Healthy",
+		MinSize:    1,
+		MaxSize:    5,
+		TargetSize: 2,
+	}, got[0])
+	require.Equal(t, "Unhealthy", got[1].Health)
+	require.Equal(t, 4, got[1].TargetSize)
+
+	require.Empty(t, parseClusterAutoscalerStatus(""))
+}
+
+func TestBuildNodeGroupsEKS(t *testing.T) {
+	nodes := []NodeSummary{
+		{Name: "ip-10-0-1-1", Labels: map[string]string{
+			"eks.amazonaws.com/nodegroup":      "general",
+			"eks.amazonaws.com/capacityType":   "ON_DEMAND",
+			"node.kubernetes.io/instance-type": "m6i.large",
+			"topology.kubernetes.io/zone":      "us-east-1a",
+		}},
+		{Name: "ip-10-0-2-1", Labels: map[string]string{
+			"eks.amazonaws.com/nodegroup":      "general",
+			"eks.amazonaws.com/capacityType":   "SPOT",
+			"node.kubernetes.io/instance-type": "m5.large",
+			"topology.kubernetes.io/zone":      "us-east-1b",
+		}},
+		{Name: "ip-10-0-3-1", Labels: map[string]string{
+			"karpenter.sh/nodepool":       "default",
+			"karpenter.sh/capacity-type":  "spot",
+			"topology.kubernetes.io/zone": "us-east-1a",
+		}},
+		{Name: "standalone", Labels: map[string]string{"kubernetes.io/hostname": "standalone"}},
+	}
+
+	got := buildNodeGroups(nodes, parseClusterAutoscalerStatus(caStatusYAML))
+	require.Len(t, got, 3)
+
+	general := got[0]
+	require.Equal(t, "eks/general", general.ID)
+	require.Equal(t, 2, general.NodeCount)
+	require.Equal(t, []string{"ip-10-0-1-1", "ip-10-0-2-1"}, general.NodeNames)
+	require.Equal(t, []string{"m5.large", "m6i.large"}, general.InstanceTypes)
+	require.Equal(t, CapacityTypeMixed, general.CapacityType)
+	require.Equal(t, map[string]int{"us-east-1a": 1, "us-east-1b": 1}, general.Zones)
+	require.NotNil(t, general.Autoscaler)
+	require.Equal(t, []string{"eks-general-5ac6a4b2-1f3e-7d4c-9b1a-0e2f3a4b5c6d"}, general.Autoscaler.NodeGroups)
+	require.Equal(t, 1, general.Autoscaler.MinSize)
+	require.Equal(t, 10, general.Autoscaler.MaxSize)
+
+	// general-gpu is scaled to zero: only Cluster Autoscaler knows about it.
+	scaledToZero := got[1]
+	require.Equal(t, "eks/general-gpu", scaledToZero.ID)
+	require.Equal(t, NodeGroupProviderEKS, scaledToZero.Provider)
+	require.Equal(t, 0, scaledToZero.NodeCount)
+	require.Equal(t, 4, scaledToZero.Autoscaler.MaxSize)
+
+	karpenter := got[2]
+	require.Equal(t, "karpenter/default", karpenter.ID)
+	require.Equal(t, CapacityTypeSpot, karpenter.CapacityType)
+	require.Nil(t, karpenter.Autoscaler)
+
+	require.Equal(t, "eks/general", nodes[0].NodeGroup)
+	require.Equal(t, "karpenter/default", nodes[2].NodeGroup)
+	require.Empty(t, nodes[3].NodeGroup)
+}
+
+func TestBuildNodeGroupsGKEAndAKS(t *testing.T) {
+	nodes := []NodeSummary{
+		{Name: "gke-a", Labels: map[string]string{
+			"cloud.google.com/gke-nodepool": "default-pool",
+			"topology.kubernetes.io/zone":   "us-central1-a",
+		}},
+		{Name: "gke-b", Labels: map[string]string{
+			"cloud.google.com/gke-nodepool": "default-pool",
+			"topology.kubernetes.io/zone":   "us-central1-b",
+		}},
+		{Name: "aks-spot-0", Labels: map[string]string{
+			"kubernetes.azure.com/agentpool":        "spot",
+			"kubernetes.azure.com/scalesetpriority": "spot",
+		}},
+	}
+
+	autoscalerGroups := append(parseClusterAutoscalerStatus(caStatusLegacy), clusterAutoscalerNodeGroup{Name: "on-prem-workers", MaxSize: 3})
+	got := buildNodeGroups(nodes, autoscalerGroups)
+	require.Len(t, got, 3)
+	require.Equal(t, "aks/spot", got[0].ID)
+	require.Equal(t, CapacityTypeSpot, got[0].CapacityType)
+	require.Nil(t, got[0].Autoscaler)
+
+	unmatched := got[1]
+	require.Equal(t, "cluster-autoscaler/on-prem-workers", unmatched.ID)
+	require.Equal(t, 0, unmatched.NodeCount)
+	require.Equal(t, 3, unmatched.Autoscaler.MaxSize)
+
+	pool := got[2]
+	require.Equal(t, "gke/default-pool", pool.ID)
+	require.Equal(t, CapacityTypeOnDemand, pool.CapacityType)
+	require.Len(t, pool.Autoscaler.NodeGroups, 2)
+	require.Equal(t, 2, pool.Autoscaler.MinSize)
+	require.Equal(t, 10, pool.Autoscaler.MaxSize)
+	require.Equal(t, 6, pool.Autoscaler.TargetSize)
+	require.Equal(t, "Unhealthy", pool.Autoscaler.Health)
+}